@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// consoleApprover asks the user on the terminal before running tools that require approval.
+//
+// It shares the chat loop's scanner: approvals happen while a response is being
+// processed, when the chat loop is not reading input.
+type consoleApprover struct {
+	mu      sync.Mutex
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+// newConsoleApprover creates an approver reading answers from scanner and writing prompts to out.
+func newConsoleApprover(scanner *bufio.Scanner, out io.Writer) *consoleApprover {
+	return &consoleApprover{scanner: scanner, out: out}
+}
+
+// Approve implements agents.Approver. Only "y" or "yes" approves the call.
+func (c *consoleApprover) Approve(ctx context.Context, toolCall llms.ToolCall) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	args, err := json.MarshalIndent(toolCall.Arguments, "   ", "  ")
+	if err != nil {
+		args = []byte(fmt.Sprintf("%v", toolCall.Arguments))
+	}
+	fmt.Fprintf(c.out, "\n%s%s⚠️  Approval required: %s%s\n   %s\n%sAllow? [y/N]: %s",
+		ColorYellow, ColorBold, toolCall.Name, ColorReset, args, ColorBold, ColorReset)
+
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	if !c.scanner.Scan() {
+		if err := c.scanner.Err(); err != nil {
+			return false, fmt.Errorf("failed to read approval: %w", err)
+		}
+		return false, fmt.Errorf("input closed before approval")
+	}
+
+	answer := strings.ToLower(strings.TrimSpace(c.scanner.Text()))
+	return answer == "y" || answer == "yes", nil
+}
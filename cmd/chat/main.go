@@ -2,14 +2,20 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/thinktwice/agentForge/src/agents"
 	"github.com/thinktwice/agentForge/src/core"
 	"github.com/thinktwice/agentForge/src/llms"
+	"github.com/thinktwice/agentForge/src/tools"
+	"github.com/thinktwice/agentForge/src/transport"
 )
 
 const (
@@ -28,30 +34,54 @@ const (
 func main() {
 	// Parse command-line flags
 	provider := flag.String("provider", "togetherai", "LLM provider to use: togetherai or openai")
+	fsRoot := flag.String("fs-root", ".", "Directory the file system agent is restricted to")
+	format := flag.String("format", "text", "Output format: text (colored) or jsonl (one JSON chunk per line)")
+	traceColors := flag.String("trace-colors", "", "Colors of agent traces as trace=color pairs, e.g. reasoning=green,research=magenta")
+	render := flag.Bool("render", false, "Render markdown in answers (headers, bold, lists, code) instead of printing it raw")
 	flag.Parse()
 
-	printBanner()
+	if *format != "text" && *format != "jsonl" {
+		fmt.Fprintf(os.Stderr, "unsupported format: %s (supported: text, jsonl)\n", *format)
+		os.Exit(2)
+	}
+
+	styles := defaultTraceStyles()
+	if err := parseTraceColors(styles, *traceColors); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	// In jsonl mode stdout only carries chunks; everything else goes to stderr
+	var ui io.Writer = os.Stdout
+	if *format == "jsonl" {
+		ui = os.Stderr
+	}
+
+	printBanner(ui)
 
 	// Display provider information
 	providerName := "TogetherAI and Llama"
 	if *provider == "openai" {
 		providerName = "OpenAI"
 	}
-	fmt.Printf("Chat with a reasoning agent powered by %s\n", providerName)
-	fmt.Printf("%sType 'exit' or 'quit' to end the conversation%s\n\n", ColorDim, ColorReset)
+	fmt.Fprintf(ui, "Chat with a reasoning agent powered by %s\n", providerName)
+	fmt.Fprintf(ui, "%sType 'exit' or 'quit' to end the conversation%s\n\n", ColorDim, ColorReset)
+
+	// The approver shares the chat loop's scanner to ask before file changes
+	scanner := bufio.NewScanner(os.Stdin)
+	approver := newConsoleApprover(scanner, ui)
 
 	// Initialize the agent
-	agent, err := initializeAgent(*provider)
+	agent, err := initializeAgent(*provider, *fsRoot, approver)
 	if err != nil {
-		fmt.Printf("%sError initializing agent: %v%s\n", ColorRed, err, ColorReset)
+		fmt.Fprintf(ui, "%sError initializing agent: %v%s\n", ColorRed, err, ColorReset)
 		os.Exit(1)
 	}
 
 	// Start chat loop
-	scanner := bufio.NewScanner(os.Stdin)
 	for {
 		// Get user input
-		fmt.Printf("%s%sYou: %s", ColorGreen, ColorBold, ColorReset)
+		fmt.Fprintf(ui, "%s%sYou: %s", ColorGreen, ColorBold, ColorReset)
 		if !scanner.Scan() {
 			break
 		}
@@ -63,35 +93,43 @@ func main() {
 
 		// Check for exit commands
 		if strings.ToLower(userInput) == "exit" || strings.ToLower(userInput) == "quit" {
-			fmt.Printf("\n%sGoodbye!%s\n", ColorBold, ColorReset)
+			fmt.Fprintf(ui, "\n%sGoodbye!%s\n", ColorBold, ColorReset)
 			break
 		}
 
 		// Send message and process response
+		if *format == "jsonl" {
+			if err := transport.WriteJSONLines(os.Stdout, agent.ChatStream(userInput)); err != nil {
+				fmt.Fprintf(ui, "%sError: %v%s\n", ColorRed, err, ColorReset)
+			}
+			continue
+		}
 		fmt.Println() // Add newline for better formatting
-		if err := processResponse(agent, userInput); err != nil {
+		if err := processResponse(agent, userInput, styles, *render); err != nil {
 			fmt.Printf("%sError: %v%s\n", ColorRed, err, ColorReset)
 		}
 		fmt.Println() // Add newline after response
 	}
 
 	if err := scanner.Err(); err != nil {
-		fmt.Printf("%sError reading input: %v%s\n", ColorRed, err, ColorReset)
+		fmt.Fprintf(ui, "%sError reading input: %v%s\n", ColorRed, err, ColorReset)
 	}
 }
 
 // printBanner displays the CLI banner
-func printBanner() {
+func printBanner(w io.Writer) {
 	banner := `
 ╔════════════════════════════════════════════╗
 ║     🤖 ThinkTwice Agent CLI 🤖             ║
 ╚════════════════════════════════════════════╝
 `
-	fmt.Printf("%s%s%s\n", ColorBold, ColorCyan, banner)
-	fmt.Print(ColorReset)
+	fmt.Fprintf(w, "%s%s%s\n", ColorBold, ColorCyan, banner)
+	fmt.Fprint(w, ColorReset)
 }
 
-func initializeFileSystemAgent() (*agents.Agent, error) {
+// initializeFileSystemAgent creates the sub-agent owning the fs tool. Every fs call
+// must be approved on the console.
+func initializeFileSystemAgent(root string, approver agents.Approver) (*agents.Agent, error) {
 	llmEngine, err := llms.NewOpenAILLMBuilder("togetherai").
 		SetModel(llms.TOGETHERAI_Qwen257BInstructTurbo).
 		Build()
@@ -106,7 +144,7 @@ func initializeFileSystemAgent() (*agents.Agent, error) {
 		Trace:       "file-system-agent",
 		Reasoning:   false,
 		SystemPrompt: `You are a helpful assistant that can read and write files to the file system.
-		You can read and write files to the file system using the "read_file" and "write_file" tools.`,
+		You can read, write, append, delete, list and glob files using the "fs" tool.`,
 		MainAgent: false,
 		AdvanceDescription: `
 		=== File System Agent ===
@@ -116,12 +154,19 @@ func initializeFileSystemAgent() (*agents.Agent, error) {
 		Troubleshooting: `
 		=== File System Agent Troubleshooting ===
 		`,
+		Approver: approver,
 	})
+
+	fsTool := tools.NewFsTool(root)
+	fsTool.(*core.Tool).SetRequiresApproval(true)
+	if err := fsAgent.SetTools(append(fsAgent.GetTools(), fsTool)); err != nil {
+		return nil, err
+	}
 	return fsAgent, nil
 }
 
 // initializeAgent creates and configures the agent with the specified provider
-func initializeAgent(provider string) (*agents.Agent, error) {
+func initializeAgent(provider string, fsRoot string, approver agents.Approver) (*agents.Agent, error) {
 
 	var llmEngine llms.LLMEngine
 	var err error
@@ -146,14 +191,18 @@ func initializeAgent(provider string) (*agents.Agent, error) {
 		return nil, fmt.Errorf("unsupported provider: %s (supported: togetherai, openai)", provider)
 	}
 
-	fsAgent, err := initializeFileSystemAgent()
+	// Fail fast on a bad API key or model instead of on the first message
+	ctx, cancel := context.WithTimeout(context.Background(), connectivityTimeout)
+	defer cancel()
+	if err := llms.CheckConnectivity(ctx, llmEngine); err != nil {
+		return nil, connectivityError(provider, err)
+	}
+
+	fsAgent, err := initializeFileSystemAgent(fsRoot, approver)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create FileSystemAgent: %w", err)
 	}
 
-	subAgents := make([]*core.SubAgent, 0)
-	subAgents = append(subAgents)
-
 	// Create agent configuration with reasoning enabled
 	config := agents.AgentConfig{
 		LLMEngine:   llmEngine,
@@ -181,14 +230,37 @@ For simple questions, greetings, casual conversation, or straightforward tasks,
 	return agent, nil
 }
 
-// processResponse sends a message to the agent and displays the response with colored output
-func processResponse(agent *agents.Agent, message string) error {
+// connectivityTimeout bounds the startup connectivity check.
+const connectivityTimeout = 15 * time.Second
+
+// connectivityError explains a failed connectivity check.
+func connectivityError(provider string, err error) error {
+	switch {
+	case errors.Is(err, llms.ErrAuthentication):
+		return fmt.Errorf("%s rejected the API key, check its environment variable: %w", provider, err)
+	case errors.Is(err, llms.ErrModelNotFound):
+		return fmt.Errorf("%s does not serve the configured model: %w", provider, err)
+	case errors.Is(err, llms.ErrUnreachable):
+		return fmt.Errorf("cannot reach %s, check your network connection: %w", provider, err)
+	}
+	return fmt.Errorf("%s connectivity check failed: %w", provider, err)
+}
+
+// processResponse sends a message to the agent and displays the response with colored output.
+// When render is set, markdown in the streamed content is rendered (see markdownRenderer).
+func processResponse(agent *agents.Agent, message string, styles map[string]traceStyle, render bool) error {
 	// Get response channel
 	responseCh := agent.ChatStream(message)
 
+	var renderer *markdownRenderer
+	if render {
+		renderer = newMarkdownRenderer()
+	}
+
 	// Track which agents we've seen
 	currentAgent := ""
 	currentTrace := ""
+	currentColor := ""
 
 	// Process streaming response
 	// No casting needed - Start() returns the concrete channel type
@@ -198,16 +270,21 @@ func processResponse(agent *agents.Agent, message string) error {
 			return fmt.Errorf("agent error: %s", chunk.Content)
 		}
 
-		// Determine color based on agent name/trace
-		color := getColorForAgent(chunk.AgentName, chunk.Trace)
+		// Determine color and label based on the trace
+		style := styleForTrace(styles, chunk.Trace)
+		color := style.color
 
 		// Print agent header if agent changed
 		if chunk.AgentName != currentAgent || chunk.Trace != currentTrace {
+			if renderer != nil {
+				fmt.Printf("%s%s%s", currentColor, renderer.Flush(), ColorReset)
+			}
 			currentAgent = chunk.AgentName
 			currentTrace = chunk.Trace
+			currentColor = color
 
 			// Print agent name header
-			agentLabel := formatAgentLabel(chunk.AgentName, chunk.Trace)
+			agentLabel := formatAgentLabel(style, chunk.AgentName, chunk.Trace)
 			fmt.Printf("\n%s%s%s%s\n", ColorBold, color, agentLabel, ColorReset)
 		}
 
@@ -220,10 +297,20 @@ func processResponse(agent *agents.Agent, message string) error {
 				if content == "" {
 					content = chunk.Delta
 				}
+				if renderer != nil {
+					content = renderer.Render(content)
+				}
 				fmt.Printf("%s%s%s", color, content, ColorReset)
 			}
 
+		case llms.TypeReasoning:
+			// Stream reasoning dimmed; the "thinking" trace selects its color
+			fmt.Printf("%s%s%s%s", color, ColorDim, chunk.Content, ColorReset)
+
 		case llms.TypeCompletion:
+			if renderer != nil {
+				fmt.Printf("%s%s%s", color, renderer.Flush(), ColorReset)
+			}
 			// Final completion - display token usage if available
 			if chunk.TotalTokens > 0 {
 				fmt.Printf("\n%s%s📊 Tokens: %d prompt + %d completion = %d total%s\n",
@@ -255,31 +342,3 @@ func processResponse(agent *agents.Agent, message string) error {
 	fmt.Println() // Final newline
 	return nil
 }
-
-// getColorForAgent returns the appropriate color code based on agent name and trace
-func getColorForAgent(agentName, trace string) string {
-	// Check if this is the reasoning agent
-	if strings.Contains(strings.ToLower(agentName), "reasoning") ||
-		strings.Contains(strings.ToLower(trace), "thinking") ||
-		strings.Contains(strings.ToLower(trace), "reasoning") {
-		return ColorYellow
-	}
-
-	// Default to cyan for main agent
-	return ColorCyan
-}
-
-// formatAgentLabel creates a formatted label for the agent
-func formatAgentLabel(agentName, trace string) string {
-	// Add emoji based on agent type
-	emoji := "💬"
-	if strings.Contains(strings.ToLower(agentName), "reasoning") ||
-		strings.Contains(strings.ToLower(trace), "thinking") {
-		emoji = "🧠"
-	}
-
-	if trace != "" {
-		return fmt.Sprintf("%s %s - %s", emoji, agentName, trace)
-	}
-	return fmt.Sprintf("%s %s", emoji, agentName)
-}
@@ -0,0 +1,182 @@
+package main
+
+import "strings"
+
+// ansiNormalIntensity turns bold and dim off without resetting the color.
+const ansiNormalIntensity = "\033[22m"
+
+// markdownRenderer lightly renders streamed markdown for the terminal: headers
+// and **bold** are shown bold, `code` and fenced code blocks dimmed, and list
+// markers as bullets. The markers themselves are stripped.
+//
+// Content arrives in deltas, so input that may be the start of a marker split
+// across chunks (a trailing "*", a line starting with "#" or "`") is held back
+// until the next delta decides it. Inline styles end at the end of a line so an
+// unmatched marker never styles the rest of the answer.
+type markdownRenderer struct {
+	// pending is held-back input that may start a marker
+	pending string
+	// lineStart is set when the next input starts a new line
+	lineStart bool
+	// skipLine discards the rest of a code fence line (its language tag)
+	skipLine bool
+
+	bold, code, header, fence bool
+}
+
+// newMarkdownRenderer creates a renderer at the start of a line.
+func newMarkdownRenderer() *markdownRenderer {
+	return &markdownRenderer{lineStart: true}
+}
+
+// style returns the escape codes applying the current styles.
+func (r *markdownRenderer) style() string {
+	s := ansiNormalIntensity
+	if r.bold || r.header {
+		s += ColorBold
+	}
+	if r.code || r.fence {
+		s += ColorDim
+	}
+	return s
+}
+
+// styled reports whether any style is active.
+func (r *markdownRenderer) styled() bool {
+	return r.bold || r.header || r.code || r.fence
+}
+
+// Render renders a delta. The output may lag behind the input by a few
+// characters held back until the next delta (see Flush).
+func (r *markdownRenderer) Render(delta string) string {
+	input := r.pending + delta
+	r.pending = ""
+
+	var out strings.Builder
+	// Each chunk is printed separately and reset afterwards: restore the styles
+	if r.styled() {
+		out.WriteString(r.style())
+	}
+
+	for i := 0; i < len(input); {
+		if r.skipLine {
+			end := strings.IndexByte(input[i:], '\n')
+			if end < 0 {
+				return out.String()
+			}
+			i += end + 1
+			r.skipLine = false
+			r.lineStart = true
+			continue
+		}
+
+		if r.lineStart {
+			n, rendered, ok := r.renderLineStart(input[i:])
+			if !ok {
+				r.pending = input[i:]
+				return out.String()
+			}
+			out.WriteString(rendered)
+			i += n
+			continue
+		}
+
+		c := input[i]
+		switch {
+		case c == '\n':
+			if r.bold || r.code || r.header {
+				r.bold, r.code, r.header = false, false, false
+				out.WriteString(r.style())
+			}
+			out.WriteByte(c)
+			r.lineStart = true
+			i++
+		case r.fence:
+			out.WriteByte(c)
+			i++
+		case c == '`':
+			r.code = !r.code
+			out.WriteString(r.style())
+			i++
+		case c == '*' && i+1 == len(input):
+			// May be the first half of "**"
+			r.pending = "*"
+			return out.String()
+		case c == '*' && input[i+1] == '*':
+			r.bold = !r.bold
+			out.WriteString(r.style())
+			i += 2
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.String()
+}
+
+// renderLineStart renders the line prefix (indentation, header, list marker or
+// code fence) at the start of s.
+//
+// Returns:
+//   - int: The number of bytes consumed
+//   - string: The rendered prefix
+//   - bool: false if s is too short to decide and must be held back
+func (r *markdownRenderer) renderLineStart(s string) (int, string, bool) {
+	indent := len(s) - len(strings.TrimLeft(s, " \t"))
+	rest := s[indent:]
+	if rest == "" {
+		return 0, "", false
+	}
+
+	// Code fences open and close blocks; the fence line itself is not shown
+	if strings.HasPrefix(rest, "```") {
+		r.fence = !r.fence
+		r.skipLine = true
+		return indent + 3, r.style(), true
+	}
+	if len(rest) < 3 && strings.HasPrefix("```", rest) {
+		return 0, "", false
+	}
+
+	if r.fence {
+		r.lineStart = false
+		return indent, s[:indent], true
+	}
+
+	switch rest[0] {
+	case '#':
+		level := len(rest) - len(strings.TrimLeft(rest, "#"))
+		if level == len(rest) {
+			return 0, "", false
+		}
+		if rest[level] == ' ' {
+			r.lineStart = false
+			r.header = true
+			return indent + level + 1, s[:indent] + r.style(), true
+		}
+	case '-', '*', '+':
+		if len(rest) == 1 {
+			return 0, "", false
+		}
+		if rest[1] == ' ' {
+			r.lineStart = false
+			return indent + 2, s[:indent] + "• ", true
+		}
+	}
+	r.lineStart = false
+	return indent, s[:indent], true
+}
+
+// Flush returns the held-back input and resets the styles, e.g. at the end of
+// a stream or when another agent starts writing.
+func (r *markdownRenderer) Flush() string {
+	out := r.pending
+	if r.skipLine {
+		out = ""
+	}
+	if r.styled() {
+		out += ansiNormalIntensity
+	}
+	*r = markdownRenderer{lineStart: true}
+	return out
+}
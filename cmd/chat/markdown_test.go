@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// renderChunks renders the chunks in order and flushes the renderer.
+func renderChunks(chunks ...string) string {
+	r := newMarkdownRenderer()
+	var out strings.Builder
+	for _, chunk := range chunks {
+		out.WriteString(r.Render(chunk))
+	}
+	out.WriteString(r.Flush())
+	return out.String()
+}
+
+// annotate replaces the escape codes of rendered output by markers: bold text
+// is wrapped in [b]...[/b] and dimmed text in [c]...[/c].
+func annotate(rendered string) string {
+	var out strings.Builder
+	bold, dim := false, false
+	setStyle := func(newBold, newDim bool) {
+		if dim && !newDim {
+			out.WriteString("[/c]")
+		}
+		if bold && !newBold {
+			out.WriteString("[/b]")
+		}
+		if newBold && !bold {
+			out.WriteString("[b]")
+		}
+		if newDim && !dim {
+			out.WriteString("[c]")
+		}
+		bold, dim = newBold, newDim
+	}
+
+	for i := 0; i < len(rendered); {
+		switch {
+		case strings.HasPrefix(rendered[i:], ansiNormalIntensity):
+			// Applied together with the codes that follow it
+			newBold, newDim := false, false
+			i += len(ansiNormalIntensity)
+			for {
+				if strings.HasPrefix(rendered[i:], ColorBold) {
+					newBold = true
+					i += len(ColorBold)
+				} else if strings.HasPrefix(rendered[i:], ColorDim) {
+					newDim = true
+					i += len(ColorDim)
+				} else {
+					break
+				}
+			}
+			setStyle(newBold, newDim)
+		default:
+			out.WriteByte(rendered[i])
+			i++
+		}
+	}
+	return out.String()
+}
+
+func TestMarkdownRenderer(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{name: "bold", markdown: "This is **bold** text", want: "This is [b]bold[/b] text"},
+		{name: "code span", markdown: "Run `go test` now", want: "Run [c]go test[/c] now"},
+		{name: "header", markdown: "## Title\nbody", want: "[b]Title[/b]\nbody"},
+		{name: "list", markdown: "- one\n  * two\n3 * 4", want: "• one\n  • two\n3 * 4"},
+		{name: "code fence", markdown: "```go\nx := 1\n```\ndone", want: "[c]x := 1\n[/c]done"},
+		{name: "unmatched bold ends with the line", markdown: "a **b\nc", want: "a [b]b[/b]\nc"},
+		{name: "plain", markdown: "#hashtag\n-1 is negative", want: "#hashtag\n-1 is negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := annotate(renderChunks(tt.markdown)); got != tt.want {
+				t.Errorf("render(%q) = %q, want %q", tt.markdown, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownRenderer_SplitAcrossChunks(t *testing.T) {
+	for _, markdown := range []string{
+		"Use **bold** and `code` spans",
+		"**start** and **end**",
+		"# Title\n- item with `code`\n```\nfenced\n```\n",
+	} {
+		want := annotate(renderChunks(markdown))
+
+		// Every split point, including inside "**" and "```"
+		for i := 1; i < len(markdown); i++ {
+			if got := annotate(renderChunks(markdown[:i], markdown[i:])); got != want {
+				t.Errorf("split %q|%q: got %q, want %q", markdown[:i], markdown[i:], got, want)
+			}
+		}
+
+		// One byte at a time
+		chunks := strings.Split(markdown, "")
+		if got := annotate(renderChunks(chunks...)); got != want {
+			t.Errorf("byte by byte %q: got %q, want %q", markdown, got, want)
+		}
+	}
+}
+
+func TestMarkdownRenderer_RestoresStylesPerChunk(t *testing.T) {
+	r := newMarkdownRenderer()
+	r.Render("**bo")
+	// The CLI resets the terminal after each chunk, so the next one re-applies bold
+	if got := r.Render("ld**"); !strings.HasPrefix(got, ansiNormalIntensity+ColorBold+"ld") {
+		t.Errorf("Expected the second chunk to restore bold, got %q", got)
+	}
+}
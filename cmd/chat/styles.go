@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// traceStyle is how the CLI shows the chunks of a trace.
+type traceStyle struct {
+	color string // ANSI color code of the chunks
+	emoji string // Prefix of the agent label
+}
+
+// defaultTraceStyle is the style of traces without an entry in the style map.
+var defaultTraceStyle = traceStyle{color: ColorCyan, emoji: "💬"}
+
+// colorsByName are the colors accepted by the -trace-colors flag.
+var colorsByName = map[string]string{
+	"cyan":    ColorCyan,
+	"yellow":  ColorYellow,
+	"red":     ColorRed,
+	"green":   ColorGreen,
+	"blue":    ColorBlue,
+	"magenta": ColorMagenta,
+}
+
+// defaultTraceStyles returns the styles of the traces of the CLI agents.
+func defaultTraceStyles() map[string]traceStyle {
+	return map[string]traceStyle{
+		"response":             defaultTraceStyle,
+		"reasoning":            {color: ColorYellow, emoji: "🧠"},
+		llms.TraceThinking:     {color: ColorYellow, emoji: "🧠"},
+		"file-system-agent":    {color: ColorBlue, emoji: "📁"},
+		llms.TraceToolProgress: {color: ColorMagenta, emoji: "⏳"},
+	}
+}
+
+// styleForTrace returns the style of a trace, or defaultTraceStyle if it has none.
+func styleForTrace(styles map[string]traceStyle, trace string) traceStyle {
+	if style, ok := styles[trace]; ok {
+		return style
+	}
+	return defaultTraceStyle
+}
+
+// parseTraceColors sets the colors of the -trace-colors flag in styles.
+//
+// Parameters:
+//   - styles: The styles to update
+//   - spec: Comma-separated trace=color pairs (e.g. "reasoning=green,research=magenta")
+//
+// Returns:
+//   - error: An error if a pair is malformed or names an unknown color
+func parseTraceColors(styles map[string]traceStyle, spec string) error {
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		trace, name, ok := strings.Cut(pair, "=")
+		trace = strings.TrimSpace(trace)
+		if !ok || trace == "" {
+			return fmt.Errorf("invalid trace color %q (expected trace=color)", pair)
+		}
+		color, ok := colorsByName[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return fmt.Errorf("unknown color %q for trace %q (supported: %s)", name, trace, strings.Join(colorNames(), ", "))
+		}
+		style := styleForTrace(styles, trace)
+		style.color = color
+		styles[trace] = style
+	}
+	return nil
+}
+
+// colorNames returns the sorted names of colorsByName.
+func colorNames() []string {
+	names := make([]string, 0, len(colorsByName))
+	for name := range colorsByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatAgentLabel creates a formatted label for the agent
+func formatAgentLabel(style traceStyle, agentName, trace string) string {
+	if trace != "" {
+		return fmt.Sprintf("%s %s - %s", style.emoji, agentName, trace)
+	}
+	return fmt.Sprintf("%s %s", style.emoji, agentName)
+}
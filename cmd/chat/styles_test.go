@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestStyleForTrace(t *testing.T) {
+	styles := defaultTraceStyles()
+	if err := parseTraceColors(styles, "reasoning=green, research=Magenta"); err != nil {
+		t.Fatalf("parseTraceColors() unexpected error = %v", err)
+	}
+
+	tests := []struct {
+		trace string
+		want  traceStyle
+	}{
+		{trace: "response", want: defaultTraceStyle},
+		{trace: "thinking", want: traceStyle{color: ColorYellow, emoji: "🧠"}},
+		{trace: "reasoning", want: traceStyle{color: ColorGreen, emoji: "🧠"}},
+		{trace: "research", want: traceStyle{color: ColorMagenta, emoji: defaultTraceStyle.emoji}},
+		{trace: "unknown", want: defaultTraceStyle},
+		{trace: "", want: defaultTraceStyle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.trace, func(t *testing.T) {
+			if got := styleForTrace(styles, tt.trace); got != tt.want {
+				t.Errorf("styleForTrace(%q) = %+v, want %+v", tt.trace, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTraceColors_Invalid(t *testing.T) {
+	for _, spec := range []string{"reasoning", "=green", "reasoning=purple"} {
+		if err := parseTraceColors(defaultTraceStyles(), spec); err == nil {
+			t.Errorf("parseTraceColors(%q) expected error", spec)
+		}
+	}
+}
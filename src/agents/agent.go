@@ -1,14 +1,23 @@
 package agents
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	agentforge "github.com/thinktwice/agentForge/src"
 	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/internal/delegation"
 	"github.com/thinktwice/agentForge/src/llms"
 	"github.com/thinktwice/agentForge/src/persistence"
 	"github.com/thinktwice/agentForge/src/tools"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Agent represents an advanced agent with an LLM engine.
@@ -22,24 +31,54 @@ type Agent struct {
 	// llmEngine is the underlying LLM engine that handles streaming responses.
 	// It implements the llms.Agent interface which provides ChatStream method.
 	llmEngine *llms.LLMEngine
-	// Response channel for the agent.
-	responseCh *core.ResponseCh
 	// Tools available to the agent.
 	tools []llms.Tool
-	// Deal with message history.
+	// toolsByName indexes tools for lookup by findTool
+	toolsByName map[string]llms.Tool
+	// toolsMu guards tools and toolsByName, which SetTools replaces while runs read them
+	toolsMu sync.RWMutex
+	// Message history of the default session.
 	history *History
+	// Sessions by ID, each with its own history (see ChatStreamSession)
+	sessions   map[string]*session
+	sessionsMu sync.Mutex
 	// Subsystem of agents
 	subAgents []*core.SubAgent
+	// ownedSubAgents are the sub-agents created by NewAgent (SubAgentConfigs and
+	// system agents), closed along with this agent
+	ownedSubAgents []*Agent
 	// If this is a main agent of a team of agents.
 	mainAgent bool
 	// Extra engine configurations for subsystems of agents.
 	extraEngines map[string]llms.LLMEngine
 	// What kind of persistence to use for the agent.
 	persistence string
-	// System Prompt as a final system prompt
-	systemPrompt string
+	// System Prompt as a final system prompt, built on first use
+	systemPrompt     string
+	systemPromptOnce sync.Once
+	// systemPromptMu guards systemPrompt, config.SystemPrompt and systemPromptUpdated
+	systemPromptMu sync.RWMutex
+	// systemPromptUpdated is set by UpdateSystemPrompt: runs then replace the
+	// system message of their history instead of keeping it
+	systemPromptUpdated bool
 	// Agent context built once at initialization
 	agentContext *core.AgentContext
+	// Summarizer used to compress old history (nil when disabled)
+	summarizer *Summarizer
+	// Summarizer used to compress large tool results (nil when disabled)
+	toolResultSummarizer *Summarizer
+	// contextWindow is the context window used by ContextCompaction (0 when disabled)
+	contextWindow int
+	// Lifecycle hooks (NoopHooks when not configured)
+	hooks AgentHooks
+	// Metrics recorder (NoopMetrics when not configured)
+	metrics Metrics
+	// Tool result cache (nil when disabled)
+	toolCache ToolCache
+	// closed is closed by Close to stop in-flight runs
+	closed    chan struct{}
+	closeOnce sync.Once
+	closeErr  error
 }
 
 // ===== Constructor =====
@@ -53,26 +92,46 @@ type Agent struct {
 // It panics if validation fails to ensure invalid agents are never created.
 //
 // Parameters:
-//   - config: AgentConfig struct containing all agent configuration parameters
+//   - config: Pointer to the AgentConfig containing all agent configuration parameters.
+//     The agent keeps the pointer and fills in defaults for unset fields.
 //
 // Returns:
 //   - *Agent: A new Agent instance
 //
 // Panics:
+//   - If config is nil
 //   - If required fields (LLMEngine or AgentName) are missing
+//   - If two tools share a name (see tools.WithName)
+//   - If config.ValidateTeam is set and ValidateTeam reports problems
+//   - If config.StrictSystemPromptVars is set and SystemPrompt has unknown placeholders
 func NewAgent(config *AgentConfig) *Agent {
+	a, err := TryNewAgent(config)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
 
+// TryNewAgent is like NewAgent but returns an invalid configuration as an error
+// instead of panicking, e.g. for agents built from user input.
+//
+// Parameters:
+//   - config: Pointer to the AgentConfig containing all agent configuration parameters
+//
+// Returns:
+//   - *Agent: A new Agent instance, nil on error
+//   - error: An error describing the invalid configuration (see NewAgent)
+func TryNewAgent(config *AgentConfig) (*Agent, error) {
 	a := &Agent{
 		config: config,
+		closed: make(chan struct{}),
 	}
-
-	a.ensureConfig()
-	a.addSystemAgents()
-	a.initSystemTools()
-	a.setResponseCh()
-	a.initAgentContext()
-
-	return a
+	if err := a.init(); err != nil {
+		// Release the sub-agents created so far
+		_ = a.Close()
+		return nil, err
+	}
+	return a, nil
 }
 
 // ===== Public Methods =====
@@ -92,48 +151,398 @@ func NewAgent(config *AgentConfig) *Agent {
 // Returns:
 //   - *core.ResponseCh: Response channel that can be used to receive streaming chunks
 func (a *Agent) ChatStream(message string) *core.ResponseCh {
-	// Retrieve history
-	a.ensureHistory()
-	a.history.get()
+	return a.startChat(context.Background(), defaultSessionID, llms.UserMessage(message), 0, a.config.MaxDelegationDepth, a.defaultGenerationOptions())
+}
+
+// ChatStreamContext is like ChatStream but ends the run when ctx is done: the LLM
+// stream is cancelled, chunks are no longer forwarded and the run ends with an
+// error wrapping ErrContextCancelled. Use it when the consumer may go away
+// without calling Stop, e.g. with the request context of an HTTP handler.
+//
+// It implements core.DelegationAwareSubAgent: when the delegate tool starts the
+// run, ctx also carries its delegation depth, and the effective depth limit is
+// the stricter of the caller's limit and this agent's own MaxDelegationDepth.
+//
+// Parameters:
+//   - ctx: Context bounding the run
+//   - message: The user message to send
+//
+// Returns:
+//   - *core.ResponseCh: Response channel that can be used to receive streaming chunks
+func (a *Agent) ChatStreamContext(ctx context.Context, message string) *core.ResponseCh {
+	depth, maxDepth, ok := delegation.Depth(ctx)
+	if !ok {
+		depth, maxDepth = 0, a.config.MaxDelegationDepth
+	}
+	return a.startChat(ctx, defaultSessionID, llms.UserMessage(message), depth, maxDepth, a.defaultGenerationOptions())
+}
+
+// ChatStreamWithOptions is like ChatStream with per-call generation options.
+//
+// Non-zero fields of opts override the agent defaults (e.g. AgentConfig.ToolChoice)
+// for this call only. Options require an engine implementing llms.LLMEngineWithOptions;
+// other engines ignore them.
+//
+// Example:
+//
+//	// Answer a greeting directly without any tool call
+//	responseCh := agent.ChatStreamWithOptions("Hi!", llms.GenerationOptions{ToolChoice: llms.ToolChoiceNone})
+//
+// Parameters:
+//   - message: The user message to send
+//   - opts: Generation options for this call
+//
+// Returns:
+//   - *core.ResponseCh: Response channel that can be used to receive streaming chunks
+func (a *Agent) ChatStreamWithOptions(message string, opts llms.GenerationOptions) *core.ResponseCh {
+	return a.startChat(context.Background(), defaultSessionID, llms.UserMessage(message), 0, a.config.MaxDelegationDepth, a.defaultGenerationOptions().Merge(opts))
+}
+
+// ChatStreamWithMetadata is like ChatStream with the user message tagged with
+// metadata (e.g. "source": "web"). The tags are stored and persisted with the
+// message, never sent to the LLM, and can be used to filter the history later.
+//
+// Parameters:
+//   - message: The user message to send
+//   - metadata: Tags of the message
+//
+// Returns:
+//   - *core.ResponseCh: Response channel that can be used to receive streaming chunks
+func (a *Agent) ChatStreamWithMetadata(message string, metadata map[string]string) *core.ResponseCh {
+	return a.startChat(context.Background(), defaultSessionID, llms.UserMessageWithMetadata(message, metadata), 0, a.config.MaxDelegationDepth, a.defaultGenerationOptions())
+}
 
-	// Get the underlying LLM's response channel
-	var messages = a.handleSystemPromptInjection()
-	messages = a.handleNewUserMessage(message)
+// ChatStreamMessages runs the agent on a conversation assembled by the caller
+// (e.g. with few-shot examples) instead of the agent's history, for stateless
+// endpoints. The tool loop runs as usual, but neither the messages nor the turn
+// are added to a session history or persisted. The agent's system prompt is
+// prepended if messages do not start with a system message.
+//
+// Use ChatStreamMessagesContext to bound the run with a context or to store the
+// conversation in a session.
+//
+// Parameters:
+//   - messages: The conversation, usually ending with a user message
+//
+// Returns:
+//   - *core.ResponseCh: Response channel that can be used to receive streaming chunks
+func (a *Agent) ChatStreamMessages(messages []llms.UnifiedMessage) *core.ResponseCh {
+	return a.ChatStreamMessagesContext(context.Background(), messages, MessagesOptions{})
+}
 
-	agentforge.Debug("messages-> %+v", messages)
-	a.initResponseCh()
+// MessagesOptions configures ChatStreamMessagesContext.
+type MessagesOptions struct {
+	// Persist stores the conversation and the turn as the history of SessionID,
+	// replacing what the session held, and saves it to the agent's persistence.
+	// The run then waits for earlier runs of that session like ChatStreamSession.
+	// Default: false (nothing is added to a session history or persisted)
+	Persist bool
+	// SessionID is the session whose history is replaced when Persist is set.
+	// Default: "" (the default session used by ChatStream)
+	SessionID string
+}
+
+// ChatStreamMessagesContext is like ChatStreamMessages but ends the run when ctx
+// is done (see ChatStreamContext) and can store the conversation in a session.
+//
+// Parameters:
+//   - ctx: Context bounding the run
+//   - messages: The conversation, usually ending with a user message
+//   - opts: Whether and where to store the conversation
+//
+// Returns:
+//   - *core.ResponseCh: Response channel that can be used to receive streaming chunks
+func (a *Agent) ChatStreamMessagesContext(ctx context.Context, messages []llms.UnifiedMessage, opts MessagesOptions) *core.ResponseCh {
+	history := &History{}
+	var s *session
+	if opts.Persist {
+		s = a.sessionFor(ctx, opts.SessionID)
+		history = s.history
+		ctx = withActiveSession(ctx, a, opts.SessionID)
+	}
+
+	runCtx, span := a.tracer().Start(ctx, SpanAgentChat, trace.WithAttributes(a.agentAttributes()...))
+	span.SetAttributes(AttrDelegationDepth.Int(0))
+	r := a.newRun(runCtx, history, a.defaultGenerationOptions(), 0, a.config.MaxDelegationDepth)
+
+	go func() {
+		defer r.responseCh.Close()
+
+		if s != nil {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+		}
+
+		var err error
+		switch {
+		case a.isClosed():
+			err = a.closedError()
+		case len(messages) == 0:
+			err = fmt.Errorf("no messages to send")
+		default:
+			// MaxUserMessageChars applies to the new message, not to the
+			// earlier turns (e.g. few-shot examples) assembled by the caller
+			if i := lastUserMessage(messages); i >= 0 {
+				err = a.checkUserMessage(messages[i])
+			}
+			if err == nil {
+				err = a.checkSystemPrompt()
+			}
+			if err == nil {
+				r.history.replace(messages)
+				r.history.addSystemMessage(a.systemPromptForRun())
+				r.history.save()
+				err = a.executeChatWithTools(r)
+			}
+		}
+		endSpan(span, err)
+		if err != nil {
+			r.responseCh.Error <- err
+		}
+	}()
+
+	return r.responseCh
+}
+
+// lastUserMessage returns the index of the last user message, or -1 if there is none.
+func lastUserMessage(messages []llms.UnifiedMessage) int {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role() == llms.MessageRoleUser {
+			return i
+		}
+	}
+	return -1
+}
+
+// defaultGenerationOptions returns the per-request options configured on the agent.
+func (a *Agent) defaultGenerationOptions() llms.GenerationOptions {
+	return llms.GenerationOptions{ToolChoice: a.config.ToolChoice}
+}
+
+// startChat starts a run on the given session at the given delegation depth with
+// the given options. ctx bounds the run (see ChatStreamContext) and is the
+// parent of its tracing span.
+//
+// The run waits for earlier runs of the same session to finish before touching
+// its history, so the returned channel is available immediately.
+func (a *Agent) startChat(ctx context.Context, sessionID string, message llms.UnifiedMessage, depth int, maxDepth int, opts llms.GenerationOptions) *core.ResponseCh {
+	s := a.sessionFor(ctx, sessionID)
+
+	runCtx, span := a.tracer().Start(ctx, SpanAgentChat, trace.WithAttributes(a.agentAttributes()...))
+	span.SetAttributes(AttrDelegationDepth.Int(depth))
+	r := a.newRun(withActiveSession(runCtx, a, sessionID), s.history, opts, depth, maxDepth)
 
 	// Start the tool execution loop in a goroutine
 	go func() {
-		defer a.responseCh.Close()
+		defer r.responseCh.Close()
 
-		if err := a.executeChatWithTools(); err != nil {
-			a.responseCh.Error <- err
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if a.isClosed() {
+			endSpan(span, a.closedError())
+			r.responseCh.Error <- a.closedError()
+			return
+		}
+		if err := a.checkUserMessage(message); err != nil {
+			endSpan(span, err)
+			r.responseCh.Error <- err
+			return
+		}
+		if err := a.checkSystemPrompt(); err != nil {
+			endSpan(span, err)
+			r.responseCh.Error <- err
+			return
+		}
+
+		// Retrieve history
+		r.history.get()
+		a.injectSystemPrompt(r.history)
+		r.history.addUserMessage(message)
+		r.history.save()
+		agentforge.Debug("messages-> %+v", r.history.History())
+
+		// Compress old history before the tool loop starts
+		a.summarizeHistory(r.history)
+
+		err := a.executeChatWithTools(r)
+		endSpan(span, err)
+		if err != nil {
+			r.responseCh.Error <- err
 		}
 	}()
 
-	return a.responseCh
+	return r.responseCh
+}
+
+// checkUserMessage enforces MaxUserMessageChars on the text of a user message.
+func (a *Agent) checkUserMessage(message llms.UnifiedMessage) error {
+	limit := a.config.MaxUserMessageChars
+	if limit <= 0 {
+		return nil
+	}
+	if n := utf8.RuneCountInString(message.Content()); n > limit {
+		return fmt.Errorf("%w: %d characters (limit %d)", ErrMessageTooLong, n, limit)
+	}
+	return nil
+}
+
+// checkSystemPrompt enforces MaxSystemPromptChars when StrictSystemPromptSize is set.
+func (a *Agent) checkSystemPrompt() error {
+	if !a.config.StrictSystemPromptSize {
+		return nil
+	}
+	return a.systemPromptSizeError(a.systemPromptForRun())
+}
+
+// systemPromptSizeError returns an error wrapping ErrSystemPromptTooLong when
+// prompt exceeds MaxSystemPromptChars.
+func (a *Agent) systemPromptSizeError(prompt string) error {
+	limit := a.config.MaxSystemPromptChars
+	if limit <= 0 {
+		return nil
+	}
+	if n := utf8.RuneCountInString(prompt); n > limit {
+		return fmt.Errorf("%w: %d characters (limit %d)", ErrSystemPromptTooLong, n, limit)
+	}
+	return nil
+}
+
+// Chat sends a message and waits for the final assistant answer.
+//
+// It is a convenience wrapper around ChatStream for request/response use cases:
+// the tool loop runs to completion and only the final assistant text is returned.
+// Individual tool failures are reported to the model and do not produce an error.
+//
+// Parameters:
+//   - message: The user message to send
+//
+// Returns:
+//   - string: The final assistant content
+//   - error: An error if the agent loop itself failed
+func (a *Agent) Chat(message string) (string, error) {
+	return a.ChatContext(context.Background(), message)
+}
+
+// ChatContext is like Chat but stops the run (see core.ResponseCh.Stop) when ctx is done.
+//
+// Parameters:
+//   - ctx: Context bounding how long to wait for the answer
+//   - message: The user message to send
+//
+// Returns:
+//   - string: The final assistant content (partial content if ctx is done first)
+//   - error: An error if the agent loop failed or ctx was done
+func (a *Agent) ChatContext(ctx context.Context, message string) (string, error) {
+	responseCh := a.startChat(ctx, defaultSessionID, llms.UserMessage(message), 0, a.config.MaxDelegationDepth, a.defaultGenerationOptions())
+	chunks := responseCh.Start()
+
+	var content string
+	var finalContent string
+	var completed bool
+
+	for {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				if completed {
+					return finalContent, nil
+				}
+				return content, nil
+			}
+
+			if chunk.Status == llms.StatusError {
+				if chunk.Err != nil {
+					return content, fmt.Errorf("agent error: %w", chunk.Err)
+				}
+				return content, fmt.Errorf("agent error: %s", chunk.Content)
+			}
+
+			// Ignore chunks forwarded from delegated sub-agents
+			if chunk.AgentName != a.Name() {
+				continue
+			}
+
+			if chunk.Type == llms.TypeContent && chunk.Trace != llms.TraceToolProgress {
+				content += chunk.Content
+			}
+			if chunk.Type == llms.TypeCompletion {
+				finalContent = chunk.FullContent
+				completed = true
+			}
+
+		case <-ctx.Done():
+			responseCh.Stop()
+			return content, fmt.Errorf("%w: %w", ErrContextCancelled, ctx.Err())
+		}
+	}
+}
+
+// GetHistory returns a window of the agent's conversation history.
+//
+// It reads from the persistence layer when one is configured, or from the
+// in-memory history otherwise. Useful for UIs that lazily load older messages.
+//
+// Parameters:
+//   - limit: Maximum number of messages to return (0 with offset 0 returns everything)
+//   - offset: Index of the first message to return
+//
+// Returns:
+//   - []llms.UnifiedMessage: The requested messages (empty if offset is out of range)
+func (a *Agent) GetHistory(limit, offset int) []llms.UnifiedMessage {
+	return a.GetSessionHistory(defaultSessionID, limit, offset)
 }
 
 // GetTools returns the list of tools currently configured for this agent.
 //
 // Returns:
-//   - []llms.Tool: Slice of tools (empty slice if no tools configured, never nil)
+//   - []llms.Tool: A copy of the tools (empty slice if no tools configured, never nil)
 func (a *Agent) GetTools() []llms.Tool {
+	a.toolsMu.RLock()
+	defer a.toolsMu.RUnlock()
 	if a.tools == nil {
 		return []llms.Tool{}
 	}
-	return a.tools
+	return slices.Clone(a.tools)
+}
+
+// SubAgents returns the agents this agent can delegate to: the configured
+// sub-agents followed by the system agents (e.g. the reasoning agent). Sub-agents
+// created by this package are *Agent values and can be inspected in turn.
+//
+// The returned slice is a copy; use AgentConfig to change the sub-agents.
+//
+// Returns:
+//   - []core.SubAgent: The sub-agents (empty slice if there are none, never nil)
+func (a *Agent) SubAgents() []core.SubAgent {
+	subAgents := make([]core.SubAgent, 0, len(a.subAgents))
+	for _, sa := range a.subAgents {
+		if sa != nil && *sa != nil {
+			subAgents = append(subAgents, *sa)
+		}
+	}
+	return subAgents
 }
 
 // SetTools sets the tools available to this agent.
 //
-// Tools can be set at any time and will be used in subsequent ChatStream calls.
+// Tools can be set at any time, also while runs are in progress, and will be
+// used from the next LLM request on.
 //
 // Parameters:
 //   - tools: Slice of tools to configure (can be nil or empty)
-func (a *Agent) SetTools(tools []llms.Tool) {
+//
+// Returns:
+//   - error: An error if two tools share a name (see tools.WithName); the tools are then unchanged
+func (a *Agent) SetTools(tools []llms.Tool) error {
+	if err := checkToolNames(tools); err != nil {
+		return err
+	}
+	a.toolsMu.Lock()
+	defer a.toolsMu.Unlock()
 	a.tools = tools
+	a.indexTools()
+	return nil
 }
 
 // ===== Sub Agent Interface =====
@@ -159,6 +568,8 @@ func (a *Agent) Trace() string {
 }
 
 func (a *Agent) SystemPrompt() string {
+	a.systemPromptMu.RLock()
+	defer a.systemPromptMu.RUnlock()
 	return a.config.SystemPrompt
 }
 
@@ -169,52 +580,186 @@ func (a *Agent) Troubleshooting() string {
 	return a.config.Troubleshooting
 }
 
+// Close stops in-flight runs and releases the persistence of every session.
+//
+// Running streams are cancelled and their runs end with an error. Close waits for
+// them to finish, saves each session's history one last time and closes its
+// persistence layer. The Agent must not be used after Close: new runs fail
+// immediately. Calling Close again returns the result of the first call.
+//
+// The sub-agents created by NewAgent (SubAgentConfigs and system agents) are
+// closed too. Agents passed in SubAgents are left open, since they may be shared.
+//
+// Returns:
+//   - error: The errors returned by the persistence layers and the sub-agents, joined
+func (a *Agent) Close() error {
+	a.closeOnce.Do(func() {
+		close(a.closed)
+
+		// Close sub-agents first so delegations in progress end and release our runs
+		var errs []error
+		for _, sa := range a.ownedSubAgents {
+			if err := sa.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("sub-agent '%s': %w", sa.Name(), err))
+			}
+		}
+
+		a.sessionsMu.Lock()
+		sessions := make([]*session, 0, len(a.sessions))
+		for _, s := range a.sessions {
+			sessions = append(sessions, s)
+		}
+		a.sessionsMu.Unlock()
+
+		for _, s := range sessions {
+			// Waits for the session's in-flight run to stop
+			s.mu.Lock()
+			if s.history.persistence != nil {
+				s.history.save()
+				if err := s.history.persistence.Close(); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			s.mu.Unlock()
+		}
+		a.closeErr = errors.Join(errs...)
+	})
+	return a.closeErr
+}
+
+// isClosed reports whether Close has been called.
+func (a *Agent) isClosed() bool {
+	select {
+	case <-a.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// closedError is the error of runs started or stopped after Close.
+func (a *Agent) closedError() error {
+	return fmt.Errorf("agent '%s' is closed", a.Name())
+}
+
 // ===== Core Chat Execution =====
 
 // executeChatWithTools executes the chat loop with automatic tool execution.
 // It handles streaming responses, tool call detection, execution, and iteration.
-func (a *Agent) executeChatWithTools() error {
+func (a *Agent) executeChatWithTools(r *agentRun) (runErr error) {
+	release, err := a.acquireRunSlot(r)
+	if errors.Is(err, errRunStopped) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	iteration := 0
+	argumentRetries := 0
+	emptyRetried := false
+	// retryNote is sent after the history in the next LLM call only, never stored
+	var retryNote string
+
+	// Span of the in-flight LLM call, ended early on error returns
+	var llmSpan trace.Span
+	defer func() {
+		if llmSpan != nil {
+			endSpan(llmSpan, runErr)
+		}
+	}()
 
+turns:
 	for iteration < a.config.MaxToolIterations {
 		iteration++
 
-		// Get current history
-		a.ensureHistory()
-		// Load history from persistence
-		a.history.get()
-		messages := a.history.History()
+		// The consumer may have stopped the run while tools were executing
+		select {
+		case <-r.responseCh.Stopped():
+			return nil
+		case <-r.ctx.Done():
+			return fmt.Errorf("%w: %w", ErrContextCancelled, r.ctx.Err())
+		default:
+		}
+
+		// The history was loaded when the run started and is kept in memory
+		// from then on: persistence may hold a trimmed copy (see
+		// persistence.JSONPersistenceOptions) that must not replace it mid-run
+		r.history.sanitize()
+		messages := a.fitContextWindow(r.history)
+		if a.config.ToolResultMetadata {
+			messages = withToolMetadata(messages)
+		}
+		if retryNote != "" {
+			messages = append(slices.Clip(messages), llms.SystemMessage(retryNote))
+			retryNote = ""
+		}
+		if a.config.MessageFilter != nil {
+			messages = a.config.MessageFilter.FilterOutgoing(messages)
+		}
+
+		// Forcing tool choices only apply to the first call of the turn,
+		// otherwise the model could never produce a final answer
+		opts := r.opts
+		if iteration > 1 && opts.ToolChoice.IsForcing() {
+			opts.ToolChoice = llms.ToolChoiceAuto
+		}
 
 		// Call LLM with current history and tools
-		llmResponseCh := (*a.llmEngine).ChatStream(messages, a.tools)
+		_, llmSpan = a.tracer().Start(r.ctx, SpanLLMCall, trace.WithAttributes(a.agentAttributes()...))
+		a.hooks.BeforeLLMCall(messages)
+		llmStart := time.Now()
+		llmResponseCh := a.streamLLM(messages, opts)
 
 		var fullContent string
 		var toolCalls []llms.ToolCall
 		var hasToolCalls bool
+		r.speakerLabeled = false
 		var completedChunkBytes []byte // Store completed chunk to forward later if needed
 		var promptTokens, completionTokens, totalTokens int
 
 		// Process streaming response
+		llmErrCh := llmResponseCh.Error
 		for {
 			select {
 			case chunkBytes, ok := <-llmResponseCh.Response:
 				if !ok {
-					// LLM response channel closed, streaming complete
+					// LLM response channel closed, streaming complete unless an
+					// error sent before the close is still buffered
+					if llmErrCh != nil {
+						if err, ok := <-llmErrCh; ok && err != nil {
+							if note, ok := a.retryToolArguments(err, &argumentRetries); ok {
+								retryNote = note
+								endSpan(llmSpan, err)
+								llmSpan = nil
+								continue turns
+							}
+							return a.streamFailed(r, fullContent, err)
+						}
+					}
 					goto processToolCalls
 				}
 
 				// Deserialize chunk
 				var chunk llms.ChunkResponse
 				if err := json.Unmarshal(chunkBytes, &chunk); err != nil {
-					return fmt.Errorf("failed to deserialize chunk: %w", err)
+					llmResponseCh.Cancel()
+					return a.streamFailed(r, fullContent, fmt.Errorf("failed to deserialize chunk: %w", err))
+				}
+				chunk, chunkBytes, err := a.filterIncoming(chunk, chunkBytes)
+				if err != nil {
+					llmResponseCh.Cancel()
+					return a.streamFailed(r, fullContent, err)
 				}
 
-				// Accumulate content (check both Content and Delta)
-				if chunk.Content != "" {
-					fullContent += chunk.Content
-				} else if chunk.Delta != "" {
-					fullContent += chunk.Delta
+				// Accumulate content (reasoning chunks are forwarded but not part of the answer)
+				text := answerText(chunk)
+				if kept, reached := a.limitOutput(r, text); reached {
+					llmResponseCh.Cancel()
+					return a.outputTruncated(r, fullContent, kept, chunk)
 				}
+				fullContent += text
 
 				// Check for tool calls
 				if chunk.Status == llms.StatusToolCall && len(chunk.ToolCalls) > 0 {
@@ -234,21 +779,94 @@ func (a *Agent) executeChatWithTools() error {
 				}
 
 				// Forward all other chunks to consumer
-				a.responseCh.Response <- chunkBytes
+				if err := a.send(r, a.labelSpeaker(r, chunk, chunkBytes)); err != nil {
+					llmResponseCh.Cancel()
+					return a.streamFailed(r, fullContent, err)
+				}
+
+			case <-a.closed:
+				llmResponseCh.Cancel()
+				return a.streamFailed(r, fullContent, a.closedError())
+
+			case <-r.responseCh.Stopped():
+				llmResponseCh.Cancel()
+				a.streamStopped(r, fullContent)
+				return nil
 
-			case err := <-llmResponseCh.Error:
+			case <-r.ctx.Done():
+				llmResponseCh.Cancel()
+				return a.streamFailed(r, fullContent, fmt.Errorf("%w: %w", ErrContextCancelled, r.ctx.Err()))
+
+			case err, ok := <-llmErrCh:
+				if !ok {
+					// Error channel closed: keep draining buffered chunks
+					llmErrCh = nil
+					continue
+				}
 				if err != nil {
-					return fmt.Errorf("llm stream error: %w", err)
+					if note, ok := a.retryToolArguments(err, &argumentRetries); ok {
+						retryNote = note
+						endSpan(llmSpan, err)
+						llmSpan = nil
+						continue turns
+					}
+					return a.streamFailed(r, fullContent+a.forwardBufferedChunks(r, llmResponseCh), err)
 				}
 				goto processToolCalls
 			}
 		}
 
 	processToolCalls:
-		// If no tool calls, forward the completed chunk (if any) and we're done
+		usage := llms.Usage{
+			PromptTokens:     promptTokens,
+			CompletionTokens: completionTokens,
+			TotalTokens:      totalTokens,
+		}
+		llmSpan.SetAttributes(usageAttributes(usage)...)
+		llmSpan.SetAttributes(AttrToolCalls.Int(len(toolCalls)))
+		llmSpan.End()
+		llmSpan = nil
+		a.hooks.AfterLLMCall(usage)
+		a.metrics.RecordLLMLatency(a.Name(), time.Since(llmStart))
+		a.metrics.RecordTokens(a.Name(), promptTokens, completionTokens)
+
+		// Models ignoring the function-calling API may write tool calls as text
+		if !hasToolCalls && a.config.ParseTextToolCalls {
+			if parsed := a.parseTextToolCalls(fullContent); len(parsed) > 0 {
+				agentforge.Debug("Agent '%s': parsed %d tool call(s) from text", a.Name(), len(parsed))
+				toolCalls = parsed
+				hasToolCalls = true
+			}
+		}
+
+		// Models occasionally end a turn without any answer
+		if !hasToolCalls && strings.TrimSpace(fullContent) == "" {
+			if a.config.EmptyResponseRetry && !emptyRetried {
+				emptyRetried = true
+				agentforge.Warn("Agent '%s': empty response, asking the model again", a.Name())
+				retryNote = emptyResponseNote
+				continue turns
+			}
+			if a.config.EmptyResponseFallback != "" {
+				fullContent, completedChunkBytes, err = a.sendEmptyResponseFallback(r, completedChunkBytes)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		// Chunks are filtered one at a time: filter the answer as a whole before saving it
+		fullContent = a.filterAnswer(fullContent)
+
+		// If no tool calls, save the answer, forward the completed chunk (if any) and we're done
 		if !hasToolCalls {
+			// Save the message to history with token usage
+			if fullContent != "" {
+				r.history.addAssistantMessage(fullContent, promptTokens, completionTokens, totalTokens)
+				r.history.save()
+			}
 			if completedChunkBytes != nil {
-				a.responseCh.Response <- completedChunkBytes
+				return a.send(r, addRunUsage(r, completedChunkBytes))
 			} else if fullContent != "" {
 				// Stream ended without StatusCompleted chunk, but we have content
 				// Send a completion chunk with accumulated content
@@ -264,96 +882,593 @@ func (a *Agent) executeChatWithTools() error {
 				}
 				completionBytes, err := json.Marshal(completionChunk)
 				if err == nil {
-					a.responseCh.Response <- completionBytes
+					return a.send(r, addRunUsage(r, completionBytes))
 				}
 			}
-			// Save the message to history with token usage
-			if fullContent != "" {
-				a.history.addAssistantMessage(fullContent, promptTokens, completionTokens, totalTokens)
-				a.history.save()
-			}
 			return nil
 		}
 
 		// Store assistant message with tool calls in history with token usage
-		a.history.addAssistantMessageWithToolCalls(fullContent, toolCalls, promptTokens, completionTokens, totalTokens)
-		a.history.save()
+		r.history.addAssistantMessageWithToolCalls(fullContent, toolCalls, promptTokens, completionTokens, totalTokens)
+		r.history.save()
+		r.usage.PromptTokens += promptTokens
+		r.usage.CompletionTokens += completionTokens
+		r.usage.TotalTokens += totalTokens
+
+		if err := a.executeToolCalls(r, toolCalls); err != nil {
+			return err
+		}
 
-		// Execute each tool
+		// Continue to next iteration (will call LLM again with tool results)
+	}
+
+	// If we reached max iterations, return error
+	return fmt.Errorf("%w (%d)", ErrMaxIterations, a.config.MaxToolIterations)
+}
+
+// executeToolCalls runs the tool calls of one assistant turn and adds their
+// results to history in call order. With ParallelToolCalls the calls run
+// concurrently (at most MaxParallelToolCalls at a time).
+func (a *Agent) executeToolCalls(r *agentRun, toolCalls []llms.ToolCall) error {
+	if !a.config.ParallelToolCalls || len(toolCalls) < 2 {
 		for _, toolCall := range toolCalls {
-			// Emit tool-executing chunk
-			executingChunk := llms.ChunkResponse{
-				Status:        llms.StatusToolExecuting,
-				Type:          llms.TypeToolExecuting,
-				ToolExecuting: &toolCall,
-			}
-			executingBytes, err := json.Marshal(executingChunk)
+			toolResult, err := a.executeToolWithChunks(r, toolCall)
 			if err != nil {
-				return fmt.Errorf("failed to serialize tool-executing chunk: %w", err)
+				return err
 			}
-			a.responseCh.Response <- executingBytes
+			a.addToolResult(r, toolCall, toolResult)
+		}
+		return nil
+	}
+
+	results := make([]llms.ToolResult, len(toolCalls))
+	errs := make([]error, len(toolCalls))
+	slots := make(chan struct{}, a.config.MaxParallelToolCalls)
+	var wg sync.WaitGroup
+	for i, toolCall := range toolCalls {
+		wg.Add(1)
+		go func(i int, toolCall llms.ToolCall) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			results[i], errs[i] = a.executeToolWithChunks(r, toolCall)
+		}(i, toolCall)
+	}
+	wg.Wait()
 
-			// Find and execute the tool
-			toolResult := a.executeTool(toolCall)
+	// History keeps the call order so each result follows its tool call
+	for i, toolCall := range toolCalls {
+		if errs[i] != nil {
+			return errs[i]
+		}
+		a.addToolResult(r, toolCall, results[i])
+	}
+	return nil
+}
+
+// executeToolWithChunks executes a tool call between its tool-executing
+// (tool-planned in dry-run mode) and tool-result chunks.
+func (a *Agent) executeToolWithChunks(r *agentRun, toolCall llms.ToolCall) (llms.ToolResult, error) {
+	executingChunk := llms.ChunkResponse{
+		Status:        llms.StatusToolExecuting,
+		Type:          llms.TypeToolExecuting,
+		ToolExecuting: &toolCall,
+	}
+	if a.config.DryRun {
+		executingChunk = llms.ChunkResponse{
+			Status:      llms.StatusToolPlanned,
+			Type:        llms.TypeToolPlanned,
+			ToolPlanned: &toolCall,
+		}
+	}
+	executingBytes, err := json.Marshal(executingChunk)
+	if err != nil {
+		return llms.ToolResult{}, fmt.Errorf("failed to serialize tool-executing chunk: %w", err)
+	}
+	if err := a.send(r, executingBytes); err != nil {
+		return llms.ToolResult{}, err
+	}
 
-			// Emit tool-result chunk
-			resultChunk := llms.ChunkResponse{
-				Status:      llms.StatusToolResult,
-				Type:        llms.TypeToolResult,
-				ToolResults: []llms.ToolResult{toolResult},
+	toolResult := a.executeTool(r, toolCall)
+
+	resultChunk := llms.ChunkResponse{
+		Status:      llms.StatusToolResult,
+		Type:        llms.TypeToolResult,
+		ToolResults: []llms.ToolResult{toolResult},
+	}
+	resultBytes, err := json.Marshal(resultChunk)
+	if err != nil {
+		return llms.ToolResult{}, fmt.Errorf("failed to serialize tool-result chunk: %w", err)
+	}
+	if err := a.send(r, resultBytes); err != nil {
+		return llms.ToolResult{}, err
+	}
+	return toolResult, nil
+}
+
+// addToolResult adds a tool result to history, surfacing failures so the LLM can react.
+func (a *Agent) addToolResult(r *agentRun, toolCall llms.ToolCall, toolResult llms.ToolResult) {
+	toolContent := toolResult.Result
+	if !toolResult.Success && toolResult.Error != "" {
+		toolContent = "Error: " + toolResult.Error
+	}
+	// The tool-result chunk carries the full result; only history is summarized or truncated
+	toolContent = a.summarizeToolContent(toolCall, toolContent)
+	toolContent = truncateToolContent(toolContent, a.config.MaxToolResultChars)
+	r.history.addToolMessage(toolCall.ID, toolContent, toolMessageMetadata(toolResult.Metadata))
+	r.history.save()
+}
+
+// toolMessageMetadata converts the metadata of a tool result to the tags stored
+// with its tool message, so persisted histories keep the duration, size and source.
+func toolMessageMetadata(metadata map[string]any) map[string]string {
+	tags := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		tags[key] = fmt.Sprint(value)
+	}
+	return tags
+}
+
+// withToolMetadata returns a copy of messages with the tags of each tool message
+// appended to its content (see AgentConfig.ToolResultMetadata).
+func withToolMetadata(messages []llms.UnifiedMessage) []llms.UnifiedMessage {
+	annotated := make([]llms.UnifiedMessage, len(messages))
+	for i, message := range messages {
+		annotated[i] = message
+		tags := message.Metadata()
+		if message.Role() != llms.MessageRoleTool || len(tags) == 0 {
+			continue
+		}
+		fields := make([]string, 0, len(tags))
+		for key, value := range tags {
+			fields = append(fields, key+"="+value)
+		}
+		slices.Sort(fields)
+		line := "[tool metadata: " + strings.Join(fields, " ") + "]"
+		annotated[i] = message.MapText(func(text string) string { return text + "\n" + line })
+	}
+	return annotated
+}
+
+// summarizeToolContent replaces a tool result longer than SummarizeToolResultsOver
+// by its summary. Failures are logged and keep the full result.
+func (a *Agent) summarizeToolContent(toolCall llms.ToolCall, content string) string {
+	if a.toolResultSummarizer == nil {
+		return content
+	}
+	size := utf8.RuneCountInString(content)
+	if size <= a.config.SummarizeToolResultsOver {
+		return content
+	}
+	summary, err := a.toolResultSummarizer.SummarizeText(fmt.Sprintf("Result of the %s tool:\n%s", toolCall.Name, content))
+	if err != nil {
+		agentforge.Warn("Agent '%s': keeping the full result of tool '%s': %v", a.Name(), toolCall.Name, err)
+		return content
+	}
+	agentforge.Debug("Agent '%s': summarized the result of tool '%s' (%d chars)", a.Name(), toolCall.Name, size)
+	return fmt.Sprintf(toolResultSummaryPrefix, size) + summary
+}
+
+// limitOutput counts text against MaxOutputChars. Once the limit is reached it
+// returns the part of text that still fits and true.
+func (a *Agent) limitOutput(r *agentRun, text string) (string, bool) {
+	limit := a.config.MaxOutputChars
+	if limit <= 0 || text == "" {
+		return text, false
+	}
+	runes := []rune(text)
+	remaining := limit - r.outputChars
+	if len(runes) <= remaining {
+		r.outputChars += len(runes)
+		return text, false
+	}
+	r.outputChars = limit
+	return string(runes[:remaining]), true
+}
+
+// addRunUsage adds the usage of the run's tool-call turns, whose completion
+// chunks are not forwarded, to a completion chunk: the completion chunk of a run
+// reports the usage of all its LLM calls.
+func addRunUsage(r *agentRun, completionBytes []byte) []byte {
+	if r.usage == (llms.Usage{}) {
+		return completionBytes
+	}
+	var chunk llms.ChunkResponse
+	if err := json.Unmarshal(completionBytes, &chunk); err != nil {
+		return completionBytes
+	}
+	chunk.PromptTokens += r.usage.PromptTokens
+	chunk.CompletionTokens += r.usage.CompletionTokens
+	chunk.TotalTokens += r.usage.TotalTokens
+	patched, err := json.Marshal(chunk)
+	if err != nil {
+		return completionBytes
+	}
+	return patched
+}
+
+// outputTruncated ends the run at MaxOutputChars: it forwards the part of the
+// last chunk that fits, emits a completion chunk flagged as truncated and saves
+// the truncated answer.
+func (a *Agent) outputTruncated(r *agentRun, fullContent, kept string, chunk llms.ChunkResponse) error {
+	fullContent = a.filterAnswer(fullContent + kept)
+	agentforge.Debug("Agent '%s': output truncated at %d chars", a.Name(), a.config.MaxOutputChars)
+	if fullContent != "" {
+		r.history.addAssistantMessage(fullContent, 0, 0, 0)
+		r.history.save()
+	}
+
+	if kept != "" {
+		chunk.Content = kept
+		chunk.Delta = kept
+		chunk.FullContent = fullContent
+		chunkBytes, err := json.Marshal(chunk)
+		if err != nil {
+			return fmt.Errorf("failed to serialize chunk: %w", err)
+		}
+		if err := a.send(r, a.labelSpeaker(r, chunk, chunkBytes)); err != nil {
+			return err
+		}
+	}
+
+	completionBytes, err := json.Marshal(llms.ChunkResponse{
+		FullContent: fullContent,
+		Status:      llms.StatusCompleted,
+		Type:        llms.TypeCompletion,
+		Truncated:   true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to serialize completion chunk: %w", err)
+	}
+	return a.send(r, addRunUsage(r, completionBytes))
+}
+
+// labelSpeaker sets DisplayName as the Speaker of the first content chunk of an
+// assistant message, prefixing its content with it when PrefixDisplayName is set.
+// Other chunks are returned unchanged.
+func (a *Agent) labelSpeaker(r *agentRun, chunk llms.ChunkResponse, chunkBytes []byte) []byte {
+	if a.config.DisplayName == "" || r.speakerLabeled || chunk.Type != llms.TypeContent || answerText(chunk) == "" {
+		return chunkBytes
+	}
+	r.speakerLabeled = true
+
+	chunk.Speaker = a.config.DisplayName
+	if a.config.PrefixDisplayName {
+		prefix := a.config.DisplayName + ": "
+		if chunk.Content != "" {
+			chunk.Content = prefix + chunk.Content
+		}
+		if chunk.Delta != "" {
+			chunk.Delta = prefix + chunk.Delta
+		}
+	}
+	labeled, err := json.Marshal(chunk)
+	if err != nil {
+		return chunkBytes
+	}
+	return labeled
+}
+
+// answerText returns the answer text carried by an LLM chunk (Content, or Delta
+// when Content is empty). Reasoning chunks carry no answer text.
+func answerText(chunk llms.ChunkResponse) string {
+	if chunk.Type == llms.TypeReasoning {
+		return ""
+	}
+	if chunk.Content != "" {
+		return chunk.Content
+	}
+	return chunk.Delta
+}
+
+// maxReportedArguments caps the raw arguments quoted back to the model on a retry.
+const maxReportedArguments = 500
+
+// retryToolArguments returns the note reporting tool call arguments that are not
+// valid JSON back to the model, sent with the next LLM call only so it can retry
+// the tool call. It returns false when err is another error or the retries
+// (AgentConfig.MaxToolArgumentRetries) are used up.
+func (a *Agent) retryToolArguments(err error, retries *int) (string, bool) {
+	var argErr *llms.ToolArgumentsError
+	if !errors.As(err, &argErr) || *retries >= a.config.MaxToolArgumentRetries {
+		return "", false
+	}
+	*retries++
+	agentforge.Warn("Agent '%s': invalid tool call arguments (retry %d/%d): %v", a.Name(), *retries, a.config.MaxToolArgumentRetries, err)
+
+	return fmt.Sprintf(
+		"Your tool arguments for '%s' were not valid JSON: %v. Received: %s\nCall the tool again with its arguments as a single valid JSON object.",
+		argErr.ToolName, argErr.Err, truncateToolContent(argErr.Arguments, maxReportedArguments),
+	), true
+}
+
+// emptyResponseNote asks the model for an answer after an empty response (see EmptyResponseRetry).
+const emptyResponseNote = "Your last response was empty. Please provide an answer to the user."
+
+// sendEmptyResponseFallback streams EmptyResponseFallback in place of an empty
+// answer and returns it with the completion chunk updated to carry it, or the
+// error of a chunk that could not be forwarded.
+func (a *Agent) sendEmptyResponseFallback(r *agentRun, completedChunkBytes []byte) (string, []byte, error) {
+	fallback := a.config.EmptyResponseFallback
+	agentforge.Warn("Agent '%s': empty response, answering with the fallback", a.Name())
+
+	fallbackChunk := llms.ChunkResponse{
+		Content:     fallback,
+		Delta:       fallback,
+		FullContent: fallback,
+		Status:      llms.StatusStreaming,
+		Type:        llms.TypeContent,
+	}
+	if chunkBytes, err := json.Marshal(fallbackChunk); err == nil {
+		if err := a.send(r, a.labelSpeaker(r, fallbackChunk, chunkBytes)); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if completedChunkBytes == nil {
+		// A completion chunk carrying the fallback is built from the content
+		return fallback, nil, nil
+	}
+	var completion llms.ChunkResponse
+	if err := json.Unmarshal(completedChunkBytes, &completion); err != nil {
+		return fallback, nil, nil
+	}
+	completion.FullContent = fallback
+	patched, err := json.Marshal(completion)
+	if err != nil {
+		return fallback, nil, nil
+	}
+	return fallback, patched, nil
+}
+
+// send forwards a chunk to the consumer. It gives up when the run's context is
+// done or the agent is closed, so a consumer that stops reading without calling
+// Stop (e.g. a disconnected client) cannot block the run forever.
+//
+// Returns:
+//   - error: nil once the chunk is forwarded, otherwise why it was dropped
+func (a *Agent) send(r *agentRun, chunkBytes []byte) error {
+	select {
+	case r.responseCh.Response <- chunkBytes:
+		return nil
+	case <-r.ctx.Done():
+		return fmt.Errorf("%w: %w", ErrContextCancelled, r.ctx.Err())
+	case <-a.closed:
+		return a.closedError()
+	}
+}
+
+// streamFailed saves the content streamed before an LLM stream error as an
+// incomplete assistant message, so a resumed session has a coherent
+// transcript, and returns the wrapped error.
+func (a *Agent) streamFailed(r *agentRun, partialContent string, err error) error {
+	if partialContent != "" {
+		r.history.addIncompleteAssistantMessage(a.filterAnswer(partialContent))
+		r.history.save()
+	}
+	return fmt.Errorf("llm stream error: %w", err)
+}
+
+// streamStopped saves the content streamed before the consumer stopped the run
+// (see core.ResponseCh.Stop) as an incomplete assistant message.
+func (a *Agent) streamStopped(r *agentRun, partialContent string) {
+	agentforge.Debug("Agent '%s': stream stopped by the consumer", a.Name())
+	if partialContent != "" {
+		r.history.addIncompleteAssistantMessage(a.filterAnswer(partialContent))
+		r.history.save()
+	}
+}
+
+// forwardBufferedChunks forwards the chunks already buffered on an errored LLM
+// stream and returns their content. Chunks sent before the error are always
+// buffered by then, so the non-blocking drain does not lose any of them. It
+// stops at the first chunk that cannot be forwarded.
+func (a *Agent) forwardBufferedChunks(r *agentRun, llmResponseCh *llms.ResponseCh) string {
+	var content string
+	for {
+		select {
+		case chunkBytes, ok := <-llmResponseCh.Response:
+			if !ok {
+				return content
+			}
+			var chunk llms.ChunkResponse
+			if err := json.Unmarshal(chunkBytes, &chunk); err != nil {
+				continue
 			}
-			resultBytes, err := json.Marshal(resultChunk)
+			chunk, chunkBytes, err := a.filterIncoming(chunk, chunkBytes)
 			if err != nil {
-				return fmt.Errorf("failed to serialize tool-result chunk: %w", err)
+				continue
 			}
-			a.responseCh.Response <- resultBytes
+			content += answerText(chunk)
+			if a.send(r, a.labelSpeaker(r, chunk, chunkBytes)) != nil {
+				return content
+			}
+		default:
+			return content
+		}
+	}
+}
 
-			// Add tool result to history
-			a.history.addToolMessage(toolCall.ID, toolResult.Result)
-			a.history.save()
+// streamLLM calls the engine, passing generation options when the engine supports them.
+func (a *Agent) streamLLM(messages []llms.UnifiedMessage, opts llms.GenerationOptions) *llms.ResponseCh {
+	agentTools := a.llmTools()
+	if opts != (llms.GenerationOptions{}) {
+		if engine, ok := (*a.llmEngine).(llms.LLMEngineWithOptions); ok {
+			return engine.ChatStreamWithOptions(messages, agentTools, opts)
 		}
+		agentforge.Debug("Agent '%s': engine does not support generation options, ignoring %+v", a.Name(), opts)
+	}
+	return (*a.llmEngine).ChatStream(messages, agentTools)
+}
 
-		// Continue to next iteration (will call LLM again with tool results)
+// llmTools returns the tools sent to the engine, with detailed descriptions
+// when DetailedToolDescriptions is set.
+func (a *Agent) llmTools() []llms.Tool {
+	a.toolsMu.RLock()
+	defer a.toolsMu.RUnlock()
+	if !a.config.DetailedToolDescriptions {
+		return a.tools
 	}
+	detailed := make([]llms.Tool, len(a.tools))
+	for i, t := range a.tools {
+		detailed[i] = detailedTool{Tool: t}
+	}
+	return detailed
+}
 
-	// If we reached max iterations, return error
-	return fmt.Errorf("reached maximum tool iterations (%d)", a.config.MaxToolIterations)
+// detailedTool sends the advanced description of a discoverable tool along with
+// its basic description. Tools with their own LLM description keep it.
+type detailedTool struct {
+	llms.Tool
 }
 
-// executeTool finds and executes a tool by name.
-func (a *Agent) executeTool(toolCall llms.ToolCall) llms.ToolResult {
-	// Build agent context from pre-built context struct
-	agentContext := a.agentContext.BuildContext(a.responseCh)
+func (t detailedTool) GetFunctionDefinition() llms.FunctionDefinition {
+	def := t.Tool.GetFunctionDefinition()
+	if custom, ok := t.Tool.(interface{ LLMDescription() string }); ok && custom.LLMDescription() != "" {
+		return def
+	}
+	if d, ok := t.Tool.(agentforge.Discoverable); ok && d.AdvanceDescription() != "" {
+		def.Description = strings.TrimSpace(def.Description + "\n\n" + d.AdvanceDescription())
+	}
+	return def
+}
 
-	// Find the tool
-	var tool llms.Tool
-	for _, t := range a.tools {
-		if t.GetName() == toolCall.Name {
-			tool = t
-			break
-		}
+// executeTool executes a tool call, invoking the tool hooks around it and recording its metrics.
+func (a *Agent) executeTool(r *agentRun, toolCall llms.ToolCall) llms.ToolResult {
+	ctx, span := a.tracer().Start(r.ctx, SpanToolCall, trace.WithAttributes(a.agentAttributes()...))
+	span.SetAttributes(AttrToolName.String(toolCall.Name))
+
+	a.hooks.BeforeToolCall(toolCall)
+	start := time.Now()
+	var result llms.ToolResult
+	var cached bool
+	if a.config.DryRun {
+		result = a.plannedToolResult(toolCall)
+	} else if denied, approved := a.approveToolCall(ctx, toolCall); !approved {
+		// The denial is reported to the LLM and the loop continues
+		result = denied
+	} else if result, cached = a.cachedToolResult(toolCall); !cached {
+		result = a.runTool(r, ctx, toolCall)
+		a.cacheToolResult(toolCall, result)
+	}
+	a.hooks.AfterToolCall(result)
+	a.metrics.RecordToolCall(a.Name(), toolCall.Name, time.Since(start), result.Success)
+
+	span.SetAttributes(AttrToolSuccess.Bool(result.Success), AttrToolCached.Bool(cached))
+	var toolErr error
+	if result.Err != nil {
+		toolErr = result.Err
+	} else if !result.Success {
+		toolErr = fmt.Errorf("%s", result.Error)
 	}
+	endSpan(span, toolErr)
+	return result
+}
 
+// runTool finds and executes a tool by name.
+func (a *Agent) runTool(r *agentRun, ctx context.Context, toolCall llms.ToolCall) llms.ToolResult {
+	// Build agent context from pre-built context struct
+	agentContext := a.agentContext.BuildContext(r.responseCh)
+	agentContext[core.ContextTraceContext] = ctx
+	agentContext[core.ContextDelegationDepth] = r.depth
+	agentContext[core.ContextMaxDelegationDepth] = r.maxDepth
+	agentContext[core.ContextCoerceArguments] = a.config.CoerceToolArguments
+	// Tools replaced with SetTools, e.g. for list_capabilities and expand
+	agentContext["tools"] = a.GetTools()
+
+	tool := a.findTool(toolCall.Name)
 	if tool == nil {
+		err := fmt.Errorf("%w: %s", ErrToolNotFound, toolCall.Name)
 		return llms.ToolResult{
 			ToolCallID: toolCall.ID,
 			ToolName:   toolCall.Name,
 			Success:    false,
 			Result:     "",
-			Error:      fmt.Sprintf("tool not found: %s", toolCall.Name),
+			Error:      err.Error(),
+			Err:        err,
+			Metadata:   a.toolResultMetadata(0, ""),
 		}
 	}
 
 	// Execute the tool
+	start := time.Now()
 	result := tool.Call(agentContext, toolCall.Arguments)
+	duration := time.Since(start)
 
-	// Convert to ToolResult
-	return llms.ToolResult{
+	// Convert to ToolResult, preserving structured data
+	toolResult := llms.ToolResult{
 		ToolCallID: toolCall.ID,
 		ToolName:   toolCall.Name,
 		Success:    result.Success(),
 		Result:     result.Data(),
 		Error:      result.Error(),
+		Metadata:   a.toolResultMetadata(duration, result.Data()),
+	}
+	if jsonResult, ok := result.(llms.ToolReturnJSON); ok {
+		toolResult.Data = jsonResult.DataJSON()
+	}
+	return toolResult
+}
+
+// plannedToolResult returns the synthetic result of a tool call skipped in dry-run mode.
+func (a *Agent) plannedToolResult(toolCall llms.ToolCall) llms.ToolResult {
+	args, err := json.Marshal(toolCall.Arguments)
+	if err != nil {
+		args = []byte(fmt.Sprintf("%v", toolCall.Arguments))
+	}
+	status := "would have been called"
+	if a.findTool(toolCall.Name) == nil {
+		status = "would have failed (tool not found)"
+	}
+	data := fmt.Sprintf("Dry run: tool '%s' was not executed. It %s with arguments: %s", toolCall.Name, status, args)
+
+	metadata := a.toolResultMetadata(0, data)
+	metadata[llms.ToolMetadataDryRun] = true
+	return llms.ToolResult{
+		ToolCallID: toolCall.ID,
+		ToolName:   toolCall.Name,
+		Success:    true,
+		Result:     data,
+		Metadata:   metadata,
+	}
+}
+
+// findTool returns the agent tool with the given name, or nil if there is none.
+func (a *Agent) findTool(name string) llms.Tool {
+	a.toolsMu.RLock()
+	defer a.toolsMu.RUnlock()
+	return a.toolsByName[name]
+}
+
+// indexTools rebuilds the name index of the agent's tools, whose names were
+// checked to be unique. The caller must hold toolsMu or own the agent
+// exclusively (during NewAgent).
+func (a *Agent) indexTools() {
+	a.toolsByName = make(map[string]llms.Tool, len(a.tools))
+	for _, t := range a.tools {
+		a.toolsByName[t.GetName()] = t
+	}
+}
+
+// truncateToolContent shortens content to at most maxChars characters, appending a
+// marker with the number of characters removed. maxChars <= 0 disables truncation.
+func truncateToolContent(content string, maxChars int) string {
+	if maxChars <= 0 {
+		return content
+	}
+	runes := []rune(content)
+	if len(runes) <= maxChars {
+		return content
+	}
+	return fmt.Sprintf("%s...[truncated %d chars]", string(runes[:maxChars]), len(runes)-maxChars)
+}
+
+// toolResultMetadata builds the structured metadata attached to every ToolResult.
+func (a *Agent) toolResultMetadata(duration time.Duration, data string) map[string]any {
+	return map[string]any{
+		llms.ToolMetadataDuration: duration.Milliseconds(),
+		llms.ToolMetadataBytes:    len(data),
+		llms.ToolMetadataSource:   a.Name(),
 	}
 }
 
@@ -363,45 +1478,159 @@ func (a *Agent) executeTool(toolCall llms.ToolCall) llms.ToolResult {
 
 func (a *Agent) ensureHistory() {
 	if a.history == nil {
-		a.history = &History{}
+		a.history = a.newHistory()
+	}
+}
 
-		// Set up persistence if configured using the factory
-		if a.persistence != "" {
-			a.history.persistence = persistence.NewPersistence(a.Name(), a.persistence)
-			if a.history.persistence != nil {
-				agentforge.Debug("Initialized %s persistence for agent '%s'", a.persistence, a.Name())
-			}
+// newHistory creates an empty history with the configured persistence.
+func (a *Agent) newHistory() *History {
+	h := &History{onSaveError: a.reportSaveError}
+
+	// Set up persistence if configured using the factory
+	if a.persistence != "" {
+		h.persistence = persistence.NewPersistenceInDirWithOptions(a.Name(), a.persistence, a.config.PersistenceDir, a.config.PersistenceJSON)
+		if h.persistence != nil {
+			agentforge.Debug("Initialized %s persistence for agent '%s'", a.persistence, a.Name())
 		}
 	}
+	return h
 }
 
-func (a *Agent) handleNewUserMessage(message string) []llms.UnifiedMessage {
-	a.ensureHistory()
-	a.history.addUserMessage(message)
-	a.history.save()
-	return a.history.History()
+// reportSaveError logs a failed history save and passes it to the hooks
+// implementing SaveErrorHooks.
+func (a *Agent) reportSaveError(err error) {
+	agentforge.Error("Agent '%s': failed to save history: %v", a.Name(), err)
+	if hooks, ok := a.hooks.(SaveErrorHooks); ok {
+		hooks.OnSaveError(a.Name(), err)
+	}
 }
 
-func (a *Agent) handleNewAssistantMessage(message string) {
-	a.ensureHistory()
-	a.history.addAssistantMessage(message, 0, 0, 0)
-	a.history.save()
+// summarizeHistory replaces the oldest turns with a summary note when the history
+// exceeds SummarizeAfterTokens. Failures are logged and leave the history untouched.
+func (a *Agent) summarizeHistory(h *History) {
+	if a.summarizer == nil {
+		return
+	}
+
+	messages := h.History()
+	if estimateTokens(messages) <= a.config.SummarizeAfterTokens {
+		return
+	}
+
+	// Keep the pinned system prompt
+	start := 0
+	if h.hasSystemMessage {
+		start = 1
+	}
+
+	// Keep the most recent turns verbatim: cut at the start of the N-th last user message
+	end := -1
+	turns := 0
+	for i := len(messages) - 1; i >= start; i-- {
+		if messages[i].Role() == llms.MessageRoleUser {
+			turns++
+			if turns == a.config.SummaryKeepTurns {
+				end = i
+				break
+			}
+		}
+	}
+	if end <= start {
+		// Not enough older turns to summarize
+		return
+	}
+
+	summary, err := a.summarizer.Summarize(messages[start:end])
+	if err != nil {
+		agentforge.Warn("Agent '%s': history summarization skipped: %v", a.Name(), err)
+		return
+	}
+
+	h.replaceWithSummary(start, end, summary)
+	h.save()
+	agentforge.Debug("Agent '%s': summarized %d messages", a.Name(), end-start)
 }
 
-func (a *Agent) handleSystemPromptInjection() []llms.UnifiedMessage {
+func (a *Agent) handleNewAssistantMessage(message string) {
 	a.ensureHistory()
-	a.ensureSystemPrompt()
-	a.history.addSystemMessage(a.systemPrompt)
+	a.history.addAssistantMessage(message, 0, 0, 0)
 	a.history.save()
-	return a.history.History()
 }
 
 // ==============================
 // ===== System Prompt Management
 // ==============================
 
+// systemPromptForRun builds the system prompt on first use and returns it.
+func (a *Agent) systemPromptForRun() string {
+	a.systemPromptOnce.Do(a.ensureSystemPrompt)
+	a.systemPromptMu.RLock()
+	defer a.systemPromptMu.RUnlock()
+	return a.systemPrompt
+}
+
+// injectSystemPrompt adds the system prompt to a history. Once the prompt has
+// been updated, a different system message already in the history is replaced.
+func (a *Agent) injectSystemPrompt(h *History) {
+	prompt := a.systemPromptForRun()
+
+	a.systemPromptMu.RLock()
+	updated := a.systemPromptUpdated
+	a.systemPromptMu.RUnlock()
+
+	if updated {
+		h.setSystemMessage(prompt)
+	} else {
+		h.addSystemMessage(prompt)
+	}
+}
+
+// UpdateSystemPrompt replaces the system prompt of the agent, e.g. to change
+// its instructions mid-session. Placeholders are expanded as for
+// AgentConfig.SystemPrompt.
+//
+// The system message of the open sessions is replaced right away (waiting for a
+// running turn of each to finish); other sessions, such as persisted ones not
+// used yet, get the new prompt at their next turn.
+//
+// Parameters:
+//   - prompt: The new system prompt
+func (a *Agent) UpdateSystemPrompt(prompt string) {
+	// Build the initial prompt first so it cannot overwrite the update
+	a.systemPromptOnce.Do(a.ensureSystemPrompt)
+
+	a.systemPromptMu.Lock()
+	a.config.SystemPrompt = prompt
+	a.buildSystemPrompt()
+	a.systemPromptUpdated = true
+	a.systemPromptMu.Unlock()
+
+	a.sessionsMu.Lock()
+	sessions := make([]*session, 0, len(a.sessions))
+	for _, s := range a.sessions {
+		sessions = append(sessions, s)
+	}
+	a.sessionsMu.Unlock()
+
+	for _, s := range sessions {
+		s.mu.Lock()
+		s.history.get()
+		a.injectSystemPrompt(s.history)
+		s.history.save()
+		s.mu.Unlock()
+	}
+}
+
 func (a *Agent) ensureSystemPrompt() {
-	a.systemPrompt = a.config.SystemPrompt
+	a.systemPromptMu.Lock()
+	defer a.systemPromptMu.Unlock()
+	a.buildSystemPrompt()
+}
+
+// buildSystemPrompt builds the final system prompt from the configured one.
+// The caller holds systemPromptMu.
+func (a *Agent) buildSystemPrompt() {
+	a.systemPrompt, _ = a.expandSystemPrompt()
 
 	if a.systemPrompt == "" {
 		a.systemPrompt = `You are an helpful assistant`
@@ -458,8 +1687,10 @@ BE MINDFUL
 `
 	}
 	a.buildSubAgentsSystemPrompt()
-	a.ensureHistory()
-	a.history.addSystemMessage(a.systemPrompt)
+
+	if err := a.systemPromptSizeError(a.systemPrompt); err != nil {
+		agentforge.Warn("Agent '%s': %v (%d sub-agents)", a.config.AgentName, err, len(a.subAgents))
+	}
 }
 
 func (a *Agent) buildSubAgentsSystemPrompt() {
@@ -500,93 +1731,211 @@ func (a *Agent) getSubAgentsAsInterfaces() []*core.SubAgent {
 	return subAgentInterfaces
 }
 
-// Add System Agents based on configs
-func (a *Agent) addSystemAgents() {
-	var systemAgents []*core.SubAgent
-
-	if a.config.Reasoning {
-		// Create reasoning agent from template
-		// Check if a specific engine is configured for this sub agent
-		var engineForReasoning llms.LLMEngine
-		if a.config.ExtraEngines != nil {
-			if engine, ok := a.config.ExtraEngines["system-reasoning"]; ok && engine != nil {
-				engineForReasoning = engine
+// addConfiguredSubAgents instantiates the sub-agents declared in SubAgentConfigs.
+func (a *Agent) addConfiguredSubAgents() error {
+	for i, saConfig := range a.config.SubAgentConfigs {
+		if saConfig == nil {
+			return fmt.Errorf("invalid AgentConfig: SubAgentConfigs[%d] is nil", i)
+		}
+		if saConfig.LLMEngine == nil {
+			if engine, ok := a.config.ExtraEngines[saConfig.AgentName]; ok && engine != nil {
+				saConfig.LLMEngine = engine
 			} else {
-				engineForReasoning = a.config.LLMEngine
+				saConfig.LLMEngine = a.config.LLMEngine
 			}
-		} else {
-			engineForReasoning = a.config.LLMEngine
 		}
-		raConfig := ReasoningAgentTemplate.ToAgentConfig(engineForReasoning)
-		ra := NewAgent(&raConfig)
-		raAsSubAgent := ra.AgentAsSubAgent()
-		systemAgents = append(systemAgents, raAsSubAgent)
+		sa, err := TryNewAgent(saConfig)
+		if err != nil {
+			return fmt.Errorf("sub-agent '%s': %w", saConfig.AgentName, err)
+		}
+		a.ownedSubAgents = append(a.ownedSubAgents, sa)
+		a.subAgents = append(a.subAgents, sa.AgentAsSubAgent())
 	}
+	return nil
+}
 
-	// Append system agents to subagents
-	a.subAgents = append(a.subAgents, systemAgents...)
+// addSystemAgents instantiates the system agents enabled by Reasoning and
+// listed in SystemAgents from their registered templates.
+func (a *Agent) addSystemAgents() error {
+	var names []string
+	if a.config.Reasoning {
+		names = append(names, ReasoningAgentTemplate.Name)
+	}
+	names = append(names, a.config.SystemAgents...)
 
-	// if len(systemAgents) > 0 {
-	// 	subAgentsInterfaces := a.getSubAgentsAsInterfaces()
-	// 	delegateTool := tools.NewDelegateTool(subAgentsInterfaces)
-	// 	a.tools = append(a.tools, delegateTool)
-	// }
+	added := make(map[string]bool)
+	for _, name := range names {
+		if added[name] {
+			continue
+		}
+		added[name] = true
+
+		// validate checked the name is registered
+		template, _ := LookupSystemAgentTemplate(name)
+		engine := a.config.LLMEngine
+		if extra, ok := a.config.ExtraEngines[name]; ok && extra != nil {
+			engine = extra
+		}
+		sa, err := TryNewAgent(template.ToAgentConfig(engine))
+		if err != nil {
+			return fmt.Errorf("system agent '%s': %w", name, err)
+		}
+		a.ownedSubAgents = append(a.ownedSubAgents, sa)
+		a.subAgents = append(a.subAgents, sa.AgentAsSubAgent())
+	}
+	return nil
 }
 
 // ==============================
 // ===== Initialization Methods
 // ==============================
 
+// init validates the configuration and builds the agent's sub-agents and tools.
+func (a *Agent) init() error {
+	if err := a.ensureConfig(); err != nil {
+		return err
+	}
+	a.persistence = a.config.Persistence
+	if err := a.addConfiguredSubAgents(); err != nil {
+		return err
+	}
+	if err := a.addSystemAgents(); err != nil {
+		return err
+	}
+	if err := a.initSystemTools(); err != nil {
+		return err
+	}
+	a.initAgentContext()
+
+	if a.config.ValidateTeam {
+		return ValidateTeam(a)
+	}
+	return nil
+}
+
 // ensureConfig validates and sets default configuration values
-func (a *Agent) ensureConfig() {
+func (a *Agent) ensureConfig() error {
 	if err := a.config.validate(); err != nil {
-		panic(fmt.Errorf("invalid AgentConfig: %w", err))
+		return fmt.Errorf("invalid AgentConfig: %w", err)
+	}
+	if err := a.validateSystemPromptVars(); err != nil {
+		return fmt.Errorf("invalid AgentConfig: %w", err)
 	}
 
 	if a.config.MaxToolIterations <= 0 {
 		a.config.MaxToolIterations = 10
 	}
 
+	if a.config.MaxDelegationDepth <= 0 {
+		a.config.MaxDelegationDepth = 5
+	}
+
+	if a.config.MaxToolArgumentRetries == 0 {
+		a.config.MaxToolArgumentRetries = 2
+	}
+
+	if a.config.MaxParallelToolCalls <= 0 {
+		a.config.MaxParallelToolCalls = 4
+	}
+
+	if a.config.ResponseBufferSize <= 0 {
+		a.config.ResponseBufferSize = core.DefaultResponseBufferSize
+	}
+
+	if a.config.SummarizeAfterTokens > 0 {
+		if a.config.SummaryKeepTurns <= 0 {
+			a.config.SummaryKeepTurns = 2
+		}
+		a.summarizer = NewSummarizer(a.config.LLMEngine)
+	}
+
+	if a.config.SummarizeToolResultsOver > 0 {
+		a.toolResultSummarizer = NewToolResultSummarizer(a.config.LLMEngine)
+	}
+
+	if a.config.ContextCompaction != "" {
+		a.initContextCompaction()
+	}
+
 	a.llmEngine = &a.config.LLMEngine
-	a.subAgents = a.config.SubAgents
-}
+	a.hooks = a.config.Hooks
+	if a.hooks == nil {
+		a.hooks = NoopHooks{}
+	}
+	a.metrics = a.config.Metrics
+	if a.metrics == nil {
+		a.metrics = NoopMetrics{}
+	}
+	a.toolCache = a.config.ToolCache
 
-func (a *Agent) setResponseCh() {
-	a.responseCh = core.NewResponseCh(a.config.AgentName, a.config.Trace)
+	// Copy so appending system agents never mutates the caller's slice
+	a.subAgents = append([]*core.SubAgent{}, a.config.SubAgents...)
+	return nil
 }
 
-func (a *Agent) initSystemTools() {
+func (a *Agent) initSystemTools() error {
 	// Ensure tools
 	if a.tools == nil {
 		a.tools = []llms.Tool{}
 	}
-	// Foo Tool
-	ft := tools.NewFooTool()
-	a.tools = append(a.tools, ft)
+	a.tools = append(a.tools, a.config.Tools...)
+	if a.config.ToolRegistry != nil {
+		a.tools = append(a.tools, a.config.ToolRegistry.All()...)
+	}
+	// Foo Tool, unless the config already provides one
+	hasFoo := false
+	for _, t := range a.tools {
+		if t.GetName() == "foo" {
+			hasFoo = true
+			break
+		}
+	}
+	if !hasFoo {
+		a.tools = append(a.tools, tools.NewFooTool())
+	}
 
 	// Delegate Tool
 	if len(a.subAgents) > 0 {
 		dt := tools.NewDelegateTool(a.subAgents)
+		if a.config.QuietDelegation {
+			dt = tools.NewQuietDelegateTool(a.subAgents)
+		}
 		a.tools = append(a.tools, dt)
 	}
+	if err := checkToolNames(a.tools); err != nil {
+		return fmt.Errorf("invalid AgentConfig: %w", err)
+	}
+	a.indexTools()
+	return nil
 }
 
-func (a *Agent) initResponseCh() {
-	a.responseCh = core.NewResponseCh(a.Name(), a.Trace())
-	a.responseCh.Start()
+// checkToolNames reports the first name shared by two tools, if any.
+func checkToolNames(tools []llms.Tool) error {
+	seen := make(map[string]bool, len(tools))
+	for _, t := range tools {
+		name := t.GetName()
+		if seen[name] {
+			return fmt.Errorf("duplicate tool name %q (rename one with tools.WithName)", name)
+		}
+		seen[name] = true
+	}
+	return nil
 }
 
 // initAgentContext builds the agent context struct with static fields
-// that don't change during the agent's lifetime.
+// that don't change during the agent's lifetime. Tools are added per tool call
+// (see runTool), since SetTools can replace them.
 func (a *Agent) initAgentContext() {
 	a.agentContext = &core.AgentContext{
 		AgentName: a.Name(),
 		Trace:     a.Trace(),
-		Tools:     a.tools,
 		SubAgents: a.subAgents,
 	}
 }
 
+// Agents propagate delegation depth and trace context to each other.
+var _ core.DelegationAwareSubAgent = (*Agent)(nil)
+
 func (a *Agent) AgentAsSubAgent() *core.SubAgent {
 	a.mainAgent = false
 	sa := core.SubAgent(a)
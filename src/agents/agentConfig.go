@@ -2,9 +2,13 @@ package agents
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/thinktwice/agentForge/src/core"
 	"github.com/thinktwice/agentForge/src/llms"
+	"github.com/thinktwice/agentForge/src/persistence"
+	"github.com/thinktwice/agentForge/src/tools"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // AgentConfig holds configuration parameters for creating a new Agent.
@@ -32,21 +36,237 @@ type AgentConfig struct {
 	// Trace is optional trace information (e.g., "thinking", "response").
 	Trace string
 
+	// DisplayName is the speaker label of the agent's answers in multi-bot UIs
+	// (e.g. "Support Bot"). When set, the first content chunk of each assistant
+	// message carries it in its Speaker field. Empty (default) disables labels.
+	DisplayName string
+
+	// PrefixDisplayName also prefixes the content of that chunk with
+	// "DisplayName: ", for consumers that only print content. The prefix is not
+	// part of the answer: FullContent and the history do not include it.
+	PrefixDisplayName bool
+
 	// Reasoning indicates whether reasoning mode is enabled.
 	// This parameter is reserved for future use.
 	Reasoning bool
 
+	// SystemAgents names registered system agent templates (see
+	// RegisterSystemAgentTemplate) that NewAgent instantiates as sub-agents.
+	// Each uses ExtraEngines[name] if set, otherwise this agent's LLMEngine.
+	// "system-reasoning" is the same agent Reasoning adds; it is added once.
+	SystemAgents []string
+
 	// SystemPrompt is the system prompt to use for the agent.
+	// {{name}} placeholders are expanded when the prompt is built on the first chat,
+	// see SystemPromptVars.
 	SystemPrompt string
 
+	// SystemPromptVars are the values of the {{name}} placeholders in SystemPrompt.
+	// Built-in placeholders are {{now}} (RFC 3339 time), {{date}} (YYYY-MM-DD) and
+	// {{agentName}}; values set here take precedence over them.
+	SystemPromptVars map[string]string
+
+	// StrictSystemPromptVars makes NewAgent panic when SystemPrompt contains a
+	// placeholder without a value. By default such placeholders are left intact.
+	StrictSystemPromptVars bool
+
+	// MaxSystemPromptChars is the size, in characters, above which the assembled
+	// system prompt (SystemPrompt plus the team instructions and sub-agent list) is
+	// reported: a warning is logged when it is built, to catch prompt bloat from many
+	// sub-agents (about 4 characters per token). 0 (default) disables the check.
+	MaxSystemPromptChars int
+
+	// StrictSystemPromptSize makes runs fail with an error wrapping
+	// ErrSystemPromptTooLong, instead of only warning, when the system prompt
+	// exceeds MaxSystemPromptChars.
+	StrictSystemPromptSize bool
+
 	// Tools is the list of tools available to the agent.
 	// Can be nil or empty if no tools are needed.
 	Tools []llms.Tool
 
+	// DetailedToolDescriptions sends the advanced description of each tool to the LLM
+	// along with its basic description, for models that use tools better with richer
+	// schemas. Tools with an LLM description (core.Tool.WithLLMDescription) keep it.
+	// By default only the terse basic description is sent.
+	DetailedToolDescriptions bool
+
+	// ToolResultMetadata appends the metadata of each tool result (duration, size and
+	// source, see llms.ToolMetadataDuration) to its tool message as a compact line,
+	// e.g. "[tool metadata: bytes=340 durationMs=5012 source=researcher]", so the
+	// model can reason about slow or large tools. The line is only added to the
+	// messages sent to the LLM; history keeps the metadata as message tags either
+	// way. Off by default to save tokens.
+	ToolResultMetadata bool
+
+	// ToolRegistry adds the tools of a registry to the agent. A registry can be
+	// shared by several agents; its tools are read once by NewAgent.
+	ToolRegistry *tools.Registry
+
 	// MaxToolIterations is the maximum number of tool execution iterations
 	// to prevent infinite loops. Defaults to 10 if not set.
 	MaxToolIterations int
 
+	// ParseTextToolCalls executes tool calls that the model wrote as text instead of
+	// using the function-calling API, as some models do. When a response has no
+	// structured tool calls, fenced JSON blocks and <tool_call> tags holding
+	// {"name": ..., "arguments": {...}} (or "parameters") for one of the agent's tools
+	// are run as tool calls. Off by default to avoid false positives.
+	ParseTextToolCalls bool
+
+	// MaxToolArgumentRetries is the number of times per turn the model is told that its
+	// tool call arguments were not valid JSON and asked to retry, before the turn fails
+	// with the parse error. Defaults to 2 if not set; a negative value disables retries.
+	MaxToolArgumentRetries int
+
+	// CoerceToolArguments makes the agent's core.Tool tools accept numbers and
+	// booleans sent as strings ("42", "true") for number and boolean parameters,
+	// as some models do, instead of rejecting the call. Tools can opt in on their
+	// own with core.Tool.SetCoerceArguments. Off by default so that real argument
+	// errors are not masked.
+	CoerceToolArguments bool
+
+	// EmptyResponseRetry asks the model once more, with a note requesting an answer,
+	// when it ends a turn with no content (or only whitespace) and no tool calls.
+	// The note is only sent with the retry, it is not added to the history.
+	EmptyResponseRetry bool
+
+	// EmptyResponseFallback is the answer streamed and saved when the model's final
+	// response is empty (after the retry, if EmptyResponseRetry is set). Empty (default)
+	// ends the turn without an answer.
+	EmptyResponseFallback string
+
+	// DryRun skips tool execution. Each tool call is reported with a TypeToolPlanned
+	// chunk carrying its arguments (instead of TypeToolExecuting), and the LLM receives
+	// a synthetic result describing the call that would have been made. Delegation is
+	// a tool call too, so sub-agents are not run. Use it to preview or approve actions.
+	DryRun bool
+
+	// Approver is consulted before running tools that require approval
+	// (core.Tool.SetRequiresApproval). A denied call is reported to the LLM as a
+	// "tool call denied by approver" error result and the tool loop continues.
+	// If nil, calls to such tools are denied. Other tools never ask for approval.
+	Approver Approver
+
+	// ParallelToolCalls runs the tool calls of one assistant turn concurrently instead of
+	// one after the other. Each TypeToolResult chunk is emitted as its call completes, and
+	// the tool messages are added to history in the original call order. Tools, hooks and
+	// the Approver must then be safe for concurrent use.
+	ParallelToolCalls bool
+
+	// MaxParallelToolCalls bounds the number of tool calls running at once when
+	// ParallelToolCalls is set. Defaults to 4 if not set.
+	MaxParallelToolCalls int
+
+	// MaxToolResultChars limits the size of a tool result stored in history. Longer results
+	// are cut to this many characters and end with a "...[truncated N chars]" marker, so a
+	// large output (e.g., an fs read of a huge file) cannot fill the context window.
+	// The TypeToolResult chunk still carries the full result for display.
+	// 0 (default) disables truncation.
+	MaxToolResultChars int
+
+	// SummarizeToolResultsOver enables tool result summarization. A tool result longer
+	// than this many characters is summarized by the LLMEngine before it is stored in
+	// history, so verbose results do not inflate every following request. Smaller
+	// results are stored as is. The TypeToolResult chunk still carries the full result,
+	// and if summarization fails the full result is kept (cut at MaxToolResultChars).
+	// 0 (default) disables summarization.
+	SummarizeToolResultsOver int
+
+	// ResponseBufferSize is the number of chunks buffered by the response channels of
+	// this agent's runs, so bursty streams do not block on a slow consumer.
+	// Defaults to core.DefaultResponseBufferSize (10) if not set.
+	ResponseBufferSize int
+
+	// MaxOutputChars caps the answer characters streamed by one chat, across all tool
+	// iterations. Once the cap is hit the agent stops forwarding content, cancels the
+	// LLM stream and ends with a completion chunk flagged Truncated; the answer is
+	// saved cut at the cap. Reasoning chunks are not counted. 0 (default) means unlimited.
+	MaxOutputChars int
+
+	// MaxUserMessageChars rejects user messages longer than this many characters,
+	// e.g. an accidentally pasted blob: the run ends with an error wrapping
+	// ErrMessageTooLong before the message is added to the history or sent to the
+	// LLM. ChatStreamMessages checks the last user message of the conversation.
+	// 0 (default) means unlimited.
+	MaxUserMessageChars int
+
+	// QuietDelegation makes the delegate tool return only the final answer of the
+	// sub-agent instead of everything it streamed (see tools.NewQuietDelegateTool).
+	// The sub-agent's chunks are still forwarded for display.
+	QuietDelegation bool
+
+	// MaxDelegationDepth is the maximum depth of the delegation chain started by this agent.
+	// When a delegation would exceed it, the delegate tool returns an error result instead
+	// of calling the sub-agent. The limit of the root agent governs the whole tree.
+	// Defaults to 5 if not set.
+	MaxDelegationDepth int
+
+	// SummarizeAfterTokens enables history summarization. When the estimated size of the
+	// history exceeds this many tokens, the oldest turns are summarized by the LLMEngine into
+	// a single note before the next request. The system prompt and the most recent
+	// SummaryKeepTurns turns are kept verbatim. Summarization never runs mid-tool-loop.
+	// 0 (default) disables summarization.
+	SummarizeAfterTokens int
+
+	// ContextCompaction keeps requests within the model's context window. Before each
+	// LLM call, if the estimated size of the history exceeds the window minus
+	// CompletionReserveTokens, the oldest turns (1, 2, 4, ... until it fits) are
+	// dropped from the request (CompactionTruncate, the history is kept) or
+	// summarized into a note stored in the history (CompactionSummarize). The system
+	// prompt and the current turn are always kept. Empty (default) disables compaction.
+	ContextCompaction string
+
+	// ContextWindow is the context window in tokens used by ContextCompaction.
+	// Defaults to the window of the LLMEngine's model (see llms.ModelCapabilities);
+	// set it for models the engine does not know.
+	ContextWindow int
+
+	// CompletionReserveTokens is the part of the context window kept free for the
+	// completion by ContextCompaction. Defaults to 4096 if not set.
+	CompletionReserveTokens int
+
+	// SummaryKeepTurns is the number of recent turns (a user message and everything after it)
+	// kept verbatim when summarizing. Defaults to 2 if not set.
+	SummaryKeepTurns int
+
+	// ToolChoice is the default tool choice for this agent's requests: auto, none,
+	// required, or a specific tool name. Forcing choices (required or a tool name)
+	// only apply to the first LLM call of a turn, so the loop can still finish.
+	// Can be overridden per call with ChatStreamWithOptions. Empty keeps the engine default.
+	ToolChoice llms.ToolChoice
+
+	// Tracer enables OpenTelemetry tracing: a span per chat invocation with child
+	// spans per LLM call and tool execution. Sub-agents reached through delegation
+	// join the same trace when they have a tracer. If nil, tracing is disabled.
+	Tracer trace.Tracer
+
+	// Hooks receives lifecycle callbacks around LLM calls and tool executions.
+	// If nil, no hooks are called.
+	Hooks AgentHooks
+
+	// Metrics records token usage, tool calls and LLM latency. Use NewMemoryMetrics
+	// for in-memory totals. If nil, nothing is recorded.
+	Metrics Metrics
+
+	// ConcurrencyLimiter bounds the number of runs executing at once; the others
+	// wait for a slot, until their context is done or they are stopped. Share one
+	// limiter (see NewConcurrencyLimiter) between agents for a server-wide limit.
+	// Only top-level runs take a slot, delegated runs use their parent's. If nil,
+	// runs are not limited.
+	ConcurrencyLimiter *ConcurrencyLimiter
+
+	// MessageFilter rewrites the messages sent to the LLM and the chunks it streams
+	// back, e.g. to redact secrets (see NewRegexRedactor). If nil, nothing is filtered.
+	MessageFilter MessageFilter
+
+	// ToolCache enables caching of successful tool results, keyed by tool name and
+	// arguments (see ToolCacheKey). A repeated identical call is answered from the
+	// cache instead of running the tool. Tools opt out with core.Tool.SetCacheable(false);
+	// the built-in delegate and fs tools never cache. If nil, caching is disabled.
+	// Use NewMemoryToolCache for an in-memory cache with a TTL.
+	ToolCache ToolCache
+
 	// MainAgent indicates whether the agent is the main agent.
 	// This parameter is reserved for future use.
 	MainAgent bool
@@ -57,28 +277,61 @@ type AgentConfig struct {
 	ExtraEngines map[string]llms.LLMEngine
 
 	// Persistence specifies the persistence layer type for conversation history.
-	// Supported values: "" (none), "json"
+	// Supported values: "" (none), "json", "redis" (connects using AF_REDIS_URL)
 	// If empty or not set, no persistence is used.
 	Persistence string
 
+	// PersistenceDir is the directory of the "json" persistence files.
+	// If empty, AF_HISTORY_DIR is used (default: ./history).
+	PersistenceDir string
+
+	// PersistenceJSON is the format of the "json" persistence files: compact and/or
+	// gzipped JSON (see persistence.JSONPersistenceOptions). The zero value writes
+	// indented, uncompressed JSON.
+	PersistenceJSON persistence.JSONPersistenceOptions
+
 	// SubAgents is the list of sub-agents available for delegation
 	SubAgents []*core.SubAgent
+
+	// SubAgentConfigs lists specialist sub-agents that NewAgent instantiates and
+	// registers for delegation alongside SubAgents.
+	// A config without LLMEngine uses ExtraEngines[AgentName] if set, otherwise
+	// this agent's LLMEngine.
+	SubAgentConfigs []*AgentConfig
+
+	// ValidateTeam runs ValidateTeam on the new agent's tree at construction time.
+	// NewAgent panics with the consolidated report if the team is invalid.
+	ValidateTeam bool
 }
 
 // validate validates that all required fields in AgentConfig are set.
 //
 // Required fields:
+//   - The config itself: Must not be nil
 //   - LLMEngine: Must not be nil
 //   - AgentName: Must not be empty
 //
 // Returns:
 //   - error: An error describing which required field is missing, or nil if validation passes
 func (c *AgentConfig) validate() error {
+	if c == nil {
+		return fmt.Errorf("AgentConfig is required but was nil")
+	}
 	if c.LLMEngine == nil {
 		return fmt.Errorf("LLMEngine is required but was nil")
 	}
 	if c.AgentName == "" {
 		return fmt.Errorf("AgentName is required but was empty")
 	}
+	for _, name := range c.SystemAgents {
+		if _, ok := LookupSystemAgentTemplate(name); !ok {
+			return fmt.Errorf("unknown system agent %q (registered: %s)", name, strings.Join(SystemAgentTemplateNames(), ", "))
+		}
+	}
+	switch c.ContextCompaction {
+	case "", CompactionTruncate, CompactionSummarize:
+	default:
+		return fmt.Errorf("unknown ContextCompaction %q (supported: %s, %s)", c.ContextCompaction, CompactionTruncate, CompactionSummarize)
+	}
 	return nil
 }
@@ -1,10 +1,21 @@
 package agents
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	agentforge "github.com/thinktwice/agentForge/src"
+	"github.com/thinktwice/agentForge/src/core"
 	"github.com/thinktwice/agentForge/src/llms"
 	"github.com/thinktwice/agentForge/src/tools"
 )
@@ -140,16 +151,14 @@ func TestAgent_fooTool_WithRealLLM(t *testing.T) {
 	}
 }
 
-// TestAgent_fooTool tests the agent with a tool using TogetherAI.
+// TestAgent_fooTool tests the agent with a tool on a scripted engine.
 // This is a basic streaming test. For comprehensive tool execution testing with
-// all chunk verification, see TestAgent_fooTool_WithRealLLM.
+// all chunk verification against a real model, see TestAgent_fooTool_WithRealLLM.
 func TestAgent_fooTool(t *testing.T) {
-	llm, err := llms.NewOpenAILLMBuilder("togetherai").
-		SetModel(llms.TOGETHERAI_Llama3170BInstructTurbo).
-		Build()
-	if err != nil {
-		t.Fatalf("failed to get together llm: %v", err)
-	}
+	llm := newMockEngine(
+		toolCallTurn(llms.ToolCall{ID: "call_1", Name: "foo", Arguments: map[string]any{"echo": "Hello, world!"}}),
+		contentTurn("Hello, world!"),
+	)
 	agent := NewAgent(&AgentConfig{
 		LLMEngine: llm,
 		AgentName: "test agent",
@@ -177,12 +186,8 @@ func TestAgent_fooTool(t *testing.T) {
 
 // TestAgentConfig_validate tests the validation of AgentConfig.
 func TestAgentConfig_validate(t *testing.T) {
-	llm, err := llms.NewOpenAILLMBuilder("togetherai").
-		SetModel(llms.TOGETHERAI_Llama3170BInstructTurbo).
-		Build()
-	if err != nil {
-		t.Skipf("skipping validation test: failed to get together llm: %v", err)
-	}
+	// Validation does not call the LLM, so a mock engine is enough
+	llm := newMockEngine()
 
 	tests := []struct {
 		name    string
@@ -249,12 +254,8 @@ func TestAgentConfig_validate(t *testing.T) {
 
 // TestNewAgent_validation tests that NewAgent panics on invalid config.
 func TestNewAgent_validation(t *testing.T) {
-	llm, err := llms.NewOpenAILLMBuilder("togetherai").
-		SetModel(llms.TOGETHERAI_Llama3170BInstructTurbo).
-		Build()
-	if err != nil {
-		t.Skipf("skipping NewAgent validation test: failed to get together llm: %v", err)
-	}
+	// Validation does not call the LLM, so a mock engine is enough
+	llm := newMockEngine()
 
 	tests := []struct {
 		name        string
@@ -314,3 +315,1901 @@ func TestNewAgent_validation(t *testing.T) {
 		})
 	}
 }
+
+// TestNewAgent_nilConfig verifies that NewAgent panics with a clear message on a nil config.
+func TestNewAgent_nilConfig(t *testing.T) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("NewAgent(nil) expected panic but did not panic")
+		}
+		if errStr := fmt.Sprintf("%v", r); !strings.Contains(errStr, "invalid AgentConfig: AgentConfig is required but was nil") {
+			t.Errorf("NewAgent(nil) panic = %v", r)
+		}
+	}()
+	_ = NewAgent(nil)
+}
+
+// TestAgent_SubAgentsAndTools verifies that the system agents and the
+// auto-added delegate tool are visible through the accessors.
+func TestAgent_SubAgentsAndTools(t *testing.T) {
+	worker := NewAgent(&AgentConfig{LLMEngine: newMockEngine(), AgentName: "worker"})
+	agent := NewAgent(&AgentConfig{
+		LLMEngine: newMockEngine(),
+		AgentName: "main",
+		Reasoning: true,
+		SubAgents: []*core.SubAgent{worker.AgentAsSubAgent()},
+	})
+
+	var names []string
+	for _, sa := range agent.SubAgents() {
+		names = append(names, sa.Name())
+	}
+	if strings.Join(names, ",") != "worker,"+ReasoningAgentTemplate.Name {
+		t.Errorf("SubAgents() = %v, want worker and the reasoning agent", names)
+	}
+	if reasoning, ok := agent.SubAgents()[1].(*Agent); !ok || len(reasoning.SubAgents()) != 0 {
+		t.Errorf("Expected the reasoning agent to be an *Agent without sub-agents, got %T", agent.SubAgents()[1])
+	}
+
+	var toolNames []string
+	for _, tool := range agent.GetTools() {
+		toolNames = append(toolNames, tool.GetName())
+	}
+	if !slices.Contains(toolNames, "delegate") {
+		t.Errorf("GetTools() = %v, want the delegate tool", toolNames)
+	}
+
+	if subAgents := worker.SubAgents(); subAgents == nil || len(subAgents) != 0 {
+		t.Errorf("Expected an empty, non-nil slice without sub-agents, got %v", subAgents)
+	}
+}
+
+// TestNewAgent_duplicateToolNames verifies that tools sharing a name are rejected
+// and that renamed instances of the same tool can be told apart.
+func TestNewAgent_duplicateToolNames(t *testing.T) {
+	t.Run("duplicate names panic", func(t *testing.T) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				t.Fatal("NewAgent() expected panic but did not panic")
+			}
+			if errStr := fmt.Sprintf("%v", r); !strings.Contains(errStr, `duplicate tool name "reverse"`) {
+				t.Errorf("NewAgent() panic = %v", r)
+			}
+		}()
+		_ = NewAgent(&AgentConfig{
+			LLMEngine: newMockEngine(),
+			AgentName: "duplicated",
+			Tools:     []llms.Tool{tools.NewReverseTool(), tools.NewReverseTool()},
+		})
+	})
+
+	t.Run("TryNewAgent and SetTools return an error", func(t *testing.T) {
+		agent, err := TryNewAgent(&AgentConfig{
+			LLMEngine: newMockEngine(),
+			AgentName: "duplicated",
+			Tools:     []llms.Tool{tools.NewReverseTool(), tools.NewReverseTool()},
+		})
+		if agent != nil || err == nil || !strings.Contains(err.Error(), `duplicate tool name "reverse"`) {
+			t.Errorf("TryNewAgent() = %v, %v, want a duplicate name error", agent, err)
+		}
+
+		agent = NewAgent(&AgentConfig{LLMEngine: newMockEngine(), AgentName: "retooled"})
+		before := agent.GetTools()
+		err = agent.SetTools(append(agent.GetTools(), tools.NewFooTool()))
+		if err == nil || !strings.Contains(err.Error(), `duplicate tool name "foo"`) {
+			t.Errorf("SetTools() error = %v, want a duplicate name error", err)
+		}
+		if len(agent.GetTools()) != len(before) {
+			t.Errorf("Expected the tools to be unchanged, got %d tools", len(agent.GetTools()))
+		}
+	})
+
+	t.Run("renamed tools are distinct", func(t *testing.T) {
+		engine := newMockEngine(
+			toolCallTurn(llms.ToolCall{ID: "call_1", Name: "reverse_b", Arguments: map[string]any{"text": "abc"}}),
+			contentTurn("done"),
+		)
+		agent := NewAgent(&AgentConfig{
+			LLMEngine: engine,
+			AgentName: "renamed",
+			Tools: []llms.Tool{
+				tools.NewReverseTool().(*core.Tool).WithName("reverse_a"),
+				tools.WithName(tools.WithRetry(tools.NewReverseTool(), 2, nil), "reverse_b"),
+			},
+		})
+		if _, err := agent.Chat("reverse abc"); err != nil {
+			t.Fatalf("Chat() unexpected error = %v", err)
+		}
+		calls := engine.Calls()
+		if last := calls[1][len(calls[1])-1]; last.Role() != llms.MessageRoleTool || last.Content() != "cba" {
+			t.Errorf("Expected the renamed tool's result, got %s: %q", last.Role(), last.Content())
+		}
+	})
+}
+
+// TestAgent_ToolResultMetadata verifies that tool-result chunks and the stored
+// tool messages carry duration, size and source metadata.
+func TestAgent_ToolResultMetadata(t *testing.T) {
+	engine := newMockEngine(
+		toolCallTurn(llms.ToolCall{ID: "call_1", Name: "foo", Arguments: map[string]any{"echo": "hello"}}),
+		contentTurn("ok"),
+	)
+	agent := NewAgent(&AgentConfig{
+		LLMEngine: engine,
+		AgentName: "meta agent",
+	})
+
+	var result *llms.ToolResult
+	for chunk := range agent.ChatStream("echo hello").Start() {
+		if chunk.Type == llms.TypeToolResult && len(chunk.ToolResults) > 0 {
+			result = &chunk.ToolResults[0]
+		}
+	}
+
+	if result == nil {
+		t.Fatal("Expected a tool-result chunk")
+	}
+	if _, ok := result.Metadata[llms.ToolMetadataDuration]; !ok {
+		t.Errorf("Expected %s in metadata, got %+v", llms.ToolMetadataDuration, result.Metadata)
+	}
+	if got := result.Metadata[llms.ToolMetadataBytes]; got != float64(len("hello")) {
+		t.Errorf("Expected %s=%d, got %v", llms.ToolMetadataBytes, len("hello"), got)
+	}
+	if got := result.Metadata[llms.ToolMetadataSource]; got != "meta agent" {
+		t.Errorf("Expected %s='meta agent', got %v", llms.ToolMetadataSource, got)
+	}
+
+	var stored map[string]string
+	for _, msg := range agent.GetHistory(0, 0) {
+		if msg.Role() == llms.MessageRoleTool {
+			stored = msg.Metadata()
+		}
+	}
+	if _, ok := stored[llms.ToolMetadataDuration]; !ok || stored[llms.ToolMetadataBytes] != "5" || stored[llms.ToolMetadataSource] != "meta agent" {
+		t.Errorf("Expected the metadata on the stored tool message, got %v", stored)
+	}
+}
+
+// TestAgent_ToolResultMetadataInContext verifies that ToolResultMetadata shows the
+// metadata to the model without changing the stored tool message.
+func TestAgent_ToolResultMetadataInContext(t *testing.T) {
+	lastToolMessage := func(messages []llms.UnifiedMessage) string {
+		var content string
+		for _, msg := range messages {
+			if msg.Role() == llms.MessageRoleTool {
+				content = msg.Content()
+			}
+		}
+		return content
+	}
+	run := func(enabled bool) (*mockEngine, *Agent) {
+		engine := newMockEngine(
+			toolCallTurn(llms.ToolCall{ID: "call_1", Name: "foo", Arguments: map[string]any{"echo": "hello"}}),
+			contentTurn("ok"),
+		)
+		agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "meta", ToolResultMetadata: enabled})
+		if _, err := agent.Chat("echo hello"); err != nil {
+			t.Fatalf("Chat() unexpected error = %v", err)
+		}
+		return engine, agent
+	}
+
+	engine, agent := run(true)
+	sent := lastToolMessage(engine.Calls()[1])
+	if !strings.HasPrefix(sent, "hello\n[tool metadata: bytes=5 durationMs=") || !strings.HasSuffix(sent, " source=meta]") {
+		t.Errorf("Expected the metadata line in the tool message sent to the LLM, got %q", sent)
+	}
+	if stored := lastToolMessage(agent.GetHistory(0, 0)); stored != "hello" {
+		t.Errorf("Expected the stored tool message unchanged, got %q", stored)
+	}
+
+	engine, _ = run(false)
+	if sent := lastToolMessage(engine.Calls()[1]); sent != "hello" {
+		t.Errorf("Expected no metadata line by default, got %q", sent)
+	}
+}
+
+// TestAgent_StructuredToolResult verifies that JSON tool results are preserved as
+// structured data in the chunk and as well-formed JSON in history.
+func TestAgent_StructuredToolResult(t *testing.T) {
+	type user struct {
+		ID    int    `json:"id"`
+		Email string `json:"email"`
+	}
+	engine := newMockEngine(
+		toolCallTurn(llms.ToolCall{ID: "call_1", Name: "lookup", Arguments: map[string]any{}}),
+		contentTurn("found"),
+	)
+	agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "json agent"})
+	agent.SetTools(append(agent.GetTools(), core.NewTool("lookup", "Look up a user", "", "", nil,
+		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+			return core.NewSuccessJSON(user{ID: 7, Email: "ada@example.com"})
+		},
+	)))
+
+	var result *llms.ToolResult
+	for chunk := range agent.ChatStream("find ada").Start() {
+		if chunk.Type == llms.TypeToolResult && len(chunk.ToolResults) > 0 {
+			result = &chunk.ToolResults[0]
+		}
+	}
+	if result == nil {
+		t.Fatal("Expected a tool-result chunk")
+	}
+
+	var got user
+	if err := json.Unmarshal(result.Data, &got); err != nil {
+		t.Fatalf("Failed to unmarshal structured data %q: %v", result.Data, err)
+	}
+	if got.ID != 7 || got.Email != "ada@example.com" {
+		t.Errorf("Structured data round-trip = %+v", got)
+	}
+
+	calls := engine.Calls()
+	stored := calls[len(calls)-1][len(calls[len(calls)-1])-1]
+	if stored.Content() != `{"id":7,"email":"ada@example.com"}` {
+		t.Errorf("Expected JSON tool message, got %q", stored.Content())
+	}
+}
+
+// TestAgent_Chat verifies that Chat runs the tool loop and returns the final answer.
+func TestAgent_Chat(t *testing.T) {
+	engine := newMockEngine(
+		toolCallTurn(llms.ToolCall{ID: "call_1", Name: "foo", Arguments: map[string]any{"echo": "Hello, test!"}}),
+		contentTurn("The tool said: ", "Hello, test!"),
+	)
+	agent := NewAgent(&AgentConfig{
+		LLMEngine: engine,
+		AgentName: "chat agent",
+	})
+
+	answer, err := agent.Chat(`Use the foo tool to echo back exactly "Hello, test!"`)
+	if err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+	if !strings.Contains(answer, "Hello, test!") {
+		t.Errorf("Chat() = %q, want it to contain the echoed text", answer)
+	}
+
+	// The tool result was fed back to the model on the second call
+	calls := engine.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("Expected 2 LLM calls, got %d", len(calls))
+	}
+	last := calls[1][len(calls[1])-1]
+	if last.Role() != llms.MessageRoleTool || last.Content() != "Hello, test!" {
+		t.Errorf("Expected tool message with echoed text, got %s: %q", last.Role(), last.Content())
+	}
+}
+
+// TestAgent_Chat_ToolFailureIsNotAnError verifies that recoverable tool errors
+// are returned to the model instead of failing Chat.
+func TestAgent_Chat_ToolFailureIsNotAnError(t *testing.T) {
+	engine := newMockEngine(
+		toolCallTurn(llms.ToolCall{ID: "call_1", Name: "missing", Arguments: map[string]any{}}),
+		contentTurn("Sorry, that tool does not exist."),
+	)
+	agent := NewAgent(&AgentConfig{
+		LLMEngine: engine,
+		AgentName: "chat agent",
+	})
+
+	answer, err := agent.Chat("call a missing tool")
+	if err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+	if answer != "Sorry, that tool does not exist." {
+		t.Errorf("Chat() = %q", answer)
+	}
+}
+
+// TestAgent_MaxToolResultChars verifies that large tool results are truncated in
+// history but streamed in full.
+func TestAgent_MaxToolResultChars(t *testing.T) {
+	large := strings.Repeat("x", 100)
+	engine := newMockEngine(
+		toolCallTurn(llms.ToolCall{ID: "call_1", Name: "foo", Arguments: map[string]any{"echo": large}}),
+		contentTurn("ok"),
+	)
+	agent := NewAgent(&AgentConfig{
+		LLMEngine:          engine,
+		AgentName:          "truncating agent",
+		MaxToolResultChars: 10,
+	})
+
+	var streamed string
+	for chunk := range agent.ChatStream("echo a lot").Start() {
+		if chunk.Type == llms.TypeToolResult && len(chunk.ToolResults) > 0 {
+			streamed = chunk.ToolResults[0].Result
+		}
+	}
+
+	if streamed != large {
+		t.Errorf("Expected the streamed chunk to carry the full result, got %d chars", len(streamed))
+	}
+
+	calls := engine.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("Expected 2 LLM calls, got %d", len(calls))
+	}
+	stored := calls[1][len(calls[1])-1]
+	expected := strings.Repeat("x", 10) + "...[truncated 90 chars]"
+	if stored.Role() != llms.MessageRoleTool || stored.Content() != expected {
+		t.Errorf("Expected truncated tool message %q, got %s: %q", expected, stored.Role(), stored.Content())
+	}
+}
+
+// TestAgent_SummarizeToolResults verifies that tool results over the threshold
+// are summarized in history but streamed in full, and small ones are kept.
+func TestAgent_SummarizeToolResults(t *testing.T) {
+	large := strings.Repeat("row ", 50)
+	var summaryInputs []string
+	engine := &mockEngine{respond: func(messages []llms.UnifiedMessage) mockTurn {
+		if messages[0].Content() == DefaultToolResultSummaryPrompt {
+			summaryInputs = append(summaryInputs, messages[1].Content())
+			return contentTurn("50 rows")
+		}
+		if last := messages[len(messages)-1]; last.Role() == llms.MessageRoleUser {
+			return toolCallTurn(
+				llms.ToolCall{ID: "call_1", Name: "foo", Arguments: map[string]any{"echo": large}},
+				llms.ToolCall{ID: "call_2", Name: "foo", Arguments: map[string]any{"echo": "small"}},
+			)
+		}
+		return contentTurn("ok")
+	}}
+	agent := NewAgent(&AgentConfig{
+		LLMEngine:                engine,
+		AgentName:                "summarizing agent",
+		SummarizeToolResultsOver: 100,
+	})
+
+	var streamed []string
+	for chunk := range agent.ChatStream("list the rows").Start() {
+		if chunk.Status == llms.StatusError {
+			t.Fatalf("Unexpected error chunk: %s", chunk.Content)
+		}
+		if chunk.Type == llms.TypeToolResult && len(chunk.ToolResults) > 0 {
+			streamed = append(streamed, chunk.ToolResults[0].Result)
+		}
+	}
+
+	if len(streamed) != 2 || streamed[0] != large {
+		t.Errorf("Expected the streamed chunks to carry the full results, got %q", streamed)
+	}
+	if len(summaryInputs) != 1 || !strings.Contains(summaryInputs[0], large) {
+		t.Fatalf("Expected only the large result to be summarized, got %q", summaryInputs)
+	}
+
+	history := agent.GetHistory(0, 0)
+	var toolMessages []string
+	for _, msg := range history {
+		if msg.Role() == llms.MessageRoleTool {
+			toolMessages = append(toolMessages, msg.Content())
+		}
+	}
+	want := []string{"[SUMMARIZED TOOL RESULT, 200 chars]\n50 rows", "small"}
+	if !reflect.DeepEqual(toolMessages, want) {
+		t.Errorf("Tool messages = %q, want %q", toolMessages, want)
+	}
+}
+
+// TestAgent_PartialContentOnStreamError verifies that content streamed before
+// a stream error is kept in history as an incomplete assistant message.
+func TestAgent_PartialContentOnStreamError(t *testing.T) {
+	partial := contentTurn("The answer ", "is")
+	partial.chunks = partial.chunks[:len(partial.chunks)-1]
+	partial.err = errors.New("connection reset")
+	engine := newMockEngine(partial, contentTurn("42"))
+	agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "flaky agent"})
+
+	var streamErr string
+	for chunk := range agent.ChatStream("what is the answer?").Start() {
+		if chunk.Status == llms.StatusError {
+			streamErr = chunk.Content
+		}
+	}
+	if !strings.Contains(streamErr, "connection reset") {
+		t.Fatalf("Expected the stream error to be surfaced, got %q", streamErr)
+	}
+
+	history := agent.GetHistory(0, 0)
+	last := history[len(history)-1]
+	if last.Role() != llms.MessageRoleAssistant || last.Content() != "The answer is" || !last.Incomplete() {
+		t.Fatalf("Expected incomplete assistant message with partial content, got %s %q (incomplete=%v)", last.Role(), last.Content(), last.Incomplete())
+	}
+
+	// The retry sees a coherent transcript: user, partial answer, user
+	for range agent.ChatStream("please finish").Start() {
+	}
+	calls := engine.Calls()
+	retry := calls[len(calls)-1]
+	if len(retry) < 3 || retry[len(retry)-2].Content() != "The answer is" || retry[len(retry)-1].Content() != "please finish" {
+		t.Errorf("Unexpected retry transcript: %+v", retry)
+	}
+
+	raw, err := json.Marshal(last)
+	if err != nil || !strings.Contains(string(raw), `"incomplete":true`) {
+		t.Errorf("Expected incomplete flag to be serialized, got %s (err=%v)", raw, err)
+	}
+	var decoded llms.UnifiedMessage
+	if err := json.Unmarshal(raw, &decoded); err != nil || !decoded.Incomplete() {
+		t.Errorf("Expected incomplete flag to survive a round-trip, got %+v (err=%v)", decoded, err)
+	}
+}
+
+// TestAgent_DryRun verifies that dry-run mode reports tool calls without executing them.
+func TestAgent_DryRun(t *testing.T) {
+	root := t.TempDir()
+	args := map[string]any{"operation": "write", "path": "notes.txt", "content": "hello"}
+	engine := newMockEngine(
+		toolCallTurn(llms.ToolCall{ID: "call_1", Name: "fs", Arguments: args}),
+		contentTurn("planned"),
+	)
+	agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "planner", DryRun: true})
+	agent.SetTools(append(agent.GetTools(), tools.NewFsTool(root)))
+
+	var planned *llms.ToolCall
+	var result llms.ToolResult
+	for chunk := range agent.ChatStream("write hello to notes.txt").Start() {
+		switch chunk.Type {
+		case llms.TypeToolExecuting:
+			t.Errorf("Unexpected tool-executing chunk in dry-run mode: %+v", chunk.ToolExecuting)
+		case llms.TypeToolPlanned:
+			planned = chunk.ToolPlanned
+		case llms.TypeToolResult:
+			result = chunk.ToolResults[0]
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "notes.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected no file to be written in dry-run mode, stat error = %v", err)
+	}
+	if planned == nil || planned.Name != "fs" || planned.Arguments["path"] != "notes.txt" || planned.Arguments["content"] != "hello" {
+		t.Fatalf("Expected planned chunk with the fs arguments, got %+v", planned)
+	}
+	if !result.Success || !strings.Contains(result.Result, "Dry run: tool 'fs' was not executed") || !strings.Contains(result.Result, `"path":"notes.txt"`) {
+		t.Errorf("Unexpected synthetic result: %+v", result)
+	}
+	if result.Metadata[llms.ToolMetadataDryRun] != true {
+		t.Errorf("Expected dryRun metadata, got %v", result.Metadata)
+	}
+}
+
+// TestAgent_ReasoningChunks verifies that reasoning chunks are forwarded with the
+// thinking trace but kept out of the answer and the history.
+func TestAgent_ReasoningChunks(t *testing.T) {
+	turn := contentTurn("42")
+	turn.chunks = append([]llms.ChunkResponse{{
+		Content: "thinking hard", Delta: "thinking hard", FullContent: "thinking hard",
+		Status: llms.StatusStreaming, Type: llms.TypeReasoning, Trace: llms.TraceThinking,
+	}}, turn.chunks...)
+	agent := NewAgent(&AgentConfig{LLMEngine: newMockEngine(turn), AgentName: "thinker", Trace: "response"})
+
+	var reasoning, answer string
+	for chunk := range agent.ChatStream("what is the answer?").Start() {
+		switch chunk.Type {
+		case llms.TypeReasoning:
+			if chunk.Trace != llms.TraceThinking {
+				t.Errorf("Expected thinking trace on reasoning chunk, got %q", chunk.Trace)
+			}
+			reasoning += chunk.Content
+		case llms.TypeCompletion:
+			answer = chunk.FullContent
+		}
+	}
+
+	if reasoning != "thinking hard" || answer != "42" {
+		t.Errorf("Expected reasoning %q and answer %q, got %q and %q", "thinking hard", "42", reasoning, answer)
+	}
+	history := agent.GetHistory(0, 0)
+	if last := history[len(history)-1]; last.Content() != "42" {
+		t.Errorf("Expected only the answer in history, got %q", last.Content())
+	}
+}
+
+// TestAgent_ToolArgumentRetry verifies that malformed tool call arguments are
+// reported back to the model, which can then retry the call.
+func TestAgent_ToolArgumentRetry(t *testing.T) {
+	badArguments := func() mockTurn {
+		return mockTurn{err: &llms.ToolArgumentsError{
+			ToolCallID: "call_1",
+			ToolName:   "foo",
+			Arguments:  `{"echo": "hi"`,
+			Err:        errors.New("unexpected end of JSON input"),
+		}}
+	}
+
+	t.Run("recovers after bad JSON", func(t *testing.T) {
+		engine := newMockEngine(
+			badArguments(),
+			toolCallTurn(llms.ToolCall{ID: "call_2", Name: "foo", Arguments: map[string]any{"echo": "hi"}}),
+			contentTurn("done"),
+		)
+		agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "retrying agent"})
+
+		answer, err := agent.Chat("echo hi")
+		if err != nil || answer != "done" {
+			t.Fatalf("Chat() = %q, %v; expected recovery", answer, err)
+		}
+
+		calls := engine.Calls()
+		if len(calls) != 3 {
+			t.Fatalf("Expected 3 LLM calls, got %d", len(calls))
+		}
+		note := calls[1][len(calls[1])-1]
+		if note.Role() != llms.MessageRoleSystem || !strings.Contains(note.Content(), "not valid JSON: unexpected end of JSON input") ||
+			!strings.Contains(note.Content(), `Received: {"echo": "hi"`) {
+			t.Errorf("Expected the parse error to be reported to the model, got %s: %q", note.Role(), note.Content())
+		}
+		if last := calls[2][len(calls[2])-1]; last.Role() != llms.MessageRoleTool {
+			t.Errorf("Expected the retried tool call to run, got %s: %q", last.Role(), last.Content())
+		}
+		for _, m := range agent.GetHistory(0, 0) {
+			if strings.Contains(m.Content(), "not valid JSON") {
+				t.Errorf("Expected the note to stay out of the history, got %q", m.Content())
+			}
+		}
+	})
+
+	t.Run("gives up after the configured retries", func(t *testing.T) {
+		engine := newMockEngine(badArguments(), badArguments())
+		agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "retrying agent", MaxToolArgumentRetries: 1})
+
+		if _, err := agent.Chat("echo hi"); err == nil || !strings.Contains(err.Error(), "failed to parse tool call arguments for foo") {
+			t.Fatalf("Expected the parse error after the retry, got %v", err)
+		}
+		if len(engine.Calls()) != 2 {
+			t.Errorf("Expected 2 LLM calls, got %d", len(engine.Calls()))
+		}
+	})
+
+	t.Run("negative disables retries", func(t *testing.T) {
+		engine := newMockEngine(badArguments())
+		agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "retrying agent", MaxToolArgumentRetries: -1})
+
+		if _, err := agent.Chat("echo hi"); err == nil {
+			t.Fatal("Expected the parse error without retries")
+		}
+		if len(engine.Calls()) != 1 {
+			t.Errorf("Expected 1 LLM call, got %d", len(engine.Calls()))
+		}
+	})
+}
+
+func TestAgent_EmptyResponse(t *testing.T) {
+	t.Run("ends silently by default", func(t *testing.T) {
+		engine := newMockEngine(contentTurn())
+		agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "quiet agent"})
+
+		answer, err := agent.Chat("Hi")
+		if err != nil || answer != "" {
+			t.Errorf("Chat() = %q, %v; want an empty answer", answer, err)
+		}
+		if len(engine.Calls()) != 1 {
+			t.Errorf("Expected 1 LLM call, got %d", len(engine.Calls()))
+		}
+	})
+
+	t.Run("retries with a nudge", func(t *testing.T) {
+		engine := newMockEngine(contentTurn(" \n"), contentTurn("Hello!"))
+		agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "quiet agent", EmptyResponseRetry: true})
+
+		answer, err := agent.Chat("Hi")
+		if err != nil || answer != "Hello!" {
+			t.Errorf("Chat() = %q, %v; want %q", answer, err, "Hello!")
+		}
+		calls := engine.Calls()
+		if len(calls) != 2 {
+			t.Fatalf("Expected 2 LLM calls, got %d", len(calls))
+		}
+		if note := calls[1][len(calls[1])-1]; note.Role() != llms.MessageRoleSystem || note.Content() != emptyResponseNote {
+			t.Errorf("Expected the retry to carry the nudge, got %s: %q", note.Role(), note.Content())
+		}
+		for _, m := range agent.GetHistory(0, 0) {
+			if m.Content() == emptyResponseNote {
+				t.Error("Expected the nudge to stay out of the history")
+			}
+		}
+	})
+
+	t.Run("falls back after the retry", func(t *testing.T) {
+		engine := newMockEngine(contentTurn(), contentTurn())
+		agent := NewAgent(&AgentConfig{
+			LLMEngine:             engine,
+			AgentName:             "quiet agent",
+			EmptyResponseRetry:    true,
+			EmptyResponseFallback: "Sorry, I have no answer.",
+		})
+
+		var streamed, completion string
+		for chunk := range agent.ChatStream("Hi").Start() {
+			if chunk.Type == llms.TypeContent {
+				streamed += chunk.Content
+			}
+			if chunk.Type == llms.TypeCompletion {
+				completion = chunk.FullContent
+			}
+		}
+		if streamed != "Sorry, I have no answer." || completion != streamed {
+			t.Errorf("Expected the fallback to be streamed and completed, got %q / %q", streamed, completion)
+		}
+		if len(engine.Calls()) != 2 {
+			t.Errorf("Expected 2 LLM calls, got %d", len(engine.Calls()))
+		}
+		history := agent.GetHistory(0, 0)
+		if last := history[len(history)-1]; last.Role() != llms.MessageRoleAssistant || last.Content() != "Sorry, I have no answer." {
+			t.Errorf("Expected the fallback in history, got %s: %q", last.Role(), last.Content())
+		}
+	})
+}
+
+func TestAgent_MaxDelegationDepth(t *testing.T) {
+	// Each agent delegates to its peer until a tool result comes back, then
+	// answers with that result so the depth error bubbles up to the root.
+	pingPong := func(target string) *mockEngine {
+		return &mockEngine{respond: func(messages []llms.UnifiedMessage) mockTurn {
+			last := messages[len(messages)-1]
+			if last.Role() == llms.MessageRoleTool {
+				return contentTurn(last.Content())
+			}
+			return toolCallTurn(llms.ToolCall{
+				ID:        "call_" + target,
+				Name:      "delegate",
+				Arguments: map[string]any{"subAgent": target, "message": "please handle this"},
+			})
+		}}
+	}
+
+	// alice and bob delegate to each other; bob reaches alice through a
+	// pointer filled in once alice exists.
+	var aliceRef core.SubAgent
+	bob := NewAgent(&AgentConfig{
+		LLMEngine: pingPong("alice"),
+		AgentName: "bob",
+		SubAgents: []*core.SubAgent{&aliceRef},
+	})
+	alice := NewAgent(&AgentConfig{
+		LLMEngine:          pingPong("bob"),
+		AgentName:          "alice",
+		SubAgents:          []*core.SubAgent{bob.AgentAsSubAgent()},
+		MaxDelegationDepth: 1,
+	})
+	aliceRef = alice
+
+	done := make(chan struct{})
+	var (
+		response string
+		err      error
+	)
+	go func() {
+		defer close(done)
+		response, err = alice.Chat("start")
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Delegation loop did not terminate")
+	}
+	if err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+	if !strings.Contains(response, "max delegation depth exceeded") {
+		t.Errorf("Expected depth-exceeded error in response, got %q", response)
+	}
+	if !strings.Contains(response, "limit 1") {
+		t.Errorf("Expected root limit to govern the tree, got %q", response)
+	}
+}
+
+func TestAgent_SummarizeHistory(t *testing.T) {
+	var summaryRequests int
+	engine := &mockEngine{respond: func(messages []llms.UnifiedMessage) mockTurn {
+		if messages[0].Content() == DefaultSummaryPrompt {
+			summaryRequests++
+			return contentTurn("the user said hello several times")
+		}
+		return contentTurn(strings.Repeat("reply ", 10))
+	}}
+	agent := NewAgent(&AgentConfig{
+		LLMEngine:            engine,
+		AgentName:            "summarizing agent",
+		SummarizeAfterTokens: 40,
+		SummaryKeepTurns:     1,
+	})
+
+	for i := 1; i <= 4; i++ {
+		if _, err := agent.Chat(fmt.Sprintf("hello number %d", i)); err != nil {
+			t.Fatalf("Chat() #%d unexpected error = %v", i, err)
+		}
+	}
+
+	if summaryRequests == 0 {
+		t.Fatal("Expected the summarizer to be called")
+	}
+
+	history := agent.history.History()
+	if len(history) >= 9 {
+		t.Errorf("Expected history to shrink below 9 messages, got %d", len(history))
+	}
+	if history[0].Role() != llms.MessageRoleSystem || history[0].Content() != agent.systemPrompt {
+		t.Errorf("Expected pinned system prompt first, got %s: %q", history[0].Role(), history[0].Content())
+	}
+	if !strings.Contains(history[1].Content(), "the user said hello several times") {
+		t.Errorf("Expected summary note after the system prompt, got %q", history[1].Content())
+	}
+
+	// The most recent turn is kept verbatim
+	last := history[len(history)-2:]
+	if last[0].Role() != llms.MessageRoleUser || last[0].Content() != "hello number 4" {
+		t.Errorf("Expected last user message verbatim, got %s: %q", last[0].Role(), last[0].Content())
+	}
+	if last[1].Role() != llms.MessageRoleAssistant {
+		t.Errorf("Expected last assistant reply, got %s", last[1].Role())
+	}
+}
+
+func TestAgent_SummarizeHistory_DisabledByDefault(t *testing.T) {
+	engine := newMockEngine()
+	agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "plain agent"})
+
+	for i := 0; i < 3; i++ {
+		if _, err := agent.Chat(strings.Repeat("long message ", 50)); err != nil {
+			t.Fatalf("Chat() unexpected error = %v", err)
+		}
+	}
+	if got := len(agent.history.History()); got != 7 {
+		t.Errorf("Expected full history of 7 messages, got %d", got)
+	}
+	if got := len(engine.Calls()); got != 3 {
+		t.Errorf("Expected 3 LLM calls and no summaries, got %d", got)
+	}
+}
+
+func TestAgent_SubAgentConfigs(t *testing.T) {
+	researcherEngine := newMockEngine(contentTurn("research notes"))
+	writerEngine := newMockEngine(contentTurn("final draft"))
+	mainEngine := newMockEngine(
+		toolCallTurn(llms.ToolCall{ID: "call_1", Name: "delegate", Arguments: map[string]any{"subAgent": "researcher", "message": "find facts"}}),
+		toolCallTurn(llms.ToolCall{ID: "call_2", Name: "delegate", Arguments: map[string]any{"subAgent": "writer", "message": "write it up"}}),
+		contentTurn("all done"),
+	)
+
+	main := NewAgent(&AgentConfig{
+		LLMEngine: mainEngine,
+		AgentName: "main",
+		MainAgent: true,
+		SubAgentConfigs: []*AgentConfig{
+			{AgentName: "researcher", Description: "Finds facts"},
+			{AgentName: "writer", Description: "Writes documents"},
+		},
+		ExtraEngines: map[string]llms.LLMEngine{
+			"researcher": researcherEngine,
+			"writer":     writerEngine,
+		},
+	})
+
+	response, err := main.Chat("write a report")
+	if err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+	if response != "all done" {
+		t.Errorf("Expected final answer 'all done', got %q", response)
+	}
+
+	systemPrompt := mainEngine.Calls()[0][0].Content()
+	for _, want := range []string{"researcher: Finds facts", "writer: Writes documents"} {
+		if !strings.Contains(systemPrompt, want) {
+			t.Errorf("Expected system prompt to list %q", want)
+		}
+	}
+
+	if len(researcherEngine.Calls()) != 1 || len(writerEngine.Calls()) != 1 {
+		t.Errorf("Expected each sub-agent to be called once, got researcher=%d writer=%d",
+			len(researcherEngine.Calls()), len(writerEngine.Calls()))
+	}
+
+	// Delegation results reach the main agent
+	finalCall := mainEngine.Calls()[2]
+	if got := finalCall[len(finalCall)-1].Content(); got != "final draft" {
+		t.Errorf("Expected last tool result 'final draft', got %q", got)
+	}
+}
+
+func TestAgent_UsageByAgent(t *testing.T) {
+	mainEngine := newMockEngine(
+		toolCallTurn(llms.ToolCall{ID: "call_1", Name: "delegate", Arguments: map[string]any{"subAgent": "researcher", "message": "find facts"}}),
+		contentTurn("all done"),
+	)
+	main := NewAgent(&AgentConfig{
+		LLMEngine:       mainEngine,
+		AgentName:       "main",
+		SubAgentConfigs: []*AgentConfig{{AgentName: "researcher", Description: "Finds facts"}},
+		ExtraEngines:    map[string]llms.LLMEngine{"researcher": newMockEngine(contentTurn("research notes"))},
+	})
+
+	result, err := core.CollectResponse(main.ChatStream("write a report").Start())
+	if err != nil {
+		t.Fatalf("CollectResponse() unexpected error = %v", err)
+	}
+
+	// Every mock turn reports 10 prompt and 5 completion tokens
+	want := map[string]llms.Usage{
+		"main":       {PromptTokens: 20, CompletionTokens: 10, TotalTokens: 30},
+		"researcher": {PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	}
+	if !reflect.DeepEqual(result.UsageByAgent, want) {
+		t.Errorf("UsageByAgent = %+v, want %+v", result.UsageByAgent, want)
+	}
+}
+
+func TestAgent_ToolChoice(t *testing.T) {
+	t.Run("config default applies to every call", func(t *testing.T) {
+		engine := newMockEngine(contentTurn("Hello!"))
+		agent := NewAgent(&AgentConfig{
+			LLMEngine:  engine,
+			AgentName:  "greeter",
+			ToolChoice: llms.ToolChoiceNone,
+		})
+
+		if _, err := agent.Chat("Hi"); err != nil {
+			t.Fatalf("Chat() unexpected error = %v", err)
+		}
+		if got := engine.Options()[0].ToolChoice; got != llms.ToolChoiceNone {
+			t.Errorf("Expected tool choice none, got %q", got)
+		}
+	})
+
+	t.Run("per-call override forces only the first call", func(t *testing.T) {
+		engine := newMockEngine(
+			toolCallTurn(llms.ToolCall{ID: "call_1", Name: "foo", Arguments: map[string]any{"echo": "hi"}}),
+			contentTurn("done"),
+		)
+		agent := NewAgent(&AgentConfig{
+			LLMEngine:  engine,
+			AgentName:  "orchestrator",
+			ToolChoice: llms.ToolChoiceNone,
+		})
+
+		for range agent.ChatStreamWithOptions("use foo", llms.GenerationOptions{ToolChoice: "foo"}).Start() {
+		}
+
+		options := engine.Options()
+		if len(options) != 2 {
+			t.Fatalf("Expected 2 LLM calls, got %d", len(options))
+		}
+		if options[0].ToolChoice != "foo" {
+			t.Errorf("Expected first call to force 'foo', got %q", options[0].ToolChoice)
+		}
+		if options[1].ToolChoice != llms.ToolChoiceAuto {
+			t.Errorf("Expected follow-up call to use auto, got %q", options[1].ToolChoice)
+		}
+	})
+}
+
+// TestAgent_ParallelToolCalls verifies that the tool calls of one turn run
+// concurrently and that their results are stored in call order.
+func TestAgent_ParallelToolCalls(t *testing.T) {
+	slowTool := func(name string, delay time.Duration) llms.Tool {
+		return core.NewTool(name, "Sleeps", "", "", nil,
+			func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+				time.Sleep(delay)
+				return core.NewSuccessResponse(name + " done")
+			},
+		)
+	}
+
+	engine := newMockEngine(
+		toolCallTurn(
+			llms.ToolCall{ID: "call_1", Name: "slow", Arguments: map[string]any{}},
+			llms.ToolCall{ID: "call_2", Name: "fast", Arguments: map[string]any{}},
+		),
+		contentTurn("both done"),
+	)
+	agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "parallel", ParallelToolCalls: true})
+	agent.SetTools(append(agent.GetTools(), slowTool("slow", 300*time.Millisecond), slowTool("fast", 200*time.Millisecond)))
+
+	start := time.Now()
+	var executing int
+	var completed []string
+	for chunk := range agent.ChatStream("run both").Start() {
+		switch chunk.Type {
+		case llms.TypeToolExecuting:
+			executing++
+		case llms.TypeToolResult:
+			completed = append(completed, chunk.ToolResults[0].ToolCallID)
+		}
+	}
+	elapsed := time.Since(start)
+
+	if elapsed >= 450*time.Millisecond {
+		t.Errorf("Expected tools to run concurrently (~300ms), took %s", elapsed)
+	}
+	if executing != 2 || len(completed) != 2 || completed[0] != "call_2" {
+		t.Errorf("Expected results in completion order [call_2 call_1], got %d executing and %v", executing, completed)
+	}
+
+	// The second LLM call sees the results in the original call order
+	calls := engine.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("Expected 2 LLM calls, got %d", len(calls))
+	}
+	messages := calls[1]
+	last := messages[len(messages)-2:]
+	if last[0].ToolCallID() != "call_1" || last[1].ToolCallID() != "call_2" || last[0].Content() != "slow done" {
+		t.Errorf("Expected tool messages in call order, got %+v", last)
+	}
+	assertToolSequencesValid(t, messages)
+}
+
+// TestAgent_MaxOutputChars verifies that streaming stops at the output cap with
+// a truncated completion and that the LLM stream is cancelled.
+func TestAgent_MaxOutputChars(t *testing.T) {
+	deltas := make([]string, 30)
+	for i := range deltas {
+		deltas[i] = "abcde"
+	}
+	engine := newMockEngine(contentTurn(deltas...))
+	agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "capped", MaxOutputChars: 12})
+
+	var streamed string
+	var completion *core.ExtendedChunkResponse
+	for chunk := range agent.ChatStream("talk a lot").Start() {
+		switch chunk.Type {
+		case llms.TypeContent:
+			streamed += chunk.Content
+		case llms.TypeCompletion:
+			completion = &chunk
+		}
+		if chunk.Status == llms.StatusError {
+			t.Fatalf("Unexpected error chunk: %s", chunk.Content)
+		}
+	}
+
+	if streamed != "abcdeabcdeab" {
+		t.Errorf("Expected 12 streamed chars, got %q", streamed)
+	}
+	if completion == nil || !completion.Truncated || completion.FullContent != "abcdeabcdeab" {
+		t.Fatalf("Expected truncated completion with the capped content, got %+v", completion)
+	}
+
+	history := agent.GetHistory(0, 0)
+	if last := history[len(history)-1]; last.Role() != llms.MessageRoleAssistant || last.Content() != "abcdeabcdeab" {
+		t.Errorf("Expected truncated answer in history, got %+v", last)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for engine.Cancelled() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if engine.Cancelled() != 1 {
+		t.Errorf("Expected the LLM stream to be cancelled, got %d cancellations", engine.Cancelled())
+	}
+}
+
+// TestAgent_MaxOutputChars_ExactLength verifies that an answer exactly
+// MaxOutputChars long is complete, not truncated.
+func TestAgent_MaxOutputChars_ExactLength(t *testing.T) {
+	engine := newMockEngine(contentTurn("abcde", "abcde"))
+	agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "capped", MaxOutputChars: 10})
+
+	var completion *core.ExtendedChunkResponse
+	for chunk := range agent.ChatStream("talk a bit").Start() {
+		if chunk.Type == llms.TypeCompletion {
+			completion = &chunk
+		}
+	}
+
+	if completion == nil || completion.Truncated || completion.FullContent != "abcdeabcde" {
+		t.Fatalf("Expected a complete, untruncated answer, got %+v", completion)
+	}
+	if engine.Cancelled() != 0 {
+		t.Errorf("Expected the LLM stream to finish, got %d cancellations", engine.Cancelled())
+	}
+}
+
+// TestAgent_SystemFingerprint verifies that the provider's system fingerprint
+// reaches the consumer with the completion chunk.
+func TestAgent_SystemFingerprint(t *testing.T) {
+	turn := contentTurn("4")
+	turn.chunks[len(turn.chunks)-1].SystemFingerprint = "fp_test123"
+	agent := NewAgent(&AgentConfig{LLMEngine: newMockEngine(turn), AgentName: "seeded"})
+
+	var fingerprint string
+	for chunk := range agent.ChatStream("2+2?").Start() {
+		if chunk.Type == llms.TypeCompletion {
+			fingerprint = chunk.SystemFingerprint
+		}
+	}
+	if fingerprint != "fp_test123" {
+		t.Errorf("Expected the fingerprint in the completion chunk, got %q", fingerprint)
+	}
+}
+
+// TestAgent_DisplayName verifies that the speaker label is set on the first
+// content chunk of each answer only, and that the prefix stays out of the history.
+func TestAgent_DisplayName(t *testing.T) {
+	labels := func(agent *Agent, message string) (speakers []string, content string) {
+		for chunk := range agent.ChatStream(message).Start() {
+			if chunk.Type != llms.TypeContent {
+				continue
+			}
+			speakers = append(speakers, chunk.Speaker)
+			content += chunk.Delta
+		}
+		return speakers, content
+	}
+
+	agent := NewAgent(&AgentConfig{LLMEngine: newMockEngine(contentTurn("Hel", "lo")), AgentName: "plain"})
+	if speakers, _ := labels(agent, "hi"); !slices.Equal(speakers, []string{"", ""}) {
+		t.Errorf("Expected no speaker by default, got %q", speakers)
+	}
+
+	engine := newMockEngine(contentTurn("Hel", "lo"), contentTurn("Bye"))
+	agent = NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "support", DisplayName: "Ava", PrefixDisplayName: true})
+	speakers, content := labels(agent, "hi")
+	if !slices.Equal(speakers, []string{"Ava", ""}) || content != "Ava: Hello" {
+		t.Errorf("Expected one label on the first chunk, got speakers %q content %q", speakers, content)
+	}
+	speakers, content = labels(agent, "bye")
+	if !slices.Equal(speakers, []string{"Ava"}) || content != "Ava: Bye" {
+		t.Errorf("Expected the next answer to be labeled again, got speakers %q content %q", speakers, content)
+	}
+	for _, msg := range agent.GetHistory(0, 0) {
+		if strings.HasPrefix(msg.Content(), "Ava:") {
+			t.Errorf("Expected no prefix in the history, got %q", msg.Content())
+		}
+	}
+}
+
+// TestAgent_MaxUserMessageChars verifies that an over-limit message ends the run
+// with an error before any LLM call and is not added to the history.
+func TestAgent_MaxUserMessageChars(t *testing.T) {
+	engine := newMockEngine(contentTurn("ok"))
+	agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "limited", MaxUserMessageChars: 10})
+
+	var errorChunk *core.ExtendedChunkResponse
+	for chunk := range agent.ChatStream(strings.Repeat("é", 11)).Start() {
+		if chunk.Status == llms.StatusError {
+			errorChunk = &chunk
+		}
+	}
+	if errorChunk == nil || !errors.Is(errorChunk.Err, ErrMessageTooLong) || !strings.Contains(errorChunk.Content, "11 characters (limit 10)") {
+		t.Fatalf("Expected a message too long error chunk, got %+v", errorChunk)
+	}
+	if calls := engine.Calls(); len(calls) != 0 {
+		t.Errorf("Expected no LLM call, got %d", len(calls))
+	}
+	for _, msg := range agent.GetHistory(0, 0) {
+		if msg.Role() == llms.MessageRoleUser {
+			t.Errorf("Expected the message not to be stored, got %q", msg.Content())
+		}
+	}
+
+	// At the limit the message is sent
+	answer, err := agent.Chat(strings.Repeat("é", 10))
+	if err != nil || answer != "ok" {
+		t.Errorf("Chat() = %q, %v; want the answer", answer, err)
+	}
+
+	// ChatStreamMessages checks the last user message only
+	var messagesErr error
+	for chunk := range agent.ChatStreamMessages([]llms.UnifiedMessage{
+		llms.UserMessage("short"),
+		llms.AssistantMessage("ok", 0, 0, 0),
+		llms.UserMessage(strings.Repeat("é", 11)),
+	}).Start() {
+		if chunk.Status == llms.StatusError {
+			messagesErr = chunk.Err
+		}
+	}
+	if !errors.Is(messagesErr, ErrMessageTooLong) {
+		t.Errorf("Expected ChatStreamMessages to fail with ErrMessageTooLong, got %v", messagesErr)
+	}
+	if calls := engine.Calls(); len(calls) != 1 {
+		t.Errorf("Expected no further LLM call, got %d calls", len(calls))
+	}
+}
+
+// TestAgent_CoerceToolArguments verifies that the agent setting lets tools accept
+// numbers and booleans sent as strings.
+func TestAgent_CoerceToolArguments(t *testing.T) {
+	var received map[string]any
+	counter := core.NewTool("count", "Count up to a number", "", "",
+		[]core.Parameter{
+			{Name: "to", Type: "number", Required: true},
+			{Name: "skipOdd", Type: "boolean"},
+		},
+		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+			received = args
+			return core.NewSuccessResponse("counted")
+		},
+	)
+	call := llms.ToolCall{ID: "call_1", Name: "count", Arguments: map[string]any{"to": "42", "skipOdd": "true"}}
+
+	agent := NewAgent(&AgentConfig{
+		LLMEngine:           newMockEngine(toolCallTurn(call), contentTurn("done")),
+		AgentName:           "counter",
+		Tools:               []llms.Tool{counter},
+		CoerceToolArguments: true,
+	})
+	if _, err := agent.Chat("count to 42"); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if received["to"] != float64(42) || received["skipOdd"] != true {
+		t.Errorf("Expected coerced arguments, got %#v", received)
+	}
+
+	received = nil
+	agent = NewAgent(&AgentConfig{
+		LLMEngine: newMockEngine(toolCallTurn(call), contentTurn("done")),
+		AgentName: "strict_counter",
+		Tools:     []llms.Tool{counter},
+	})
+	if _, err := agent.Chat("count to 42"); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if received != nil {
+		t.Errorf("Expected the string arguments to be rejected by default, got %#v", received)
+	}
+}
+
+// logBuffer is an io.Writer collecting log output, safe for concurrent use.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// captureLogs redirects the global logger to a buffer for the rest of the test.
+func captureLogs(t *testing.T) *logBuffer {
+	t.Helper()
+	logs := &logBuffer{}
+	agentforge.GetLogger().SetOutput(logs)
+	t.Cleanup(func() { agentforge.GetLogger().SetOutput(os.Stdout) })
+	return logs
+}
+
+// TestAgent_MaxSystemPromptChars verifies that a system prompt bloated by many
+// sub-agents is reported, and fails runs in strict mode.
+func TestAgent_MaxSystemPromptChars(t *testing.T) {
+	team := func(n int) []*AgentConfig {
+		var configs []*AgentConfig
+		for i := 0; i < n; i++ {
+			configs = append(configs, &AgentConfig{
+				AgentName:   fmt.Sprintf("specialist_%d", i),
+				Description: "Handles one narrow kind of request. " + strings.Repeat("Detail. ", 20),
+			})
+		}
+		return configs
+	}
+	logs := captureLogs(t)
+
+	small := NewAgent(&AgentConfig{LLMEngine: newMockEngine(), AgentName: "small", SubAgentConfigs: team(2), MaxSystemPromptChars: 5000})
+	if _, err := small.Chat("hi"); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if strings.Contains(logs.String(), "system prompt too long") {
+		t.Errorf("Expected no warning below the limit, got %q", logs.String())
+	}
+
+	large := NewAgent(&AgentConfig{LLMEngine: newMockEngine(), AgentName: "large", SubAgentConfigs: team(40), MaxSystemPromptChars: 5000})
+	if _, err := large.Chat("hi"); err != nil {
+		t.Fatalf("Expected only a warning by default, got error %v", err)
+	}
+	if !strings.Contains(logs.String(), "Agent 'large': system prompt too long") || !strings.Contains(logs.String(), "(limit 5000) (40 sub-agents)") {
+		t.Errorf("Expected a warning above the limit, got %q", logs.String())
+	}
+
+	engine := newMockEngine()
+	strict := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "strict", SubAgentConfigs: team(40), MaxSystemPromptChars: 5000, StrictSystemPromptSize: true})
+	if _, err := strict.Chat("hi"); !errors.Is(err, ErrSystemPromptTooLong) {
+		t.Errorf("Expected ErrSystemPromptTooLong in strict mode, got %v", err)
+	}
+	if calls := engine.Calls(); len(calls) != 0 {
+		t.Errorf("Expected no LLM call in strict mode, got %d", len(calls))
+	}
+}
+
+// closeCountingPersistence is an in-memory persistence.Persistence counting Close calls.
+type closeCountingPersistence struct {
+	mu       sync.Mutex
+	history  []llms.UnifiedMessage
+	closes   int
+	closeErr error
+	// saveErr, when set, fails every save
+	saveErr error
+}
+
+func (p *closeCountingPersistence) SaveHystory(history []llms.UnifiedMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.saveErr != nil {
+		return p.saveErr
+	}
+	p.history = append([]llms.UnifiedMessage{}, history...)
+	return nil
+}
+
+func (p *closeCountingPersistence) GetHystory(limit, offset int) []llms.UnifiedMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]llms.UnifiedMessage{}, p.history...)
+}
+
+func (p *closeCountingPersistence) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closes++
+	return p.closeErr
+}
+
+func TestAgent_Close(t *testing.T) {
+	t.Run("closes persistence once", func(t *testing.T) {
+		agent := NewAgent(&AgentConfig{LLMEngine: newMockEngine(contentTurn("hi")), AgentName: "closing"})
+		store := &closeCountingPersistence{closeErr: errors.New("disk gone")}
+		agent.sessionFor(context.Background(), defaultSessionID).history.persistence = store
+
+		if _, err := agent.Chat("hello"); err != nil {
+			t.Fatalf("Chat() unexpected error = %v", err)
+		}
+		first := agent.Close()
+		if first == nil || !strings.Contains(first.Error(), "disk gone") {
+			t.Errorf("Expected the persistence error, got %v", first)
+		}
+		if second := agent.Close(); second != first {
+			t.Errorf("Expected the second Close to return the first result, got %v", second)
+		}
+		if store.closes != 1 {
+			t.Errorf("Expected persistence to be closed once, got %d", store.closes)
+		}
+		if len(store.history) != 3 {
+			t.Errorf("Expected the history to be flushed, got %d messages", len(store.history))
+		}
+
+		if _, err := agent.Chat("again"); err == nil || !strings.Contains(err.Error(), "closed") {
+			t.Errorf("Expected Chat after Close to fail, got %v", err)
+		}
+	})
+
+	t.Run("closes owned sub-agents", func(t *testing.T) {
+		shared := NewAgent(&AgentConfig{LLMEngine: newMockEngine(), AgentName: "shared"})
+		agent := NewAgent(&AgentConfig{
+			LLMEngine:       newMockEngine(),
+			AgentName:       "closing",
+			Reasoning:       true,
+			SubAgents:       []*core.SubAgent{shared.AgentAsSubAgent()},
+			SubAgentConfigs: []*AgentConfig{{AgentName: "worker"}},
+		})
+		var worker *Agent
+		for _, sa := range agent.SubAgents() {
+			if sa.Name() == "worker" {
+				worker = sa.(*Agent)
+			}
+		}
+		store := &closeCountingPersistence{closeErr: errors.New("disk gone")}
+		worker.sessionFor(context.Background(), defaultSessionID).history.persistence = store
+
+		err := agent.Close()
+		if err == nil || !strings.Contains(err.Error(), "sub-agent 'worker': disk gone") {
+			t.Errorf("Expected the sub-agent's persistence error, got %v", err)
+		}
+		for _, sa := range agent.SubAgents() {
+			closed := sa.(*Agent).isClosed()
+			if sa.Name() == "shared" && closed {
+				t.Error("Expected the shared sub-agent to stay open")
+			}
+			if sa.Name() != "shared" && !closed {
+				t.Errorf("Expected sub-agent %q to be closed", sa.Name())
+			}
+		}
+	})
+
+	t.Run("stops in-flight streams", func(t *testing.T) {
+		turn := contentTurn("partial")
+		turn.chunks = turn.chunks[:1]
+		turn.stall = true
+		engine := newMockEngine(turn)
+		agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "closing"})
+
+		chunks := agent.ChatStream("hello").Start()
+		if first := <-chunks; first.Content != "partial" {
+			t.Fatalf("Expected the first chunk, got %+v", first)
+		}
+
+		closeDone := make(chan error, 1)
+		go func() { closeDone <- agent.Close() }()
+
+		var failed bool
+		for chunk := range chunks {
+			if chunk.Status == llms.StatusError && strings.Contains(chunk.Content, "closed") {
+				failed = true
+			}
+		}
+		if !failed {
+			t.Error("Expected the run to end with a closed error")
+		}
+		select {
+		case err := <-closeDone:
+			if err != nil {
+				t.Errorf("Close() unexpected error = %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Close did not return")
+		}
+		deadline := time.Now().Add(time.Second)
+		for engine.Cancelled() == 0 && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if engine.Cancelled() != 1 {
+			t.Errorf("Expected the LLM stream to be cancelled, got %d cancellations", engine.Cancelled())
+		}
+	})
+}
+
+func TestAgent_Stop(t *testing.T) {
+	turn := contentTurn("partial")
+	turn.chunks = turn.chunks[:1]
+	turn.stall = true
+	engine := newMockEngine(turn)
+	agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "stopping"})
+
+	responseCh := agent.ChatStream("hello")
+	chunks := responseCh.Start()
+	if first := <-chunks; first.Content != "partial" {
+		t.Fatalf("Expected the first chunk, got %+v", first)
+	}
+	responseCh.Stop()
+	responseCh.Stop()
+
+	select {
+	case _, ok := <-chunks:
+		for ok {
+			_, ok = <-chunks
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the chunk channel to close after Stop")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for engine.Cancelled() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if engine.Cancelled() != 1 {
+		t.Errorf("Expected the LLM stream to be cancelled, got %d cancellations", engine.Cancelled())
+	}
+
+	// The next turn waits for the stopped run, which saved its partial answer
+	if _, err := agent.Chat("again"); err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+	history := agent.GetHistory(0, 0)
+	if len(history) < 3 || !history[2].Incomplete() || history[2].Content() != "partial" {
+		t.Errorf("Expected the partial answer saved as incomplete, got %+v", history)
+	}
+}
+
+// TestAgent_ChatStreamContext_AbandonedConsumer verifies that a run whose consumer
+// stopped reading without calling Stop ends once its context is cancelled.
+func TestAgent_ChatStreamContext_AbandonedConsumer(t *testing.T) {
+	deltas := make([]string, 50)
+	for i := range deltas {
+		deltas[i] = "x"
+	}
+	engine := newMockEngine(contentTurn(deltas...))
+	agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "abandoned"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	responseCh := agent.ChatStreamContext(ctx, "talk a lot")
+
+	// Nobody reads: the run blocks once the buffer is full, until ctx is cancelled
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	deadline := time.Now().Add(time.Second)
+	for engine.Cancelled() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if engine.Cancelled() != 1 {
+		t.Fatalf("Expected the LLM stream to be cancelled, got %d cancellations", engine.Cancelled())
+	}
+
+	// The run exited: only the buffered chunks are left and the channels are closed
+	buffered := 0
+	for range responseCh.Response {
+		buffered++
+	}
+	if buffered > core.DefaultResponseBufferSize {
+		t.Errorf("Expected at most %d buffered chunks, got %d", core.DefaultResponseBufferSize, buffered)
+	}
+	if err := <-responseCh.Error; !errors.Is(err, ErrContextCancelled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a context cancelled error, got %v", err)
+	}
+}
+
+// cancelAfterLLMHooks cancels the run's context once the LLM call completes.
+type cancelAfterLLMHooks struct {
+	NoopHooks
+	cancel context.CancelFunc
+}
+
+func (h *cancelAfterLLMHooks) AfterLLMCall(usage llms.Usage) { h.cancel() }
+
+// TestAgent_ChatStreamContext_AbortsOnFailedSend verifies that a run ends as soon
+// as a chunk cannot be forwarded, instead of running the tools it announces.
+func TestAgent_ChatStreamContext_AbortsOnFailedSend(t *testing.T) {
+	var calls int
+	engine := newMockEngine(
+		toolCallTurn(llms.ToolCall{ID: "call_1", Name: "counted", Arguments: map[string]any{"x": float64(1)}}),
+		contentTurn("done"),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	agent := NewAgent(&AgentConfig{
+		LLMEngine:          engine,
+		AgentName:          "unsent",
+		Tools:              []llms.Tool{newCountingTool("counted", &calls)},
+		ResponseBufferSize: 1,
+		Hooks:              &cancelAfterLLMHooks{cancel: cancel},
+	})
+
+	// Nobody reads: the tool-call chunk fills the buffer, so the tool-executing
+	// chunk cannot be sent once the hook cancels ctx
+	responseCh := agent.ChatStreamContext(ctx, "count")
+	select {
+	case err := <-responseCh.Error:
+		if !errors.Is(err, ErrContextCancelled) {
+			t.Errorf("Expected a context cancelled error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the run to end")
+	}
+	for range responseCh.Response {
+	}
+
+	if calls != 0 {
+		t.Errorf("Expected the tool not to run, got %d calls", calls)
+	}
+	if got := len(engine.Calls()); got != 1 {
+		t.Errorf("Expected a single LLM call, got %d", got)
+	}
+}
+
+func TestAgent_DetailedToolDescriptions(t *testing.T) {
+	newTools := func() []llms.Tool {
+		custom := core.NewTool("custom", "Custom tool", "Custom details", "", nil,
+			func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+				return core.NewSuccessResponse("ok")
+			},
+		).(*core.Tool).WithLLMDescription("Custom LLM description")
+		return []llms.Tool{tools.NewReverseTool(), custom}
+	}
+	descriptions := func(detailed bool) map[string]string {
+		engine := newMockEngine(contentTurn("hi"))
+		agent := NewAgent(&AgentConfig{
+			LLMEngine:                engine,
+			AgentName:                "describing",
+			Tools:                    newTools(),
+			DetailedToolDescriptions: detailed,
+		})
+		if _, err := agent.Chat("hello"); err != nil {
+			t.Fatalf("Chat() unexpected error = %v", err)
+		}
+		byName := make(map[string]string)
+		for _, def := range engine.ToolDefinitions()[0] {
+			byName[def.Name] = def.Description
+		}
+		return byName
+	}
+
+	reverse := tools.NewReverseTool().(*core.Tool)
+
+	basic := descriptions(false)
+	if basic["reverse"] != reverse.BasicDescription() {
+		t.Errorf("Expected the basic description by default, got %q", basic["reverse"])
+	}
+	if basic["custom"] != "Custom LLM description" {
+		t.Errorf("Expected the tool's LLM description, got %q", basic["custom"])
+	}
+
+	detailed := descriptions(true)
+	if !strings.Contains(detailed["reverse"], reverse.BasicDescription()) || !strings.Contains(detailed["reverse"], reverse.AdvanceDescription()) {
+		t.Errorf("Expected basic and advanced descriptions, got %q", detailed["reverse"])
+	}
+	if detailed["custom"] != "Custom LLM description" {
+		t.Errorf("Expected the tool's LLM description to be kept, got %q", detailed["custom"])
+	}
+}
+
+func TestAgent_ResponseBufferSize(t *testing.T) {
+	agent := NewAgent(&AgentConfig{LLMEngine: newMockEngine(), AgentName: "buffered", ResponseBufferSize: 128})
+	if got := cap(agent.ChatStream("hi").Response); got != 128 {
+		t.Errorf("cap(Response) = %d, want 128", got)
+	}
+
+	agent = NewAgent(&AgentConfig{LLMEngine: newMockEngine(), AgentName: "default", ResponseBufferSize: -5})
+	if got := cap(agent.ChatStream("hi").Response); got != core.DefaultResponseBufferSize {
+		t.Errorf("cap(Response) = %d, want %d", got, core.DefaultResponseBufferSize)
+	}
+}
+
+func TestAgent_ToolRegistry(t *testing.T) {
+	calls := 0
+	registry, err := tools.NewRegistry(newCountingTool("shared", &calls))
+	if err != nil {
+		t.Fatalf("NewRegistry() unexpected error = %v", err)
+	}
+
+	for _, name := range []string{"first", "second"} {
+		engine := newMockEngine(
+			toolCallTurn(llms.ToolCall{ID: "call_1", Name: "shared", Arguments: map[string]any{"x": float64(1)}}),
+			contentTurn("done"),
+		)
+		agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: name, ToolRegistry: registry})
+		if agent.findTool("shared") == nil {
+			t.Fatalf("Agent %s: expected registry tool to be found", name)
+		}
+		if _, err := agent.Chat("use the shared tool"); err != nil {
+			t.Fatalf("Chat() unexpected error = %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Expected the shared tool to run once per agent, ran %d times", calls)
+	}
+}
+
+func TestAgent_SetToolsReindexes(t *testing.T) {
+	agent := NewAgent(&AgentConfig{LLMEngine: newMockEngine(), AgentName: "indexed"})
+	calls := 0
+	agent.SetTools(append(agent.GetTools(), newCountingTool("added", &calls)))
+
+	if agent.findTool("added") == nil {
+		t.Error("Expected tool added with SetTools to be found")
+	}
+	agent.SetTools(nil)
+	if agent.findTool("foo") != nil {
+		t.Error("Expected removed tools to be gone from the index")
+	}
+}
+
+func TestAgent_SetToolsDuringRuns(t *testing.T) {
+	// Run with -race: SetTools must not race with the tool lookups of running turns
+	engine := newMockEngine()
+	engine.respond = func(messages []llms.UnifiedMessage) mockTurn {
+		if messages[len(messages)-1].Role() == llms.MessageRoleTool {
+			return contentTurn("done")
+		}
+		return toolCallTurn(llms.ToolCall{ID: "call_1", Name: "foo", Arguments: map[string]any{"echo": "hi"}})
+	}
+	agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "retooled"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			drainAnswer(t, agent, fmt.Sprintf("session-%d", i), "echo")
+		}(i)
+	}
+	for i := 0; i < 20; i++ {
+		calls := 0
+		agent.SetTools(append(agent.GetTools(), newCountingTool(fmt.Sprintf("extra_%d", i), &calls)))
+	}
+	wg.Wait()
+
+	if agent.findTool("extra_19") == nil {
+		t.Error("Expected the last tool set to be found")
+	}
+}
+
+func TestAgent_SetToolsListsNewTools(t *testing.T) {
+	engine := newMockEngine(
+		toolCallTurn(llms.ToolCall{ID: "call_1", Name: "list_capabilities", Arguments: map[string]any{}}),
+		contentTurn("done"),
+	)
+	agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "retooled", Tools: []llms.Tool{tools.NewListCapabilitiesTool()}})
+	if err := agent.SetTools(append(agent.GetTools(), tools.NewReverseTool())); err != nil {
+		t.Fatalf("SetTools() unexpected error = %v", err)
+	}
+
+	if _, err := agent.Chat("what can you do?"); err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+	var catalog string
+	for _, msg := range agent.GetHistory(0, 0) {
+		if msg.Role() == llms.MessageRoleTool {
+			catalog = msg.Content()
+		}
+	}
+	if !strings.Contains(catalog, "reverse") {
+		t.Errorf("Expected the tool set with SetTools in the catalog, got %q", catalog)
+	}
+}
+
+func TestAgent_RoutesAmongTestTools(t *testing.T) {
+	engine := newMockEngine(
+		toolCallTurn(
+			llms.ToolCall{ID: "call_1", Name: "reverse", Arguments: map[string]any{"text": "abc"}},
+			llms.ToolCall{ID: "call_2", Name: "uppercase", Arguments: map[string]any{"text": "abc"}},
+			llms.ToolCall{ID: "call_3", Name: "fail", Arguments: map[string]any{"reason": "nope"}},
+		),
+		contentTurn("done"),
+	)
+	agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "router"})
+	agent.SetTools(append(agent.GetTools(), tools.NewReverseTool(), tools.NewUppercaseTool(), tools.NewFailTool()))
+
+	if _, err := agent.Chat("use every tool"); err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+
+	results := map[string]string{}
+	for _, msg := range agent.history.History() {
+		if msg.Role() == llms.MessageRoleTool {
+			results[msg.ToolCallID()] = msg.Content()
+		}
+	}
+	if results["call_1"] != "cba" || results["call_2"] != "ABC" {
+		t.Errorf("Expected each call routed to its tool, got %v", results)
+	}
+	if !strings.Contains(results["call_3"], "nope") {
+		t.Errorf("Expected the failure to be reported to the model, got %q", results["call_3"])
+	}
+	assertToolSequencesValid(t, agent.history.History())
+}
+
+func TestAgent_ParseTextToolCalls(t *testing.T) {
+	textCall := "Let me call the tool.\n```json\n{\"name\": \"foo\", \"arguments\": {\"echo\": \"Hello, text!\"}}\n```"
+	run := func(parse bool) (*mockEngine, string) {
+		engine := newMockEngine(
+			contentTurn(textCall),
+			contentTurn("The tool said: Hello, text!"),
+		)
+		agent := NewAgent(&AgentConfig{
+			LLMEngine:          engine,
+			AgentName:          "text caller",
+			ParseTextToolCalls: parse,
+		})
+		answer, err := agent.Chat("echo something")
+		if err != nil {
+			t.Fatalf("Chat() unexpected error = %v", err)
+		}
+		return engine, answer
+	}
+
+	engine, answer := run(false)
+	if len(engine.Calls()) != 1 || answer != textCall {
+		t.Errorf("Expected the text to be the answer when parsing is off, got %d calls and %q", len(engine.Calls()), answer)
+	}
+
+	engine, answer = run(true)
+	if answer != "The tool said: Hello, text!" {
+		t.Errorf("Chat() = %q, want the answer after the tool call", answer)
+	}
+	calls := engine.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("Expected 2 LLM calls, got %d", len(calls))
+	}
+	last := calls[1][len(calls[1])-1]
+	if last.Role() != llms.MessageRoleTool || last.Content() != "Hello, text!" {
+		t.Errorf("Expected tool message with echoed text, got %s: %q", last.Role(), last.Content())
+	}
+}
+
+func TestAgent_parseTextToolCalls(t *testing.T) {
+	agent := NewAgent(&AgentConfig{LLMEngine: newMockEngine(), AgentName: "parser", Tools: []llms.Tool{tools.NewReverseTool()}})
+
+	tests := []struct {
+		name    string
+		content string
+		want    []llms.ToolCall
+	}{
+		{
+			name:    "plain text",
+			content: "No tools needed.",
+		},
+		{
+			name:    "ordinary code block",
+			content: "```go\nfmt.Println(\"hi\")\n```",
+		},
+		{
+			name:    "unknown tool",
+			content: "```json\n{\"name\": \"missing\", \"arguments\": {}}\n```",
+		},
+		{
+			name:    "fenced block with parameters",
+			content: "```\n{\"name\": \"reverse\", \"parameters\": {\"text\": \"abc\"}}\n```",
+			want:    []llms.ToolCall{{ID: "text_call_1", Name: "reverse", Arguments: map[string]any{"text": "abc"}}},
+		},
+		{
+			name:    "tool_call tags with string arguments",
+			content: "<tool_call>{\"name\": \"reverse\", \"arguments\": \"{\\\"text\\\": \\\"abc\\\"}\"}</tool_call>",
+			want:    []llms.ToolCall{{ID: "text_call_1", Name: "reverse", Arguments: map[string]any{"text": "abc"}}},
+		},
+		{
+			name:    "array of calls",
+			content: "```json\n[{\"name\": \"reverse\", \"arguments\": {\"text\": \"a\"}}, {\"name\": \"foo\"}]\n```",
+			want: []llms.ToolCall{
+				{ID: "text_call_1", Name: "reverse", Arguments: map[string]any{"text": "a"}},
+				{ID: "text_call_2", Name: "foo", Arguments: map[string]any{}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := agent.parseTextToolCalls(tt.content)
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseTextToolCalls() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].ID != tt.want[i].ID || got[i].Name != tt.want[i].Name || !reflect.DeepEqual(got[i].Arguments, tt.want[i].Arguments) {
+					t.Errorf("parseTextToolCalls()[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAgent_ChatStreamMessages(t *testing.T) {
+	engine := newMockEngine(
+		toolCallTurn(llms.ToolCall{ID: "call_1", Name: "foo", Arguments: map[string]any{"echo": "Paris"}}),
+		contentTurn("Paris"),
+	)
+	agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "stateless", SystemPrompt: "Be brief."})
+
+	messages := []llms.UnifiedMessage{
+		llms.UserMessage("Capital of Italy?"),
+		llms.AssistantMessage("Rome", 0, 0, 0),
+		llms.UserMessage("Capital of France?"),
+	}
+
+	var final string
+	for chunk := range agent.ChatStreamMessages(messages).Start() {
+		if chunk.Status == llms.StatusError {
+			t.Fatalf("Unexpected error chunk: %v", chunk.Err)
+		}
+		if chunk.Type == llms.TypeCompletion && chunk.AgentName == agent.Name() {
+			final = chunk.FullContent
+		}
+	}
+	if final != "Paris" {
+		t.Errorf("Expected final answer %q, got %q", "Paris", final)
+	}
+
+	// The tool loop ran on the given messages with the system prompt prepended
+	calls := engine.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("Expected 2 LLM calls, got %d", len(calls))
+	}
+	first := calls[0]
+	if len(first) != 4 || first[0].Role() != llms.MessageRoleSystem || first[3].Content() != "Capital of France?" {
+		t.Errorf("Expected system prompt followed by the given messages, got %+v", first)
+	}
+	if last := calls[1][len(calls[1])-1]; last.Role() != llms.MessageRoleTool || last.Content() != "Paris" {
+		t.Errorf("Expected the tool result to be sent back, got %s: %q", last.Role(), last.Content())
+	}
+
+	// Neither the caller's slice nor the agent's history was touched
+	if len(messages) != 3 {
+		t.Errorf("Expected the caller's messages to be unchanged, got %d", len(messages))
+	}
+	if history := agent.GetHistory(0, 0); len(history) != 0 {
+		t.Errorf("Expected an empty agent history, got %d messages", len(history))
+	}
+}
+
+func TestAgent_ChatStreamMessagesContext(t *testing.T) {
+	t.Run("persists the conversation in the session", func(t *testing.T) {
+		agent := NewAgent(&AgentConfig{LLMEngine: newMockEngine(contentTurn("Paris")), AgentName: "stored"})
+		store := &closeCountingPersistence{}
+		agent.sessionFor(context.Background(), "user-1").history.persistence = store
+
+		messages := []llms.UnifiedMessage{
+			llms.UserMessage("Capital of Italy?"),
+			llms.AssistantMessage("Rome", 0, 0, 0),
+			llms.UserMessage("Capital of France?"),
+		}
+		rc := agent.ChatStreamMessagesContext(context.Background(), messages, MessagesOptions{Persist: true, SessionID: "user-1"})
+		for chunk := range rc.Start() {
+			if chunk.Status == llms.StatusError {
+				t.Fatalf("Unexpected error chunk: %v", chunk.Err)
+			}
+		}
+
+		// System prompt, the three messages and the answer
+		stored := store.GetHystory(0, 0)
+		if len(stored) != 5 || stored[3].Content() != "Capital of France?" || stored[4].Content() != "Paris" {
+			t.Errorf("Expected the conversation and the answer to be persisted, got %+v", stored)
+		}
+		if history := agent.GetHistory(0, 0); len(history) != 0 {
+			t.Errorf("Expected the default session to be untouched, got %d messages", len(history))
+		}
+	})
+
+	t.Run("ends the run when ctx is done", func(t *testing.T) {
+		turn := contentTurn("partial")
+		turn.chunks = turn.chunks[:1]
+		turn.stall = true
+		agent := NewAgent(&AgentConfig{LLMEngine: newMockEngine(turn), AgentName: "bounded"})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		chunks := agent.ChatStreamMessagesContext(ctx, []llms.UnifiedMessage{llms.UserMessage("hi")}, MessagesOptions{}).Start()
+		<-chunks
+		cancel()
+
+		var err error
+		for chunk := range chunks {
+			if chunk.Status == llms.StatusError {
+				err = chunk.Err
+			}
+		}
+		if !errors.Is(err, ErrContextCancelled) {
+			t.Errorf("Expected ErrContextCancelled, got %v", err)
+		}
+	})
+}
+
+func TestAgent_ChatStreamMessages_Empty(t *testing.T) {
+	agent := NewAgent(&AgentConfig{LLMEngine: newMockEngine(), AgentName: "stateless"})
+	var gotErr bool
+	for chunk := range agent.ChatStreamMessages(nil).Start() {
+		if chunk.Status == llms.StatusError {
+			gotErr = true
+		}
+	}
+	if !gotErr {
+		t.Error("Expected an error chunk for an empty conversation")
+	}
+}
+
+func TestAgent_ToolProgress(t *testing.T) {
+	slowTool := core.NewTool("slow", "A long-running tool", "", "", nil,
+		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+			tools.Progress(agentContext, "step 1/2")
+			tools.Progress(agentContext, "step 2/2")
+			return core.NewSuccessResponse("finished")
+		},
+	)
+	engine := newMockEngine(
+		toolCallTurn(llms.ToolCall{ID: "call_1", Name: "slow", Arguments: map[string]any{}}),
+		contentTurn("All done"),
+	)
+	agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "progressing", Tools: []llms.Tool{slowTool}})
+
+	var progress []string
+	var content string
+	for chunk := range agent.ChatStream("run it").Start() {
+		if chunk.Trace == llms.TraceToolProgress {
+			if chunk.Type != llms.TypeContent || chunk.AgentName != "progressing" {
+				t.Errorf("Unexpected progress chunk: %+v", chunk)
+			}
+			progress = append(progress, chunk.Content)
+			continue
+		}
+		if chunk.Type == llms.TypeContent {
+			content += chunk.Content
+		}
+	}
+
+	if !reflect.DeepEqual(progress, []string{"step 1/2", "step 2/2"}) {
+		t.Errorf("Expected both progress messages, got %v", progress)
+	}
+	if content != "All done" {
+		t.Errorf("Expected the answer without progress messages, got %q", content)
+	}
+
+	// Progress is a no-op outside an agent run
+	tools.Progress(map[string]any{}, "ignored")
+}
@@ -0,0 +1,80 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// Approver decides whether a tool call that requires approval may run.
+//
+// Implementations may block (e.g. to ask a human) and must be safe for
+// concurrent use when the agent serves several sessions.
+type Approver interface {
+	// Approve reports whether the tool call may run.
+	//
+	// Parameters:
+	//   - ctx: Context of the agent run (carries the tool call span)
+	//   - toolCall: The tool call awaiting approval
+	//
+	// Returns:
+	//   - bool: true to run the tool, false to deny the call
+	//   - error: If approval could not be obtained; the call is not run
+	Approve(ctx context.Context, toolCall llms.ToolCall) (bool, error)
+}
+
+// ApproverFunc adapts a function to the Approver interface.
+type ApproverFunc func(ctx context.Context, toolCall llms.ToolCall) (bool, error)
+
+// Approve implements Approver.
+func (f ApproverFunc) Approve(ctx context.Context, toolCall llms.ToolCall) (bool, error) {
+	return f(ctx, toolCall)
+}
+
+// ApprovalRequiredTool is implemented by tools that can require approval before
+// running (see core.Tool.SetRequiresApproval). Tools that do not implement it
+// run without approval.
+type ApprovalRequiredTool interface {
+	RequiresApproval() bool
+}
+
+// requiresApproval reports whether calls to the named tool must be approved.
+func (a *Agent) requiresApproval(name string) bool {
+	tool := a.findTool(name)
+	if tool == nil {
+		return false
+	}
+	if at, ok := tool.(ApprovalRequiredTool); ok {
+		return at.RequiresApproval()
+	}
+	return false
+}
+
+// approveToolCall consults the approver for tools that require approval.
+// It returns the error result to report instead of running the tool, and false
+// when the call is not approved. Without an Approver, such calls are denied.
+func (a *Agent) approveToolCall(ctx context.Context, toolCall llms.ToolCall) (llms.ToolResult, bool) {
+	if !a.requiresApproval(toolCall.Name) {
+		return llms.ToolResult{}, true
+	}
+
+	var reason string
+	if a.config.Approver == nil {
+		reason = fmt.Sprintf("tool call denied: '%s' requires approval but no approver is configured", toolCall.Name)
+	} else if approved, err := a.config.Approver.Approve(ctx, toolCall); err != nil {
+		reason = fmt.Sprintf("tool call approval failed: %v", err)
+	} else if !approved {
+		reason = fmt.Sprintf("tool call denied by approver: %s", toolCall.Name)
+	} else {
+		return llms.ToolResult{}, true
+	}
+
+	return llms.ToolResult{
+		ToolCallID: toolCall.ID,
+		ToolName:   toolCall.Name,
+		Success:    false,
+		Error:      reason,
+		Metadata:   a.toolResultMetadata(0, ""),
+	}, false
+}
@@ -0,0 +1,108 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// newDangerousTool returns a tool requiring approval that counts its executions.
+func newDangerousTool(runs *int) llms.Tool {
+	tool := core.NewTool("delete_everything", "Deletes everything", "", "", nil,
+		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+			*runs++
+			return core.NewSuccessResponse("deleted")
+		},
+	)
+	tool.(*core.Tool).SetRequiresApproval(true)
+	return tool
+}
+
+func TestAgent_Approver(t *testing.T) {
+	call := llms.ToolCall{ID: "call_1", Name: "delete_everything", Arguments: map[string]any{}}
+
+	cases := []struct {
+		name       string
+		approver   Approver
+		wantRuns   int
+		wantResult string
+	}{
+		{
+			name:       "auto-approve",
+			approver:   ApproverFunc(func(ctx context.Context, tc llms.ToolCall) (bool, error) { return true, nil }),
+			wantRuns:   1,
+			wantResult: "deleted",
+		},
+		{
+			name:       "auto-deny",
+			approver:   ApproverFunc(func(ctx context.Context, tc llms.ToolCall) (bool, error) { return false, nil }),
+			wantResult: "Error: tool call denied by approver: delete_everything",
+		},
+		{
+			name:       "approver error",
+			approver:   ApproverFunc(func(ctx context.Context, tc llms.ToolCall) (bool, error) { return false, errors.New("console closed") }),
+			wantResult: "Error: tool call approval failed: console closed",
+		},
+		{
+			name:       "no approver",
+			wantResult: "Error: tool call denied: 'delete_everything' requires approval but no approver is configured",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			runs := 0
+			var asked []llms.ToolCall
+			approver := c.approver
+			if approver != nil {
+				approver = ApproverFunc(func(ctx context.Context, tc llms.ToolCall) (bool, error) {
+					asked = append(asked, tc)
+					return c.approver.Approve(ctx, tc)
+				})
+			}
+
+			engine := newMockEngine(toolCallTurn(call), contentTurn("ok"))
+			agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "careful agent", Approver: approver})
+			agent.SetTools(append(agent.GetTools(), newDangerousTool(&runs)))
+
+			if answer, err := agent.Chat("clean up"); err != nil || answer != "ok" {
+				t.Fatalf("Chat() = %q, %v; expected the loop to continue to the answer", answer, err)
+			}
+			if runs != c.wantRuns {
+				t.Errorf("Expected %d tool runs, got %d", c.wantRuns, runs)
+			}
+			if approver != nil && (len(asked) != 1 || asked[0].ID != call.ID) {
+				t.Errorf("Expected the approver to be asked once for %s, got %+v", call.ID, asked)
+			}
+
+			calls := engine.Calls()
+			stored := calls[len(calls)-1][len(calls[len(calls)-1])-1]
+			if stored.Role() != llms.MessageRoleTool || !strings.Contains(stored.Content(), c.wantResult) {
+				t.Errorf("Expected tool message %q, got %s: %q", c.wantResult, stored.Role(), stored.Content())
+			}
+		})
+	}
+
+	t.Run("tools without the flag are not gated", func(t *testing.T) {
+		asked := false
+		engine := newMockEngine(toolCallTurn(llms.ToolCall{ID: "call_1", Name: "foo", Arguments: map[string]any{"echo": "hi"}}), contentTurn("ok"))
+		agent := NewAgent(&AgentConfig{
+			LLMEngine: engine,
+			AgentName: "careful agent",
+			Approver: ApproverFunc(func(ctx context.Context, tc llms.ToolCall) (bool, error) {
+				asked = true
+				return false, nil
+			}),
+		})
+		if _, err := agent.Chat("echo hi"); err != nil {
+			t.Fatalf("Chat() unexpected error = %v", err)
+		}
+		if asked {
+			t.Error("Expected no approval request for a tool without RequiresApproval")
+		}
+	})
+}
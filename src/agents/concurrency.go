@@ -0,0 +1,101 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ConcurrencyLimiter bounds the number of agent runs executing at once, e.g. to
+// protect memory and API quota when agents are embedded in a server. Runs beyond
+// the limit wait for a slot in the order they arrive. Share one limiter between
+// agents to apply a common limit (see AgentConfig.ConcurrencyLimiter).
+type ConcurrencyLimiter struct {
+	slots  chan struct{}
+	active atomic.Int64
+}
+
+// NewConcurrencyLimiter creates a limiter allowing n concurrent runs.
+//
+// Parameters:
+//   - n: The maximum number of concurrent runs (values below 1 are treated as 1)
+//
+// Returns:
+//   - *ConcurrencyLimiter: A limiter safe for concurrent use
+func NewConcurrencyLimiter(n int) *ConcurrencyLimiter {
+	if n < 1 {
+		n = 1
+	}
+	return &ConcurrencyLimiter{slots: make(chan struct{}, n)}
+}
+
+// Acquire blocks until a slot is free. It returns ctx's error if ctx is done first.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		l.active.Add(1)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot taken by Acquire.
+func (l *ConcurrencyLimiter) Release() {
+	l.active.Add(-1)
+	<-l.slots
+}
+
+// Active returns the number of runs currently holding a slot.
+func (l *ConcurrencyLimiter) Active() int {
+	return int(l.active.Load())
+}
+
+// Limit returns the maximum number of concurrent runs.
+func (l *ConcurrencyLimiter) Limit() int {
+	return cap(l.slots)
+}
+
+// errRunStopped is returned by acquireRunSlot when the consumer stopped the run
+// while it was waiting.
+var errRunStopped = errors.New("run stopped while waiting for a concurrency slot")
+
+// acquireRunSlot waits for a slot of the agent's ConcurrencyLimiter. Only
+// top-level runs take a slot: delegated runs execute within their parent's,
+// which also keeps a limit of 1 from deadlocking delegation.
+//
+// Returns:
+//   - func(): Releases the slot (a no-op when no slot was taken)
+//   - error: The run's context error, the closed agent error, or errRunStopped
+func (a *Agent) acquireRunSlot(r *agentRun) (func(), error) {
+	limiter := a.config.ConcurrencyLimiter
+	if limiter == nil || r.depth > 0 {
+		return func() {}, nil
+	}
+
+	ctx, cancel := context.WithCancel(r.ctx)
+	defer cancel()
+	var stopped, closed atomic.Bool
+	go func() {
+		select {
+		case <-r.responseCh.Stopped():
+			stopped.Store(true)
+			cancel()
+		case <-a.closed:
+			closed.Store(true)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if err := limiter.Acquire(ctx); err != nil {
+		switch {
+		case stopped.Load():
+			return nil, errRunStopped
+		case closed.Load():
+			return nil, a.closedError()
+		}
+		return nil, err
+	}
+	return limiter.Release, nil
+}
@@ -0,0 +1,112 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// slowEngine answers every call after a delay and tracks the calls in flight.
+type slowEngine struct {
+	delay   time.Duration
+	running atomic.Int64
+	maxSeen atomic.Int64
+}
+
+func (e *slowEngine) ChatStream(messages []llms.UnifiedMessage, tools []llms.Tool) *llms.ResponseCh {
+	running := e.running.Add(1)
+	for {
+		seen := e.maxSeen.Load()
+		if running <= seen || e.maxSeen.CompareAndSwap(seen, running) {
+			break
+		}
+	}
+
+	engine := newMockEngine(contentTurn("ok"))
+	time.Sleep(e.delay)
+	e.running.Add(-1)
+	return engine.ChatStream(messages, tools)
+}
+
+func TestAgent_ConcurrencyLimiter(t *testing.T) {
+	t.Run("at most N runs execute at once", func(t *testing.T) {
+		engine := &slowEngine{delay: 20 * time.Millisecond}
+		limiter := NewConcurrencyLimiter(2)
+		agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "limited", ConcurrencyLimiter: limiter})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(session string) {
+				defer wg.Done()
+				for chunk := range agent.ChatStreamSession(session, "hello").Start() {
+					if chunk.Status == llms.StatusError {
+						t.Errorf("Unexpected error chunk: %s", chunk.Content)
+					}
+				}
+			}(fmt.Sprintf("session-%d", i))
+		}
+		wg.Wait()
+
+		if got := engine.maxSeen.Load(); got != 2 {
+			t.Errorf("Expected at most (and up to) 2 concurrent runs, got %d", got)
+		}
+		if limiter.Active() != 0 {
+			t.Errorf("Expected every slot to be released, %d active", limiter.Active())
+		}
+	})
+
+	t.Run("waiting run honours its context", func(t *testing.T) {
+		limiter := NewConcurrencyLimiter(1)
+		if err := limiter.Acquire(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		defer limiter.Release()
+
+		engine := newMockEngine(contentTurn("never"))
+		agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "queued", ConcurrencyLimiter: limiter})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if _, err := agent.ChatContext(ctx, "hello"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the context error, got %v", err)
+		}
+		if len(engine.Calls()) != 0 {
+			t.Errorf("Expected no LLM call without a slot, got %d", len(engine.Calls()))
+		}
+	})
+
+	t.Run("delegated runs use the parent's slot", func(t *testing.T) {
+		limiter := NewConcurrencyLimiter(1)
+		main := NewAgent(&AgentConfig{
+			LLMEngine: newMockEngine(
+				toolCallTurn(llms.ToolCall{ID: "call_1", Name: "delegate", Arguments: map[string]any{"subAgent": "worker", "message": "work"}}),
+				contentTurn("all done"),
+			),
+			AgentName:          "main",
+			ConcurrencyLimiter: limiter,
+			SubAgentConfigs:    []*AgentConfig{{AgentName: "worker", Description: "Works", ConcurrencyLimiter: limiter}},
+			ExtraEngines:       map[string]llms.LLMEngine{"worker": newMockEngine(contentTurn("worked"))},
+		})
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := main.Chat("delegate it")
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Chat() unexpected error = %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Delegation deadlocked waiting for a slot")
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package agents
+
+import (
+	agentforge "github.com/thinktwice/agentForge/src"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// AgentConfig.ContextCompaction strategies.
+const (
+	// CompactionTruncate drops the oldest turns from the request.
+	CompactionTruncate = "truncate"
+	// CompactionSummarize replaces the oldest turns with a summary in the history.
+	CompactionSummarize = "summarize"
+)
+
+// defaultCompletionReserveTokens is the default AgentConfig.CompletionReserveTokens.
+const defaultCompletionReserveTokens = 4096
+
+// initContextCompaction resolves the context window and defaults of ContextCompaction.
+func (a *Agent) initContextCompaction() {
+	if a.config.CompletionReserveTokens <= 0 {
+		a.config.CompletionReserveTokens = defaultCompletionReserveTokens
+	}
+
+	a.contextWindow = a.config.ContextWindow
+	if a.contextWindow <= 0 {
+		if reporter, ok := a.config.LLMEngine.(llms.ModelCapabilitiesReporter); ok {
+			a.contextWindow = reporter.Capabilities().ContextWindow
+		}
+	}
+	if a.contextWindow <= 0 {
+		agentforge.Warn("Agent '%s': context window of the model is unknown, set ContextWindow to enable context compaction", a.Name())
+		return
+	}
+
+	if a.config.ContextCompaction == CompactionSummarize && a.summarizer == nil {
+		a.summarizer = NewSummarizer(a.config.LLMEngine)
+	}
+}
+
+// fitContextWindow returns the messages of the history to send to the LLM,
+// compacted per ContextCompaction when they do not fit the context window.
+//
+// The oldest turns are dropped exponentially (1, 2, 4, ... turns) until the rest
+// fits, keeping the system prompt and the current turn. Dropping whole turns keeps
+// tool-call sequences intact.
+func (a *Agent) fitContextWindow(h *History) []llms.UnifiedMessage {
+	messages := h.History()
+	if a.contextWindow <= 0 {
+		return messages
+	}
+	budget := a.contextWindow - a.config.CompletionReserveTokens
+	if estimateTokens(messages) <= budget {
+		return messages
+	}
+
+	// Keep the pinned system prompt
+	start := 0
+	if len(messages) > 0 && messages[0].Role() == llms.MessageRoleSystem {
+		start = 1
+	}
+	var turnStarts []int
+	for i := start; i < len(messages); i++ {
+		if messages[i].Role() == llms.MessageRoleUser {
+			turnStarts = append(turnStarts, i)
+		}
+	}
+	// The current turn starts at the last user message and is always kept
+	if len(turnStarts) < 2 {
+		agentforge.Warn("Agent '%s': the current turn alone exceeds the context window (%d tokens)", a.Name(), budget)
+		return messages
+	}
+
+	var end int
+	var compacted []llms.UnifiedMessage
+	for dropped := 1; ; dropped *= 2 {
+		if dropped > len(turnStarts)-1 {
+			dropped = len(turnStarts) - 1
+		}
+		end = turnStarts[dropped]
+		compacted = dropMessages(messages, start, end)
+		if estimateTokens(compacted) <= budget || dropped == len(turnStarts)-1 {
+			break
+		}
+	}
+	if estimateTokens(compacted) > budget {
+		agentforge.Warn("Agent '%s': the current turn alone exceeds the context window (%d tokens)", a.Name(), budget)
+	}
+
+	if a.config.ContextCompaction == CompactionSummarize {
+		summary, err := a.summarizer.Summarize(messages[start:end])
+		if err == nil {
+			h.replaceWithSummary(start, end, summary)
+			h.save()
+			agentforge.Debug("Agent '%s': summarized %d messages to fit the context window", a.Name(), end-start)
+			return h.History()
+		}
+		agentforge.Warn("Agent '%s': context summarization failed, truncating instead: %v", a.Name(), err)
+	}
+
+	agentforge.Debug("Agent '%s': dropped %d messages from the request to fit the context window", a.Name(), end-start)
+	return compacted
+}
+
+// dropMessages returns a copy of messages without messages[start:end].
+func dropMessages(messages []llms.UnifiedMessage, start, end int) []llms.UnifiedMessage {
+	kept := make([]llms.UnifiedMessage, 0, len(messages)-(end-start))
+	kept = append(kept, messages[:start]...)
+	return append(kept, messages[end:]...)
+}
@@ -0,0 +1,109 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// windowedEngine is a mockEngine reporting a context window.
+type windowedEngine struct {
+	*mockEngine
+	window int
+}
+
+func (e windowedEngine) Capabilities() llms.ModelCapabilities {
+	return llms.ModelCapabilities{ContextWindow: e.window}
+}
+
+func TestAgent_ContextCompaction(t *testing.T) {
+	// Each user message is ~38 tokens; the window fits two turns but not three
+	messages := []string{strings.Repeat("a", 150), strings.Repeat("b", 150), strings.Repeat("c", 150)}
+
+	run := func(t *testing.T, compaction string, turns ...mockTurn) (*mockEngine, *Agent) {
+		engine := newMockEngine(turns...)
+		agent := NewAgent(&AgentConfig{
+			LLMEngine:               windowedEngine{mockEngine: engine, window: 120},
+			AgentName:               "compacting",
+			SystemPrompt:            "Be brief.",
+			ContextCompaction:       compaction,
+			CompletionReserveTokens: 20,
+		})
+		for _, message := range messages {
+			if _, err := agent.Chat(message); err != nil {
+				t.Fatalf("Chat() unexpected error = %v", err)
+			}
+		}
+		return engine, agent
+	}
+
+	t.Run("truncate", func(t *testing.T) {
+		engine, agent := run(t, CompactionTruncate, contentTurn("ok 1"), contentTurn("ok 2"), contentTurn("ok 3"))
+
+		calls := engine.Calls()
+		if len(calls) != 3 {
+			t.Fatalf("Expected 3 LLM calls, got %d", len(calls))
+		}
+		if len(calls[1]) != 4 {
+			t.Errorf("Expected the second request to fit uncompacted, got %d messages", len(calls[1]))
+		}
+		last := calls[2]
+		if len(last) != 4 || last[0].Role() != llms.MessageRoleSystem || last[1].Content() != messages[1] || last[3].Content() != messages[2] {
+			t.Errorf("Expected the oldest turn to be dropped from the request, got %+v", last)
+		}
+		if estimateTokens(last) > 100 {
+			t.Errorf("Expected the request to fit the window minus the reserve, got %d tokens", estimateTokens(last))
+		}
+
+		// The history keeps every message
+		if history := agent.GetHistory(0, 0); len(history) != 7 {
+			t.Errorf("Expected the full history to be kept, got %d messages", len(history))
+		}
+	})
+
+	t.Run("summarize", func(t *testing.T) {
+		engine, agent := run(t, CompactionSummarize, contentTurn("ok 1"), contentTurn("ok 2"), contentTurn("the user sent a's"), contentTurn("ok 3"))
+
+		calls := engine.Calls()
+		if len(calls) != 4 {
+			t.Fatalf("Expected 3 chat calls and 1 summary call, got %d", len(calls))
+		}
+		if !strings.Contains(calls[2][1].Content(), messages[0]) {
+			t.Errorf("Expected the oldest turn to be summarized, got %+v", calls[2])
+		}
+
+		history := agent.GetHistory(0, 0)
+		if len(history) != 6 {
+			t.Fatalf("Expected system, summary and two turns, got %d messages", len(history))
+		}
+		if history[1].Role() != llms.MessageRoleSystem || history[1].Content() != summaryPrefix+"the user sent a's" {
+			t.Errorf("Expected the summary note, got %s: %q", history[1].Role(), history[1].Content())
+		}
+		if !equalMessages(calls[3], history[:5]) {
+			t.Errorf("Expected the summarized history to be sent, got %+v", calls[3])
+		}
+	})
+}
+
+func TestNewAgent_InvalidContextCompaction(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(error).Error(), "unknown ContextCompaction") {
+			t.Errorf("NewAgent() panic = %v, want unknown ContextCompaction", r)
+		}
+	}()
+	NewAgent(&AgentConfig{LLMEngine: newMockEngine(), AgentName: "invalid", ContextCompaction: "drop"})
+}
+
+// equalMessages reports whether two message lists have the same roles and contents.
+func equalMessages(a, b []llms.UnifiedMessage) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Role() != b[i].Role() || a[i].Content() != b[i].Content() {
+			return false
+		}
+	}
+	return true
+}
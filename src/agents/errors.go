@@ -0,0 +1,30 @@
+package agents
+
+import "errors"
+
+// Sentinel errors wrapped by the agent, so callers can tell failures apart with errors.Is.
+var (
+	// ErrMaxIterations is returned when the tool loop reaches MaxToolIterations
+	// without a final answer.
+	ErrMaxIterations = errors.New("reached maximum tool iterations")
+
+	// ErrToolNotFound is the error of tool results for tools the agent does not have.
+	// The failure is reported to the model; the error is available on llms.ToolResult.Err
+	// (e.g. in AgentHooks.AfterToolCall).
+	ErrToolNotFound = errors.New("tool not found")
+
+	// ErrContextCancelled is returned by ChatContext when its context is done before
+	// the answer completes. The context error is wrapped too.
+	ErrContextCancelled = errors.New("context cancelled")
+
+	// ErrPromptLeak is returned when a blocking PromptLeakGuard stops an answer
+	// that echoes the agent's internal instructions.
+	ErrPromptLeak = errors.New("answer blocked: it leaked the system prompt")
+
+	// ErrMessageTooLong is returned when a user message exceeds MaxUserMessageChars.
+	ErrMessageTooLong = errors.New("message too long")
+
+	// ErrSystemPromptTooLong is returned when the system prompt exceeds
+	// MaxSystemPromptChars and StrictSystemPromptSize is set.
+	ErrSystemPromptTooLong = errors.New("system prompt too long")
+)
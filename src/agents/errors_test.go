@@ -0,0 +1,72 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// toolResultHooks records the results passed to AfterToolCall.
+type toolResultHooks struct {
+	NoopHooks
+	results []llms.ToolResult
+}
+
+func (h *toolResultHooks) AfterToolCall(result llms.ToolResult) {
+	h.results = append(h.results, result)
+}
+
+func TestAgent_SentinelErrors(t *testing.T) {
+	t.Run("max iterations", func(t *testing.T) {
+		engine := newMockEngine()
+		engine.respond = func(messages []llms.UnifiedMessage) mockTurn {
+			return toolCallTurn(llms.ToolCall{ID: "call_1", Name: "foo", Arguments: map[string]any{"echo": "again"}})
+		}
+		agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "looping", MaxToolIterations: 2})
+
+		_, err := agent.Chat("loop forever")
+		if !errors.Is(err, ErrMaxIterations) {
+			t.Errorf("Expected ErrMaxIterations, got %v", err)
+		}
+	})
+
+	t.Run("tool not found", func(t *testing.T) {
+		engine := newMockEngine(
+			toolCallTurn(llms.ToolCall{ID: "call_1", Name: "missing", Arguments: map[string]any{}}),
+			contentTurn("done"),
+		)
+		hooks := &toolResultHooks{}
+		agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "lost", Hooks: hooks})
+
+		if _, err := agent.Chat("call a missing tool"); err != nil {
+			t.Fatalf("Chat() unexpected error = %v", err)
+		}
+		if len(hooks.results) != 1 || !errors.Is(hooks.results[0].Err, ErrToolNotFound) {
+			t.Fatalf("Expected a result wrapping ErrToolNotFound, got %+v", hooks.results)
+		}
+		if hooks.results[0].Error != "tool not found: missing" {
+			t.Errorf("Expected the error message to be unchanged, got %q", hooks.results[0].Error)
+		}
+	})
+
+	t.Run("context cancelled", func(t *testing.T) {
+		turn := contentTurn("partial")
+		turn.chunks = turn.chunks[:1]
+		turn.stall = true
+		agent := NewAgent(&AgentConfig{LLMEngine: newMockEngine(turn), AgentName: "slow"})
+		defer agent.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		content, err := agent.ChatContext(ctx, "take your time")
+		if !errors.Is(err, ErrContextCancelled) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected ErrContextCancelled wrapping the context error, got %v", err)
+		}
+		if content != "partial" {
+			t.Errorf("Expected the partial content, got %q", content)
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// HistorySchemaVersion is the version of the history export format written by
+// ExportHistory. ImportHistory rejects other versions.
+const HistorySchemaVersion = 1
+
+// HistoryExport is the portable envelope of an exported conversation.
+//
+// Its message format is independent of the JSON encoding of llms.UnifiedMessage
+// used by persistence, so exports stay readable when that encoding changes.
+type HistoryExport struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	AgentName     string            `json:"agentName"`
+	ExportedAt    time.Time         `json:"exportedAt"`
+	Messages      []ExportedMessage `json:"messages"`
+}
+
+// ExportedMessage is one message of a HistoryExport.
+type ExportedMessage struct {
+	Role         string             `json:"role"`
+	Content      string             `json:"content"`
+	ContentParts []llms.ContentPart `json:"contentParts,omitempty"`
+	ToolCallID   string             `json:"toolCallId,omitempty"`
+	ToolCalls    []ExportedToolCall `json:"toolCalls,omitempty"`
+	Usage        *ExportedUsage     `json:"usage,omitempty"`
+	Incomplete   bool               `json:"incomplete,omitempty"`
+	Metadata     map[string]string  `json:"metadata,omitempty"`
+}
+
+// ExportedToolCall is a tool call requested by an assistant message.
+type ExportedToolCall struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+// ExportedUsage is the token usage recorded on an assistant message.
+type ExportedUsage struct {
+	PromptTokens     int `json:"promptTokens"`
+	CompletionTokens int `json:"completionTokens"`
+	TotalTokens      int `json:"totalTokens"`
+}
+
+// ExportHistory exports the conversation of the default session as a versioned
+// JSON envelope (see HistoryExport), e.g. to move it to another backend or
+// attach it to a support ticket. Restore it with ImportHistory.
+//
+// Returns:
+//   - []byte: The JSON export
+//   - error: An error if the history cannot be serialized
+func (a *Agent) ExportHistory() ([]byte, error) {
+	messages := a.GetHistory(0, 0)
+
+	export := HistoryExport{
+		SchemaVersion: HistorySchemaVersion,
+		AgentName:     a.Name(),
+		ExportedAt:    time.Now().UTC(),
+		Messages:      make([]ExportedMessage, len(messages)),
+	}
+	for i, m := range messages {
+		export.Messages[i] = exportMessage(m)
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize history export: %w", err)
+	}
+	return data, nil
+}
+
+// ImportHistory restores the messages of an export written by ExportHistory.
+//
+// Parameters:
+//   - data: The JSON export
+//
+// Returns:
+//   - []llms.UnifiedMessage: The exported messages, in order
+//   - error: An error if data is not a valid export or has another schema version
+func ImportHistory(data []byte) ([]llms.UnifiedMessage, error) {
+	var export HistoryExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("invalid history export: %w", err)
+	}
+	if export.SchemaVersion != HistorySchemaVersion {
+		return nil, fmt.Errorf("unsupported history export schema version %d (supported: %d)", export.SchemaVersion, HistorySchemaVersion)
+	}
+
+	messages := make([]llms.UnifiedMessage, len(export.Messages))
+	for i, m := range export.Messages {
+		message, err := importMessage(m)
+		if err != nil {
+			return nil, fmt.Errorf("invalid history export: message %d: %w", i, err)
+		}
+		messages[i] = message.WithMetadata(m.Metadata)
+	}
+	return messages, nil
+}
+
+// exportMessage converts a message to its export format.
+func exportMessage(m llms.UnifiedMessage) ExportedMessage {
+	exported := ExportedMessage{
+		Role:         m.Role().String(),
+		Content:      m.Content(),
+		ContentParts: m.ContentParts(),
+		ToolCallID:   m.ToolCallID(),
+		Incomplete:   m.Incomplete(),
+		Metadata:     m.Metadata(),
+	}
+	for _, tc := range m.ToolCalls() {
+		exported.ToolCalls = append(exported.ToolCalls, ExportedToolCall{ID: tc.ID, Name: tc.Name, Arguments: tc.Arguments})
+	}
+	if m.PromptTokens() > 0 || m.CompletionTokens() > 0 || m.TotalTokens() > 0 {
+		exported.Usage = &ExportedUsage{
+			PromptTokens:     m.PromptTokens(),
+			CompletionTokens: m.CompletionTokens(),
+			TotalTokens:      m.TotalTokens(),
+		}
+	}
+	return exported
+}
+
+// importMessage converts an exported message back to a UnifiedMessage.
+func importMessage(m ExportedMessage) (llms.UnifiedMessage, error) {
+	switch llms.MessageRole(m.Role) {
+	case llms.MessageRoleSystem:
+		return llms.SystemMessage(m.Content), nil
+	case llms.MessageRoleDeveloper:
+		return llms.DeveloperMessage(m.Content), nil
+	case llms.MessageRoleUser:
+		if len(m.ContentParts) > 0 {
+			return llms.UserMessageWithParts(m.ContentParts...), nil
+		}
+		return llms.UserMessage(m.Content), nil
+	case llms.MessageRoleTool:
+		if m.ToolCallID == "" {
+			return llms.UnifiedMessage{}, fmt.Errorf("tool message without toolCallId")
+		}
+		return llms.ToolMessage(m.ToolCallID, m.Content), nil
+	case llms.MessageRoleAssistant:
+		if m.Incomplete {
+			return llms.IncompleteAssistantMessage(m.Content), nil
+		}
+		var usage ExportedUsage
+		if m.Usage != nil {
+			usage = *m.Usage
+		}
+		if len(m.ToolCalls) > 0 {
+			toolCalls := make([]llms.ToolCall, len(m.ToolCalls))
+			for i, tc := range m.ToolCalls {
+				toolCalls[i] = llms.ToolCall{ID: tc.ID, Name: tc.Name, Arguments: tc.Arguments}
+			}
+			return llms.AssistantMessageWithToolCalls(m.Content, toolCalls, usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens), nil
+		}
+		return llms.AssistantMessage(m.Content, usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens), nil
+	default:
+		return llms.UnifiedMessage{}, fmt.Errorf("unknown role %q", m.Role)
+	}
+}
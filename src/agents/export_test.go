@@ -0,0 +1,94 @@
+package agents
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+func TestAgent_ExportImportHistory(t *testing.T) {
+	engine := newMockEngine(
+		toolCallTurn(llms.ToolCall{ID: "call_1", Name: "foo", Arguments: map[string]any{"echo": "hi"}}),
+		contentTurn("done"),
+	)
+	agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "exporter"})
+	for range agent.ChatStreamWithMetadata("call foo", map[string]string{"source": "web"}).Start() {
+	}
+
+	data, err := agent.ExportHistory()
+	if err != nil {
+		t.Fatalf("ExportHistory() error = %v", err)
+	}
+
+	var envelope map[string]any
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		t.Fatalf("Export is not valid JSON: %v", err)
+	}
+	if envelope["schemaVersion"] != float64(HistorySchemaVersion) || envelope["agentName"] != "exporter" {
+		t.Errorf("Unexpected envelope: %v", envelope)
+	}
+
+	messages, err := ImportHistory(data)
+	if err != nil {
+		t.Fatalf("ImportHistory() error = %v", err)
+	}
+	original := agent.GetHistory(0, 0)
+	if !reflect.DeepEqual(messages, original) {
+		t.Errorf("Round trip mismatch:\n got  %+v\n want %+v", messages, original)
+	}
+
+	// system, user, assistant tool call, tool result, final answer
+	if len(messages) != 5 {
+		t.Fatalf("Expected 5 messages, got %d", len(messages))
+	}
+	if calls := messages[2].ToolCalls(); len(calls) != 1 || calls[0].Name != "foo" || calls[0].Arguments["echo"] != "hi" {
+		t.Errorf("Expected the foo tool call, got %+v", calls)
+	}
+	if messages[1].Metadata()["source"] != "web" {
+		t.Errorf("Expected the user message metadata to round-trip, got %v", messages[1].Metadata())
+	}
+	if messages[3].Role() != llms.MessageRoleTool || messages[3].ToolCallID() != "call_1" {
+		t.Errorf("Expected the tool result for call_1, got %+v", messages[3])
+	}
+}
+
+func TestImportHistory_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name:    "version mismatch",
+			data:    `{"schemaVersion": 99, "agentName": "a", "messages": []}`,
+			wantErr: "unsupported history export schema version 99",
+		},
+		{
+			name:    "missing version",
+			data:    `{"agentName": "a", "messages": []}`,
+			wantErr: "unsupported history export schema version 0",
+		},
+		{
+			name:    "unknown role",
+			data:    `{"schemaVersion": 1, "messages": [{"role": "narrator", "content": "x"}]}`,
+			wantErr: `message 0: unknown role "narrator"`,
+		},
+		{
+			name:    "not JSON",
+			data:    `history`,
+			wantErr: "invalid history export",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ImportHistory([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ImportHistory() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
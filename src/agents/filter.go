@@ -0,0 +1,218 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// MessageFilter rewrites what an agent sends to and receives from the LLM,
+// e.g. to redact secrets or personal data.
+//
+// Filters are called synchronously from the agent loop and concurrently by runs
+// of different sessions, so implementations must be safe for concurrent use.
+type MessageFilter interface {
+	// FilterOutgoing is called with the messages about to be sent to the LLM and
+	// returns the messages to send. History keeps the unfiltered messages.
+	FilterOutgoing(messages []llms.UnifiedMessage) []llms.UnifiedMessage
+
+	// FilterIncoming is called with each chunk streamed by the LLM and returns the
+	// chunk to forward. The answer saved to history is built from filtered chunks
+	// (see AnswerFilter).
+	FilterIncoming(chunk llms.ChunkResponse) llms.ChunkResponse
+}
+
+// AnswerFilter is implemented by message filters that can rewrite a whole answer.
+// Models stream a few characters per chunk, so a match split across chunks is
+// missed by FilterIncoming; FilterAnswer is called with the accumulated answer
+// before it is saved to history, and catches it.
+type AnswerFilter interface {
+	FilterAnswer(text string) string
+}
+
+// APIKeyPattern matches common API key and token formats
+// (e.g., "sk-...", "AKIA...", "ghp_...", "xoxb-...").
+const APIKeyPattern = `\b(?:sk-[A-Za-z0-9_-]{16,}|AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{20,}|xox[abpr]-[A-Za-z0-9-]{10,})`
+
+// DefaultRedaction is the replacement text of RegexRedactor.
+const DefaultRedaction = "[REDACTED]"
+
+// RegexRedactor is a MessageFilter that replaces the matches of regular
+// expressions in outgoing messages and streamed content.
+//
+// Incoming chunks are filtered one at a time, so a secret split across two
+// chunks is not matched in their Delta; it is in the FullContent of later chunks
+// and in the answer saved to history (see AnswerFilter).
+type RegexRedactor struct {
+	patterns []*regexp.Regexp
+	// Replacement replaces each match (default: DefaultRedaction).
+	Replacement string
+}
+
+// NewRegexRedactor creates a RegexRedactor for the given patterns.
+//
+// Parameters:
+//   - patterns: Regular expressions to redact (e.g., APIKeyPattern)
+//
+// Returns:
+//   - *RegexRedactor: A new redactor
+//   - error: An error if a pattern does not compile
+func NewRegexRedactor(patterns ...string) (*RegexRedactor, error) {
+	r := &RegexRedactor{Replacement: DefaultRedaction}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Redact returns text with every match replaced.
+func (r *RegexRedactor) Redact(text string) string {
+	for _, re := range r.patterns {
+		text = re.ReplaceAllLiteralString(text, r.Replacement)
+	}
+	return text
+}
+
+// FilterOutgoing implements MessageFilter.
+func (r *RegexRedactor) FilterOutgoing(messages []llms.UnifiedMessage) []llms.UnifiedMessage {
+	filtered := make([]llms.UnifiedMessage, len(messages))
+	for i, message := range messages {
+		filtered[i] = message.MapText(r.Redact)
+	}
+	return filtered
+}
+
+// FilterIncoming implements MessageFilter.
+func (r *RegexRedactor) FilterIncoming(chunk llms.ChunkResponse) llms.ChunkResponse {
+	chunk.Content = r.Redact(chunk.Content)
+	chunk.Delta = r.Redact(chunk.Delta)
+	chunk.FullContent = r.Redact(chunk.FullContent)
+	return chunk
+}
+
+// FilterAnswer implements AnswerFilter.
+func (r *RegexRedactor) FilterAnswer(text string) string {
+	return r.Redact(text)
+}
+
+// BlockingFilter is implemented by message filters that can stop a run instead of
+// rewriting a chunk. CheckIncoming is called with each chunk streamed by the LLM,
+// before FilterIncoming; an error fails the run with that error.
+type BlockingFilter interface {
+	CheckIncoming(chunk llms.ChunkResponse) error
+}
+
+// DefaultPromptLeakMarkers are the headers of the instructions the agent adds to
+// the system prompt of main agents and agents with sub-agents.
+var DefaultPromptLeakMarkers = []string{
+	"[SYSTEM] This are information in addition",
+	"IMPORTANT - TOOL CALLS ARE OPTIONAL",
+	"=== SUB AGENTS ===",
+	"[SUB AGENTS]",
+}
+
+// DefaultPromptLeakMask is the replacement text of PromptLeakGuard.
+const DefaultPromptLeakMask = "[hidden]"
+
+// PromptLeakGuard is a MessageFilter keeping the agent's internal instructions out
+// of its answers, which models sometimes echo. Streamed content containing a marker
+// has the marker masked, or, with Block set, the run fails with ErrPromptLeak.
+// Outgoing messages are not changed: the system prompt must reach the model.
+//
+// Masking works on each chunk, so a marker split across two chunks is only masked
+// in the FullContent of later chunks and in the answer saved to history. Blocking
+// checks FullContent and catches it.
+type PromptLeakGuard struct {
+	markers []string
+	// Replacement replaces each marker (default: DefaultPromptLeakMask).
+	Replacement string
+	// Block fails the run with ErrPromptLeak instead of masking.
+	Block bool
+}
+
+// NewPromptLeakGuard creates a guard for the given markers.
+//
+// Parameters:
+//   - markers: Substrings that must not appear in answers (none uses DefaultPromptLeakMarkers)
+//
+// Returns:
+//   - *PromptLeakGuard: A new guard masking the markers
+func NewPromptLeakGuard(markers ...string) *PromptLeakGuard {
+	if len(markers) == 0 {
+		markers = DefaultPromptLeakMarkers
+	}
+	return &PromptLeakGuard{markers: markers, Replacement: DefaultPromptLeakMask}
+}
+
+// mask returns text with every marker replaced.
+func (g *PromptLeakGuard) mask(text string) string {
+	for _, marker := range g.markers {
+		text = strings.ReplaceAll(text, marker, g.Replacement)
+	}
+	return text
+}
+
+// FilterOutgoing implements MessageFilter. Messages are sent unchanged.
+func (g *PromptLeakGuard) FilterOutgoing(messages []llms.UnifiedMessage) []llms.UnifiedMessage {
+	return messages
+}
+
+// FilterIncoming implements MessageFilter.
+func (g *PromptLeakGuard) FilterIncoming(chunk llms.ChunkResponse) llms.ChunkResponse {
+	chunk.Content = g.mask(chunk.Content)
+	chunk.Delta = g.mask(chunk.Delta)
+	chunk.FullContent = g.mask(chunk.FullContent)
+	return chunk
+}
+
+// FilterAnswer implements AnswerFilter.
+func (g *PromptLeakGuard) FilterAnswer(text string) string {
+	return g.mask(text)
+}
+
+// CheckIncoming implements BlockingFilter. It only rejects chunks when Block is set.
+func (g *PromptLeakGuard) CheckIncoming(chunk llms.ChunkResponse) error {
+	if !g.Block {
+		return nil
+	}
+	for _, marker := range g.markers {
+		if strings.Contains(chunk.FullContent, marker) || strings.Contains(chunk.Content, marker) {
+			return ErrPromptLeak
+		}
+	}
+	return nil
+}
+
+// filterIncoming applies the MessageFilter to a chunk read from the LLM and
+// returns the chunk and its serialized form to forward.
+func (a *Agent) filterIncoming(chunk llms.ChunkResponse, chunkBytes []byte) (llms.ChunkResponse, []byte, error) {
+	if a.config.MessageFilter == nil {
+		return chunk, chunkBytes, nil
+	}
+	if blocking, ok := a.config.MessageFilter.(BlockingFilter); ok {
+		if err := blocking.CheckIncoming(chunk); err != nil {
+			return chunk, nil, err
+		}
+	}
+	chunk = a.config.MessageFilter.FilterIncoming(chunk)
+	chunkBytes, err := json.Marshal(chunk)
+	if err != nil {
+		return chunk, nil, fmt.Errorf("failed to serialize chunk: %w", err)
+	}
+	return chunk, chunkBytes, nil
+}
+
+// filterAnswer applies the MessageFilter to an answer about to be saved to history.
+func (a *Agent) filterAnswer(text string) string {
+	if filter, ok := a.config.MessageFilter.(AnswerFilter); ok && text != "" {
+		return filter.FilterAnswer(text)
+	}
+	return text
+}
@@ -0,0 +1,84 @@
+package agents
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// Fork creates a new agent that continues the conversation of the default
+// session from where it is now, e.g. to explore an alternative continuation
+// without touching the original dialogue.
+//
+// The fork is built like NewAgent from a copy of the configuration, so sub-agents
+// declared in SubAgentConfigs and system agents are new instances; engines, tools
+// and SubAgents are shared. Tools replaced with SetTools are not carried over.
+// The fork's history is a deep copy of the session's current history, kept in
+// memory only: the fork and its new sub-agents are not persisted, since
+// persistence is keyed by the agent name and would overwrite the original's.
+//
+// Returns:
+//   - *Agent: The fork, whose default session starts with the copied history
+func (a *Agent) Fork() *Agent {
+	return a.ForkSession(defaultSessionID)
+}
+
+// ForkSession is like Fork for the conversation of the given session (see
+// ChatStreamSession). The fork's default session starts with its history.
+//
+// Parameters:
+//   - sessionID: The session to copy
+//
+// Returns:
+//   - *Agent: The fork, whose default session starts with the copied history
+func (a *Agent) ForkSession(sessionID string) *Agent {
+	a.systemPromptMu.RLock()
+	config := a.config.forkConfig()
+	a.systemPromptMu.RUnlock()
+
+	fork := NewAgent(config)
+
+	// Wait for a running turn of the session to finish
+	s := a.sessionFor(context.Background(), sessionID)
+	s.mu.Lock()
+	s.history.get()
+	fork.history = s.history.clone()
+	s.mu.Unlock()
+
+	return fork
+}
+
+// clone returns a deep copy of the history's messages without its persistence.
+func (h *History) clone() *History {
+	messages := make([]llms.UnifiedMessage, len(h.history))
+	for i, m := range h.history {
+		messages[i] = m.Clone()
+	}
+	return &History{
+		history:          messages,
+		hasSystemMessage: h.hasSystemMessage,
+	}
+}
+
+// forkConfig returns a copy of the configuration without persistence that shares
+// no slice or map with c. SubAgentConfigs are copied the same way.
+func (c *AgentConfig) forkConfig() *AgentConfig {
+	clone := *c
+	clone.Persistence = ""
+	clone.SystemAgents = slices.Clone(c.SystemAgents)
+	clone.SystemPromptVars = maps.Clone(c.SystemPromptVars)
+	clone.Tools = slices.Clone(c.Tools)
+	clone.ExtraEngines = maps.Clone(c.ExtraEngines)
+	clone.SubAgents = slices.Clone(c.SubAgents)
+	if c.SubAgentConfigs != nil {
+		clone.SubAgentConfigs = make([]*AgentConfig, len(c.SubAgentConfigs))
+		for i, saConfig := range c.SubAgentConfigs {
+			if saConfig != nil {
+				clone.SubAgentConfigs[i] = saConfig.forkConfig()
+			}
+		}
+	}
+	return &clone
+}
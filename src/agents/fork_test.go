@@ -0,0 +1,76 @@
+package agents
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// historyContents returns the role and content of each message of a history.
+func historyContents(messages []llms.UnifiedMessage) []string {
+	contents := make([]string, len(messages))
+	for i, m := range messages {
+		contents[i] = string(m.Role()) + ": " + m.Content()
+	}
+	return contents
+}
+
+func TestAgent_Fork(t *testing.T) {
+	agent := NewAgent(&AgentConfig{LLMEngine: newEchoEngine(), AgentName: "brancher"})
+	if answer := drainAnswer(t, agent, defaultSessionID, "first"); answer != "echo: first" {
+		t.Fatalf("Unexpected answer %q", answer)
+	}
+	before := historyContents(agent.GetHistory(0, 0))
+
+	fork := agent.Fork()
+	if answer := drainAnswer(t, fork, defaultSessionID, "alternative"); answer != "echo: alternative" {
+		t.Fatalf("Unexpected fork answer %q", answer)
+	}
+
+	if after := historyContents(agent.GetHistory(0, 0)); len(after) != len(before) || after[len(after)-1] != "assistant: echo: first" {
+		t.Errorf("Expected the original history to be unchanged, got %q (was %q)", after, before)
+	}
+	forked := historyContents(fork.GetHistory(0, 0))
+	if len(forked) != len(before)+2 || forked[len(before)] != "user: alternative" {
+		t.Errorf("Expected the fork to continue the conversation, got %q", forked)
+	}
+	for i := range before {
+		if forked[i] != before[i] {
+			t.Errorf("Expected the fork to start with the original history, got %q at %d, want %q", forked[i], i, before[i])
+		}
+	}
+
+	// The original can go on independently
+	drainAnswer(t, agent, defaultSessionID, "second")
+	if got := len(fork.GetHistory(0, 0)); got != len(forked) {
+		t.Errorf("Expected the fork history to be unchanged by the original, got %d messages, want %d", got, len(forked))
+	}
+}
+
+func TestAgent_ForkSession_Persistence(t *testing.T) {
+	dir := t.TempDir()
+	agent := NewAgent(&AgentConfig{
+		LLMEngine:      newEchoEngine(),
+		AgentName:      "persisted",
+		Persistence:    "json",
+		PersistenceDir: dir,
+	})
+	drainAnswer(t, agent, "support", "hello")
+
+	fork := agent.ForkSession("support")
+	if got := historyContents(fork.GetHistory(0, 0)); len(got) != 3 || got[1] != "user: hello" {
+		t.Fatalf("Expected the fork to start with the session history, got %q", got)
+	}
+	drainAnswer(t, fork, defaultSessionID, "fork only")
+
+	for _, m := range agent.GetSessionHistory("support", 0, 0) {
+		if m.Content() == "fork only" {
+			t.Error("Expected the fork not to write to the original's persistence")
+		}
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil || len(files) != 1 {
+		t.Errorf("Expected only the original's history file, got %v (%v)", files, err)
+	}
+}
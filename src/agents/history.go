@@ -1,6 +1,8 @@
 package agents
 
 import (
+	"slices"
+
 	"github.com/thinktwice/agentForge/src/llms"
 	"github.com/thinktwice/agentForge/src/persistence"
 )
@@ -9,14 +11,31 @@ type History struct {
 	history          []llms.UnifiedMessage
 	hasSystemMessage bool
 	persistence      persistence.Persistence
+	// onSaveError is called when persistence fails to save the history (nil ignores failures)
+	onSaveError func(err error)
+	// unsaved is set while persistence holds an older history than memory (after a failed save)
+	unsaved bool
 }
 
 func (h *History) History() []llms.UnifiedMessage {
 	return h.history
 }
 
-func (h *History) addUserMessage(message string) {
-	h.history = append(h.history, llms.UserMessage(message))
+// Filter returns the messages of the history for which keep returns true, e.g.
+// the messages tagged with a metadata value.
+func (h *History) Filter(keep func(llms.UnifiedMessage) bool) []llms.UnifiedMessage {
+	return llms.FilterMessages(h.history, keep)
+}
+
+// replace sets the messages of the history, e.g. to a conversation assembled by
+// the caller. The messages are copied.
+func (h *History) replace(messages []llms.UnifiedMessage) {
+	h.history = slices.Clone(messages)
+	h.hasSystemMessage = len(messages) > 0 && messages[0].Role() == llms.MessageRoleSystem
+}
+
+func (h *History) addUserMessage(message llms.UnifiedMessage) {
+	h.history = append(h.history, message)
 }
 
 func (h *History) addSystemMessage(message string) {
@@ -27,28 +46,135 @@ func (h *History) addSystemMessage(message string) {
 	}
 }
 
+// setSystemMessage replaces the system message at the start of the history, or
+// adds it if there is none.
+func (h *History) setSystemMessage(message string) {
+	if len(h.history) > 0 && h.history[0].Role() == llms.MessageRoleSystem {
+		h.history[0] = llms.SystemMessage(message)
+		h.hasSystemMessage = true
+		return
+	}
+	h.hasSystemMessage = false
+	h.addSystemMessage(message)
+}
+
 func (h *History) addAssistantMessage(message string, promptTokens, completionTokens, totalTokens int) {
 	h.history = append(h.history, llms.AssistantMessage(message, promptTokens, completionTokens, totalTokens))
 }
 
+// addIncompleteAssistantMessage records the partial content of a failed stream.
+func (h *History) addIncompleteAssistantMessage(message string) {
+	h.history = append(h.history, llms.IncompleteAssistantMessage(message))
+}
+
 func (h *History) addAssistantMessageWithToolCalls(content string, toolCalls []llms.ToolCall, promptTokens, completionTokens, totalTokens int) {
 	h.history = append(h.history, llms.AssistantMessageWithToolCalls(content, toolCalls, promptTokens, completionTokens, totalTokens))
 }
 
-func (h *History) addToolMessage(toolCallID, result string) {
-	h.history = append(h.history, llms.ToolMessage(toolCallID, result))
+// addToolMessage appends a tool result tagged with metadata (see llms.ToolMetadataDuration).
+func (h *History) addToolMessage(toolCallID, result string, metadata map[string]string) {
+	h.history = append(h.history, llms.ToolMessage(toolCallID, result).WithMetadata(metadata))
 }
 
-func (h *History) save() {
+// page returns a window of the history, from persistence when configured.
+func (h *History) page(limit, offset int) []llms.UnifiedMessage {
 	if h.persistence != nil {
-		h.persistence.SaveHystory(h.history)
+		return h.persistence.GetHystory(limit, offset)
+	}
+	return persistence.Paginate(h.history, limit, offset)
+}
+
+// save stores the history in persistence when configured. Failures are reported
+// to onSaveError and the in-memory history is kept, so the conversation continues;
+// the next save retries with the full history.
+func (h *History) save() {
+	if h.persistence == nil {
+		return
+	}
+	err := h.persistence.SaveHystory(h.history)
+	h.unsaved = err != nil
+	if err != nil && h.onSaveError != nil {
+		h.onSaveError(err)
 	}
 }
 
 func (h *History) get() {
 	var limit = 0
 	var offset = 0
-	if h.persistence != nil {
+	// After a failed save the stored history is stale: keep the in-memory one
+	if h.persistence != nil && !h.unsaved {
 		h.history = h.persistence.GetHystory(limit, offset)
 	}
+	h.sanitize()
+}
+
+// interruptedToolResult is the content of synthetic tool messages injected by sanitize.
+const interruptedToolResult = "Error: tool execution was interrupted before a result was recorded"
+
+// sanitize repairs tool-call sequences so the history is valid for provider APIs.
+//
+// A crash between saving an assistant tool-call message and its tool results leaves
+// dangling tool calls, which providers reject ("tool_calls must be followed by tool
+// messages"). For each missing result a synthetic error tool message is injected right
+// after the existing results. Tool messages that answer no pending tool call are dropped.
+//
+// Returns:
+//   - bool: true if the history was modified
+func (h *History) sanitize() bool {
+	sanitized := make([]llms.UnifiedMessage, 0, len(h.history))
+	modified := false
+
+	for i := 0; i < len(h.history); i++ {
+		msg := h.history[i]
+
+		if msg.Role() == llms.MessageRoleTool {
+			// Not preceded by a matching tool call
+			modified = true
+			continue
+		}
+
+		sanitized = append(sanitized, msg)
+		toolCalls := msg.ToolCalls()
+		if msg.Role() != llms.MessageRoleAssistant || len(toolCalls) == 0 {
+			continue
+		}
+
+		// Collect the tool messages that follow this tool-call message
+		pending := make(map[string]bool, len(toolCalls))
+		for _, tc := range toolCalls {
+			pending[tc.ID] = true
+		}
+		for i+1 < len(h.history) && h.history[i+1].Role() == llms.MessageRoleTool {
+			i++
+			result := h.history[i]
+			if pending[result.ToolCallID()] {
+				delete(pending, result.ToolCallID())
+				sanitized = append(sanitized, result)
+			} else {
+				modified = true
+			}
+		}
+
+		// Inject synthetic results for calls that never got one, in call order
+		for _, tc := range toolCalls {
+			if pending[tc.ID] {
+				sanitized = append(sanitized, llms.ToolMessage(tc.ID, interruptedToolResult))
+				modified = true
+			}
+		}
+	}
+
+	if modified {
+		h.history = sanitized
+	}
+	return modified
+}
+
+// replaceWithSummary replaces history[start:end] with a single summary note.
+func (h *History) replaceWithSummary(start, end int, summary string) {
+	compressed := make([]llms.UnifiedMessage, 0, len(h.history)-(end-start)+1)
+	compressed = append(compressed, h.history[:start]...)
+	compressed = append(compressed, llms.SystemMessage(summaryPrefix+summary))
+	compressed = append(compressed, h.history[end:]...)
+	h.history = compressed
 }
@@ -0,0 +1,283 @@
+package agents
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/llms"
+	"github.com/thinktwice/agentForge/src/persistence"
+)
+
+func TestAgent_GetHistory(t *testing.T) {
+	var messages []llms.UnifiedMessage
+	for i := 0; i < 5; i++ {
+		messages = append(messages, llms.UserMessage(fmt.Sprintf("message %d", i)))
+	}
+
+	jsonStore := persistence.NewJSONPersistence(filepath.Join(t.TempDir(), "history.json"))
+	jsonStore.SaveHystory(messages)
+
+	withJSON := NewAgent(&AgentConfig{LLMEngine: newMockEngine(), AgentName: "json agent"})
+	withJSON.ensureHistory()
+	withJSON.history.persistence = jsonStore
+
+	inMemory := NewAgent(&AgentConfig{LLMEngine: newMockEngine(), AgentName: "memory agent"})
+	inMemory.ensureHistory()
+	inMemory.history.history = messages
+
+	tests := []struct {
+		name   string
+		limit  int
+		offset int
+		want   []string
+	}{
+		{"all messages", 0, 0, []string{"message 0", "message 1", "message 2", "message 3", "message 4"}},
+		{"first page", 2, 0, []string{"message 0", "message 1"}},
+		{"middle window", 2, 2, []string{"message 2", "message 3"}},
+		{"window clipped at end", 3, 4, []string{"message 4"}},
+		{"offset without limit", 0, 3, []string{"message 3", "message 4"}},
+		{"out-of-range offset", 2, 5, []string{}},
+	}
+
+	for _, agent := range []*Agent{withJSON, inMemory} {
+		for _, tt := range tests {
+			t.Run(agent.Name()+"/"+tt.name, func(t *testing.T) {
+				got := agent.GetHistory(tt.limit, tt.offset)
+				if len(got) != len(tt.want) {
+					t.Fatalf("GetHistory(%d, %d) returned %d messages, want %d", tt.limit, tt.offset, len(got), len(tt.want))
+				}
+				for i := range got {
+					if got[i].Content() != tt.want[i] {
+						t.Errorf("message %d = %q, want %q", i, got[i].Content(), tt.want[i])
+					}
+				}
+			})
+		}
+	}
+}
+
+// assertToolSequencesValid checks that every tool call is immediately answered by
+// exactly one tool message and that no tool message is orphaned.
+func assertToolSequencesValid(t *testing.T, messages []llms.UnifiedMessage) {
+	t.Helper()
+	for i := 0; i < len(messages); i++ {
+		msg := messages[i]
+		if msg.Role() == llms.MessageRoleTool {
+			t.Fatalf("message %d: orphaned tool message for %q", i, msg.ToolCallID())
+		}
+		calls := msg.ToolCalls()
+		if len(calls) == 0 {
+			continue
+		}
+		answered := make(map[string]int)
+		for i+1 < len(messages) && messages[i+1].Role() == llms.MessageRoleTool {
+			i++
+			answered[messages[i].ToolCallID()]++
+		}
+		for _, tc := range calls {
+			if answered[tc.ID] != 1 {
+				t.Fatalf("tool call %q answered %d times", tc.ID, answered[tc.ID])
+			}
+		}
+		if len(answered) != len(calls) {
+			t.Fatalf("tool-call message has %d extra results", len(answered)-len(calls))
+		}
+	}
+}
+
+func TestHistory_Sanitize(t *testing.T) {
+	call := func(id string) llms.ToolCall {
+		return llms.ToolCall{ID: id, Name: "foo", Arguments: map[string]any{"echo": id}}
+	}
+
+	t.Run("valid history is untouched", func(t *testing.T) {
+		h := &History{history: []llms.UnifiedMessage{
+			llms.SystemMessage("system"),
+			llms.UserMessage("hi"),
+			llms.AssistantMessageWithToolCalls("", []llms.ToolCall{call("a")}, 0, 0, 0),
+			llms.ToolMessage("a", "result a"),
+			llms.AssistantMessage("done", 0, 0, 0),
+		}}
+		if h.sanitize() {
+			t.Error("Expected valid history to be left unmodified")
+		}
+		if len(h.history) != 5 {
+			t.Errorf("Expected 5 messages, got %d", len(h.history))
+		}
+	})
+
+	t.Run("dangling tool calls get synthetic results", func(t *testing.T) {
+		h := &History{history: []llms.UnifiedMessage{
+			llms.SystemMessage("system"),
+			llms.UserMessage("run two tools"),
+			llms.AssistantMessageWithToolCalls("", []llms.ToolCall{call("a"), call("b")}, 0, 0, 0),
+			llms.ToolMessage("a", "result a"),
+			// crash before the result of "b" was saved
+			llms.UserMessage("are you there?"),
+			llms.AssistantMessageWithToolCalls("", []llms.ToolCall{call("c")}, 0, 0, 0),
+			// crash before any result was saved
+		}}
+
+		if !h.sanitize() {
+			t.Fatal("Expected broken history to be modified")
+		}
+		assertToolSequencesValid(t, h.history)
+
+		if got := h.history[3].Content(); got != "result a" {
+			t.Errorf("Expected real result to be kept, got %q", got)
+		}
+		if h.history[4].ToolCallID() != "b" || h.history[4].Content() != interruptedToolResult {
+			t.Errorf("Expected synthetic result for 'b', got %q: %q", h.history[4].ToolCallID(), h.history[4].Content())
+		}
+		last := h.history[len(h.history)-1]
+		if last.ToolCallID() != "c" || last.Content() != interruptedToolResult {
+			t.Errorf("Expected synthetic result for 'c', got %q: %q", last.ToolCallID(), last.Content())
+		}
+	})
+
+	t.Run("orphaned and duplicate tool messages are dropped", func(t *testing.T) {
+		h := &History{history: []llms.UnifiedMessage{
+			llms.UserMessage("hi"),
+			llms.ToolMessage("ghost", "no matching call"),
+			llms.AssistantMessageWithToolCalls("", []llms.ToolCall{call("a")}, 0, 0, 0),
+			llms.ToolMessage("a", "result a"),
+			llms.ToolMessage("a", "duplicate"),
+			llms.ToolMessage("other", "unknown id"),
+			llms.AssistantMessage("done", 0, 0, 0),
+		}}
+
+		if !h.sanitize() {
+			t.Fatal("Expected broken history to be modified")
+		}
+		assertToolSequencesValid(t, h.history)
+		if len(h.history) != 4 {
+			t.Errorf("Expected 4 messages after sanitizing, got %d", len(h.history))
+		}
+	})
+
+	t.Run("repaired on load from persistence", func(t *testing.T) {
+		store := persistence.NewJSONPersistence(filepath.Join(t.TempDir(), "history.json"))
+		store.SaveHystory([]llms.UnifiedMessage{
+			llms.UserMessage("hi"),
+			llms.AssistantMessageWithToolCalls("", []llms.ToolCall{call("a")}, 0, 0, 0),
+		})
+
+		h := &History{persistence: store}
+		h.get()
+		assertToolSequencesValid(t, h.History())
+	})
+}
+
+func TestAgent_PersistenceDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sessions")
+	agent := NewAgent(&AgentConfig{
+		LLMEngine:      newMockEngine(),
+		AgentName:      "persisted",
+		Persistence:    "json",
+		PersistenceDir: dir,
+	})
+
+	agent.ensureHistory()
+	agent.history.addUserMessage(llms.UserMessage("hello"))
+	agent.history.save()
+
+	files, err := filepath.Glob(filepath.Join(dir, "persisted-*.json"))
+	if err != nil {
+		t.Fatalf("Glob() unexpected error = %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("Expected one history file in %s, got %v", dir, files)
+	}
+}
+
+// TestAgent_TrimmedPersistenceKeepsRunHistory verifies that trimming the saved
+// history does not drop messages from the requests of the running turn.
+func TestAgent_TrimmedPersistenceKeepsRunHistory(t *testing.T) {
+	engine := newMockEngine(
+		toolCallTurn(llms.ToolCall{ID: "call_1", Name: "foo", Arguments: map[string]any{"echo": "one"}}),
+		toolCallTurn(llms.ToolCall{ID: "call_2", Name: "foo", Arguments: map[string]any{"echo": "two"}}),
+		contentTurn("done"),
+	)
+	agent := NewAgent(&AgentConfig{
+		LLMEngine:       engine,
+		AgentName:       "trimmed",
+		Persistence:     "json",
+		PersistenceDir:  t.TempDir(),
+		PersistenceJSON: persistence.JSONPersistenceOptions{MaxMessages: 3},
+	})
+
+	if _, err := agent.Chat("echo twice"); err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+
+	calls := engine.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("Expected 3 LLM calls, got %d", len(calls))
+	}
+	if len(calls[2]) != 6 || calls[2][1].Content() != "echo twice" {
+		t.Errorf("Expected the third call to carry the whole turn, got %+v", calls[2])
+	}
+}
+
+func TestHistory_Filter(t *testing.T) {
+	engine := newMockEngine(contentTurn("from web"), contentTurn("from cli"))
+	agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "tagged"})
+
+	for _, source := range []string{"web", "cli"} {
+		responseCh := agent.ChatStreamWithMetadata("hello from "+source, map[string]string{"source": source})
+		for range responseCh.Start() {
+		}
+	}
+
+	agent.ensureHistory()
+	web := agent.history.Filter(func(m llms.UnifiedMessage) bool {
+		return m.Metadata()["source"] == "web"
+	})
+	if len(web) != 1 || web[0].Content() != "hello from web" {
+		t.Fatalf("Expected the web user message, got %+v", web)
+	}
+
+	// Engines receive the tagged messages; providers do not send the tags
+	if got := engine.Calls()[1][3].Metadata()["source"]; got != "cli" {
+		t.Errorf("Expected the second user message to be tagged cli, got %q", got)
+	}
+}
+
+func TestAgent_UpdateSystemPrompt(t *testing.T) {
+	engine := newMockEngine(contentTurn("first"), contentTurn("second"), contentTurn("other"))
+	agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "updatable", SystemPrompt: "Be formal."})
+
+	if _, err := agent.Chat("hello"); err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+
+	agent.UpdateSystemPrompt("Be casual, {{agentName}}.")
+	if agent.SystemPrompt() != "Be casual, {{agentName}}." {
+		t.Errorf("SystemPrompt() = %q, want the updated prompt", agent.SystemPrompt())
+	}
+
+	// The open session's system message is replaced right away
+	history := agent.GetHistory(0, 0)
+	if history[0].Role() != llms.MessageRoleSystem || history[0].Content() != "Be casual, updatable." {
+		t.Fatalf("Expected the updated system message, got %s: %q", history[0].Role(), history[0].Content())
+	}
+	systemMessages := llms.FilterMessages(history, func(m llms.UnifiedMessage) bool {
+		return m.Role() == llms.MessageRoleSystem
+	})
+	if len(systemMessages) != 1 {
+		t.Errorf("Expected a single system message, got %d", len(systemMessages))
+	}
+
+	// Next turns, including of new sessions, use the new prompt
+	if _, err := agent.Chat("hello again"); err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+	for range agent.ChatStreamSession("other", "hi").Start() {
+	}
+	for i, call := range engine.Calls()[1:] {
+		if call[0].Content() != "Be casual, updatable." {
+			t.Errorf("call %d: expected the updated system prompt, got %q", i+2, call[0].Content())
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package agents
+
+import "github.com/thinktwice/agentForge/src/llms"
+
+// AgentHooks observes what an agent does without parsing the chunk stream.
+//
+// Hooks are called synchronously from the agent loop, so implementations should
+// return quickly. Runs of different sessions call them concurrently, so
+// implementations must be safe for concurrent use. They are useful for logging,
+// metrics and auditing.
+type AgentHooks interface {
+	// BeforeLLMCall is called with the messages about to be sent to the LLM.
+	BeforeLLMCall(messages []llms.UnifiedMessage)
+
+	// AfterLLMCall is called once the LLM stream has completed, with its token usage.
+	AfterLLMCall(usage llms.Usage)
+
+	// BeforeToolCall is called before a tool requested by the LLM is executed.
+	BeforeToolCall(toolCall llms.ToolCall)
+
+	// AfterToolCall is called with the result of a tool execution, including failures.
+	AfterToolCall(result llms.ToolResult)
+}
+
+// SaveErrorHooks is implemented by hooks that want to know when the history could
+// not be saved to persistence. The agent keeps the history in memory and continues
+// the conversation; later saves retry with the full history.
+type SaveErrorHooks interface {
+	// OnSaveError is called with the error of a failed history save.
+	OnSaveError(agentName string, err error)
+}
+
+// NoopHooks implements AgentHooks with methods that do nothing.
+// Embed it to implement only the hooks you need.
+type NoopHooks struct{}
+
+func (NoopHooks) BeforeLLMCall(messages []llms.UnifiedMessage) {}
+
+func (NoopHooks) AfterLLMCall(usage llms.Usage) {}
+
+func (NoopHooks) BeforeToolCall(toolCall llms.ToolCall) {}
+
+func (NoopHooks) AfterToolCall(result llms.ToolResult) {}
+
+func (NoopHooks) OnSaveError(agentName string, err error) {}
@@ -0,0 +1,127 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// recordingHooks records hook invocations in order.
+type recordingHooks struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (h *recordingHooks) record(event string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, event)
+}
+
+func (h *recordingHooks) BeforeLLMCall(messages []llms.UnifiedMessage) {
+	h.record(fmt.Sprintf("BeforeLLMCall(%d)", len(messages)))
+}
+
+func (h *recordingHooks) AfterLLMCall(usage llms.Usage) {
+	h.record(fmt.Sprintf("AfterLLMCall(%d)", usage.TotalTokens))
+}
+
+func (h *recordingHooks) BeforeToolCall(toolCall llms.ToolCall) {
+	h.record("BeforeToolCall(" + toolCall.Name + ")")
+}
+
+func (h *recordingHooks) AfterToolCall(result llms.ToolResult) {
+	h.record(fmt.Sprintf("AfterToolCall(%s, %t)", result.ToolName, result.Success))
+}
+
+func TestAgent_Hooks(t *testing.T) {
+	engine := newMockEngine(
+		toolCallTurn(llms.ToolCall{ID: "call_1", Name: "foo", Arguments: map[string]any{"echo": "hi"}}),
+		contentTurn("done"),
+	)
+	hooks := &recordingHooks{}
+	agent := NewAgent(&AgentConfig{
+		LLMEngine: engine,
+		AgentName: "hooked agent",
+		Hooks:     hooks,
+	})
+
+	if _, err := agent.Chat("call foo"); err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+
+	// system + user, then + assistant tool call + tool result
+	expected := []string{
+		"BeforeLLMCall(2)",
+		"AfterLLMCall(15)",
+		"BeforeToolCall(foo)",
+		"AfterToolCall(foo, true)",
+		"BeforeLLMCall(4)",
+		"AfterLLMCall(15)",
+	}
+	if !reflect.DeepEqual(hooks.events, expected) {
+		t.Errorf("Hook order = %v, want %v", hooks.events, expected)
+	}
+}
+
+func TestAgent_NilHooks(t *testing.T) {
+	engine := newMockEngine(
+		toolCallTurn(llms.ToolCall{ID: "call_1", Name: "foo", Arguments: map[string]any{"echo": "hi"}}),
+	)
+	agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "plain agent"})
+
+	if _, err := agent.Chat("call foo"); err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+}
+
+// saveErrorHooks records the errors passed to OnSaveError.
+type saveErrorHooks struct {
+	NoopHooks
+	mu     sync.Mutex
+	errors []error
+}
+
+func (h *saveErrorHooks) OnSaveError(agentName string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.errors = append(h.errors, fmt.Errorf("%s: %w", agentName, err))
+}
+
+func TestAgent_SaveErrors(t *testing.T) {
+	engine := newMockEngine(contentTurn("first"), contentTurn("second"))
+	hooks := &saveErrorHooks{}
+	agent := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "unsaved", Hooks: hooks})
+	saveErr := errors.New("disk full")
+	agent.sessionFor(context.Background(), defaultSessionID).history.persistence = &closeCountingPersistence{saveErr: saveErr}
+
+	for _, want := range []string{"first", "second"} {
+		answer, err := agent.Chat("hello")
+		if err != nil {
+			t.Fatalf("Chat() unexpected error = %v", err)
+		}
+		if answer != want {
+			t.Errorf("Chat() = %q, want %q", answer, want)
+		}
+	}
+
+	if len(hooks.errors) == 0 {
+		t.Fatal("Expected the save failures to be reported")
+	}
+	for _, err := range hooks.errors {
+		if !errors.Is(err, saveErr) || !strings.HasPrefix(err.Error(), "unsaved: ") {
+			t.Errorf("Unexpected reported error %v", err)
+		}
+	}
+
+	// The conversation continues from the in-memory history
+	if calls := engine.Calls(); len(calls[1]) != 4 || calls[1][2].Content() != "first" {
+		t.Errorf("Expected the second request to include the first turn, got %+v", calls[1])
+	}
+}
@@ -0,0 +1,171 @@
+package agents
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/thinktwice/agentForge/src/llms"
+	"github.com/thinktwice/agentForge/src/tools"
+	"gopkg.in/yaml.v3"
+)
+
+// BedrockProvider is the AgentSpec provider name of AWS Bedrock models.
+const BedrockProvider = "bedrock"
+
+// AgentSpec is the declarative description of an agent read by LoadFromFile.
+//
+// Sub-agents without a provider use the LLM engine of their parent.
+type AgentSpec struct {
+	Name         string `yaml:"name"`
+	Description  string `yaml:"description"`
+	SystemPrompt string `yaml:"systemPrompt"`
+	Trace        string `yaml:"trace"`
+
+	// Provider is an OpenAI-compatible provider ("openai", "deepseek", "togetherai")
+	// or "bedrock". Model is the provider's model (empty uses its default model).
+	Provider string `yaml:"provider"`
+	Model    string `yaml:"model"`
+	// Region is the AWS region of the "bedrock" provider (empty uses the AWS configuration).
+	Region string `yaml:"region"`
+
+	// Tools are tool names resolved in the registry passed to the loader.
+	Tools []string `yaml:"tools"`
+
+	SubAgents []AgentSpec `yaml:"subAgents"`
+
+	Persistence       string `yaml:"persistence"`
+	PersistenceDir    string `yaml:"persistenceDir"`
+	MaxToolIterations int    `yaml:"maxToolIterations"`
+}
+
+// LoadFromFile creates an agent tree from a YAML or JSON spec file (see AgentSpec).
+// Tool names are resolved in tools.NewBuiltinRegistry; use LoadFromFileWithRegistry
+// for other tools.
+//
+// Parameters:
+//   - path: Path of the spec file
+//
+// Returns:
+//   - *Agent: The root agent, with its sub-agents registered for delegation
+//   - error: An error if the file cannot be read or parsed, or the spec is invalid
+func LoadFromFile(path string) (*Agent, error) {
+	return LoadFromFileWithRegistry(path, tools.NewBuiltinRegistry())
+}
+
+// LoadFromFileWithRegistry is like LoadFromFile with the registry used to resolve tool names.
+func LoadFromFileWithRegistry(path string, registry *tools.Registry) (*Agent, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent spec: %w", err)
+	}
+	spec, err := ParseAgentSpec(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return spec.Build(registry)
+}
+
+// ParseAgentSpec parses a YAML or JSON agent spec. Unknown fields are rejected.
+func ParseAgentSpec(data []byte) (*AgentSpec, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var spec AgentSpec
+	if err := dec.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("invalid agent spec: %w", err)
+	}
+	return &spec, nil
+}
+
+// Build creates the agent tree described by the spec.
+//
+// Parameters:
+//   - registry: The registry used to resolve tool names (nil means no tools are known)
+//
+// Returns:
+//   - *Agent: The root agent
+//   - error: An error naming the agent whose provider, tool or persistence is invalid
+func (s *AgentSpec) Build(registry *tools.Registry) (agent *Agent, err error) {
+	if s.Provider == "" {
+		return nil, fmt.Errorf("agent %q: provider is required", s.Name)
+	}
+	config, err := s.toAgentConfig(registry)
+	if err != nil {
+		return nil, err
+	}
+
+	agent, err = TryNewAgent(config)
+	if err != nil {
+		return nil, fmt.Errorf("agent %q: %w", s.Name, err)
+	}
+	return agent, nil
+}
+
+// toAgentConfig converts the spec and its sub-agents to an AgentConfig.
+func (s *AgentSpec) toAgentConfig(registry *tools.Registry) (*AgentConfig, error) {
+	if s.Name == "" {
+		return nil, fmt.Errorf("agent name is required")
+	}
+
+	config := &AgentConfig{
+		AgentName:         s.Name,
+		Description:       s.Description,
+		SystemPrompt:      s.SystemPrompt,
+		Trace:             s.Trace,
+		MaxToolIterations: s.MaxToolIterations,
+		PersistenceDir:    s.PersistenceDir,
+	}
+
+	if s.Provider != "" {
+		engine, err := newSpecEngine(s.Provider, s.Model, s.Region)
+		if err != nil {
+			return nil, fmt.Errorf("agent %q: %w", s.Name, err)
+		}
+		config.LLMEngine = engine
+	}
+
+	switch s.Persistence {
+	case "", "json", "redis":
+		config.Persistence = s.Persistence
+	default:
+		return nil, fmt.Errorf("agent %q: unknown persistence %q (supported: json, redis)", s.Name, s.Persistence)
+	}
+
+	for _, name := range s.Tools {
+		var tool llms.Tool
+		var ok bool
+		if registry != nil {
+			tool, ok = registry.Get(name)
+		}
+		if !ok {
+			return nil, fmt.Errorf("agent %q: unknown tool %q", s.Name, name)
+		}
+		config.Tools = append(config.Tools, tool)
+	}
+
+	for i := range s.SubAgents {
+		subConfig, err := s.SubAgents[i].toAgentConfig(registry)
+		if err != nil {
+			return nil, fmt.Errorf("sub-agent of %q: %w", s.Name, err)
+		}
+		config.SubAgentConfigs = append(config.SubAgentConfigs, subConfig)
+	}
+
+	return config, nil
+}
+
+// newSpecEngine creates the LLM engine of a spec provider and model.
+func newSpecEngine(provider, model, region string) (llms.LLMEngine, error) {
+	if strings.EqualFold(strings.TrimSpace(provider), BedrockProvider) {
+		return llms.GetBedrockLLM(context.Background(), region, model)
+	}
+	if region != "" {
+		return nil, fmt.Errorf("region is only supported by the %s provider", BedrockProvider)
+	}
+
+	builder := &llms.OpenAILLMBuilder{Provider: provider, Ctx: context.Background()}
+	return builder.SetModel(model).Build()
+}
@@ -0,0 +1,130 @@
+package agents
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleAgentSpec = `
+name: main
+description: The main agent
+systemPrompt: You are a helpful assistant.
+provider: openai
+model: gpt-4o-mini
+tools:
+  - foo
+maxToolIterations: 3
+subAgents:
+  - name: researcher
+    description: Looks things up
+    systemPrompt: You research topics.
+`
+
+func writeSpec(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	return path
+}
+
+func TestLoadFromFile(t *testing.T) {
+	t.Setenv("AF_OPENAI_API_KEY", "test-key")
+
+	agent, err := LoadFromFile(writeSpec(t, "agent.yaml", sampleAgentSpec))
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+
+	if agent.Name() != "main" {
+		t.Errorf("Name() = %q, want %q", agent.Name(), "main")
+	}
+	if agent.Description() != "The main agent" {
+		t.Errorf("Description() = %q", agent.Description())
+	}
+	if agent.config.MaxToolIterations != 3 {
+		t.Errorf("MaxToolIterations = %d, want 3", agent.config.MaxToolIterations)
+	}
+	if agent.findTool("foo") == nil {
+		t.Error("Expected the foo tool to be registered")
+	}
+
+	var researcher *Agent
+	for _, sa := range agent.subAgents {
+		if a, ok := (*sa).(*Agent); ok && a.Name() == "researcher" {
+			researcher = a
+		}
+	}
+	if researcher == nil {
+		t.Fatal("Expected the researcher sub-agent")
+	}
+	if researcher.config.LLMEngine != agent.config.LLMEngine {
+		t.Error("Expected the sub-agent to use the parent's engine")
+	}
+}
+
+func TestLoadFromFileJSON(t *testing.T) {
+	t.Setenv("AF_OPENAI_API_KEY", "test-key")
+
+	spec := `{"name": "main", "provider": "openai", "tools": ["foo", "calculator"]}`
+	agent, err := LoadFromFile(writeSpec(t, "agent.json", spec))
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if agent.findTool("calculator") == nil {
+		t.Error("Expected the calculator tool to be registered")
+	}
+}
+
+func TestLoadFromFileErrors(t *testing.T) {
+	t.Setenv("AF_OPENAI_API_KEY", "test-key")
+
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr string
+	}{
+		{
+			name:    "unknown tool",
+			spec:    "name: main\nprovider: openai\ntools: [nope]\n",
+			wantErr: `agent "main": unknown tool "nope"`,
+		},
+		{
+			name:    "unknown sub-agent tool",
+			spec:    "name: main\nprovider: openai\nsubAgents:\n  - name: helper\n    tools: [nope]\n",
+			wantErr: `agent "helper": unknown tool "nope"`,
+		},
+		{
+			name:    "unknown provider",
+			spec:    "name: main\nprovider: acme\n",
+			wantErr: "invalid provider: acme",
+		},
+		{
+			name:    "missing provider",
+			spec:    "name: main\n",
+			wantErr: "provider is required",
+		},
+		{
+			name:    "unknown persistence",
+			spec:    "name: main\nprovider: openai\npersistence: sqlite\n",
+			wantErr: `unknown persistence "sqlite"`,
+		},
+		{
+			name:    "unknown field",
+			spec:    "name: main\nprovider: openai\nmodle: gpt-4o\n",
+			wantErr: "field modle not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromFile(writeSpec(t, "agent.yaml", tt.spec))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadFromFile() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
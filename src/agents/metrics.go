@@ -0,0 +1,138 @@
+package agents
+
+import (
+	"sync"
+	"time"
+)
+
+// Metrics accounts for token usage, tool calls and LLM latency across conversations.
+//
+// Methods are called synchronously from the agent loop, so implementations should
+// return quickly. Runs of different sessions and parallel tool calls call them
+// concurrently, so implementations must be safe for concurrent use. A Metrics can
+// be shared by several agents; each call carries the agent name.
+type Metrics interface {
+	// RecordTokens is called once per LLM call with its token usage.
+	RecordTokens(agent string, prompt, completion int)
+
+	// RecordToolCall is called once per tool call with its duration and outcome.
+	RecordToolCall(agent string, tool string, dur time.Duration, ok bool)
+
+	// RecordLLMLatency is called once per LLM call with the time until the stream completed.
+	RecordLLMLatency(agent string, dur time.Duration)
+}
+
+// NoopMetrics implements Metrics with methods that do nothing.
+type NoopMetrics struct{}
+
+func (NoopMetrics) RecordTokens(agent string, prompt, completion int) {}
+
+func (NoopMetrics) RecordToolCall(agent string, tool string, dur time.Duration, ok bool) {}
+
+func (NoopMetrics) RecordLLMLatency(agent string, dur time.Duration) {}
+
+// MemoryMetrics is an in-memory Metrics that keeps totals per agent and per tool.
+type MemoryMetrics struct {
+	mu     sync.Mutex
+	agents map[string]*memoryAgentMetrics
+}
+
+type memoryAgentMetrics struct {
+	promptTokens     int
+	completionTokens int
+	llmCalls         int
+	llmLatency       time.Duration
+	toolCalls        map[string]int
+	toolFailures     map[string]int
+	toolDuration     map[string]time.Duration
+}
+
+// NewMemoryMetrics creates an empty in-memory metrics recorder.
+//
+// Returns:
+//   - *MemoryMetrics: A new recorder with all totals at zero
+func NewMemoryMetrics() *MemoryMetrics {
+	return &MemoryMetrics{agents: make(map[string]*memoryAgentMetrics)}
+}
+
+// agent returns the totals of the named agent, creating them on first use.
+// The caller must hold m.mu.
+func (m *MemoryMetrics) agent(name string) *memoryAgentMetrics {
+	totals, ok := m.agents[name]
+	if !ok {
+		totals = &memoryAgentMetrics{
+			toolCalls:    make(map[string]int),
+			toolFailures: make(map[string]int),
+			toolDuration: make(map[string]time.Duration),
+		}
+		m.agents[name] = totals
+	}
+	return totals
+}
+
+// RecordTokens adds the token usage of one LLM call.
+func (m *MemoryMetrics) RecordTokens(agent string, prompt, completion int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	totals := m.agent(agent)
+	totals.promptTokens += prompt
+	totals.completionTokens += completion
+}
+
+// RecordToolCall counts one tool call and adds its duration.
+func (m *MemoryMetrics) RecordToolCall(agent string, tool string, dur time.Duration, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	totals := m.agent(agent)
+	totals.toolCalls[tool]++
+	totals.toolDuration[tool] += dur
+	if !ok {
+		totals.toolFailures[tool]++
+	}
+}
+
+// RecordLLMLatency counts one LLM call and adds its latency.
+func (m *MemoryMetrics) RecordLLMLatency(agent string, dur time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	totals := m.agent(agent)
+	totals.llmCalls++
+	totals.llmLatency += dur
+}
+
+// Tokens returns the prompt and completion tokens recorded for the agent.
+func (m *MemoryMetrics) Tokens(agent string) (prompt, completion int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if totals, ok := m.agents[agent]; ok {
+		return totals.promptTokens, totals.completionTokens
+	}
+	return 0, 0
+}
+
+// LLMCalls returns the number of LLM calls recorded for the agent and their total latency.
+func (m *MemoryMetrics) LLMCalls(agent string) (int, time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if totals, ok := m.agents[agent]; ok {
+		return totals.llmCalls, totals.llmLatency
+	}
+	return 0, 0
+}
+
+// ToolCalls returns the number of calls of the tool recorded for the agent,
+// how many of them failed and their total duration.
+func (m *MemoryMetrics) ToolCalls(agent string, tool string) (calls, failures int, dur time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if totals, ok := m.agents[agent]; ok {
+		return totals.toolCalls[tool], totals.toolFailures[tool], totals.toolDuration[tool]
+	}
+	return 0, 0, 0
+}
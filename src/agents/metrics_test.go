@@ -0,0 +1,45 @@
+package agents
+
+import (
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+func TestAgent_Metrics(t *testing.T) {
+	engine := newMockEngine(
+		toolCallTurn(
+			llms.ToolCall{ID: "call_1", Name: "foo", Arguments: map[string]any{"echo": "hi"}},
+			llms.ToolCall{ID: "call_2", Name: "missing", Arguments: map[string]any{}},
+		),
+		toolCallTurn(llms.ToolCall{ID: "call_3", Name: "foo", Arguments: map[string]any{"echo": "again"}}),
+		contentTurn("done"),
+	)
+	metrics := NewMemoryMetrics()
+	agent := NewAgent(&AgentConfig{
+		LLMEngine: engine,
+		AgentName: "measured agent",
+		Metrics:   metrics,
+	})
+
+	if _, err := agent.Chat("call foo"); err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+
+	// Three LLM calls, each reporting 10 prompt and 5 completion tokens in the mock
+	if calls, _ := metrics.LLMCalls("measured agent"); calls != 3 {
+		t.Errorf("Expected 3 LLM calls, got %d", calls)
+	}
+	if prompt, completion := metrics.Tokens("measured agent"); prompt != 30 || completion != 15 {
+		t.Errorf("Expected 30 prompt and 15 completion tokens, got %d and %d", prompt, completion)
+	}
+	if calls, failures, _ := metrics.ToolCalls("measured agent", "foo"); calls != 2 || failures != 0 {
+		t.Errorf("Expected 2 successful foo calls, got %d calls and %d failures", calls, failures)
+	}
+	if calls, failures, _ := metrics.ToolCalls("measured agent", "missing"); calls != 1 || failures != 1 {
+		t.Errorf("Expected 1 failed missing call, got %d calls and %d failures", calls, failures)
+	}
+	if calls, _, _ := metrics.ToolCalls("other agent", "foo"); calls != 0 {
+		t.Errorf("Expected no calls for another agent, got %d", calls)
+	}
+}
@@ -0,0 +1,162 @@
+package agents
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// mockTurn is one scripted LLM response: the chunks to stream and an optional
+// error sent after them.
+type mockTurn struct {
+	chunks []llms.ChunkResponse
+	err    error
+	// stall keeps the stream open after the chunks until it is cancelled
+	stall bool
+}
+
+// mockEngine is a scripted llms.LLMEngine used to drive the agent loop without
+// a real provider. Each ChatStream call consumes the next turn; once the script
+// is exhausted a plain "done" completion is returned.
+type mockEngine struct {
+	mu    sync.Mutex
+	turns []mockTurn
+	calls [][]llms.UnifiedMessage
+	// respond, when set, computes the turn from the received messages instead of the script
+	respond func(messages []llms.UnifiedMessage) mockTurn
+	// options records the generation options of each call (zero value for ChatStream)
+	options []llms.GenerationOptions
+	// cancelled counts the streams stopped by ResponseCh.Cancel
+	cancelled int
+	// toolDefs records the function definitions of the tools of each call
+	toolDefs [][]llms.FunctionDefinition
+}
+
+func newMockEngine(turns ...mockTurn) *mockEngine {
+	return &mockEngine{turns: turns}
+}
+
+func (m *mockEngine) ChatStream(messages []llms.UnifiedMessage, tools []llms.Tool) *llms.ResponseCh {
+	return m.ChatStreamWithOptions(messages, tools, llms.GenerationOptions{})
+}
+
+func (m *mockEngine) ChatStreamWithOptions(messages []llms.UnifiedMessage, tools []llms.Tool, opts llms.GenerationOptions) *llms.ResponseCh {
+	m.mu.Lock()
+	m.options = append(m.options, opts)
+	snapshot := make([]llms.UnifiedMessage, len(messages))
+	copy(snapshot, messages)
+	m.calls = append(m.calls, snapshot)
+	defs := make([]llms.FunctionDefinition, len(tools))
+	for i, tool := range tools {
+		defs[i] = tool.GetFunctionDefinition()
+	}
+	m.toolDefs = append(m.toolDefs, defs)
+
+	turn := contentTurn("done")
+	if m.respond != nil {
+		turn = m.respond(snapshot)
+	} else if len(m.turns) > 0 {
+		turn = m.turns[0]
+		m.turns = m.turns[1:]
+	}
+	m.mu.Unlock()
+
+	responseCh := llms.NewResponseCh()
+	go func() {
+		defer responseCh.Close()
+		for _, chunk := range turn.chunks {
+			chunkBytes, _ := json.Marshal(chunk)
+			select {
+			case responseCh.Response <- chunkBytes:
+			case <-responseCh.Cancelled():
+				m.mu.Lock()
+				m.cancelled++
+				m.mu.Unlock()
+				return
+			}
+		}
+		if turn.stall {
+			<-responseCh.Cancelled()
+			m.mu.Lock()
+			m.cancelled++
+			m.mu.Unlock()
+			return
+		}
+		if turn.err != nil {
+			responseCh.Error <- turn.err
+		}
+	}()
+	return responseCh
+}
+
+// Calls returns the message lists received by the engine, one per ChatStream call.
+func (m *mockEngine) Calls() [][]llms.UnifiedMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
+
+// Cancelled returns the number of streams stopped by ResponseCh.Cancel.
+func (m *mockEngine) Cancelled() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cancelled
+}
+
+// ToolDefinitions returns the tool function definitions received by the engine, one list per call.
+func (m *mockEngine) ToolDefinitions() [][]llms.FunctionDefinition {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.toolDefs
+}
+
+// Options returns the generation options received by the engine, one per call.
+func (m *mockEngine) Options() []llms.GenerationOptions {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.options
+}
+
+// contentTurn streams the given deltas and then a completion chunk.
+func contentTurn(deltas ...string) mockTurn {
+	var chunks []llms.ChunkResponse
+	var full string
+	for _, d := range deltas {
+		full += d
+		chunks = append(chunks, llms.ChunkResponse{
+			Content:     d,
+			Delta:       d,
+			FullContent: full,
+			Status:      llms.StatusStreaming,
+			Type:        llms.TypeContent,
+		})
+	}
+	chunks = append(chunks, llms.ChunkResponse{
+		FullContent:      full,
+		Status:           llms.StatusCompleted,
+		Type:             llms.TypeCompletion,
+		PromptTokens:     10,
+		CompletionTokens: 5,
+		TotalTokens:      15,
+	})
+	return mockTurn{chunks: chunks}
+}
+
+// toolCallTurn emits a tool-call chunk followed by a completion chunk.
+func toolCallTurn(toolCalls ...llms.ToolCall) mockTurn {
+	return mockTurn{chunks: []llms.ChunkResponse{
+		{
+			Status:    llms.StatusToolCall,
+			Type:      llms.TypeToolCall,
+			ToolCalls: toolCalls,
+		},
+		{
+			Status:           llms.StatusCompleted,
+			Type:             llms.TypeCompletion,
+			PromptTokens:     10,
+			CompletionTokens: 5,
+			TotalTokens:      15,
+		},
+	}}
+}
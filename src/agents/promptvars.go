@@ -0,0 +1,65 @@
+package agents
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// promptVarPattern matches {{name}} placeholders (spaces inside the braces are allowed).
+var promptVarPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*\}\}`)
+
+// builtinPromptVars returns the placeholders available in every system prompt.
+func (a *Agent) builtinPromptVars(now time.Time) map[string]string {
+	return map[string]string{
+		"now":       now.Format(time.RFC3339),
+		"date":      now.Format(time.DateOnly),
+		"agentName": a.Name(),
+	}
+}
+
+// ExpandPromptVars replaces {{name}} placeholders in prompt with their values.
+// Placeholders without a value are left intact and reported.
+//
+// Parameters:
+//   - prompt: The text containing placeholders
+//   - vars: The values by placeholder name
+//
+// Returns:
+//   - string: The expanded text
+//   - []string: The names of the placeholders without a value, in order of appearance
+func ExpandPromptVars(prompt string, vars map[string]string) (string, []string) {
+	var unknown []string
+	expanded := promptVarPattern.ReplaceAllStringFunc(prompt, func(placeholder string) string {
+		name := promptVarPattern.FindStringSubmatch(placeholder)[1]
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		unknown = append(unknown, name)
+		return placeholder
+	})
+	return expanded, unknown
+}
+
+// expandSystemPrompt expands the placeholders of the configured system prompt
+// with the built-ins and AgentConfig.SystemPromptVars (which take precedence).
+// It also returns the names of the placeholders without a value.
+func (a *Agent) expandSystemPrompt() (string, []string) {
+	vars := a.builtinPromptVars(time.Now())
+	for name, value := range a.config.SystemPromptVars {
+		vars[name] = value
+	}
+	return ExpandPromptVars(a.config.SystemPrompt, vars)
+}
+
+// validateSystemPromptVars reports unknown placeholders when StrictSystemPromptVars is set.
+func (a *Agent) validateSystemPromptVars() error {
+	if !a.config.StrictSystemPromptVars {
+		return nil
+	}
+	if _, unknown := a.expandSystemPrompt(); len(unknown) > 0 {
+		return fmt.Errorf("unknown system prompt variables: {{%s}}", strings.Join(unknown, "}}, {{"))
+	}
+	return nil
+}
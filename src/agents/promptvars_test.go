@@ -0,0 +1,67 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpandPromptVars(t *testing.T) {
+	prompt, unknown := ExpandPromptVars("Hi {{ user }}, you are {{role}}. {{missing}} stays.", map[string]string{
+		"user": "Ada",
+		"role": "a reviewer",
+	})
+	if prompt != "Hi Ada, you are a reviewer. {{missing}} stays." {
+		t.Errorf("Unexpected expansion %q", prompt)
+	}
+	if len(unknown) != 1 || unknown[0] != "missing" {
+		t.Errorf("Expected unknown [missing], got %v", unknown)
+	}
+}
+
+func TestAgent_SystemPromptVars(t *testing.T) {
+	t.Run("built-ins and custom vars are expanded", func(t *testing.T) {
+		agent := NewAgent(&AgentConfig{
+			LLMEngine:        newMockEngine(contentTurn("ok")),
+			AgentName:        "prompt agent",
+			SystemPrompt:     "I am {{agentName}} serving {{tenant}} on {{date}}. Unknown: {{other}}",
+			SystemPromptVars: map[string]string{"tenant": "acme"},
+		})
+
+		prompt := agent.systemPromptForRun()
+		want := "I am prompt agent serving acme on " + time.Now().Format(time.DateOnly) + ". Unknown: {{other}}"
+		if !strings.HasPrefix(prompt, want) {
+			t.Errorf("Expected prompt to start with %q, got %q", want, prompt)
+		}
+	})
+
+	t.Run("custom vars override built-ins", func(t *testing.T) {
+		agent := NewAgent(&AgentConfig{
+			LLMEngine:        newMockEngine(contentTurn("ok")),
+			AgentName:        "prompt agent",
+			SystemPrompt:     "Time: {{now}}",
+			SystemPromptVars: map[string]string{"now": "frozen"},
+		})
+		if prompt := agent.systemPromptForRun(); !strings.HasPrefix(prompt, "Time: frozen") {
+			t.Errorf("Expected overridden {{now}}, got %q", prompt)
+		}
+	})
+
+	t.Run("strict mode panics on unknown vars", func(t *testing.T) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				t.Fatal("Expected NewAgent to panic")
+			}
+			if err, ok := r.(error); !ok || !strings.Contains(err.Error(), "{{tenant}}") {
+				t.Errorf("Expected panic naming {{tenant}}, got %v", r)
+			}
+		}()
+		NewAgent(&AgentConfig{
+			LLMEngine:              newMockEngine(contentTurn("ok")),
+			AgentName:              "strict agent",
+			SystemPrompt:           "Serving {{tenant}} on {{date}}",
+			StrictSystemPromptVars: true,
+		})
+	})
+}
@@ -0,0 +1,63 @@
+package agents
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+func TestAgent_RecordAndReplay(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "run.jsonl")
+
+	run := func(engine llms.LLMEngine) []core.ExtendedChunkResponse {
+		calls := 0
+		agent := NewAgent(&AgentConfig{
+			LLMEngine: engine,
+			AgentName: "replayed",
+			Tools:     []llms.Tool{newCountingTool("lookup", &calls)},
+		})
+		var chunks []core.ExtendedChunkResponse
+		for chunk := range agent.ChatStream("look it up").Start() {
+			// Tool durations differ between runs
+			for i := range chunk.ToolResults {
+				chunk.ToolResults[i].Metadata = nil
+			}
+			chunks = append(chunks, chunk)
+		}
+		if calls != 1 {
+			t.Errorf("Expected the tool to run once, got %d", calls)
+		}
+		return chunks
+	}
+
+	recorder, err := llms.NewRecordingEngine(newMockEngine(
+		toolCallTurn(llms.ToolCall{ID: "call_1", Name: "lookup", Arguments: map[string]any{"x": float64(1)}}),
+		contentTurn("found ", "it"),
+	), logPath)
+	if err != nil {
+		t.Fatalf("NewRecordingEngine() unexpected error = %v", err)
+	}
+	recorded := run(recorder)
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close() unexpected error = %v", err)
+	}
+
+	replay, err := llms.NewReplayEngine(logPath)
+	if err != nil {
+		t.Fatalf("NewReplayEngine() unexpected error = %v", err)
+	}
+	if calls := replay.Calls(); len(calls) != 2 || calls[0].Tools[0] != "lookup" || len(calls[1].Messages) != 4 {
+		t.Fatalf("Expected the two LLM calls to be logged, got %+v", calls)
+	}
+
+	replayed := run(replay)
+	if !reflect.DeepEqual(recorded, replayed) {
+		t.Errorf("Replayed chunks differ:\nrecorded: %+v\nreplayed: %+v", recorded, replayed)
+	}
+	if replay.Remaining() != 0 {
+		t.Errorf("Expected every call to be replayed, %d left", replay.Remaining())
+	}
+}
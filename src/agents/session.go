@@ -0,0 +1,158 @@
+package agents
+
+import (
+	"context"
+	"sync"
+
+	agentforge "github.com/thinktwice/agentForge/src"
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// defaultSessionID is the session used by ChatStream, Chat and delegation.
+const defaultSessionID = ""
+
+// session is one conversation of an agent.
+//
+// Runs hold mu for their whole duration, so runs of the same session are
+// serialized while runs of different sessions proceed in parallel.
+type session struct {
+	mu      sync.Mutex
+	history *History
+}
+
+// agentRun holds the state of one chat invocation. It is owned by the run's
+// goroutine, so concurrent runs on the same Agent never share it.
+type agentRun struct {
+	// ctx carries the chat tracing span and the sessions active up the delegation chain
+	ctx context.Context
+	// responseCh receives the chunks of this run
+	responseCh *core.ResponseCh
+	// history is the history of the run's session
+	history *History
+	// opts are the generation options of this run
+	opts llms.GenerationOptions
+	// depth is the delegation depth of this run (0 when called directly)
+	depth int
+	// maxDepth is the effective maximum delegation depth of this run
+	maxDepth int
+	// outputChars counts the answer characters streamed so far (see MaxOutputChars)
+	outputChars int
+	// usage is the token usage of the tool-call turns so far, added to the usage
+	// reported by the completion chunk
+	usage llms.Usage
+	// speakerLabeled is set once the current assistant message carries its
+	// speaker label (see DisplayName)
+	speakerLabeled bool
+}
+
+// newRun creates the state of a run. The effective depth limit is the stricter
+// of maxDepth and the agent's own MaxDelegationDepth.
+func (a *Agent) newRun(ctx context.Context, history *History, opts llms.GenerationOptions, depth int, maxDepth int) *agentRun {
+	r := &agentRun{
+		ctx:        ctx,
+		responseCh: core.NewResponseChWithBuffer(a.Name(), a.Trace(), a.config.ResponseBufferSize),
+		history:    history,
+		opts:       opts,
+		depth:      depth,
+		maxDepth:   a.config.MaxDelegationDepth,
+	}
+	if maxDepth > 0 && maxDepth < r.maxDepth {
+		r.maxDepth = maxDepth
+	}
+	return r
+}
+
+// ChatStreamSession is like ChatStream but runs on the conversation identified by sessionID.
+//
+// Each session has its own history, so a single Agent can serve several users
+// concurrently (e.g. from an HTTP handler keyed by user or conversation ID).
+// Calls on the same session are serialized: a call waits for the previous turn
+// of that session to finish before it starts. The empty session ID is the default
+// session used by ChatStream, Chat and delegation.
+//
+// Parameters:
+//   - sessionID: Identifier of the conversation
+//   - message: The user message to send
+//
+// Returns:
+//   - *core.ResponseCh: Response channel that can be used to receive streaming chunks
+func (a *Agent) ChatStreamSession(sessionID string, message string) *core.ResponseCh {
+	return a.startChat(context.Background(), sessionID, llms.UserMessage(message), 0, a.config.MaxDelegationDepth, a.defaultGenerationOptions())
+}
+
+// GetSessionHistory returns a window of a session's conversation history.
+//
+// It waits for a running turn of the session to finish, so the window is consistent.
+//
+// Parameters:
+//   - sessionID: Identifier of the conversation (empty for the default session)
+//   - limit: Maximum number of messages to return (0 with offset 0 returns everything)
+//   - offset: Index of the first message to return
+//
+// Returns:
+//   - []llms.UnifiedMessage: The requested messages (empty for an unknown session)
+func (a *Agent) GetSessionHistory(sessionID string, limit, offset int) []llms.UnifiedMessage {
+	s := a.sessionFor(context.Background(), sessionID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.history.page(limit, offset)
+}
+
+// sessionFor returns the session with the given ID, creating it on first use.
+//
+// If the session is already running further up the delegation chain in ctx
+// (a delegation cycle back to this agent), a temporary session is returned
+// instead, since waiting for the running turn would deadlock.
+func (a *Agent) sessionFor(ctx context.Context, sessionID string) *session {
+	if isSessionActive(ctx, a, sessionID) {
+		agentforge.Debug("Agent '%s': session %q is already running in this delegation chain, using a temporary session", a.Name(), sessionID)
+		return &session{history: &History{}}
+	}
+
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+
+	if a.sessions == nil {
+		a.sessions = make(map[string]*session)
+	}
+	s, ok := a.sessions[sessionID]
+	if !ok {
+		s = &session{}
+		if sessionID == defaultSessionID {
+			a.ensureHistory()
+			s.history = a.history
+		} else {
+			s.history = a.newHistory()
+		}
+		a.sessions[sessionID] = s
+	}
+	return s
+}
+
+// activeSessionKey is the context key of the sessions running up the delegation chain.
+type activeSessionKey struct{}
+
+// activeSession is a node of the immutable list of sessions running in a delegation chain.
+type activeSession struct {
+	agent  *Agent
+	id     string
+	parent *activeSession
+}
+
+// withActiveSession returns a context recording that the agent's session is running.
+func withActiveSession(ctx context.Context, a *Agent, sessionID string) context.Context {
+	parent, _ := ctx.Value(activeSessionKey{}).(*activeSession)
+	return context.WithValue(ctx, activeSessionKey{}, &activeSession{agent: a, id: sessionID, parent: parent})
+}
+
+// isSessionActive reports whether the agent's session is running up the delegation chain in ctx.
+func isSessionActive(ctx context.Context, a *Agent, sessionID string) bool {
+	node, _ := ctx.Value(activeSessionKey{}).(*activeSession)
+	for ; node != nil; node = node.parent {
+		if node.agent == a && node.id == sessionID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package agents
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// newEchoEngine returns an engine answering "echo: <last user message>".
+func newEchoEngine() *mockEngine {
+	engine := newMockEngine()
+	engine.respond = func(messages []llms.UnifiedMessage) mockTurn {
+		for i := len(messages) - 1; i >= 0; i-- {
+			if messages[i].Role() == llms.MessageRoleUser {
+				return contentTurn("echo: " + messages[i].Content())
+			}
+		}
+		return contentTurn("no user message")
+	}
+	return engine
+}
+
+// drainAnswer runs a turn on a session and returns its final content.
+func drainAnswer(t *testing.T, agent *Agent, sessionID, message string) string {
+	t.Helper()
+	var answer string
+	for chunk := range agent.ChatStreamSession(sessionID, message).Start() {
+		if chunk.Status == llms.StatusError {
+			t.Errorf("Session %q: unexpected error chunk: %s", sessionID, chunk.Content)
+		}
+		if chunk.Type == llms.TypeCompletion {
+			answer = chunk.FullContent
+		}
+	}
+	return answer
+}
+
+func TestAgent_ConcurrentSessions(t *testing.T) {
+	agent := NewAgent(&AgentConfig{LLMEngine: newEchoEngine(), AgentName: "shared agent"})
+
+	const sessions = 8
+	var wg sync.WaitGroup
+	for i := 0; i < sessions; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sessionID := fmt.Sprintf("user-%d", i)
+			for turn := 0; turn < 2; turn++ {
+				message := fmt.Sprintf("hello %d/%d", i, turn)
+				if answer := drainAnswer(t, agent, sessionID, message); answer != "echo: "+message {
+					t.Errorf("Session %q: answer = %q, want %q", sessionID, answer, "echo: "+message)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < sessions; i++ {
+		sessionID := fmt.Sprintf("user-%d", i)
+		history := agent.GetSessionHistory(sessionID, 0, 0)
+		// system + 2 x (user + assistant)
+		if len(history) != 5 {
+			t.Fatalf("Session %q: expected 5 messages, got %d", sessionID, len(history))
+		}
+		for turn := 0; turn < 2; turn++ {
+			message := fmt.Sprintf("hello %d/%d", i, turn)
+			user, assistant := history[1+2*turn], history[2+2*turn]
+			if user.Content() != message || assistant.Content() != "echo: "+message {
+				t.Errorf("Session %q turn %d: got %q / %q", sessionID, turn, user.Content(), assistant.Content())
+			}
+		}
+	}
+
+	if history := agent.GetHistory(0, 0); len(history) != 0 {
+		t.Errorf("Expected the default session to be untouched, got %d messages", len(history))
+	}
+}
+
+func TestAgent_ConcurrentCallsOnSameSession(t *testing.T) {
+	agent := NewAgent(&AgentConfig{LLMEngine: newEchoEngine(), AgentName: "shared agent"})
+
+	const calls = 5
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			message := fmt.Sprintf("message %d", i)
+			if answer := drainAnswer(t, agent, "shared", message); answer != "echo: "+message {
+				t.Errorf("answer = %q, want %q", answer, "echo: "+message)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Turns are serialized: every user message is directly followed by its answer
+	history := agent.GetSessionHistory("shared", 0, 0)
+	if len(history) != 1+2*calls {
+		t.Fatalf("Expected %d messages, got %d", 1+2*calls, len(history))
+	}
+	for i := 1; i < len(history); i += 2 {
+		user, assistant := history[i], history[i+1]
+		if user.Role() != llms.MessageRoleUser || assistant.Content() != "echo: "+user.Content() {
+			t.Errorf("Interleaved turn at %d: %q / %q", i, user.Content(), assistant.Content())
+		}
+	}
+}
+
+func TestAgent_DelegationCycleDoesNotDeadlock(t *testing.T) {
+	// alice -> bob -> alice: the inner alice run must not wait for the outer one
+	pingPong := func(target string) *mockEngine {
+		return &mockEngine{respond: func(messages []llms.UnifiedMessage) mockTurn {
+			last := messages[len(messages)-1]
+			if last.Role() == llms.MessageRoleTool {
+				return contentTurn(last.Content())
+			}
+			return toolCallTurn(llms.ToolCall{
+				ID:        "call_" + target,
+				Name:      "delegate",
+				Arguments: map[string]any{"subAgent": target, "message": "please handle this"},
+			})
+		}}
+	}
+
+	var aliceRef core.SubAgent
+	bob := NewAgent(&AgentConfig{
+		LLMEngine: pingPong("alice"),
+		AgentName: "bob",
+		SubAgents: []*core.SubAgent{&aliceRef},
+	})
+	alice := NewAgent(&AgentConfig{
+		LLMEngine:          pingPong("bob"),
+		AgentName:          "alice",
+		SubAgents:          []*core.SubAgent{bob.AgentAsSubAgent()},
+		MaxDelegationDepth: 2,
+	})
+	aliceRef = alice
+
+	done := make(chan struct{})
+	var (
+		response string
+		err      error
+	)
+	go func() {
+		defer close(done)
+		response, err = alice.Chat("start")
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Delegation cycle deadlocked")
+	}
+	if err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+	if !strings.Contains(response, "max delegation depth exceeded") {
+		t.Errorf("Expected depth-exceeded error in response, got %q", response)
+	}
+
+	// The re-entrant run used a temporary session: alice's history only has the root turn
+	for _, msg := range alice.GetHistory(0, 0) {
+		if msg.Role() == llms.MessageRoleUser && msg.Content() != "start" {
+			t.Errorf("Unexpected delegated message in alice's history: %q", msg.Content())
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// DefaultSummaryPrompt is the system prompt sent to the LLM when summarizing history.
+const DefaultSummaryPrompt = `Summarize this conversation.
+Keep every fact, decision, open question and tool result that later turns may rely on.
+Write a concise neutral summary in plain text; do not add greetings or commentary.`
+
+// DefaultToolResultSummaryPrompt is the system prompt sent to the LLM when summarizing
+// a large tool result (see AgentConfig.SummarizeToolResultsOver).
+const DefaultToolResultSummaryPrompt = `Summarize this tool result for the assistant that called the tool.
+Keep every value, identifier, error and detail needed to answer the user; drop repetition and formatting.
+Write a concise plain-text summary; do not add commentary.`
+
+// toolResultSummaryPrefix marks a tool result replaced by its summary in history.
+const toolResultSummaryPrefix = "[SUMMARIZED TOOL RESULT, %d chars]\n"
+
+// summaryPrefix marks the synthetic message that replaces a summarized span.
+const summaryPrefix = "[CONVERSATION SUMMARY]\n"
+
+// Summarizer compresses a span of conversation history into a single note by asking
+// an LLM to summarize it.
+type Summarizer struct {
+	engine llms.LLMEngine
+	prompt string
+}
+
+// NewSummarizer creates a Summarizer backed by the given LLM engine.
+//
+// Parameters:
+//   - engine: The LLM engine used to produce summaries
+//
+// Returns:
+//   - *Summarizer: A summarizer using DefaultSummaryPrompt
+func NewSummarizer(engine llms.LLMEngine) *Summarizer {
+	return &Summarizer{
+		engine: engine,
+		prompt: DefaultSummaryPrompt,
+	}
+}
+
+// NewToolResultSummarizer creates a Summarizer for tool results backed by the given LLM engine.
+//
+// Parameters:
+//   - engine: The LLM engine used to produce summaries
+//
+// Returns:
+//   - *Summarizer: A summarizer using DefaultToolResultSummaryPrompt
+func NewToolResultSummarizer(engine llms.LLMEngine) *Summarizer {
+	return &Summarizer{
+		engine: engine,
+		prompt: DefaultToolResultSummaryPrompt,
+	}
+}
+
+// Summarize asks the LLM to summarize the given messages.
+//
+// Parameters:
+//   - messages: The messages to summarize (system prompt excluded)
+//
+// Returns:
+//   - string: The summary text
+//   - error: An error if the LLM call failed or returned an empty summary
+func (s *Summarizer) Summarize(messages []llms.UnifiedMessage) (string, error) {
+	return s.SummarizeText(renderTranscript(messages))
+}
+
+// SummarizeText asks the LLM to summarize a text.
+//
+// Parameters:
+//   - text: The text to summarize
+//
+// Returns:
+//   - string: The summary text
+//   - error: An error if the LLM call failed or returned an empty summary
+func (s *Summarizer) SummarizeText(text string) (string, error) {
+	request := []llms.UnifiedMessage{
+		llms.SystemMessage(s.prompt),
+		llms.UserMessage(text),
+	}
+
+	responseCh := s.engine.ChatStream(request, nil)
+
+	var content, finalContent string
+	errCh := responseCh.Error
+	for {
+		select {
+		case chunkBytes, ok := <-responseCh.Response:
+			if !ok {
+				if finalContent == "" {
+					finalContent = content
+				}
+				finalContent = strings.TrimSpace(finalContent)
+				if finalContent == "" {
+					return "", fmt.Errorf("summarizer returned an empty summary")
+				}
+				return finalContent, nil
+			}
+			var chunk llms.ChunkResponse
+			if err := json.Unmarshal(chunkBytes, &chunk); err != nil {
+				return "", fmt.Errorf("failed to deserialize summary chunk: %w", err)
+			}
+			if chunk.Type == llms.TypeContent {
+				content += chunk.Content
+			}
+			if chunk.Type == llms.TypeCompletion {
+				finalContent = chunk.FullContent
+			}
+		case err, ok := <-errCh:
+			if !ok {
+				errCh = nil
+				continue
+			}
+			return "", fmt.Errorf("failed to summarize: %w", err)
+		}
+	}
+}
+
+// renderTranscript formats messages as a plain-text transcript for the summarizer.
+func renderTranscript(messages []llms.UnifiedMessage) string {
+	var b strings.Builder
+	for i := range messages {
+		msg := &messages[i]
+		if content := msg.Content(); content != "" {
+			fmt.Fprintf(&b, "%s: %s\n", msg.Role(), content)
+		}
+		for _, tc := range msg.ToolCalls() {
+			args, _ := json.Marshal(tc.Arguments)
+			fmt.Fprintf(&b, "%s: called tool %s with %s\n", msg.Role(), tc.Name, args)
+		}
+	}
+	return b.String()
+}
+
+// estimateTokens roughly estimates the token count of messages (about 4 characters per token).
+func estimateTokens(messages []llms.UnifiedMessage) int {
+	chars := 0
+	for i := range messages {
+		chars += len(messages[i].Content())
+		for _, tc := range messages[i].ToolCalls() {
+			args, _ := json.Marshal(tc.Arguments)
+			chars += len(tc.Name) + len(args)
+		}
+	}
+	return chars / 4
+}
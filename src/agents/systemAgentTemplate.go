@@ -2,7 +2,9 @@ package agents
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/thinktwice/agentForge/src/llms"
 )
@@ -213,9 +215,9 @@ func (t *SystemAgentTemplate) Troubleshooting() string {
 //   - llmEngine: The LLM engine to use for this agent
 //
 // Returns:
-//   - AgentConfig: Configuration ready to pass to NewAgent()
-func (t *SystemAgentTemplate) ToAgentConfig(llmEngine llms.LLMEngine) AgentConfig {
-	return AgentConfig{
+//   - *AgentConfig: Configuration ready to pass to NewAgent()
+func (t *SystemAgentTemplate) ToAgentConfig(llmEngine llms.LLMEngine) *AgentConfig {
+	return &AgentConfig{
 		LLMEngine:          llmEngine,
 		AgentName:          t.Name,
 		Trace:              t.Trace,
@@ -226,3 +228,61 @@ func (t *SystemAgentTemplate) ToAgentConfig(llmEngine llms.LLMEngine) AgentConfi
 		MainAgent:          false,
 	}
 }
+
+// systemAgentTemplates holds the templates AgentConfig.SystemAgents can name.
+var (
+	systemAgentTemplatesMu sync.RWMutex
+	systemAgentTemplates   = map[string]*SystemAgentTemplate{
+		ReasoningAgentTemplate.Name: ReasoningAgentTemplate,
+	}
+)
+
+// RegisterSystemAgentTemplate registers a template under a name, so agents can
+// add it as a sub-agent by listing the name in AgentConfig.SystemAgents.
+// ReasoningAgentTemplate is registered as "system-reasoning".
+//
+// Parameters:
+//   - name: The name agents refer to the template by
+//   - t: The template to register
+//
+// Returns:
+//   - error: An error if name is empty, t is nil or name is already registered
+func RegisterSystemAgentTemplate(name string, t *SystemAgentTemplate) error {
+	if name == "" {
+		return fmt.Errorf("name is required but was empty")
+	}
+	if t == nil {
+		return fmt.Errorf("cannot register a nil system agent template %q", name)
+	}
+
+	systemAgentTemplatesMu.Lock()
+	defer systemAgentTemplatesMu.Unlock()
+
+	if _, ok := systemAgentTemplates[name]; ok {
+		return fmt.Errorf("system agent template %q is already registered", name)
+	}
+	systemAgentTemplates[name] = t
+	return nil
+}
+
+// LookupSystemAgentTemplate returns the template registered under name and whether it was found.
+func LookupSystemAgentTemplate(name string) (*SystemAgentTemplate, bool) {
+	systemAgentTemplatesMu.RLock()
+	defer systemAgentTemplatesMu.RUnlock()
+
+	t, ok := systemAgentTemplates[name]
+	return t, ok
+}
+
+// SystemAgentTemplateNames returns the registered template names, sorted.
+func SystemAgentTemplateNames() []string {
+	systemAgentTemplatesMu.RLock()
+	defer systemAgentTemplatesMu.RUnlock()
+
+	names := make([]string, 0, len(systemAgentTemplates))
+	for name := range systemAgentTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,87 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+func TestRegisterSystemAgentTemplate(t *testing.T) {
+	template, err := NewSystemAgentTemplate("system-translator", "translation")
+	if err != nil {
+		t.Fatalf("NewSystemAgentTemplate() error = %v", err)
+	}
+	template.AddSystemPrompt("You translate text to French.", nil, "", nil, nil).
+		AddDescription("Translates text to French", nil)
+	if err := RegisterSystemAgentTemplate("translator", template); err != nil {
+		t.Fatalf("RegisterSystemAgentTemplate() error = %v", err)
+	}
+
+	if err := RegisterSystemAgentTemplate("translator", template); err == nil {
+		t.Error("Expected an error registering a name twice")
+	}
+	if err := RegisterSystemAgentTemplate("nil-template", nil); err == nil {
+		t.Error("Expected an error registering a nil template")
+	}
+	if _, ok := LookupSystemAgentTemplate("system-reasoning"); !ok {
+		t.Error("Expected the reasoning template to be registered")
+	}
+
+	translatorEngine := newMockEngine(contentTurn("bonjour"))
+	mainEngine := newMockEngine(
+		toolCallTurn(llms.ToolCall{ID: "call_1", Name: "delegate", Arguments: map[string]any{"subAgent": "system-translator", "message": "translate hello"}}),
+		contentTurn("It is bonjour"),
+	)
+	main := NewAgent(&AgentConfig{
+		LLMEngine:    mainEngine,
+		AgentName:    "main",
+		SystemAgents: []string{"translator"},
+		ExtraEngines: map[string]llms.LLMEngine{"translator": translatorEngine},
+	})
+
+	response, err := main.Chat("How do you say hello in French?")
+	if err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+	if response != "It is bonjour" {
+		t.Errorf("Expected final answer 'It is bonjour', got %q", response)
+	}
+
+	if !strings.Contains(mainEngine.Calls()[0][0].Content(), "system-translator: Translates text to French") {
+		t.Error("Expected the system prompt to list the translator")
+	}
+	calls := translatorEngine.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("Expected the translator to be called once, got %d", len(calls))
+	}
+	if got := calls[0][0].Content(); !strings.HasPrefix(got, "You translate text to French.") {
+		t.Errorf("Expected the translator to use the template's system prompt, got %q", got)
+	}
+}
+
+func TestAgentConfig_UnknownSystemAgent(t *testing.T) {
+	defer func() {
+		r := recover()
+		if r == nil || !strings.Contains(r.(error).Error(), `unknown system agent "no-such-agent"`) {
+			t.Errorf("Expected an unknown system agent panic, got %v", r)
+		}
+	}()
+	NewAgent(&AgentConfig{
+		LLMEngine:    newMockEngine(),
+		AgentName:    "main",
+		SystemAgents: []string{"no-such-agent"},
+	})
+}
+
+func TestAgent_SystemAgentsAddedOnce(t *testing.T) {
+	agent := NewAgent(&AgentConfig{
+		LLMEngine:    newMockEngine(),
+		AgentName:    "main",
+		Reasoning:    true,
+		SystemAgents: []string{"system-reasoning"},
+	})
+	if len(agent.subAgents) != 1 {
+		t.Errorf("Expected one reasoning sub-agent, got %d", len(agent.subAgents))
+	}
+}
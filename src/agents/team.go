@@ -0,0 +1,98 @@
+package agents
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TeamValidationError is returned by ValidateTeam and lists every structural
+// problem found in an agent tree.
+type TeamValidationError struct {
+	// Problems contains one human-readable entry per detected issue.
+	Problems []string
+}
+
+// Error implements the error interface with a consolidated report.
+func (e *TeamValidationError) Error() string {
+	return fmt.Sprintf("invalid agent team (%d problems):\n- %s", len(e.Problems), strings.Join(e.Problems, "\n- "))
+}
+
+// ValidateTeam walks the agent tree rooted at root and reports structural problems
+// before the first message is sent.
+//
+// Checks performed:
+//   - Every agent has a config and an LLM engine
+//   - Every delegate target (sub-agent entry) is set and has a name
+//   - Sub-agent names are unique within the same parent (the delegate tool routes by name)
+//   - No agent is reachable from itself (delegation cycles)
+//
+// Sub-agents that are not *Agent values are checked for name only.
+//
+// Parameters:
+//   - root: The main agent of the team
+//
+// Returns:
+//   - error: A *TeamValidationError listing all problems, or nil if the team is valid
+func ValidateTeam(root *Agent) error {
+	if root == nil {
+		return &TeamValidationError{Problems: []string{"root agent is nil"}}
+	}
+
+	var problems []string
+	onPath := make(map[*Agent]bool)
+	visited := make(map[*Agent]bool)
+
+	var walk func(a *Agent, path []string)
+	walk = func(a *Agent, path []string) {
+		if a.config == nil {
+			problems = append(problems, fmt.Sprintf("%s: agent has no config", strings.Join(path, " -> ")))
+			return
+		}
+		path = append(path, a.Name())
+		location := strings.Join(path, " -> ")
+
+		if onPath[a] {
+			problems = append(problems, fmt.Sprintf("%s: delegation cycle detected", location))
+			return
+		}
+		if visited[a] {
+			// Shared sub-agent already validated through another parent
+			return
+		}
+		onPath[a] = true
+		visited[a] = true
+		defer delete(onPath, a)
+
+		if a.config.LLMEngine == nil {
+			problems = append(problems, fmt.Sprintf("%s: LLMEngine is nil", location))
+		}
+
+		seen := make(map[string]bool)
+		for i, sa := range a.subAgents {
+			if sa == nil || *sa == nil {
+				problems = append(problems, fmt.Sprintf("%s: sub-agent #%d is nil (missing delegate target)", location, i))
+				continue
+			}
+			name := (*sa).Name()
+			if name == "" {
+				problems = append(problems, fmt.Sprintf("%s: sub-agent #%d has an empty name", location, i))
+				continue
+			}
+			if seen[name] {
+				problems = append(problems, fmt.Sprintf("%s: duplicate sub-agent name '%s'", location, name))
+			}
+			seen[name] = true
+
+			if child, ok := (*sa).(*Agent); ok {
+				walk(child, path)
+			}
+		}
+	}
+
+	walk(root, nil)
+
+	if len(problems) > 0 {
+		return &TeamValidationError{Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package agents
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/core"
+)
+
+func TestValidateTeam(t *testing.T) {
+	engine := newMockEngine()
+
+	t.Run("valid team", func(t *testing.T) {
+		specialist := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "specialist"})
+		main := NewAgent(&AgentConfig{
+			LLMEngine: engine,
+			AgentName: "main",
+			Reasoning: true,
+			SubAgents: []*core.SubAgent{specialist.AgentAsSubAgent()},
+		})
+		if err := ValidateTeam(main); err != nil {
+			t.Errorf("ValidateTeam() unexpected error = %v", err)
+		}
+	})
+
+	t.Run("duplicate names and nil target", func(t *testing.T) {
+		first := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "worker"})
+		second := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "worker"})
+		main := NewAgent(&AgentConfig{
+			LLMEngine: engine,
+			AgentName: "main",
+			SubAgents: []*core.SubAgent{first.AgentAsSubAgent(), second.AgentAsSubAgent(), nil},
+		})
+
+		err := ValidateTeam(main)
+		var teamErr *TeamValidationError
+		if !errors.As(err, &teamErr) {
+			t.Fatalf("ValidateTeam() error = %v, want *TeamValidationError", err)
+		}
+		if len(teamErr.Problems) != 2 {
+			t.Errorf("Expected 2 problems, got %d: %v", len(teamErr.Problems), teamErr.Problems)
+		}
+		if !strings.Contains(err.Error(), "duplicate sub-agent name 'worker'") {
+			t.Errorf("Expected duplicate name problem, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "missing delegate target") {
+			t.Errorf("Expected missing delegate target problem, got %v", err)
+		}
+	})
+
+	t.Run("cycle and nil engine", func(t *testing.T) {
+		a := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "a"})
+		b := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "b"})
+		a.subAgents = []*core.SubAgent{b.AgentAsSubAgent()}
+		b.subAgents = []*core.SubAgent{a.AgentAsSubAgent()}
+		b.config.LLMEngine = nil
+
+		err := ValidateTeam(a)
+		if err == nil {
+			t.Fatal("ValidateTeam() expected error but got nil")
+		}
+		if !strings.Contains(err.Error(), "a -> b -> a: delegation cycle detected") {
+			t.Errorf("Expected cycle problem, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "a -> b: LLMEngine is nil") {
+			t.Errorf("Expected nil engine problem, got %v", err)
+		}
+	})
+
+	t.Run("NewAgent panics when ValidateTeam is set", func(t *testing.T) {
+		worker := NewAgent(&AgentConfig{LLMEngine: engine, AgentName: "worker"})
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("NewAgent() expected panic but did not panic")
+			}
+		}()
+		_ = NewAgent(&AgentConfig{
+			LLMEngine:    engine,
+			AgentName:    "main",
+			SubAgents:    []*core.SubAgent{worker.AgentAsSubAgent(), worker.AgentAsSubAgent()},
+			ValidateTeam: true,
+		})
+	})
+}
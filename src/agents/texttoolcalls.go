@@ -0,0 +1,88 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// textToolCallPattern matches the blocks some models write tool calls in when they
+// ignore the function-calling API: fenced code blocks (```json ... ```) and
+// <tool_call>...</tool_call> tags.
+var textToolCallPattern = regexp.MustCompile("(?s)```(?:json|tool_call)?\\s*\\n(.*?)```|<tool_call>(.*?)</tool_call>")
+
+// textToolCall is the JSON shape of a textual tool call. Llama-style models
+// name the arguments "parameters".
+type textToolCall struct {
+	Name       string          `json:"name"`
+	Arguments  json.RawMessage `json:"arguments"`
+	Parameters json.RawMessage `json:"parameters"`
+}
+
+// parseTextToolCalls extracts the tool calls written as text in content (see
+// AgentConfig.ParseTextToolCalls). Blocks that are not JSON objects (or arrays
+// of objects) naming one of the agent's tools are ignored, so ordinary code
+// blocks in an answer are not mistaken for tool calls.
+func (a *Agent) parseTextToolCalls(content string) []llms.ToolCall {
+	var toolCalls []llms.ToolCall
+	for _, match := range textToolCallPattern.FindAllStringSubmatch(content, -1) {
+		block := strings.TrimSpace(match[1] + match[2])
+
+		var candidates []textToolCall
+		if strings.HasPrefix(block, "[") {
+			if err := json.Unmarshal([]byte(block), &candidates); err != nil {
+				continue
+			}
+		} else {
+			var candidate textToolCall
+			if err := json.Unmarshal([]byte(block), &candidate); err != nil {
+				continue
+			}
+			candidates = append(candidates, candidate)
+		}
+
+		for _, candidate := range candidates {
+			if candidate.Name == "" || a.findTool(candidate.Name) == nil {
+				continue
+			}
+			args, ok := textToolCallArguments(candidate)
+			if !ok {
+				continue
+			}
+			toolCalls = append(toolCalls, llms.ToolCall{
+				ID:        fmt.Sprintf("text_call_%d", len(toolCalls)+1),
+				Name:      candidate.Name,
+				Arguments: args,
+			})
+		}
+	}
+	return toolCalls
+}
+
+// textToolCallArguments decodes the arguments of a textual tool call, given as a
+// JSON object or as a string holding one (the OpenAI wire format).
+func textToolCallArguments(call textToolCall) (map[string]any, bool) {
+	raw := call.Arguments
+	if len(raw) == 0 {
+		raw = call.Parameters
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return map[string]any{}, true
+	}
+
+	var encoded string
+	if err := json.Unmarshal(raw, &encoded); err == nil {
+		raw = json.RawMessage(encoded)
+	}
+	var args map[string]any
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, false
+	}
+	if args == nil {
+		args = map[string]any{}
+	}
+	return args, true
+}
@@ -0,0 +1,150 @@
+package agents
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// ToolCache stores tool results so repeated identical tool calls can skip execution.
+//
+// Implementations must be safe for concurrent use; a cache can be shared by several agents.
+type ToolCache interface {
+	// Get returns the result stored under key and whether it was found.
+	Get(key string) (llms.ToolResult, bool)
+
+	// Set stores result under key.
+	Set(key string, result llms.ToolResult)
+}
+
+// CacheableTool is implemented by tools that can opt out of result caching.
+// Tools that do not implement it are cacheable.
+type CacheableTool interface {
+	Cacheable() bool
+}
+
+// ToolCacheKey returns the cache key of a tool call: a hash of the tool name and its
+// canonicalized arguments. Argument order does not change the key.
+//
+// Parameters:
+//   - name: The tool name
+//   - args: The tool call arguments
+//
+// Returns:
+//   - string: The hex-encoded SHA-256 key
+func ToolCacheKey(name string, args map[string]any) string {
+	// json.Marshal sorts map keys, which canonicalizes nested objects too
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		encoded = []byte(err.Error())
+	}
+	h := sha256.New()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write(encoded)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// MemoryToolCache is an in-memory ToolCache whose entries expire after a TTL.
+type MemoryToolCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]memoryToolCacheEntry
+}
+
+type memoryToolCacheEntry struct {
+	result  llms.ToolResult
+	expires time.Time
+}
+
+// NewMemoryToolCache creates an in-memory tool cache.
+//
+// Parameters:
+//   - ttl: How long an entry stays valid. 0 or less keeps entries forever.
+//
+// Returns:
+//   - *MemoryToolCache: A new, empty cache
+func NewMemoryToolCache(ttl time.Duration) *MemoryToolCache {
+	return &MemoryToolCache{
+		ttl:     ttl,
+		entries: make(map[string]memoryToolCacheEntry),
+	}
+}
+
+// Get returns the result stored under key if it has not expired.
+func (c *MemoryToolCache) Get(key string) (llms.ToolResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return llms.ToolResult{}, false
+	}
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return llms.ToolResult{}, false
+	}
+	return entry.result, true
+}
+
+// Set stores result under key for the cache TTL.
+func (c *MemoryToolCache) Set(key string, result llms.ToolResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := memoryToolCacheEntry{result: result}
+	if c.ttl > 0 {
+		entry.expires = time.Now().Add(c.ttl)
+	}
+	c.entries[key] = entry
+}
+
+// isCacheable reports whether results of the named tool may be cached.
+func (a *Agent) isCacheable(name string) bool {
+	if a.toolCache == nil {
+		return false
+	}
+	tool := a.findTool(name)
+	if tool == nil {
+		return false
+	}
+	if ct, ok := tool.(CacheableTool); ok {
+		return ct.Cacheable()
+	}
+	return true
+}
+
+// cachedToolResult looks up a previous result for the tool call.
+// The returned result is bound to the current tool call ID and marked as cached.
+func (a *Agent) cachedToolResult(toolCall llms.ToolCall) (llms.ToolResult, bool) {
+	if !a.isCacheable(toolCall.Name) {
+		return llms.ToolResult{}, false
+	}
+	result, ok := a.toolCache.Get(ToolCacheKey(toolCall.Name, toolCall.Arguments))
+	if !ok {
+		return llms.ToolResult{}, false
+	}
+
+	result.ToolCallID = toolCall.ID
+	result.Metadata = maps.Clone(result.Metadata)
+	if result.Metadata == nil {
+		result.Metadata = map[string]any{}
+	}
+	result.Metadata[llms.ToolMetadataDuration] = int64(0)
+	result.Metadata[llms.ToolMetadataSource] = a.Name()
+	result.Metadata[llms.ToolMetadataCached] = true
+	return result, true
+}
+
+// cacheToolResult stores a successful tool result. Failures are never cached.
+func (a *Agent) cacheToolResult(toolCall llms.ToolCall, result llms.ToolResult) {
+	if !result.Success || !a.isCacheable(toolCall.Name) {
+		return
+	}
+	a.toolCache.Set(ToolCacheKey(toolCall.Name, toolCall.Arguments), result)
+}
@@ -0,0 +1,180 @@
+package agents
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+	"github.com/thinktwice/agentForge/src/tools"
+)
+
+// newCountingTool returns a tool that counts its executions.
+func newCountingTool(name string, calls *int) llms.Tool {
+	return core.NewTool(name, "Counts calls", "", "",
+		[]core.Parameter{{Name: "x", Type: "number"}},
+		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+			*calls++
+			return core.NewSuccessResponse("computed")
+		},
+	)
+}
+
+func TestAgent_ToolCache(t *testing.T) {
+	args := map[string]any{"x": float64(1)}
+
+	t.Run("second identical call hits the cache", func(t *testing.T) {
+		engine := newMockEngine(
+			toolCallTurn(llms.ToolCall{ID: "call_1", Name: "expensive", Arguments: args}),
+			toolCallTurn(llms.ToolCall{ID: "call_2", Name: "expensive", Arguments: args}),
+			contentTurn("done"),
+		)
+		agent := NewAgent(&AgentConfig{
+			LLMEngine: engine,
+			AgentName: "cached agent",
+			ToolCache: NewMemoryToolCache(time.Minute),
+		})
+		calls := 0
+		agent.SetTools(append(agent.GetTools(), newCountingTool("expensive", &calls)))
+
+		if _, err := agent.Chat("compute twice"); err != nil {
+			t.Fatalf("Chat() unexpected error = %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("Expected the tool to run once, ran %d times", calls)
+		}
+
+		var second llms.UnifiedMessage
+		for _, msg := range agent.history.History() {
+			if msg.Role() == llms.MessageRoleTool && msg.ToolCallID() == "call_2" {
+				second = msg
+			}
+		}
+		if second.Content() != "computed" {
+			t.Errorf("Expected cached result for call_2, got %q", second.Content())
+		}
+	})
+
+	t.Run("non-cacheable tool always executes", func(t *testing.T) {
+		engine := newMockEngine(
+			toolCallTurn(llms.ToolCall{ID: "call_1", Name: "volatile", Arguments: args}),
+			toolCallTurn(llms.ToolCall{ID: "call_2", Name: "volatile", Arguments: args}),
+			contentTurn("done"),
+		)
+		agent := NewAgent(&AgentConfig{
+			LLMEngine: engine,
+			AgentName: "uncached agent",
+			ToolCache: NewMemoryToolCache(time.Minute),
+		})
+		calls := 0
+		tool := newCountingTool("volatile", &calls)
+		tool.(*core.Tool).SetCacheable(false)
+		agent.SetTools(append(agent.GetTools(), tool))
+
+		if _, err := agent.Chat("compute twice"); err != nil {
+			t.Fatalf("Chat() unexpected error = %v", err)
+		}
+		if calls != 2 {
+			t.Errorf("Expected the tool to run twice, ran %d times", calls)
+		}
+	})
+
+	t.Run("memory tool always executes", func(t *testing.T) {
+		store := map[string]any{"operation": "store", "text": "The user likes tea"}
+		recall := map[string]any{"operation": "recall", "text": "drinks"}
+		engine := newMockEngine(
+			toolCallTurn(llms.ToolCall{ID: "call_1", Name: "memory", Arguments: store}),
+			toolCallTurn(llms.ToolCall{ID: "call_2", Name: "memory", Arguments: recall}),
+			toolCallTurn(llms.ToolCall{ID: "call_3", Name: "memory", Arguments: store}),
+			toolCallTurn(llms.ToolCall{ID: "call_4", Name: "memory", Arguments: recall}),
+			contentTurn("done"),
+		)
+		memories := tools.NewMemoryVectorStore()
+		agent := NewAgent(&AgentConfig{
+			LLMEngine: engine,
+			AgentName: "remembering agent",
+			Tools:     []llms.Tool{tools.NewMemoryTool(memories, unitEmbedder{})},
+			ToolCache: NewMemoryToolCache(time.Minute),
+		})
+
+		if _, err := agent.Chat("remember twice"); err != nil {
+			t.Fatalf("Chat() unexpected error = %v", err)
+		}
+		if memories.Len() != 2 {
+			t.Errorf("Expected both stores to run, got %d memories", memories.Len())
+		}
+
+		recalls := make(map[string]string)
+		for _, msg := range agent.history.History() {
+			if msg.Role() == llms.MessageRoleTool {
+				recalls[msg.ToolCallID()] = msg.Content()
+			}
+		}
+		if recalls["call_4"] == recalls["call_2"] {
+			t.Errorf("Expected the second recall to see the new memory, got %q", recalls["call_4"])
+		}
+	})
+}
+
+// unitEmbedder embeds every text as the same vector.
+type unitEmbedder struct{}
+
+func (unitEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i := range texts {
+		vectors[i] = []float32{1}
+	}
+	return vectors, nil
+}
+
+func TestAgent_CachedToolResult(t *testing.T) {
+	agent := NewAgent(&AgentConfig{
+		LLMEngine: newMockEngine(),
+		AgentName: "cached agent",
+		ToolCache: NewMemoryToolCache(0),
+	})
+	calls := 0
+	agent.SetTools(append(agent.GetTools(), newCountingTool("expensive", &calls)))
+
+	run := agent.newRun(context.Background(), agent.history, llms.GenerationOptions{}, 0, 0)
+	first := agent.executeTool(run, llms.ToolCall{ID: "call_1", Name: "expensive", Arguments: map[string]any{"x": float64(1)}})
+	second := agent.executeTool(run, llms.ToolCall{ID: "call_2", Name: "expensive", Arguments: map[string]any{"x": float64(1)}})
+
+	if second.ToolCallID != "call_2" || second.Result != first.Result {
+		t.Errorf("Expected cached result bound to call_2, got %+v", second)
+	}
+	if second.Metadata[llms.ToolMetadataCached] != true {
+		t.Errorf("Expected cached metadata on hit, got %v", second.Metadata)
+	}
+	if _, ok := first.Metadata[llms.ToolMetadataCached]; ok {
+		t.Errorf("Expected no cached metadata on miss, got %v", first.Metadata)
+	}
+}
+
+func TestToolCacheKey(t *testing.T) {
+	a := ToolCacheKey("search", map[string]any{"query": "go", "limit": float64(5)})
+	b := ToolCacheKey("search", map[string]any{"limit": float64(5), "query": "go"})
+	if a != b {
+		t.Error("Expected argument order not to change the key")
+	}
+	if a == ToolCacheKey("lookup", map[string]any{"query": "go", "limit": float64(5)}) {
+		t.Error("Expected different tools to have different keys")
+	}
+	if a == ToolCacheKey("search", map[string]any{"query": "go", "limit": float64(6)}) {
+		t.Error("Expected different arguments to have different keys")
+	}
+}
+
+func TestMemoryToolCache_TTL(t *testing.T) {
+	cache := NewMemoryToolCache(20 * time.Millisecond)
+	cache.Set("key", llms.ToolResult{Result: "value", Success: true})
+
+	if result, ok := cache.Get("key"); !ok || result.Result != "value" {
+		t.Fatalf("Expected fresh entry, got %+v (found=%t)", result, ok)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if _, ok := cache.Get("key"); ok {
+		t.Error("Expected entry to expire after the TTL")
+	}
+}
@@ -0,0 +1,70 @@
+package agents
+
+import (
+	"github.com/thinktwice/agentForge/src/llms"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Span names emitted by agents when AgentConfig.Tracer is set.
+const (
+	// SpanAgentChat covers one chat invocation, from the user message to the final answer.
+	SpanAgentChat = "agent.chat"
+	// SpanLLMCall covers one streamed LLM request inside the tool loop.
+	SpanLLMCall = "agent.llm_call"
+	// SpanToolCall covers one tool execution.
+	SpanToolCall = "agent.tool_call"
+)
+
+// Span attribute keys.
+const (
+	AttrAgentName        = attribute.Key("agentforge.agent.name")
+	AttrAgentTrace       = attribute.Key("agentforge.agent.trace")
+	AttrDelegationDepth  = attribute.Key("agentforge.delegation.depth")
+	AttrToolName         = attribute.Key("agentforge.tool.name")
+	AttrToolSuccess      = attribute.Key("agentforge.tool.success")
+	AttrToolCached       = attribute.Key("agentforge.tool.cached")
+	AttrToolCalls        = attribute.Key("agentforge.llm.tool_calls")
+	AttrPromptTokens     = attribute.Key("agentforge.llm.prompt_tokens")
+	AttrCompletionTokens = attribute.Key("agentforge.llm.completion_tokens")
+	AttrTotalTokens      = attribute.Key("agentforge.llm.total_tokens")
+)
+
+// noopTracer is used when no tracer is configured so spans cost next to nothing.
+var noopTracer = noop.NewTracerProvider().Tracer("")
+
+// tracer returns the configured tracer or a no-op tracer.
+func (a *Agent) tracer() trace.Tracer {
+	if a.config.Tracer != nil {
+		return a.config.Tracer
+	}
+	return noopTracer
+}
+
+// agentAttributes returns the attributes identifying this agent on every span.
+func (a *Agent) agentAttributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		AttrAgentName.String(a.Name()),
+		AttrAgentTrace.String(a.Trace()),
+	}
+}
+
+// endSpan records err on span (if any) and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// usageAttributes converts token usage to span attributes.
+func usageAttributes(usage llms.Usage) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		AttrPromptTokens.Int(usage.PromptTokens),
+		AttrCompletionTokens.Int(usage.CompletionTokens),
+		AttrTotalTokens.Int(usage.TotalTokens),
+	}
+}
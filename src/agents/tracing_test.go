@@ -0,0 +1,149 @@
+package agents
+
+import (
+	"context"
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestTracer(t *testing.T) (*tracetest.InMemoryExporter, *sdktrace.TracerProvider) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	return exporter, provider
+}
+
+// spanAttr returns the value of an attribute on a recorded span.
+func spanAttr(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestAgent_Tracing(t *testing.T) {
+	exporter, provider := newTestTracer(t)
+
+	engine := newMockEngine(
+		toolCallTurn(llms.ToolCall{ID: "call_1", Name: "foo", Arguments: map[string]any{"echo": "hi"}}),
+		contentTurn("done"),
+	)
+	agent := NewAgent(&AgentConfig{
+		LLMEngine: engine,
+		AgentName: "traced agent",
+		Tracer:    provider.Tracer("test"),
+	})
+
+	if _, err := agent.Chat("call foo"); err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	var chat tracetest.SpanStub
+	var llmCalls, toolCalls []tracetest.SpanStub
+	for _, s := range spans {
+		switch s.Name {
+		case SpanAgentChat:
+			chat = s
+		case SpanLLMCall:
+			llmCalls = append(llmCalls, s)
+		case SpanToolCall:
+			toolCalls = append(toolCalls, s)
+		}
+	}
+
+	if !chat.SpanContext.IsValid() {
+		t.Fatalf("Expected an %s span, got %d spans", SpanAgentChat, len(spans))
+	}
+	if chat.Parent.IsValid() {
+		t.Error("Expected chat span to be a root span")
+	}
+	if got := spanAttr(chat, AttrAgentName).AsString(); got != "traced agent" {
+		t.Errorf("Expected agent name attribute, got %q", got)
+	}
+	if len(llmCalls) != 2 || len(toolCalls) != 1 {
+		t.Fatalf("Expected 2 LLM spans and 1 tool span, got %d and %d", len(llmCalls), len(toolCalls))
+	}
+
+	for _, s := range append(llmCalls, toolCalls...) {
+		if s.Parent.SpanID() != chat.SpanContext.SpanID() {
+			t.Errorf("Expected %s span to be a child of the chat span", s.Name)
+		}
+	}
+	if got := spanAttr(llmCalls[0], AttrTotalTokens).AsInt64(); got != 15 {
+		t.Errorf("Expected total tokens 15 on LLM span, got %d", got)
+	}
+	if got := spanAttr(llmCalls[0], AttrToolCalls).AsInt64(); got != 1 {
+		t.Errorf("Expected 1 tool call on first LLM span, got %d", got)
+	}
+	if got := spanAttr(toolCalls[0], AttrToolName).AsString(); got != "foo" {
+		t.Errorf("Expected tool name 'foo' on tool span, got %q", got)
+	}
+	if !spanAttr(toolCalls[0], AttrToolSuccess).AsBool() {
+		t.Error("Expected tool success attribute to be true")
+	}
+}
+
+func TestAgent_Tracing_DelegationJoinsTrace(t *testing.T) {
+	exporter, provider := newTestTracer(t)
+	tracer := provider.Tracer("test")
+
+	worker := NewAgent(&AgentConfig{
+		LLMEngine: newMockEngine(contentTurn("worked")),
+		AgentName: "worker",
+		Tracer:    tracer,
+	})
+	main := NewAgent(&AgentConfig{
+		LLMEngine: newMockEngine(
+			toolCallTurn(llms.ToolCall{ID: "call_1", Name: "delegate", Arguments: map[string]any{"subAgent": "worker", "message": "work"}}),
+			contentTurn("done"),
+		),
+		AgentName: "main",
+		SubAgents: []*core.SubAgent{worker.AgentAsSubAgent()},
+		Tracer:    tracer,
+	})
+
+	if _, err := main.Chat("delegate please"); err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+
+	var delegateSpan, workerChat tracetest.SpanStub
+	for _, s := range exporter.GetSpans() {
+		if s.Name == SpanToolCall && spanAttr(s, AttrToolName).AsString() == "delegate" {
+			delegateSpan = s
+		}
+		if s.Name == SpanAgentChat && spanAttr(s, AttrAgentName).AsString() == "worker" {
+			workerChat = s
+		}
+	}
+	if !delegateSpan.SpanContext.IsValid() || !workerChat.SpanContext.IsValid() {
+		t.Fatal("Expected both the delegate tool span and the worker chat span")
+	}
+	if workerChat.Parent.SpanID() != delegateSpan.SpanContext.SpanID() {
+		t.Error("Expected the worker chat span to be a child of the delegate tool span")
+	}
+	if workerChat.SpanContext.TraceID() != delegateSpan.SpanContext.TraceID() {
+		t.Error("Expected the worker to join the main agent's trace")
+	}
+	if got := spanAttr(workerChat, AttrDelegationDepth).AsInt64(); got != 1 {
+		t.Errorf("Expected delegation depth 1 on worker span, got %d", got)
+	}
+}
+
+func TestAgent_Tracing_DisabledByDefault(t *testing.T) {
+	agent := NewAgent(&AgentConfig{LLMEngine: newMockEngine(), AgentName: "untraced"})
+	if _, err := agent.Chat("hi"); err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+	if agent.tracer() != noopTracer {
+		t.Error("Expected the no-op tracer when none is configured")
+	}
+}
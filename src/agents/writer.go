@@ -0,0 +1,76 @@
+package agents
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// StreamCallbacks are the optional callbacks of ChatStreamToWithCallbacks. They
+// are called from the calling goroutine, for the agent and its delegated
+// sub-agents; nil callbacks are skipped.
+type StreamCallbacks struct {
+	// OnToolCall is called when an agent requests a tool call
+	OnToolCall func(agentName string, toolCall llms.ToolCall)
+	// OnToolResult is called with the result of each executed tool call
+	OnToolResult func(agentName string, result llms.ToolResult)
+}
+
+// ChatStreamTo sends a message and writes the answer to w as it is streamed,
+// for CLI-like consumers that do not need the chunk channel. Only the agent's own
+// content is written: reasoning, tool progress and the content of delegated
+// sub-agents are not.
+//
+// Parameters:
+//   - w: The writer receiving the content deltas
+//   - message: The user message to send
+//
+// Returns:
+//   - error: An error if the agent loop failed, or the first write error (which stops the run)
+func (a *Agent) ChatStreamTo(w io.Writer, message string) error {
+	return a.ChatStreamToWithCallbacks(w, message, StreamCallbacks{})
+}
+
+// ChatStreamToWithCallbacks is like ChatStreamTo with callbacks for tool events.
+//
+// Parameters:
+//   - w: The writer receiving the content deltas
+//   - message: The user message to send
+//   - callbacks: The tool event callbacks
+//
+// Returns:
+//   - error: An error if the agent loop failed, or the first write error (which stops the run)
+func (a *Agent) ChatStreamToWithCallbacks(w io.Writer, message string, callbacks StreamCallbacks) error {
+	responseCh := a.ChatStream(message)
+
+	for chunk := range responseCh.Start() {
+		switch {
+		case chunk.Status == llms.StatusError:
+			if chunk.Err != nil {
+				return fmt.Errorf("agent error: %w", chunk.Err)
+			}
+			return fmt.Errorf("agent error: %s", chunk.Content)
+		case chunk.Status == llms.StatusToolCall:
+			if callbacks.OnToolCall != nil {
+				for _, toolCall := range chunk.ToolCalls {
+					callbacks.OnToolCall(chunk.AgentName, toolCall)
+				}
+			}
+		case chunk.Status == llms.StatusToolResult:
+			if callbacks.OnToolResult != nil {
+				for _, result := range chunk.ToolResults {
+					callbacks.OnToolResult(chunk.AgentName, result)
+				}
+			}
+		case chunk.AgentName != a.Name():
+			// Content of a delegated sub-agent
+		case chunk.Type == llms.TypeContent && chunk.Trace != llms.TraceToolProgress && chunk.Content != "":
+			if _, err := io.WriteString(w, chunk.Content); err != nil {
+				responseCh.Stop()
+				return fmt.Errorf("failed to write the answer: %w", err)
+			}
+		}
+	}
+	return nil
+}
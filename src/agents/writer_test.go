@@ -0,0 +1,81 @@
+package agents
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+func TestAgent_ChatStreamTo(t *testing.T) {
+	agent := NewAgent(&AgentConfig{
+		LLMEngine: newMockEngine(contentTurn("Hello", ", ", "world!")),
+		AgentName: "writer agent",
+	})
+
+	var buf bytes.Buffer
+	if err := agent.ChatStreamTo(&buf, "Hi"); err != nil {
+		t.Fatalf("ChatStreamTo() unexpected error = %v", err)
+	}
+	if buf.String() != "Hello, world!" {
+		t.Errorf("Written content = %q, want %q", buf.String(), "Hello, world!")
+	}
+}
+
+func TestAgent_ChatStreamToWithCallbacks(t *testing.T) {
+	agent := NewAgent(&AgentConfig{
+		LLMEngine: newMockEngine(
+			toolCallTurn(llms.ToolCall{ID: "call_1", Name: "foo", Arguments: map[string]any{"echo": "hello"}}),
+			contentTurn("The tool said hello"),
+		),
+		AgentName: "writer agent",
+	})
+
+	var buf bytes.Buffer
+	var events []string
+	err := agent.ChatStreamToWithCallbacks(&buf, "Call foo", StreamCallbacks{
+		OnToolCall: func(agentName string, toolCall llms.ToolCall) {
+			events = append(events, agentName+" calls "+toolCall.Name)
+		},
+		OnToolResult: func(agentName string, result llms.ToolResult) {
+			events = append(events, agentName+" got "+result.ToolName)
+		},
+	})
+	if err != nil {
+		t.Fatalf("ChatStreamToWithCallbacks() unexpected error = %v", err)
+	}
+	if buf.String() != "The tool said hello" {
+		t.Errorf("Written content = %q", buf.String())
+	}
+	want := []string{"writer agent calls foo", "writer agent got foo"}
+	if len(events) != 2 || events[0] != want[0] || events[1] != want[1] {
+		t.Errorf("Events = %q, want %q", events, want)
+	}
+}
+
+// failingWriter fails every write.
+type failingWriter struct{ err error }
+
+func (w failingWriter) Write(p []byte) (int, error) { return 0, w.err }
+
+func TestAgent_ChatStreamTo_Errors(t *testing.T) {
+	t.Run("write error", func(t *testing.T) {
+		agent := NewAgent(&AgentConfig{LLMEngine: newMockEngine(contentTurn("Hello")), AgentName: "writer agent"})
+		broken := errors.New("broken pipe")
+		if err := agent.ChatStreamTo(failingWriter{err: broken}, "Hi"); !errors.Is(err, broken) {
+			t.Errorf("ChatStreamTo() error = %v, want %v", err, broken)
+		}
+	})
+
+	t.Run("run error", func(t *testing.T) {
+		agent := NewAgent(&AgentConfig{
+			LLMEngine: newMockEngine(mockTurn{err: errors.New("rate limited")}),
+			AgentName: "writer agent",
+		})
+		var buf bytes.Buffer
+		if err := agent.ChatStreamTo(&buf, "Hi"); err == nil {
+			t.Error("Expected the run error")
+		}
+	})
+}
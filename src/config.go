@@ -2,6 +2,7 @@ package agentforge
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/joho/godotenv"
@@ -10,7 +11,7 @@ import (
 // Config holds the application configuration loaded from environment variables.
 //
 // Configuration values are loaded from:
-//  1. .env file (if present)
+//  1. .env file (if present; see AF_ENV_FILE and AF_DISABLE_DOTENV)
 //  2. System environment variables (takes precedence over .env)
 type Config struct {
 	// AF_LOG_LEVEL defines the logging level for the application.
@@ -29,26 +30,72 @@ type Config struct {
 	// AF_OPENAI_API_KEY is the API key for OpenAI LLM provider.
 	// Optional - only required if using OpenAI models
 	AFOpenAIAPIKey string
+
+	// AF_AZURE_OPENAI_API_KEY is the API key for Azure OpenAI deployments.
+	// Optional - only required if using Azure OpenAI
+	AFAzureOpenAIAPIKey string
+
+	// AF_TAVILY_API_KEY is the API key of the Tavily web search API.
+	// Optional - only required if using the default search tool provider
+	AFTavilyAPIKey string
+
+	// AF_REDIS_URL is the connection string of the Redis persistence backend.
+	// Default: redis://localhost:6379/0
+	AFRedisURL string
+
+	// AF_HISTORY_DIR is the directory of the "json" persistence backend files.
+	// Default: ./history
+	AFHistoryDir string
+
+	// AF_ENV_FILE is the .env file to load instead of searching the current
+	// directory and its parents. Read from the system environment only.
+	// Optional - the file must exist when set
+	AFEnvFile string
+
+	// AF_DISABLE_DOTENV disables .env files: values come from the system
+	// environment only. Read from the system environment only.
+	// Default: false
+	AFDisableDotenv bool
 }
 
 // NewConfig creates a new Config instance by loading environment variables.
 //
-// It attempts to load a .env file from the current directory first, then
-// reads configuration values from environment variables. Environment variables
-// take precedence over .env file values.
+// It attempts to load a .env file from the current directory (or the file named
+// by AF_ENV_FILE) first, then reads configuration values from environment
+// variables. Environment variables take precedence over .env file values. No
+// .env file is read when AF_DISABLE_DOTENV is true.
 //
 // Returns:
 //   - *Config: The loaded configuration
 //   - error: An error if configuration loading fails
 func NewConfig() (*Config, error) {
-	// Try to load .env file (ignore error if file doesn't exist)
-	_ = godotenv.Load()
+	envFile := os.Getenv(EnvFileVar)
+	disableDotenv := dotenvDisabled()
+
+	switch {
+	case disableDotenv:
+	case envFile != "":
+		if err := godotenv.Load(envFile); err != nil {
+			return nil, fmt.Errorf("failed to load %s %q: %w", EnvFileVar, envFile, err)
+		}
+	default:
+		// Try to load .env file (ignore error if file doesn't exist)
+		_ = godotenv.Load()
+	}
 
 	config := &Config{
 		AFLogLevel:         getEnv("AF_LOG_LEVEL", "INFO"),
 		AFDeepSeekAPIKey:   getEnv("AF_DEEPSEEK_API_KEY", ""),
 		AFTogetherAIAPIKey: getEnv("AF_TOGETHERAI_API_KEY", ""),
 		AFOpenAIAPIKey:     getEnv("AF_OPENAI_API_KEY", ""),
+
+		AFAzureOpenAIAPIKey: getEnv("AF_AZURE_OPENAI_API_KEY", ""),
+		AFTavilyAPIKey:      getEnv("AF_TAVILY_API_KEY", ""),
+		AFRedisURL:          getEnv("AF_REDIS_URL", "redis://localhost:6379/0"),
+		AFHistoryDir:        getEnv("AF_HISTORY_DIR", "./history"),
+
+		AFEnvFile:       envFile,
+		AFDisableDotenv: disableDotenv,
 	}
 
 	// Validate the configuration
@@ -2,6 +2,7 @@ package agentforge
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -206,3 +207,41 @@ func TestGetEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestNewConfig_EnvFile(t *testing.T) {
+	// t.Setenv restores the variables godotenv sets
+	t.Setenv("AF_HISTORY_DIR", "")
+	os.Unsetenv("AF_HISTORY_DIR")
+	t.Setenv(DisableDotenvVar, "")
+
+	envFile := filepath.Join(t.TempDir(), "app.env")
+	if err := os.WriteFile(envFile, []byte("AF_HISTORY_DIR=/data/history\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	t.Setenv(EnvFileVar, envFile)
+
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.AFHistoryDir != "/data/history" {
+		t.Errorf("expected history dir from %s, got %s", envFile, config.AFHistoryDir)
+	}
+	if config.AFEnvFile != envFile || config.AFDisableDotenv {
+		t.Errorf("expected AFEnvFile=%s and dotenv enabled, got %s and %v", envFile, config.AFEnvFile, config.AFDisableDotenv)
+	}
+
+	t.Setenv(EnvFileVar, filepath.Join(t.TempDir(), "missing.env"))
+	if _, err := NewConfig(); err == nil {
+		t.Error("expected error for a missing AF_ENV_FILE")
+	}
+
+	t.Setenv(DisableDotenvVar, "true")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("unexpected error with dotenv disabled: %v", err)
+	}
+	if !config.AFDisableDotenv {
+		t.Error("expected AFDisableDotenv to be true")
+	}
+}
@@ -17,6 +17,20 @@ type AgentContext struct {
 	SubAgents []*SubAgent
 }
 
+// Agent context keys for per-run delegation tracking.
+const (
+	// ContextDelegationDepth is the depth of the running agent in the delegation chain (int, 0 for the root).
+	ContextDelegationDepth = "delegationDepth"
+	// ContextMaxDelegationDepth is the maximum allowed delegation depth (int, 0 means unlimited).
+	ContextMaxDelegationDepth = "maxDelegationDepth"
+	// ContextTraceContext is the context.Context of the running tool call, carrying its
+	// tracing span. Tools starting nested work should use it as the parent context.
+	ContextTraceContext = "traceContext"
+	// ContextCoerceArguments makes core.Tool parse string values of number and boolean
+	// parameters (bool, see agents.AgentConfig.CoerceToolArguments).
+	ContextCoerceArguments = "coerceArguments"
+)
+
 // BuildContext converts the AgentContext struct to a map[string]any and merges
 // in the session-specific responseCh parameter.
 //
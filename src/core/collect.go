@@ -0,0 +1,117 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// ConversationResult is the outcome of a run collected by CollectResponse.
+type ConversationResult struct {
+	// AgentName is the agent that answered (the agent of the first chunk)
+	AgentName string
+	// Content is the final answer, or the answer streamed so far if the run did
+	// not complete
+	Content string
+	// Completed reports whether the agent sent its completion chunk
+	Completed bool
+	// Truncated reports whether the answer was cut at the agent's MaxOutputChars
+	Truncated bool
+	// ToolCalls are the tool calls requested during the run, sub-agents included
+	ToolCalls []llms.ToolCall
+	// ToolResults are the results of the executed tool calls, sub-agents included
+	ToolResults []llms.ToolResult
+	// Usage is the token usage of the run, sub-agents included
+	Usage llms.Usage
+	// UsageByAgent is the token usage of the run per agent name. Agents that
+	// reported no usage are not listed.
+	UsageByAgent map[string]llms.Usage
+}
+
+// CollectResponse reads a run to the end and aggregates it, for callers that do
+// not need the individual chunks.
+//
+// Chunks forwarded from delegated sub-agents contribute their tool calls,
+// results and token usage, but not their content. Their error chunks are
+// skipped: the run goes on with the failed delegation as a tool result. Token
+// usage is also totalled per agent in UsageByAgent.
+//
+// Example:
+//
+//	result, err := core.CollectResponse(agent.ChatStream("Plan my trip").Start())
+//
+// Parameters:
+//   - ch: The chunks of the run (see ResponseCh.Start)
+//
+// Returns:
+//   - ConversationResult: The aggregated run (partial if the run failed)
+//   - error: The error of the first error chunk, if any
+func CollectResponse(ch <-chan ExtendedChunkResponse) (ConversationResult, error) {
+	var result ConversationResult
+	var streamed string
+
+	for chunk := range ch {
+		if chunk.Status == llms.StatusError && isDelegated(chunk, result.AgentName) {
+			continue
+		}
+		if chunk.Status == llms.StatusError {
+			if !result.Completed {
+				result.Content = streamed
+			}
+			if chunk.Err != nil {
+				return result, chunk.Err
+			}
+			return result, errors.New(chunk.Content)
+		}
+
+		if result.AgentName == "" {
+			result.AgentName = chunk.AgentName
+		}
+
+		if chunk.PromptTokens != 0 || chunk.CompletionTokens != 0 || chunk.TotalTokens != 0 {
+			addUsage(&result.Usage, chunk)
+			if result.UsageByAgent == nil {
+				result.UsageByAgent = make(map[string]llms.Usage)
+			}
+			agentUsage := result.UsageByAgent[chunk.AgentName]
+			addUsage(&agentUsage, chunk)
+			result.UsageByAgent[chunk.AgentName] = agentUsage
+		}
+
+		switch {
+		case chunk.Status == llms.StatusToolCall:
+			result.ToolCalls = append(result.ToolCalls, chunk.ToolCalls...)
+		case chunk.Status == llms.StatusToolResult:
+			result.ToolResults = append(result.ToolResults, chunk.ToolResults...)
+		case chunk.AgentName != result.AgentName:
+			// Content of a delegated sub-agent
+		case chunk.Type == llms.TypeContent && chunk.Trace != llms.TraceToolProgress:
+			streamed += chunk.Content
+		case chunk.Type == llms.TypeCompletion:
+			result.Content = chunk.FullContent
+			result.Completed = true
+			result.Truncated = chunk.Truncated
+		}
+	}
+
+	if !result.Completed {
+		result.Content = streamed
+	}
+	return result, nil
+}
+
+// isDelegated reports whether chunk was forwarded from a delegated sub-agent of
+// the agent answering the run.
+func isDelegated(chunk ExtendedChunkResponse, agentName string) bool {
+	if chunk.DelegationID != "" {
+		return true
+	}
+	return agentName != "" && chunk.AgentName != "" && chunk.AgentName != agentName
+}
+
+// addUsage adds the token usage of a chunk to usage.
+func addUsage(usage *llms.Usage, chunk ExtendedChunkResponse) {
+	usage.PromptTokens += chunk.PromptTokens
+	usage.CompletionTokens += chunk.CompletionTokens
+	usage.TotalTokens += chunk.TotalTokens
+}
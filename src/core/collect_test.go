@@ -0,0 +1,112 @@
+package core_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// chunkStream returns a closed channel holding the chunks.
+func chunkStream(chunks ...core.ExtendedChunkResponse) <-chan core.ExtendedChunkResponse {
+	ch := make(chan core.ExtendedChunkResponse, len(chunks))
+	for _, chunk := range chunks {
+		ch <- chunk
+	}
+	close(ch)
+	return ch
+}
+
+func TestCollectResponse(t *testing.T) {
+	toolCall := llms.ToolCall{ID: "call_1", Name: "delegate", Arguments: map[string]any{"agentName": "researcher"}}
+	subCall := llms.ToolCall{ID: "call_2", Name: "search", Arguments: map[string]any{"query": "paris"}}
+
+	result, err := core.CollectResponse(chunkStream(
+		core.ExtendedChunkResponse{AgentName: "main", Type: llms.TypeContent, Status: llms.StatusStreaming, Content: "Let me check. "},
+		core.ExtendedChunkResponse{AgentName: "main", Type: llms.TypeToolCall, Status: llms.StatusToolCall, ToolCalls: []llms.ToolCall{toolCall}},
+		core.ExtendedChunkResponse{AgentName: "researcher", Type: llms.TypeToolCall, Status: llms.StatusToolCall, ToolCalls: []llms.ToolCall{subCall}},
+		core.ExtendedChunkResponse{AgentName: "researcher", Type: llms.TypeToolResult, Status: llms.StatusToolResult, ToolResults: []llms.ToolResult{{ToolCallID: "call_2", Success: true, Result: "Paris is the capital"}}},
+		core.ExtendedChunkResponse{AgentName: "researcher", Type: llms.TypeContent, Status: llms.StatusStreaming, Content: "Paris."},
+		core.ExtendedChunkResponse{AgentName: "researcher", Type: llms.TypeCompletion, Status: llms.StatusCompleted, FullContent: "Paris.", PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12},
+		core.ExtendedChunkResponse{AgentName: "main", Type: llms.TypeToolResult, Status: llms.StatusToolResult, ToolResults: []llms.ToolResult{{ToolCallID: "call_1", Success: true, Result: "Paris."}}},
+		core.ExtendedChunkResponse{AgentName: "main", Type: llms.TypeContent, Status: llms.StatusStreaming, Content: "The capital is Paris."},
+		core.ExtendedChunkResponse{AgentName: "main", Type: llms.TypeCompletion, Status: llms.StatusCompleted, FullContent: "The capital is Paris.", PromptTokens: 30, CompletionTokens: 5, TotalTokens: 35},
+	))
+	if err != nil {
+		t.Fatalf("CollectResponse() unexpected error = %v", err)
+	}
+
+	if result.AgentName != "main" || !result.Completed || result.Content != "The capital is Paris." {
+		t.Errorf("Unexpected answer: agent=%q completed=%v content=%q", result.AgentName, result.Completed, result.Content)
+	}
+	if !reflect.DeepEqual(result.ToolCalls, []llms.ToolCall{toolCall, subCall}) {
+		t.Errorf("ToolCalls = %+v", result.ToolCalls)
+	}
+	if len(result.ToolResults) != 2 || result.ToolResults[0].ToolCallID != "call_2" || result.ToolResults[1].ToolCallID != "call_1" {
+		t.Errorf("ToolResults = %+v", result.ToolResults)
+	}
+	if want := (llms.Usage{PromptTokens: 40, CompletionTokens: 7, TotalTokens: 47}); result.Usage != want {
+		t.Errorf("Usage = %+v, want %+v", result.Usage, want)
+	}
+}
+
+func TestCollectResponse_UsageByAgent(t *testing.T) {
+	result, err := core.CollectResponse(chunkStream(
+		core.ExtendedChunkResponse{AgentName: "main", Type: llms.TypeToolCall, Status: llms.StatusToolCall, PromptTokens: 20, CompletionTokens: 4, TotalTokens: 24},
+		core.ExtendedChunkResponse{AgentName: "system-reasoning", Type: llms.TypeContent, Status: llms.StatusStreaming, Content: "Step 1."},
+		core.ExtendedChunkResponse{AgentName: "system-reasoning", Type: llms.TypeCompletion, Status: llms.StatusCompleted, FullContent: "Step 1.", PromptTokens: 10, CompletionTokens: 3, TotalTokens: 13},
+		core.ExtendedChunkResponse{AgentName: "main", Type: llms.TypeToolResult, Status: llms.StatusToolResult, ToolResults: []llms.ToolResult{{ToolCallID: "call_1", Success: true, Result: "Step 1."}}},
+		core.ExtendedChunkResponse{AgentName: "main", Type: llms.TypeCompletion, Status: llms.StatusCompleted, FullContent: "Done.", PromptTokens: 40, CompletionTokens: 6, TotalTokens: 46},
+	))
+	if err != nil {
+		t.Fatalf("CollectResponse() unexpected error = %v", err)
+	}
+
+	want := map[string]llms.Usage{
+		"main":             {PromptTokens: 60, CompletionTokens: 10, TotalTokens: 70},
+		"system-reasoning": {PromptTokens: 10, CompletionTokens: 3, TotalTokens: 13},
+	}
+	if !reflect.DeepEqual(result.UsageByAgent, want) {
+		t.Errorf("UsageByAgent = %+v, want %+v", result.UsageByAgent, want)
+	}
+	if want := (llms.Usage{PromptTokens: 70, CompletionTokens: 13, TotalTokens: 83}); result.Usage != want {
+		t.Errorf("Usage = %+v, want %+v", result.Usage, want)
+	}
+}
+
+func TestCollectResponse_Error(t *testing.T) {
+	failure := errors.New("rate limited")
+
+	result, err := core.CollectResponse(chunkStream(
+		core.ExtendedChunkResponse{AgentName: "main", Type: llms.TypeContent, Status: llms.StatusStreaming, Content: "The answer"},
+		core.ExtendedChunkResponse{AgentName: "main", Status: llms.StatusError, Content: failure.Error(), Err: failure},
+	))
+	if !errors.Is(err, failure) {
+		t.Errorf("CollectResponse() error = %v, want %v", err, failure)
+	}
+	if result.Completed || result.Content != "The answer" {
+		t.Errorf("Expected the partial answer, got completed=%v content=%q", result.Completed, result.Content)
+	}
+
+	_, err = core.CollectResponse(chunkStream(core.ExtendedChunkResponse{Status: llms.StatusError, Content: "boom"}))
+	if err == nil || err.Error() != "boom" {
+		t.Errorf("Expected an error from the chunk content, got %v", err)
+	}
+}
+
+func TestCollectResponse_SubAgentError(t *testing.T) {
+	result, err := core.CollectResponse(chunkStream(
+		core.ExtendedChunkResponse{AgentName: "main", Status: llms.StatusToolCall, ToolCalls: []llms.ToolCall{{ID: "1", Name: "delegate"}}},
+		core.ExtendedChunkResponse{AgentName: "helper", DelegationID: "d1", Status: llms.StatusError, Content: "helper failed"},
+		core.ExtendedChunkResponse{AgentName: "main", Status: llms.StatusToolResult, ToolResults: []llms.ToolResult{{ToolCallID: "1", Success: false}}},
+		core.ExtendedChunkResponse{AgentName: "main", Type: llms.TypeCompletion, Status: llms.StatusCompleted, FullContent: "The helper failed"},
+	))
+	if err != nil {
+		t.Fatalf("CollectResponse() unexpected error = %v", err)
+	}
+	if !result.Completed || result.Content != "The helper failed" {
+		t.Errorf("Expected the root answer, got completed=%v content=%q", result.Completed, result.Content)
+	}
+}
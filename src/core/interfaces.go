@@ -1,5 +1,7 @@
 package core
 
+import "context"
+
 // SubAgent represents an agent that can be used as a sub-agent
 // for delegation. This interface defines the minimal contract
 // that any agent must satisfy to participate in delegation.
@@ -22,3 +24,16 @@ type SubAgent interface {
 	// and configuration guidance for this agent
 	Troubleshooting() string
 }
+
+// DelegationAwareSubAgent is implemented by sub-agents that track how deep
+// they are in a delegation chain. The delegate tool prefers this method over
+// ChatStream so depth limits hold across the whole agent tree.
+type DelegationAwareSubAgent interface {
+	SubAgent
+
+	// ChatStreamContext is like ChatStream but bound to ctx. When called by the
+	// delegate tool, ctx carries the caller's trace context, so the sub-agent's
+	// spans join the same trace, and the delegation depth of the run, which only
+	// this module can set.
+	ChatStreamContext(ctx context.Context, message string) *ResponseCh
+}
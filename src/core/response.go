@@ -13,19 +13,28 @@ import (
 // This struct includes all properties from ChunkResponse plus agentName and trace
 // fields for enhanced context in multi-agent scenarios.
 type ExtendedChunkResponse struct {
-	Content          string            `json:"content"`                    // Current chunk content
-	Delta            string            `json:"delta"`                      // Incremental delta
-	FullContent      string            `json:"fullContent"`                // Accumulated full content
-	Status           string            `json:"status"`                     // Status: see llms.Status* constants (StatusStreaming, StatusCompleted, etc.)
-	Type             string            `json:"type"`                       // Response type: see llms.Type* constants (TypeContent, TypeCompletion, etc.)
-	ToolCalls        []llms.ToolCall   `json:"toolCalls,omitempty"`        // Tool calls (when Type is "tool-call")
-	ToolExecuting    *llms.ToolCall    `json:"toolExecuting,omitempty"`    // Tool being executed (when Status is "tool-executing")
-	ToolResults      []llms.ToolResult `json:"toolResults,omitempty"`      // Tool execution results (when Status is "tool-result")
-	PromptTokens     int               `json:"promptTokens,omitempty"`     // Input tokens consumed
-	CompletionTokens int               `json:"completionTokens,omitempty"` // Output tokens generated
-	TotalTokens      int               `json:"totalTokens,omitempty"`      // Total tokens used
-	AgentName        string            `json:"agentName"`                  // Name of the agent producing this chunk
-	Trace            string            `json:"trace"`                      // Trace information (e.g., "thinking", "response")
+	Content           string            `json:"content"`                     // Current chunk content
+	Delta             string            `json:"delta"`                       // Incremental delta
+	FullContent       string            `json:"fullContent"`                 // Accumulated full content
+	Status            string            `json:"status"`                      // Status: see llms.Status* constants (StatusStreaming, StatusCompleted, etc.)
+	Type              string            `json:"type"`                        // Response type: see llms.Type* constants (TypeContent, TypeCompletion, etc.)
+	ToolCalls         []llms.ToolCall   `json:"toolCalls,omitempty"`         // Tool calls (when Type is "tool-call")
+	ToolExecuting     *llms.ToolCall    `json:"toolExecuting,omitempty"`     // Tool being executed (when Status is "tool-executing")
+	ToolPlanned       *llms.ToolCall    `json:"toolPlanned,omitempty"`       // Tool that would have been executed (when Status is "tool-planned")
+	ToolResults       []llms.ToolResult `json:"toolResults,omitempty"`       // Tool execution results (when Status is "tool-result")
+	PromptTokens      int               `json:"promptTokens,omitempty"`      // Input tokens consumed
+	CompletionTokens  int               `json:"completionTokens,omitempty"`  // Output tokens generated
+	TotalTokens       int               `json:"totalTokens,omitempty"`       // Total tokens used
+	Speaker           string            `json:"speaker,omitempty"`           // Display name of the agent, on the first content chunk of each assistant message (see agents.AgentConfig.DisplayName)
+	Truncated         bool              `json:"truncated,omitempty"`         // The answer was cut at the agent's MaxOutputChars (completion chunks)
+	SystemFingerprint string            `json:"systemFingerprint,omitempty"` // Backend configuration that served the request, when reported by the provider (completion chunks)
+	AgentName         string            `json:"agentName"`                   // Name of the agent producing this chunk
+	Trace             string            `json:"trace"`                       // Trace information (e.g., "thinking", "response")
+	DelegationID      string            `json:"delegationId,omitempty"`      // ID of the delegation that produced the chunk (empty for the root agent)
+
+	// Err is the error behind an error chunk sent by Start for the Error channel,
+	// for use with errors.Is. Not serialized.
+	Err error `json:"-"`
 }
 
 // ResponseCh manages channels for streaming responses and errors at the Agent level.
@@ -42,8 +51,15 @@ type ResponseCh struct {
 	started bool
 	closed  bool
 	mu      sync.Mutex
+
+	// stop is closed by Stop to ask the producer to end the stream early
+	stop     chan struct{}
+	stopOnce sync.Once
 }
 
+// DefaultResponseBufferSize is the number of chunks a ResponseCh buffers by default.
+const DefaultResponseBufferSize = 10
+
 // NewResponseCh creates a new ResponseCh instance.
 //
 // Parameters:
@@ -53,9 +69,19 @@ type ResponseCh struct {
 // Returns:
 //   - *ResponseCh: A new ResponseCh instance
 func NewResponseCh(agentName string, trace string) *ResponseCh {
+	return NewResponseChWithBuffer(agentName, trace, DefaultResponseBufferSize)
+}
+
+// NewResponseChWithBuffer is like NewResponseCh with the number of chunks the
+// Response channel buffers. A larger buffer lets the producer run ahead of a slow
+// consumer. A zero or negative size uses DefaultResponseBufferSize.
+func NewResponseChWithBuffer(agentName string, trace string, size int) *ResponseCh {
+	if size <= 0 {
+		size = DefaultResponseBufferSize
+	}
 	return &ResponseCh{
-		Response:  make(chan []byte, 10), // Buffered channel
-		Error:     make(chan error, 1),   // Buffered channel for errors
+		Response:  make(chan []byte, size), // Buffered channel
+		Error:     make(chan error, 1),     // Buffered channel for errors
 		agentName: agentName,
 		trace:     trace,
 		started:   false,
@@ -79,27 +105,67 @@ func NewResponseCh(agentName string, trace string) *ResponseCh {
 //   - <-chan ExtendedChunkResponse: A receive-only channel of ExtendedChunkResponse that can be ranged over
 func (arc *ResponseCh) Start() <-chan ExtendedChunkResponse {
 	chunkChan := make(chan ExtendedChunkResponse)
+	stopped := arc.stopped()
 
 	go func() {
 		defer close(chunkChan)
 
+		// send delivers a chunk unless the consumer called Stop
+		send := func(chunk ExtendedChunkResponse) bool {
+			select {
+			case chunkChan <- chunk:
+				return true
+			case <-stopped:
+				return false
+			}
+		}
+
+		errCh := arc.Error
 		for {
 			select {
 			case chunkBytes, ok := <-arc.Response:
 				if !ok {
-					// Response channel closed, streaming complete
+					// Response channel closed, streaming complete. An error
+					// sent before the close may still be buffered.
+					if errCh != nil {
+						if err, ok := <-errCh; ok && err != nil {
+							send(ExtendedChunkResponse{
+								Content:   err.Error(),
+								Status:    llms.StatusError,
+								AgentName: arc.agentName,
+								Trace:     arc.trace,
+								Err:       err,
+							})
+						}
+					}
 					return
 				}
 
 				// Try to deserialize as ExtendedChunkResponse first (may have AgentName/Trace already)
 				var extendedChunk ExtendedChunkResponse
 				if err := json.Unmarshal(chunkBytes, &extendedChunk); err != nil {
+					if text, ok := llms.PlainTextPayload(chunkBytes); ok {
+						// Not a chunk but text (e.g. sent by a tool): surface it as content
+						if !send(ExtendedChunkResponse{
+							Content:   text,
+							Delta:     text,
+							Status:    llms.StatusStreaming,
+							Type:      llms.TypeContent,
+							AgentName: arc.agentName,
+							Trace:     arc.trace,
+						}) {
+							return
+						}
+						continue
+					}
 					// Send error as extended chunk
-					chunkChan <- ExtendedChunkResponse{
+					if !send(ExtendedChunkResponse{
 						Status:    llms.StatusError,
 						Content:   fmt.Sprintf("Error deserializing chunk: %v", err),
 						AgentName: arc.agentName,
 						Trace:     arc.trace,
+					}) {
+						return
 					}
 					continue
 				}
@@ -114,19 +180,30 @@ func (arc *ResponseCh) Start() <-chan ExtendedChunkResponse {
 				}
 
 				// Send chunk
-				chunkChan <- extendedChunk
+				if !send(extendedChunk) {
+					return
+				}
 
-			case err := <-arc.Error:
+			case err, ok := <-errCh:
+				if !ok {
+					// Error channel closed: keep draining buffered chunks
+					errCh = nil
+					continue
+				}
 				if err != nil {
 					// Send error as extended chunk
-					chunkChan <- ExtendedChunkResponse{
+					send(ExtendedChunkResponse{
 						Content:   err.Error(),
 						Status:    llms.StatusError,
 						AgentName: arc.agentName,
 						Trace:     arc.trace,
-					}
+						Err:       err,
+					})
 				}
 				return
+
+			case <-stopped:
+				return
 			}
 		}
 	}()
@@ -151,6 +228,56 @@ func (arc *ResponseCh) Close() {
 	arc.closed = true
 }
 
+// Stop asks the producer to end the stream early, e.g. when a UI's "stop
+// generating" button is pressed or the consumer breaks out of its range loop.
+//
+// The channel returned by Start is closed promptly and the chunks still sent by
+// the producer are discarded, so it never blocks. Agents cancel the upstream LLM
+// stream and end the run without an error, saving the content streamed so far.
+//
+// Safe to call multiple times and after the stream has completed.
+func (arc *ResponseCh) Stop() {
+	stop := arc.stopped()
+	arc.stopOnce.Do(func() {
+		close(stop)
+		go arc.discard()
+	})
+}
+
+// Stopped returns a channel that is closed when Stop is called.
+//
+// Producers select on it to stop streaming as soon as the consumer is gone.
+func (arc *ResponseCh) Stopped() <-chan struct{} {
+	return arc.stopped()
+}
+
+// stopped returns the stop channel, creating it for zero-value ResponseCh values.
+func (arc *ResponseCh) stopped() chan struct{} {
+	arc.mu.Lock()
+	defer arc.mu.Unlock()
+	if arc.stop == nil {
+		arc.stop = make(chan struct{})
+	}
+	return arc.stop
+}
+
+// discard reads and drops what the producer sends after Stop until it closes the channels.
+func (arc *ResponseCh) discard() {
+	errCh := arc.Error
+	for {
+		select {
+		case _, ok := <-arc.Response:
+			if !ok {
+				return
+			}
+		case _, ok := <-errCh:
+			if !ok {
+				errCh = nil
+			}
+		}
+	}
+}
+
 // GetResponseChan returns the response channel for sending chunks.
 // This method is used by tools to send custom chunks during execution.
 // This implements IParentResponseCh.
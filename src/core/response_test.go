@@ -0,0 +1,60 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+func TestNewResponseChWithBuffer(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		want int
+	}{
+		{name: "custom size", size: 64, want: 64},
+		{name: "zero uses default", size: 0, want: core.DefaultResponseBufferSize},
+		{name: "negative uses default", size: -1, want: core.DefaultResponseBufferSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := core.NewResponseChWithBuffer("agent", "", tt.size)
+			if got := cap(rc.Response); got != tt.want {
+				t.Errorf("cap(Response) = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if got := cap(core.NewResponseCh("agent", "").Response); got != core.DefaultResponseBufferSize {
+		t.Errorf("NewResponseCh buffer = %d, want %d", got, core.DefaultResponseBufferSize)
+	}
+}
+
+func TestResponseCh_Start_PlainTextPayload(t *testing.T) {
+	rc := core.NewResponseCh("agent", "trace")
+	rc.Response <- []byte("plain progress text")
+	rc.Response <- []byte(`"a JSON string"`)
+	rc.Response <- []byte{0xff, 0xfe}
+	rc.Close()
+
+	var chunks []core.ExtendedChunkResponse
+	for chunk := range rc.Start() {
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("Expected 3 chunks, got %d: %+v", len(chunks), chunks)
+	}
+	for i, want := range []string{"plain progress text", "a JSON string"} {
+		chunk := chunks[i]
+		if chunk.Type != llms.TypeContent || chunk.Status != llms.StatusStreaming || chunk.Content != want || chunk.Delta != want {
+			t.Errorf("Expected content chunk %q, got %+v", want, chunk)
+		}
+		if chunk.AgentName != "agent" || chunk.Trace != "trace" {
+			t.Errorf("Expected the agent name and trace on the chunk, got %q %q", chunk.AgentName, chunk.Trace)
+		}
+	}
+	if chunks[2].Status != llms.StatusError {
+		t.Errorf("Expected invalid UTF-8 to be an error chunk, got %+v", chunks[2])
+	}
+}
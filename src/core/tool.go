@@ -2,6 +2,10 @@ package core
 
 import (
 	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/thinktwice/agentForge/src/llms"
 )
@@ -19,6 +23,10 @@ type Parameter struct {
 	Description string
 	Required    bool
 	Validator   func(value any) error // Optional custom validation
+	Properties  []Parameter           // Optional field schemas when Type is "object"
+	Items       *Parameter            // Optional element schema when Type is "array" (Name is ignored)
+	Enum        []any                 // Optional set of allowed values
+	Default     any                   // Optional value injected when an optional parameter is omitted
 }
 
 // Tool is a universal tool implementation that satisfies both llms.Tool and agentforge.Discoverable interfaces
@@ -27,9 +35,13 @@ type Tool struct {
 	description        string
 	advanceDescription string
 	troubleshooting    string
+	llmDescription     string // Replaces description in the function definition when set
 	parameters         []Parameter
 	handler            func(agentContext map[string]any, args map[string]any) llms.ToolReturn
 	hooks              Hooks // Optional external validation hooks
+	noCache            bool  // Opts the tool out of result caching
+	requiresApproval   bool  // Calls must be approved by the agent's Approver
+	coerceArguments    bool  // Parse string values of number and boolean parameters
 }
 
 // NewTool creates a new universal tool
@@ -82,25 +94,88 @@ func (t *Tool) SetHooks(hooks Hooks) {
 	t.hooks = hooks
 }
 
+// Cacheable reports whether results of this tool may be served from a tool cache.
+// Tools are cacheable by default.
+func (t *Tool) Cacheable() bool {
+	return !t.noCache
+}
+
+// SetCacheable sets whether results of this tool may be served from a tool cache.
+// Disable it for tools with side effects or results that change between calls.
+func (t *Tool) SetCacheable(cacheable bool) {
+	t.noCache = !cacheable
+}
+
+// RequiresApproval reports whether calls to this tool must be approved by the
+// agent's Approver before running. Tools run without approval by default.
+func (t *Tool) RequiresApproval() bool {
+	return t.requiresApproval
+}
+
+// SetRequiresApproval sets whether calls to this tool must be approved before running.
+// Enable it for dangerous tools (writes, deletes, commands).
+func (t *Tool) SetRequiresApproval(required bool) {
+	t.requiresApproval = required
+}
+
+// CoercesArguments reports whether string values of number and boolean parameters
+// are parsed into the declared type before validation. Off by default.
+func (t *Tool) CoercesArguments() bool {
+	return t.coerceArguments
+}
+
+// SetCoerceArguments sets whether string values of number and boolean parameters
+// (e.g. "42" or "true", as some models send them) are parsed into the declared
+// type instead of being rejected. Agents can enable it for all their tools with
+// AgentConfig.CoerceToolArguments.
+func (t *Tool) SetCoerceArguments(coerce bool) {
+	t.coerceArguments = coerce
+}
+
+// WithName returns a copy of the tool under another name, e.g. to tell apart two
+// instances of the same tool given to one agent ("fs_docs" and "fs_src"). Agents
+// reject duplicate tool names. The original tool is left untouched; to rename a
+// tool that is not a *Tool (e.g. a wrapped one), use tools.WithName.
+func (t *Tool) WithName(name string) *Tool {
+	clone := *t
+	clone.name = name
+	return &clone
+}
+
+// WithLLMDescription sets the description sent to the LLM in the function definition,
+// in place of the terse basic description. Use it for models that benefit from richer
+// schemas (e.g. parameter-level detail or usage rules). An empty description restores
+// the basic one. Discovery (BasicDescription) is not affected.
+func (t *Tool) WithLLMDescription(description string) *Tool {
+	t.llmDescription = description
+	return t
+}
+
+// LLMDescription returns the description set with WithLLMDescription, or "" if none.
+func (t *Tool) LLMDescription() string {
+	return t.llmDescription
+}
+
 // GetFunctionDefinition returns the function definition for LLM API calls (implements llms.Tool)
 func (t *Tool) GetFunctionDefinition() llms.FunctionDefinition {
 	properties := make(map[string]llms.FunctionObjectParameter)
 	var required []string
 
 	for _, param := range t.parameters {
-		properties[param.Name] = llms.FunctionObjectParameter{
-			Type_:       param.Type,
-			Description: param.Description,
-			Name:        param.Name,
-		}
+		properties[param.Name] = toFunctionObjectParameter(param)
 		if param.Required {
 			required = append(required, param.Name)
 		}
 	}
 
+	description := t.description
+	if t.llmDescription != "" {
+		description = t.llmDescription
+	}
+
 	return llms.FunctionDefinition{
 		Name:        t.name,
-		Description: t.description,
+		Description: description,
 		Parameters: llms.FunctionParameters{
 			Type_:      "object",
 			Properties: properties,
@@ -109,8 +184,42 @@ func (t *Tool) GetFunctionDefinition() llms.FunctionDefinition {
 	}
 }
 
+// toFunctionObjectParameter converts a Parameter to its JSON schema representation,
+// recursing into object properties and array items.
+func toFunctionObjectParameter(param Parameter) llms.FunctionObjectParameter {
+	schema := llms.FunctionObjectParameter{
+		Type_:       param.Type,
+		Description: param.Description,
+		Name:        param.Name,
+		Enum:        param.Enum,
+		Default:     param.Default,
+	}
+
+	if len(param.Properties) > 0 {
+		schema.Properties = make(map[string]llms.FunctionObjectParameter)
+		for _, prop := range param.Properties {
+			schema.Properties[prop.Name] = toFunctionObjectParameter(prop)
+			if prop.Required {
+				schema.Required = append(schema.Required, prop.Name)
+			}
+		}
+	}
+
+	if param.Items != nil {
+		items := toFunctionObjectParameter(*param.Items)
+		items.Name = ""
+		schema.Items = &items
+	}
+
+	return schema
+}
+
 // Call executes the tool with validation (implements llms.Tool)
 func (t *Tool) Call(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+	if coerce, _ := agentContext[ContextCoerceArguments].(bool); coerce || t.coerceArguments {
+		args = t.coerceArgs(args)
+	}
+
 	// Validate arguments
 	validated, err := t.validateAndExtractArgs(args)
 	if err != nil {
@@ -121,6 +230,36 @@ func (t *Tool) Call(agentContext map[string]any, args map[string]any) llms.ToolR
 	return t.handler(agentContext, validated)
 }
 
+// coerceArgs returns a copy of args where string values of number and boolean
+// parameters are replaced by the parsed value. Strings that do not parse are kept,
+// so validation still reports them.
+func (t *Tool) coerceArgs(args map[string]any) map[string]any {
+	coerced := make(map[string]any, len(args))
+	for name, value := range args {
+		coerced[name] = value
+	}
+	for _, param := range t.parameters {
+		s, ok := args[param.Name].(string)
+		if !ok {
+			continue
+		}
+		s = strings.TrimSpace(s)
+		switch param.Type {
+		case "number":
+			if n, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(n, 0) && !math.IsNaN(n) {
+				coerced[param.Name] = n
+			}
+		case "boolean":
+			if strings.EqualFold(s, "true") {
+				coerced[param.Name] = true
+			} else if strings.EqualFold(s, "false") {
+				coerced[param.Name] = false
+			}
+		}
+	}
+	return coerced
+}
+
 // validateAndExtractArgs validates arguments and extracts them with proper types
 func (t *Tool) validateAndExtractArgs(args map[string]any) (map[string]any, llms.ToolReturn) {
 	validated := make(map[string]any)
@@ -139,6 +278,11 @@ func (t *Tool) validateAndExtractArgs(args map[string]any) (map[string]any, llms
 				return nil, NewErrorResponse(fmt.Sprintf("invalid type for %s: %v", param.Name, err))
 			}
 
+			// Enum validation
+			if len(param.Enum) > 0 && !enumContains(param.Enum, value) {
+				return nil, NewErrorResponse(fmt.Sprintf("invalid value for %s: %v (allowed: %v)", param.Name, value, param.Enum))
+			}
+
 			// Custom validation
 			if param.Validator != nil {
 				if err := param.Validator(value); err != nil {
@@ -147,12 +291,50 @@ func (t *Tool) validateAndExtractArgs(args map[string]any) (map[string]any, llms
 			}
 
 			validated[param.Name] = value
+		} else if param.Default != nil {
+			// Inject default for omitted optional parameters
+			validated[param.Name] = param.Default
 		}
 	}
 
 	return validated, nil
 }
 
+// enumContains reports whether value is one of the allowed enum values.
+// Numbers are compared by value regardless of their Go type, since JSON
+// arguments are always decoded as float64. Arrays and objects are compared
+// deeply (== would panic on them).
+func enumContains(enum []any, value any) bool {
+	for _, allowed := range enum {
+		if reflect.DeepEqual(allowed, value) {
+			return true
+		}
+		if a, ok := toFloat64(allowed); ok {
+			if v, ok := toFloat64(value); ok && a == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// toFloat64 converts supported numeric types to float64.
+func toFloat64(value any) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	}
+	return 0, false
+}
+
 // validateType checks if a value matches the expected type
 func (t *Tool) validateType(value any, expectedType string) error {
 	switch expectedType {
@@ -1,12 +1,18 @@
 package core
 
-import "github.com/thinktwice/agentForge/src/llms"
+import (
+	"encoding/json"
+	"fmt"
 
-// ToolResponse implements llms.ToolReturn interface
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// ToolResponse implements llms.ToolReturn and llms.ToolReturnJSON interfaces
 type ToolResponse struct {
-	success bool
-	error   string
-	data    string
+	success  bool
+	error    string
+	data     string
+	dataJSON json.RawMessage // Set by NewSuccessJSON
 }
 
 func (t *ToolResponse) Success() bool {
@@ -21,6 +27,11 @@ func (t *ToolResponse) Data() string {
 	return t.data
 }
 
+// DataJSON returns the structured data of a NewSuccessJSON response, or nil for string responses.
+func (t *ToolResponse) DataJSON() json.RawMessage {
+	return t.dataJSON
+}
+
 // NewSuccessResponse creates a successful tool response
 func NewSuccessResponse(data string) llms.ToolReturn {
 	return &ToolResponse{
@@ -30,6 +41,30 @@ func NewSuccessResponse(data string) llms.ToolReturn {
 	}
 }
 
+// NewSuccessJSON creates a successful tool response carrying structured data.
+//
+// The value is marshaled to JSON; Data() returns the JSON as a string and
+// DataJSON() returns it as raw JSON. If the value cannot be marshaled, an
+// error response is returned instead.
+//
+// Parameters:
+//   - v: The value to return (struct, map, slice, ...)
+//
+// Returns:
+//   - llms.ToolReturn: A response implementing llms.ToolReturnJSON
+func NewSuccessJSON(v any) llms.ToolReturn {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return NewErrorResponse(fmt.Sprintf("failed to serialize tool result: %v", err))
+	}
+	return &ToolResponse{
+		success:  true,
+		error:    "",
+		data:     string(data),
+		dataJSON: data,
+	}
+}
+
 // NewErrorResponse creates an error response without data
 func NewErrorResponse(errorMsg string) llms.ToolReturn {
 	return &ToolResponse{
@@ -0,0 +1,307 @@
+package core_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+func TestTool_NestedParameterSchema(t *testing.T) {
+	tool := core.NewTool(
+		"create-users",
+		"Create several users",
+		"",
+		"",
+		[]core.Parameter{
+			{
+				Name:        "users",
+				Type:        "array",
+				Description: "Users to create",
+				Required:    true,
+				Items: &core.Parameter{
+					Type: "object",
+					Properties: []core.Parameter{
+						{Name: "name", Type: "string", Required: true},
+						{Name: "age", Type: "number"},
+					},
+				},
+			},
+			{Name: "dryRun", Type: "boolean"},
+		},
+		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+			return core.NewSuccessResponse("ok")
+		},
+	)
+
+	def := tool.GetFunctionDefinition()
+
+	users, ok := def.Parameters.Properties["users"]
+	if !ok {
+		t.Fatal("Expected 'users' property in function definition")
+	}
+	if users.Type_ != "array" {
+		t.Errorf("Expected users type 'array', got '%s'", users.Type_)
+	}
+	if users.Items == nil {
+		t.Fatal("Expected users to have an items schema")
+	}
+	if users.Items.Type_ != "object" {
+		t.Errorf("Expected items type 'object', got '%s'", users.Items.Type_)
+	}
+	if users.Items.Properties["name"].Type_ != "string" || users.Items.Properties["age"].Type_ != "number" {
+		t.Errorf("Unexpected item properties: %+v", users.Items.Properties)
+	}
+	if len(users.Items.Required) != 1 || users.Items.Required[0] != "name" {
+		t.Errorf("Expected items required [name], got %v", users.Items.Required)
+	}
+
+	// Flat parameters keep the original shape
+	if dryRun := def.Parameters.Properties["dryRun"]; dryRun.Items != nil || dryRun.Properties != nil {
+		t.Errorf("Expected flat schema for dryRun, got %+v", dryRun)
+	}
+
+	// The serialized schema nests as JSON Schema expects
+	raw, err := json.Marshal(def.Parameters)
+	if err != nil {
+		t.Fatalf("Failed to marshal parameters: %v", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		t.Fatalf("Failed to unmarshal parameters: %v", err)
+	}
+	items := schema["properties"].(map[string]any)["users"].(map[string]any)["items"].(map[string]any)
+	if _, ok := items["name"]; ok {
+		t.Error("Expected items schema to have no 'name' field")
+	}
+	if _, ok := items["properties"].(map[string]any)["age"]; !ok {
+		t.Errorf("Expected items.properties.age in serialized schema, got %s", raw)
+	}
+}
+
+func TestTool_EnumAndDefault(t *testing.T) {
+	var received map[string]any
+	tool := core.NewTool(
+		"convert",
+		"Convert a value",
+		"",
+		"",
+		[]core.Parameter{
+			{Name: "format", Type: "string", Required: true, Enum: []any{"json", "yaml"}},
+			{Name: "indent", Type: "number", Enum: []any{2, 4}, Default: 2},
+		},
+		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+			received = args
+			return core.NewSuccessResponse("ok")
+		},
+	)
+
+	t.Run("out-of-enum value is rejected", func(t *testing.T) {
+		result := tool.Call(nil, map[string]any{"format": "xml"})
+		if result.Success() {
+			t.Fatal("Expected out-of-enum value to be rejected")
+		}
+		if !strings.Contains(result.Error(), "invalid value for format: xml") {
+			t.Errorf("Unexpected error: %s", result.Error())
+		}
+	})
+
+	t.Run("JSON numbers match integer enum values", func(t *testing.T) {
+		result := tool.Call(nil, map[string]any{"format": "json", "indent": float64(4)})
+		if !result.Success() {
+			t.Fatalf("Expected success, got error: %s", result.Error())
+		}
+		if received["indent"] != float64(4) {
+			t.Errorf("Expected indent 4, got %v", received["indent"])
+		}
+	})
+
+	t.Run("missing optional value is defaulted", func(t *testing.T) {
+		result := tool.Call(nil, map[string]any{"format": "yaml"})
+		if !result.Success() {
+			t.Fatalf("Expected success, got error: %s", result.Error())
+		}
+		if received["indent"] != 2 {
+			t.Errorf("Expected default indent 2, got %v", received["indent"])
+		}
+	})
+
+	t.Run("array and object values do not panic", func(t *testing.T) {
+		sizes := core.NewTool("resize", "Resize", "", "",
+			[]core.Parameter{
+				{Name: "size", Type: "array", Enum: []any{[]any{float64(16), float64(16)}, []any{float64(32), float64(32)}}},
+				{Name: "format", Type: "string", Enum: []any{"png"}},
+			},
+			func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+				return core.NewSuccessResponse("ok")
+			},
+		)
+		if result := sizes.Call(nil, map[string]any{"size": []any{float64(32), float64(32)}}); !result.Success() {
+			t.Errorf("Expected an allowed array to pass, got error: %s", result.Error())
+		}
+		if result := sizes.Call(nil, map[string]any{"size": []any{float64(64), float64(64)}}); result.Success() {
+			t.Error("Expected an array outside the enum to be rejected")
+		}
+		if result := sizes.Call(nil, map[string]any{"format": map[string]any{"type": "png"}}); result.Success() {
+			t.Error("Expected an object to be rejected")
+		}
+	})
+
+	t.Run("enum and default are in the schema", func(t *testing.T) {
+		indent := tool.GetFunctionDefinition().Parameters.Properties["indent"]
+		if len(indent.Enum) != 2 || indent.Default != 2 {
+			t.Errorf("Expected enum and default in schema, got %+v", indent)
+		}
+	})
+}
+
+func TestTool_CoerceArguments(t *testing.T) {
+	var received map[string]any
+	newTool := func() *core.Tool {
+		return core.NewTool(
+			"repeat",
+			"Repeat a word",
+			"",
+			"",
+			[]core.Parameter{
+				{Name: "times", Type: "number", Required: true},
+				{Name: "upper", Type: "boolean"},
+			},
+			func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+				received = args
+				return core.NewSuccessResponse("ok")
+			},
+		).(*core.Tool)
+	}
+	stringArgs := map[string]any{"times": "42", "upper": "true"}
+
+	t.Run("strings are rejected by default", func(t *testing.T) {
+		result := newTool().Call(nil, stringArgs)
+		if result.Success() || !strings.Contains(result.Error(), "invalid type for times: expected number, got string") {
+			t.Errorf("Expected a type error, got success=%v error=%q", result.Success(), result.Error())
+		}
+	})
+
+	t.Run("tool opt-in parses numbers and booleans", func(t *testing.T) {
+		tool := newTool()
+		tool.SetCoerceArguments(true)
+		result := tool.Call(nil, stringArgs)
+		if !result.Success() {
+			t.Fatalf("Expected success, got error: %s", result.Error())
+		}
+		if received["times"] != float64(42) || received["upper"] != true {
+			t.Errorf("Expected times 42 and upper true, got %#v", received)
+		}
+		if stringArgs["times"] != "42" {
+			t.Error("Expected the caller's arguments to be left unchanged")
+		}
+	})
+
+	t.Run("agent context opt-in", func(t *testing.T) {
+		result := newTool().Call(map[string]any{core.ContextCoerceArguments: true}, map[string]any{"times": " 2.5 ", "upper": "FALSE"})
+		if !result.Success() || received["times"] != 2.5 || received["upper"] != false {
+			t.Errorf("Expected coerced arguments, got success=%v error=%q args=%#v", result.Success(), result.Error(), received)
+		}
+	})
+
+	t.Run("unparsable strings are still rejected", func(t *testing.T) {
+		tool := newTool()
+		tool.SetCoerceArguments(true)
+		for _, args := range []map[string]any{{"times": "forty-two"}, {"times": "NaN"}, {"times": 1.0, "upper": "yes"}} {
+			if result := tool.Call(nil, args); result.Success() {
+				t.Errorf("Expected %v to be rejected", args)
+			}
+		}
+	})
+}
+
+func TestTool_WithLLMDescription(t *testing.T) {
+	tool := core.NewTool("search", "Search the web", "Returns the top 5 results with titles and URLs.", "", nil,
+		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+			return core.NewSuccessResponse("ok")
+		},
+	).(*core.Tool)
+
+	if got := tool.GetFunctionDefinition().Description; got != "Search the web" {
+		t.Errorf("Expected the basic description by default, got %q", got)
+	}
+
+	tool.WithLLMDescription("Search the web. Use short keyword queries; results are ranked by relevance.")
+	if got := tool.GetFunctionDefinition().Description; got != tool.LLMDescription() {
+		t.Errorf("Expected the LLM description, got %q", got)
+	}
+	if tool.BasicDescription() != "Search the web" {
+		t.Errorf("Expected the basic description to be unchanged, got %q", tool.BasicDescription())
+	}
+
+	tool.WithLLMDescription("")
+	if got := tool.GetFunctionDefinition().Description; got != "Search the web" {
+		t.Errorf("Expected an empty LLM description to restore the basic one, got %q", got)
+	}
+}
+
+func TestNewSuccessJSON(t *testing.T) {
+	type forecast struct {
+		City  string   `json:"city"`
+		TempC float64  `json:"tempC"`
+		Tags  []string `json:"tags"`
+	}
+	want := forecast{City: "Oslo", TempC: -3.5, Tags: []string{"snow"}}
+
+	tool := core.NewTool("forecast", "Get a forecast", "", "", nil,
+		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+			return core.NewSuccessJSON(want)
+		},
+	)
+
+	result := tool.Call(nil, map[string]any{})
+	if !result.Success() {
+		t.Fatalf("Expected success, got error: %s", result.Error())
+	}
+	jsonResult, ok := result.(llms.ToolReturnJSON)
+	if !ok {
+		t.Fatal("Expected result to implement llms.ToolReturnJSON")
+	}
+
+	var got forecast
+	if err := json.Unmarshal(jsonResult.DataJSON(), &got); err != nil {
+		t.Fatalf("Failed to unmarshal DataJSON: %v", err)
+	}
+	if got.City != want.City || got.TempC != want.TempC || len(got.Tags) != 1 {
+		t.Errorf("DataJSON round-trip = %+v, want %+v", got, want)
+	}
+	if result.Data() != string(jsonResult.DataJSON()) {
+		t.Errorf("Expected Data() to return the JSON string, got %q", result.Data())
+	}
+
+	// String responses keep working and carry no JSON
+	if plain := core.NewSuccessResponse("ok").(llms.ToolReturnJSON); plain.DataJSON() != nil {
+		t.Errorf("Expected nil DataJSON for string response, got %s", plain.DataJSON())
+	}
+
+	// Unserializable values become error responses
+	if bad := core.NewSuccessJSON(make(chan int)); bad.Success() || !strings.Contains(bad.Error(), "failed to serialize tool result") {
+		t.Errorf("Expected serialization error, got %+v", bad)
+	}
+}
+
+func TestTool_WithName(t *testing.T) {
+	original := core.NewTool("fs", "File system access", "", "", nil,
+		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+			return core.NewSuccessResponse("ok")
+		},
+	).(*core.Tool)
+	tool := original.WithName("fs_docs")
+
+	if original.GetName() != "fs" {
+		t.Errorf("Expected the original tool to keep its name, got %q", original.GetName())
+	}
+	if tool.GetName() != "fs_docs" {
+		t.Errorf("GetName() = %q, want %q", tool.GetName(), "fs_docs")
+	}
+	if got := tool.GetFunctionDefinition().Name; got != "fs_docs" {
+		t.Errorf("Expected the function definition to use the new name, got %q", got)
+	}
+}
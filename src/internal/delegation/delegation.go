@@ -0,0 +1,41 @@
+// Package delegation carries the delegation depth of a run from the delegate tool
+// to the sub-agent it starts.
+//
+// The depth travels in a context.Context under an unexported key, so code outside
+// this module cannot start a run at an arbitrary depth and bypass
+// MaxDelegationDepth.
+package delegation
+
+import "context"
+
+type depthKey struct{}
+
+// depth is the delegation position stored in a context.
+type depth struct {
+	depth    int
+	maxDepth int
+}
+
+// WithDepth returns a copy of ctx running at the given delegation depth.
+//
+// Parameters:
+//   - ctx: Parent context
+//   - d: Delegation depth of the run (0 for the root agent)
+//   - maxDepth: Maximum delegation depth inherited from the caller (0 means unlimited)
+//
+// Returns:
+//   - context.Context: The context carrying the depth
+func WithDepth(ctx context.Context, d int, maxDepth int) context.Context {
+	return context.WithValue(ctx, depthKey{}, depth{depth: d, maxDepth: maxDepth})
+}
+
+// Depth returns the delegation depth stored in ctx by WithDepth.
+//
+// Returns:
+//   - int: Delegation depth of the run
+//   - int: Maximum delegation depth inherited from the caller (0 means unlimited)
+//   - bool: Whether ctx carries a depth
+func Depth(ctx context.Context) (int, int, bool) {
+	d, ok := ctx.Value(depthKey{}).(depth)
+	return d.depth, d.maxDepth, ok
+}
@@ -0,0 +1,64 @@
+package llms
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/openai/openai-go/v3/option"
+	agentforge "github.com/thinktwice/agentForge/src"
+)
+
+// GetAzureOpenAILLM creates an LLM engine for an Azure OpenAI deployment.
+//
+// Azure routes requests by deployment ({endpoint}/openai/deployments/{deployment}/...),
+// requires an api-version query parameter and authenticates with an api-key header
+// instead of a bearer token. The deployment name is also used as the model.
+// The API key is read from AF_AZURE_OPENAI_API_KEY.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - endpoint: Azure resource endpoint (e.g., "https://my-resource.openai.azure.com")
+//   - deployment: Name of the model deployment
+//   - apiVersion: Azure REST API version (empty uses AZURE_OPENAI_API_VERSION)
+//
+// Returns:
+//   - LLMEngine: The configured engine
+//   - error: An error if a required setting or the API key is missing
+func GetAzureOpenAILLM(ctx context.Context, endpoint, deployment, apiVersion string) (LLMEngine, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("azure openai endpoint is required")
+	}
+	if deployment == "" {
+		return nil, fmt.Errorf("azure openai deployment is required")
+	}
+	if apiVersion == "" {
+		apiVersion = AZURE_OPENAI_API_VERSION
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	c, err := agentforge.NewConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if c.AFAzureOpenAIAPIKey == "" {
+		return nil, fmt.Errorf("no API key found for azure openai: set %s", AzureOpenAIAPIKeyEnvVar)
+	}
+
+	return newAzureOpenAILLM(ctx, endpoint, deployment, apiVersion, c.AFAzureOpenAIAPIKey), nil
+}
+
+// newAzureOpenAILLM creates an openAILLM configured with Azure OpenAI conventions.
+func newAzureOpenAILLM(ctx context.Context, endpoint, deployment, apiVersion, apiKey string) *openAILLM {
+	baseURL := fmt.Sprintf("%s/openai/deployments/%s/", strings.TrimRight(endpoint, "/"), deployment)
+	opts := []option.RequestOption{
+		option.WithBaseURL(baseURL),
+		option.WithQuery("api-version", apiVersion),
+		option.WithHeader("api-key", apiKey),
+		// Azure rejects bearer auth alongside api-key (e.g. from OPENAI_API_KEY)
+		option.WithHeaderDel("authorization"),
+	}
+	return newOpenAILLMWithOptions(ctx, baseURL, deployment, apiKey, opts)
+}
@@ -0,0 +1,64 @@
+package llms
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetAzureOpenAILLM(t *testing.T) {
+	server := newMockOpenAIServer(t, []string{contentChunkJSON("Hello")}, false)
+	t.Setenv("AF_AZURE_OPENAI_API_KEY", "azure-key")
+	t.Setenv("OPENAI_API_KEY", "should-not-be-sent")
+
+	llm, err := GetAzureOpenAILLM(context.Background(), server.URL+"/", "my-deployment", "2024-06-01")
+	if err != nil {
+		t.Fatalf("GetAzureOpenAILLM() unexpected error = %v", err)
+	}
+
+	_, err = collectChunks(t, llm.ChatStream([]UnifiedMessage{UserMessage("hi")}, nil), 5*time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected stream error: %v", err)
+	}
+
+	req := server.LastRequest()
+	if req.URL.Path != "/openai/deployments/my-deployment/chat/completions" {
+		t.Errorf("Unexpected request path: %s", req.URL.Path)
+	}
+	if got := req.URL.Query().Get("api-version"); got != "2024-06-01" {
+		t.Errorf("Expected api-version 2024-06-01, got %q", got)
+	}
+	if got := req.Header.Get("api-key"); got != "azure-key" {
+		t.Errorf("Expected api-key header 'azure-key', got %q", got)
+	}
+	if got := req.Header.Get("Authorization"); got != "" {
+		t.Errorf("Expected no Authorization header, got %q", got)
+	}
+	if !strings.Contains(server.LastBody(), `"model":"my-deployment"`) {
+		t.Errorf("Expected deployment as model in body, got %s", server.LastBody())
+	}
+}
+
+func TestGetAzureOpenAILLM_MissingSettings(t *testing.T) {
+	t.Setenv("AF_AZURE_OPENAI_API_KEY", "")
+
+	tests := []struct {
+		name       string
+		endpoint   string
+		deployment string
+		wantErr    string
+	}{
+		{"missing endpoint", "", "dep", "endpoint is required"},
+		{"missing deployment", "https://example.openai.azure.com", "", "deployment is required"},
+		{"missing api key", "https://example.openai.azure.com", "dep", "AF_AZURE_OPENAI_API_KEY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := GetAzureOpenAILLM(context.Background(), tt.endpoint, tt.deployment, "")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("GetAzureOpenAILLM() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
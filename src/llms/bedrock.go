@@ -0,0 +1,463 @@
+package llms
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+)
+
+// bedrockConverseStreamer is the part of the Bedrock Runtime client used by bedrockLLM.
+type bedrockConverseStreamer interface {
+	converseStream(ctx context.Context, input *bedrockruntime.ConverseStreamInput) (bedrockruntime.ConverseStreamOutputReader, error)
+}
+
+// bedrockRuntimeClient adapts *bedrockruntime.Client to bedrockConverseStreamer.
+type bedrockRuntimeClient struct {
+	client *bedrockruntime.Client
+}
+
+func (c *bedrockRuntimeClient) converseStream(ctx context.Context, input *bedrockruntime.ConverseStreamInput) (bedrockruntime.ConverseStreamOutputReader, error) {
+	out, err := c.client.ConverseStream(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return out.GetStream(), nil
+}
+
+// bedrockLLM implements an AWS Bedrock llm on top of the ConverseStream API.
+//
+// ConverseStream offers one message and tool format for every model hosted on
+// Bedrock (Claude, Llama, Mistral, ...), so the engine only needs the model ID.
+type bedrockLLM struct {
+	ctx     context.Context
+	modelID string
+	client  bedrockConverseStreamer
+}
+
+// GetBedrockLLM creates an LLM engine for a model hosted on AWS Bedrock.
+//
+// Credentials are resolved from the standard AWS chain (environment variables,
+// shared config and credentials files, SSO, instance or container roles).
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - region: AWS region (e.g., "us-east-1"); empty uses the region of the AWS config
+//   - modelID: Bedrock model or inference profile ID (e.g., BEDROCK_CLAUDE_3_5_HAIKU)
+//
+// Returns:
+//   - LLMEngine: The configured engine
+//   - error: An error if the model ID or region is missing or the AWS config cannot be loaded
+func GetBedrockLLM(ctx context.Context, region, modelID string) (LLMEngine, error) {
+	if modelID == "" {
+		return nil, fmt.Errorf("bedrock model ID is required")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("no AWS region for bedrock: pass a region or set AWS_REGION")
+	}
+
+	return newBedrockLLM(ctx, modelID, &bedrockRuntimeClient{client: bedrockruntime.NewFromConfig(cfg)}), nil
+}
+
+// newBedrockLLM creates a bedrockLLM using the given client.
+func newBedrockLLM(ctx context.Context, modelID string, client bedrockConverseStreamer) *bedrockLLM {
+	return &bedrockLLM{
+		ctx:     ctx,
+		modelID: modelID,
+		client:  client,
+	}
+}
+
+// ChatStream sends messages with optional tools and returns a ResponseCh for streaming responses.
+//
+// Parameters:
+//   - messages: The messages to send
+//   - tools: Optional tools available for this request (can be nil or empty)
+//
+// Returns:
+//   - *responseCh: responseCh instance with channels for streaming
+func (b *bedrockLLM) ChatStream(messages []UnifiedMessage, tools []Tool) *responseCh {
+	responseCh := newResponseCh()
+
+	go b.streamResponse(messages, tools, responseCh)
+
+	return responseCh
+}
+
+// toBedrockMessages converts unified messages to Bedrock Converse messages.
+//
+// System and developer messages become system content blocks. Tool results are
+// sent as user messages, and consecutive messages with the same role are merged
+// because Converse requires user and assistant turns to alternate.
+func toBedrockMessages(messages []UnifiedMessage) ([]types.SystemContentBlock, []types.Message, error) {
+	var system []types.SystemContentBlock
+	var bedrockMessages []types.Message
+
+	for i, message := range messages {
+		var role types.ConversationRole
+		var content []types.ContentBlock
+
+		switch message.Role() {
+		case MessageRoleSystem, MessageRoleDeveloper:
+			system = append(system, &types.SystemContentBlockMemberText{Value: message.Content()})
+			continue
+		case MessageRoleUser:
+			role = types.ConversationRoleUser
+			if len(message.ContentParts()) > 0 {
+				parts, err := toBedrockContentBlocks(message.ContentParts())
+				if err != nil {
+					return nil, nil, fmt.Errorf("message %d: %w", i, err)
+				}
+				content = parts
+			} else {
+				content = []types.ContentBlock{&types.ContentBlockMemberText{Value: message.Content()}}
+			}
+		case MessageRoleAssistant:
+			role = types.ConversationRoleAssistant
+			if message.Content() != "" {
+				content = append(content, &types.ContentBlockMemberText{Value: message.Content()})
+			}
+			for _, toolCall := range message.ToolCalls() {
+				args := toolCall.Arguments
+				if args == nil {
+					args = map[string]any{}
+				}
+				content = append(content, &types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+					ToolUseId: stringPtr(toolCall.ID),
+					Name:      stringPtr(toolCall.Name),
+					Input:     document.NewLazyDocument(args),
+				}})
+			}
+			if len(content) == 0 {
+				// Converse rejects empty messages
+				continue
+			}
+		case MessageRoleTool:
+			role = types.ConversationRoleUser
+			content = []types.ContentBlock{&types.ContentBlockMemberToolResult{Value: types.ToolResultBlock{
+				ToolUseId: stringPtr(message.ToolCallID()),
+				Content:   []types.ToolResultContentBlock{&types.ToolResultContentBlockMemberText{Value: message.Content()}},
+			}}}
+		default:
+			return nil, nil, fmt.Errorf("invalid message role: %s", message.Role())
+		}
+
+		if last := len(bedrockMessages) - 1; last >= 0 && bedrockMessages[last].Role == role {
+			bedrockMessages[last].Content = append(bedrockMessages[last].Content, content...)
+			continue
+		}
+		bedrockMessages = append(bedrockMessages, types.Message{Role: role, Content: content})
+	}
+
+	return system, bedrockMessages, nil
+}
+
+// toBedrockContentBlocks converts multi-part content to Bedrock content blocks.
+// Images must be base64 data URLs (see ImageBase64Part); Bedrock does not fetch remote URLs.
+func toBedrockContentBlocks(parts []ContentPart) ([]types.ContentBlock, error) {
+	blocks := make([]types.ContentBlock, len(parts))
+	for i, part := range parts {
+		switch part.Type {
+		case ContentPartTypeText:
+			blocks[i] = &types.ContentBlockMemberText{Value: part.Text}
+		case ContentPartTypeImageURL:
+			image, err := toBedrockImage(part.ImageURL)
+			if err != nil {
+				return nil, fmt.Errorf("content part %d: %w", i, err)
+			}
+			blocks[i] = &types.ContentBlockMemberImage{Value: image}
+		default:
+			return nil, fmt.Errorf("content part %d: invalid content part type: %s", i, part.Type)
+		}
+	}
+	return blocks, nil
+}
+
+// toBedrockImage decodes a base64 data URL into a Bedrock image block.
+func toBedrockImage(url string) (types.ImageBlock, error) {
+	if url == "" {
+		return types.ImageBlock{}, fmt.Errorf("image part has no URL")
+	}
+	header, data, ok := strings.Cut(strings.TrimPrefix(url, "data:"), ";base64,")
+	if !ok || !strings.HasPrefix(url, "data:") {
+		return types.ImageBlock{}, fmt.Errorf("bedrock only supports base64 data URL images (use ImageBase64Part)")
+	}
+
+	var format types.ImageFormat
+	switch header {
+	case "image/png":
+		format = types.ImageFormatPng
+	case "image/jpeg", "image/jpg":
+		format = types.ImageFormatJpeg
+	case "image/gif":
+		format = types.ImageFormatGif
+	case "image/webp":
+		format = types.ImageFormatWebp
+	default:
+		return types.ImageBlock{}, fmt.Errorf("unsupported image media type for bedrock: %s", header)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return types.ImageBlock{}, fmt.Errorf("invalid base64 image data: %w", err)
+	}
+	return types.ImageBlock{Format: format, Source: &types.ImageSourceMemberBytes{Value: raw}}, nil
+}
+
+// toBedrockToolConfig converts tools to a Bedrock tool configuration (nil without tools).
+func toBedrockToolConfig(tools []Tool) (*types.ToolConfiguration, error) {
+	if len(tools) == 0 {
+		return nil, nil
+	}
+
+	bedrockTools := make([]types.Tool, len(tools))
+	for i, tool := range tools {
+		fnDef := tool.GetFunctionDefinition()
+
+		// Round-trip through JSON so the schema uses the json tag names;
+		// smithy documents do not read json tags.
+		raw, err := json.Marshal(fnDef.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize parameters of tool %s: %w", fnDef.Name, err)
+		}
+		var schema map[string]any
+		if err := json.Unmarshal(raw, &schema); err != nil {
+			return nil, fmt.Errorf("failed to serialize parameters of tool %s: %w", fnDef.Name, err)
+		}
+
+		spec := types.ToolSpecification{
+			Name:        stringPtr(fnDef.Name),
+			InputSchema: &types.ToolInputSchemaMemberJson{Value: document.NewLazyDocument(schema)},
+		}
+		if fnDef.Description != "" {
+			spec.Description = stringPtr(fnDef.Description)
+		}
+		bedrockTools[i] = &types.ToolMemberToolSpec{Value: spec}
+	}
+
+	return &types.ToolConfiguration{Tools: bedrockTools}, nil
+}
+
+// stringPtr returns a pointer to s.
+func stringPtr(s string) *string {
+	return &s
+}
+
+// streamResponse handles the actual streaming from the Bedrock ConverseStream API.
+func (b *bedrockLLM) streamResponse(messages []UnifiedMessage, tools []Tool, responseCh *responseCh) {
+	defer responseCh.Close()
+
+	system, bedrockMessages, err := toBedrockMessages(messages)
+	if err != nil {
+		responseCh.Error <- fmt.Errorf("failed to convert messages to Bedrock messages: %w", err)
+		return
+	}
+
+	toolConfig, err := toBedrockToolConfig(tools)
+	if err != nil {
+		responseCh.Error <- fmt.Errorf("failed to convert tools to Bedrock tools: %w", err)
+		return
+	}
+
+	// The request context is cancelled by responseCh.Cancel
+	streamCtx, cancel := context.WithCancel(b.ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-responseCh.Cancelled():
+			cancel()
+		case <-streamCtx.Done():
+		}
+	}()
+
+	stream, err := b.client.converseStream(streamCtx, &bedrockruntime.ConverseStreamInput{
+		ModelId:    stringPtr(b.modelID),
+		Messages:   bedrockMessages,
+		System:     system,
+		ToolConfig: toolConfig,
+	})
+	if err != nil {
+		responseCh.Error <- fmt.Errorf("bedrock converse stream error: %w", err)
+		return
+	}
+	defer stream.Close()
+
+	var fullContent, fullReasoning string
+	var promptTokens, completionTokens, totalTokens int
+	// Tool calls by content block index, in the order they started
+	type pendingToolCall struct {
+		ID        string
+		Name      string
+		Arguments string
+	}
+	toolCallsByBlock := make(map[int32]*pendingToolCall)
+	var toolCallOrder []*pendingToolCall
+
+	for event := range stream.Events() {
+		switch e := event.(type) {
+		case *types.ConverseStreamOutputMemberContentBlockStart:
+			if start, ok := e.Value.Start.(*types.ContentBlockStartMemberToolUse); ok {
+				toolCall := &pendingToolCall{}
+				if start.Value.ToolUseId != nil {
+					toolCall.ID = *start.Value.ToolUseId
+				}
+				if start.Value.Name != nil {
+					toolCall.Name = *start.Value.Name
+				}
+				toolCallsByBlock[blockIndex(e.Value.ContentBlockIndex)] = toolCall
+				toolCallOrder = append(toolCallOrder, toolCall)
+			}
+
+		case *types.ConverseStreamOutputMemberContentBlockDelta:
+			switch delta := e.Value.Delta.(type) {
+			case *types.ContentBlockDeltaMemberText:
+				if delta.Value == "" {
+					continue
+				}
+				fullContent += delta.Value
+				if !b.sendChunk(responseCh, ChunkResponse{
+					Content:     delta.Value,
+					Delta:       delta.Value,
+					FullContent: fullContent,
+					Status:      StatusStreaming,
+					Type:        TypeContent,
+				}) {
+					return
+				}
+
+			case *types.ContentBlockDeltaMemberReasoningContent:
+				text, ok := delta.Value.(*types.ReasoningContentBlockDeltaMemberText)
+				if !ok || text.Value == "" {
+					continue
+				}
+				fullReasoning += text.Value
+				if !b.sendChunk(responseCh, ChunkResponse{
+					Content:     text.Value,
+					Delta:       text.Value,
+					FullContent: fullReasoning,
+					Status:      StatusStreaming,
+					Type:        TypeReasoning,
+					Trace:       TraceThinking,
+				}) {
+					return
+				}
+
+			case *types.ContentBlockDeltaMemberToolUse:
+				toolCall := toolCallsByBlock[blockIndex(e.Value.ContentBlockIndex)]
+				if toolCall != nil && delta.Value.Input != nil {
+					toolCall.Arguments += *delta.Value.Input
+				}
+			}
+
+		case *types.ConverseStreamOutputMemberMetadata:
+			if usage := e.Value.Usage; usage != nil {
+				promptTokens = int32Value(usage.InputTokens)
+				completionTokens = int32Value(usage.OutputTokens)
+				totalTokens = int32Value(usage.TotalTokens)
+			}
+		}
+	}
+
+	select {
+	case <-responseCh.Cancelled():
+		return
+	default:
+	}
+	if err := stream.Err(); err != nil {
+		responseCh.Error <- fmt.Errorf("bedrock stream error: %w", err)
+		return
+	}
+
+	if len(toolCallOrder) > 0 {
+		toolCalls := make([]ToolCall, 0, len(toolCallOrder))
+		for _, toolData := range toolCallOrder {
+			args := make(map[string]any)
+			if toolData.Arguments != "" {
+				if err := json.Unmarshal([]byte(toolData.Arguments), &args); err != nil {
+					responseCh.Error <- &ToolArgumentsError{
+						ToolCallID: toolData.ID,
+						ToolName:   toolData.Name,
+						Arguments:  toolData.Arguments,
+						Err:        err,
+					}
+					return
+				}
+			}
+			toolCalls = append(toolCalls, ToolCall{
+				ID:        toolData.ID,
+				Name:      toolData.Name,
+				Arguments: args,
+			})
+		}
+
+		if !b.sendChunk(responseCh, ChunkResponse{
+			FullContent: fullContent,
+			Status:      StatusToolCall,
+			Type:        TypeToolCall,
+			ToolCalls:   toolCalls,
+		}) {
+			return
+		}
+	}
+
+	b.sendChunk(responseCh, ChunkResponse{
+		FullContent:      fullContent,
+		Status:           StatusCompleted,
+		Type:             TypeCompletion,
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      totalTokens,
+	})
+}
+
+// sendChunk serializes and sends a chunk. It returns false when the stream must stop.
+func (b *bedrockLLM) sendChunk(responseCh *responseCh, chunk ChunkResponse) bool {
+	jsonBytes, err := serializeChunk(chunk)
+	if err != nil {
+		responseCh.Error <- fmt.Errorf("failed to serialize chunk: %w", err)
+		return false
+	}
+
+	select {
+	case responseCh.Response <- jsonBytes:
+		return true
+	case <-b.ctx.Done():
+		return false
+	case <-responseCh.Cancelled():
+		return false
+	}
+}
+
+// blockIndex returns the content block index of a stream event (0 when unset).
+func blockIndex(index *int32) int32 {
+	if index == nil {
+		return 0
+	}
+	return *index
+}
+
+// int32Value returns the value of an optional token count as an int.
+func int32Value(v *int32) int {
+	if v == nil {
+		return 0
+	}
+	return int(*v)
+}
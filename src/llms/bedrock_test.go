@@ -0,0 +1,397 @@
+package llms
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+)
+
+// fixtureStream replays recorded ConverseStream events.
+type fixtureStream struct {
+	events chan types.ConverseStreamOutput
+	err    error
+}
+
+func (s *fixtureStream) Events() <-chan types.ConverseStreamOutput { return s.events }
+func (s *fixtureStream) Close() error                              { return nil }
+func (s *fixtureStream) Err() error                                { return s.err }
+
+// fixtureClient returns a fixtureStream and records the last request.
+type fixtureClient struct {
+	stream *fixtureStream
+	input  *bedrockruntime.ConverseStreamInput
+}
+
+func (c *fixtureClient) converseStream(ctx context.Context, input *bedrockruntime.ConverseStreamInput) (bedrockruntime.ConverseStreamOutputReader, error) {
+	c.input = input
+	return c.stream, nil
+}
+
+// fixtureEvent is one line of a recorded ConverseStream fixture, in the
+// event shapes of the Bedrock wire format.
+type fixtureEvent struct {
+	MessageStart *struct {
+		Role string `json:"role"`
+	} `json:"messageStart"`
+	ContentBlockStart *struct {
+		ContentBlockIndex int32 `json:"contentBlockIndex"`
+		Start             struct {
+			ToolUse *struct {
+				ToolUseID string `json:"toolUseId"`
+				Name      string `json:"name"`
+			} `json:"toolUse"`
+		} `json:"start"`
+	} `json:"contentBlockStart"`
+	ContentBlockDelta *struct {
+		ContentBlockIndex int32 `json:"contentBlockIndex"`
+		Delta             struct {
+			Text             *string `json:"text"`
+			ReasoningContent *struct {
+				Text string `json:"text"`
+			} `json:"reasoningContent"`
+			ToolUse *struct {
+				Input string `json:"input"`
+			} `json:"toolUse"`
+		} `json:"delta"`
+	} `json:"contentBlockDelta"`
+	ContentBlockStop *struct {
+		ContentBlockIndex int32 `json:"contentBlockIndex"`
+	} `json:"contentBlockStop"`
+	MessageStop *struct {
+		StopReason string `json:"stopReason"`
+	} `json:"messageStop"`
+	Metadata *struct {
+		Usage struct {
+			InputTokens  int32 `json:"inputTokens"`
+			OutputTokens int32 `json:"outputTokens"`
+			TotalTokens  int32 `json:"totalTokens"`
+		} `json:"usage"`
+	} `json:"metadata"`
+}
+
+// toSDK converts the fixture event to its SDK type.
+func (e fixtureEvent) toSDK(t *testing.T) types.ConverseStreamOutput {
+	t.Helper()
+	switch {
+	case e.MessageStart != nil:
+		return &types.ConverseStreamOutputMemberMessageStart{Value: types.MessageStartEvent{Role: types.ConversationRole(e.MessageStart.Role)}}
+	case e.ContentBlockStart != nil:
+		start := e.ContentBlockStart
+		event := types.ContentBlockStartEvent{ContentBlockIndex: &start.ContentBlockIndex}
+		if start.Start.ToolUse != nil {
+			event.Start = &types.ContentBlockStartMemberToolUse{Value: types.ToolUseBlockStart{
+				ToolUseId: &start.Start.ToolUse.ToolUseID,
+				Name:      &start.Start.ToolUse.Name,
+			}}
+		}
+		return &types.ConverseStreamOutputMemberContentBlockStart{Value: event}
+	case e.ContentBlockDelta != nil:
+		delta := e.ContentBlockDelta
+		event := types.ContentBlockDeltaEvent{ContentBlockIndex: &delta.ContentBlockIndex}
+		switch {
+		case delta.Delta.Text != nil:
+			event.Delta = &types.ContentBlockDeltaMemberText{Value: *delta.Delta.Text}
+		case delta.Delta.ReasoningContent != nil:
+			event.Delta = &types.ContentBlockDeltaMemberReasoningContent{Value: &types.ReasoningContentBlockDeltaMemberText{Value: delta.Delta.ReasoningContent.Text}}
+		case delta.Delta.ToolUse != nil:
+			event.Delta = &types.ContentBlockDeltaMemberToolUse{Value: types.ToolUseBlockDelta{Input: &delta.Delta.ToolUse.Input}}
+		}
+		return &types.ConverseStreamOutputMemberContentBlockDelta{Value: event}
+	case e.ContentBlockStop != nil:
+		return &types.ConverseStreamOutputMemberContentBlockStop{Value: types.ContentBlockStopEvent{ContentBlockIndex: &e.ContentBlockStop.ContentBlockIndex}}
+	case e.MessageStop != nil:
+		return &types.ConverseStreamOutputMemberMessageStop{Value: types.MessageStopEvent{StopReason: types.StopReason(e.MessageStop.StopReason)}}
+	case e.Metadata != nil:
+		usage := e.Metadata.Usage
+		return &types.ConverseStreamOutputMemberMetadata{Value: types.ConverseStreamMetadataEvent{Usage: &types.TokenUsage{
+			InputTokens:  &usage.InputTokens,
+			OutputTokens: &usage.OutputTokens,
+			TotalTokens:  &usage.TotalTokens,
+		}}}
+	}
+	t.Fatalf("Unknown fixture event: %+v", e)
+	return nil
+}
+
+// loadBedrockFixture reads a recorded event stream from testdata.
+func loadBedrockFixture(t *testing.T, name string) *fixtureStream {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	defer f.Close()
+
+	var events []types.ConverseStreamOutput
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event fixtureEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Failed to parse fixture line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, event.toSDK(t))
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	stream := &fixtureStream{events: make(chan types.ConverseStreamOutput, len(events))}
+	for _, event := range events {
+		stream.events <- event
+	}
+	close(stream.events)
+	return stream
+}
+
+// weatherTool is a minimal Tool used to check tool-spec generation.
+type weatherTool struct {
+	name string
+}
+
+func (w weatherTool) GetName() string { return w.name }
+
+func (w weatherTool) Call(agentContext map[string]any, args map[string]any) ToolReturn { return nil }
+
+func (w weatherTool) GetFunctionDefinition() FunctionDefinition {
+	return FunctionDefinition{
+		Name:        w.name,
+		Description: "Get the weather for a city",
+		Parameters: FunctionParameters{
+			Type_: "object",
+			Properties: map[string]FunctionObjectParameter{
+				"city":  {Type_: "string", Description: "City name"},
+				"units": {Type_: "string", Enum: []any{"metric", "imperial"}},
+			},
+			Required: []string{"city"},
+		},
+	}
+}
+
+func TestToBedrockMessages(t *testing.T) {
+	messages := []UnifiedMessage{
+		SystemMessage("be brief"),
+		DeveloperMessage("use metric units"),
+		UserMessage("Weather and time in Oslo?"),
+		AssistantMessageWithToolCalls("Let me check.", []ToolCall{
+			{ID: "tooluse_1", Name: "get_weather", Arguments: map[string]any{"city": "Oslo"}},
+			{ID: "tooluse_2", Name: "get_time"},
+		}, 0, 0, 0),
+		ToolMessage("tooluse_1", "-3C, snow"),
+		ToolMessage("tooluse_2", "14:05"),
+		AssistantMessage("It is -3C and 14:05.", 0, 0, 0),
+		UserMessageWithParts(TextPart("And this?"), ImageBase64Part("image/png", []byte{0x89, 'P', 'N', 'G'})),
+	}
+
+	system, got, err := toBedrockMessages(messages)
+	if err != nil {
+		t.Fatalf("toBedrockMessages() unexpected error = %v", err)
+	}
+
+	if len(system) != 2 || system[1].(*types.SystemContentBlockMemberText).Value != "use metric units" {
+		t.Errorf("Expected system and developer messages as system blocks, got %+v", system)
+	}
+
+	wantRoles := []types.ConversationRole{
+		types.ConversationRoleUser,
+		types.ConversationRoleAssistant,
+		types.ConversationRoleUser,
+		types.ConversationRoleAssistant,
+		types.ConversationRoleUser,
+	}
+	if len(got) != len(wantRoles) {
+		t.Fatalf("Expected %d alternating messages, got %d: %+v", len(wantRoles), len(got), got)
+	}
+	for i, role := range wantRoles {
+		if got[i].Role != role {
+			t.Errorf("message %d: expected role %s, got %s", i, role, got[i].Role)
+		}
+	}
+
+	// Assistant text and tool uses share one message
+	assistant := got[1].Content
+	if len(assistant) != 3 {
+		t.Fatalf("Expected text and two tool uses, got %+v", assistant)
+	}
+	toolUse := assistant[1].(*types.ContentBlockMemberToolUse).Value
+	if *toolUse.ToolUseId != "tooluse_1" || *toolUse.Name != "get_weather" {
+		t.Errorf("Unexpected tool use block: %+v", toolUse)
+	}
+	raw, err := toolUse.Input.MarshalSmithyDocument()
+	if err != nil || string(raw) != `{"city":"Oslo"}` {
+		t.Errorf("Expected tool use input {\"city\":\"Oslo\"}, got %s (%v)", raw, err)
+	}
+	if raw, _ := assistant[2].(*types.ContentBlockMemberToolUse).Value.Input.MarshalSmithyDocument(); string(raw) != `{}` {
+		t.Errorf("Expected nil arguments as an empty object, got %s", raw)
+	}
+
+	// Consecutive tool results are merged into one user message
+	results := got[2].Content
+	if len(results) != 2 {
+		t.Fatalf("Expected two tool results in one message, got %+v", results)
+	}
+	result := results[1].(*types.ContentBlockMemberToolResult).Value
+	if *result.ToolUseId != "tooluse_2" || result.Content[0].(*types.ToolResultContentBlockMemberText).Value != "14:05" {
+		t.Errorf("Unexpected tool result block: %+v", result)
+	}
+
+	image := got[4].Content[1].(*types.ContentBlockMemberImage).Value
+	if image.Format != types.ImageFormatPng || string(image.Source.(*types.ImageSourceMemberBytes).Value) != "\x89PNG" {
+		t.Errorf("Unexpected image block: %+v", image)
+	}
+
+	t.Run("remote image URLs are rejected", func(t *testing.T) {
+		_, _, err := toBedrockMessages([]UnifiedMessage{UserMessageWithImages("look", []string{"https://example.com/cat.png"})})
+		if err == nil || !strings.Contains(err.Error(), "base64 data URL") {
+			t.Errorf("Expected data URL error, got %v", err)
+		}
+	})
+}
+
+func TestToBedrockToolConfig(t *testing.T) {
+	if config, err := toBedrockToolConfig(nil); err != nil || config != nil {
+		t.Errorf("Expected nil tool config without tools, got %+v (%v)", config, err)
+	}
+
+	config, err := toBedrockToolConfig([]Tool{weatherTool{name: "get_weather"}})
+	if err != nil {
+		t.Fatalf("toBedrockToolConfig() unexpected error = %v", err)
+	}
+	if len(config.Tools) != 1 {
+		t.Fatalf("Expected 1 tool, got %d", len(config.Tools))
+	}
+	spec := config.Tools[0].(*types.ToolMemberToolSpec).Value
+	if *spec.Name != "get_weather" || *spec.Description != "Get the weather for a city" {
+		t.Errorf("Unexpected tool spec: %+v", spec)
+	}
+
+	raw, err := spec.InputSchema.(*types.ToolInputSchemaMemberJson).Value.MarshalSmithyDocument()
+	if err != nil {
+		t.Fatalf("Failed to marshal input schema: %v", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		t.Fatalf("Failed to parse input schema %s: %v", raw, err)
+	}
+	if schema["type"] != "object" {
+		t.Errorf("Expected object schema, got %s", raw)
+	}
+	city, ok := schema["properties"].(map[string]any)["city"].(map[string]any)
+	if !ok || city["type"] != "string" || city["description"] != "City name" {
+		t.Errorf("Expected JSON Schema field names in properties, got %s", raw)
+	}
+	if required, _ := schema["required"].([]any); len(required) != 1 || required[0] != "city" {
+		t.Errorf("Expected required [city], got %s", raw)
+	}
+}
+
+func TestBedrockLLM_StreamText(t *testing.T) {
+	client := &fixtureClient{stream: loadBedrockFixture(t, "bedrock_text_stream.jsonl")}
+	llm := newBedrockLLM(context.Background(), BEDROCK_CLAUDE_3_5_HAIKU, client)
+
+	chunks, err := collectChunks(t, llm.ChatStream([]UnifiedMessage{SystemMessage("be brief"), UserMessage("hi")}, nil), 5*time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if *client.input.ModelId != BEDROCK_CLAUDE_3_5_HAIKU || len(client.input.System) != 1 || client.input.ToolConfig != nil {
+		t.Errorf("Unexpected request: %+v", client.input)
+	}
+
+	if len(chunks) != 4 {
+		t.Fatalf("Expected reasoning, 2 content and completion chunks, got %+v", chunks)
+	}
+	if chunks[0].Type != TypeReasoning || chunks[0].Trace != TraceThinking || chunks[0].Content != "The user greets me. " {
+		t.Errorf("Unexpected reasoning chunk: %+v", chunks[0])
+	}
+	if chunks[2].Type != TypeContent || chunks[2].Delta != " there!" || chunks[2].FullContent != "Hello there!" {
+		t.Errorf("Unexpected content chunk: %+v", chunks[2])
+	}
+	last := chunks[3]
+	if last.Status != StatusCompleted || last.FullContent != "Hello there!" {
+		t.Errorf("Expected completed chunk with the answer only, got %+v", last)
+	}
+	if last.PromptTokens != 11 || last.CompletionTokens != 7 || last.TotalTokens != 18 {
+		t.Errorf("Expected usage from the metadata event, got %+v", last)
+	}
+}
+
+func TestBedrockLLM_StreamToolCalls(t *testing.T) {
+	client := &fixtureClient{stream: loadBedrockFixture(t, "bedrock_tool_stream.jsonl")}
+	llm := newBedrockLLM(context.Background(), BEDROCK_CLAUDE_3_5_HAIKU, client)
+
+	tools := []Tool{weatherTool{name: "get_weather"}, weatherTool{name: "get_time"}}
+	chunks, err := collectChunks(t, llm.ChatStream([]UnifiedMessage{UserMessage("Weather and time in Oslo?")}, tools), 5*time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if client.input.ToolConfig == nil || len(client.input.ToolConfig.Tools) != 2 {
+		t.Errorf("Expected 2 tools in the request, got %+v", client.input.ToolConfig)
+	}
+
+	var toolCalls []ToolCall
+	for _, chunk := range chunks {
+		if chunk.Type == TypeToolCall {
+			toolCalls = chunk.ToolCalls
+		}
+	}
+	if len(toolCalls) != 2 {
+		t.Fatalf("Expected 2 tool calls, got %+v", chunks)
+	}
+	if toolCalls[0].ID != "tooluse_kZJMlvQmRJ6eAyJE5GIl7Q" || toolCalls[0].Name != "get_weather" || toolCalls[0].Arguments["city"] != "Oslo" {
+		t.Errorf("Unexpected first tool call: %+v", toolCalls[0])
+	}
+	if toolCalls[1].Name != "get_time" || len(toolCalls[1].Arguments) != 0 {
+		t.Errorf("Expected get_time with empty arguments, got %+v", toolCalls[1])
+	}
+
+	last := chunks[len(chunks)-1]
+	if last.Status != StatusCompleted || last.FullContent != "Let me check the weather." || last.TotalTokens != 314 {
+		t.Errorf("Unexpected completion chunk: %+v", last)
+	}
+}
+
+func TestBedrockLLM_StreamErrors(t *testing.T) {
+	t.Run("malformed tool arguments", func(t *testing.T) {
+		stream := &fixtureStream{events: make(chan types.ConverseStreamOutput, 2)}
+		index := int32(0)
+		input := `{"city": `
+		stream.events <- &types.ConverseStreamOutputMemberContentBlockStart{Value: types.ContentBlockStartEvent{
+			ContentBlockIndex: &index,
+			Start:             &types.ContentBlockStartMemberToolUse{Value: types.ToolUseBlockStart{ToolUseId: stringPtr("tooluse_1"), Name: stringPtr("get_weather")}},
+		}}
+		stream.events <- &types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: &index,
+			Delta:             &types.ContentBlockDeltaMemberToolUse{Value: types.ToolUseBlockDelta{Input: &input}},
+		}}
+		close(stream.events)
+
+		llm := newBedrockLLM(context.Background(), BEDROCK_CLAUDE_3_5_HAIKU, &fixtureClient{stream: stream})
+		_, err := collectChunks(t, llm.ChatStream([]UnifiedMessage{UserMessage("hi")}, nil), 5*time.Second)
+
+		var argErr *ToolArgumentsError
+		if !errors.As(err, &argErr) || argErr.ToolCallID != "tooluse_1" || argErr.Arguments != input {
+			t.Errorf("Expected *ToolArgumentsError, got %v", err)
+		}
+	})
+
+	t.Run("stream error", func(t *testing.T) {
+		stream := &fixtureStream{events: make(chan types.ConverseStreamOutput), err: errors.New("throttled")}
+		close(stream.events)
+
+		llm := newBedrockLLM(context.Background(), BEDROCK_CLAUDE_3_5_HAIKU, &fixtureClient{stream: stream})
+		_, err := collectChunks(t, llm.ChatStream([]UnifiedMessage{UserMessage("hi")}, nil), 5*time.Second)
+		if err == nil || !strings.Contains(err.Error(), "bedrock stream error: throttled") {
+			t.Errorf("Expected bedrock stream error, got %v", err)
+		}
+	})
+}
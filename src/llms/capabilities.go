@@ -0,0 +1,57 @@
+package llms
+
+// ModelCapabilities describes the limits of a model.
+type ModelCapabilities struct {
+	// ContextWindow is the maximum number of tokens of a request and its completion
+	// (0 if unknown)
+	ContextWindow int
+}
+
+// KnownModelCapabilities lists the capabilities of the models with a constant in this package.
+var KnownModelCapabilities = map[string]ModelCapabilities{
+	OPENAI_GPT5O:  {ContextWindow: 400000},
+	OPENAI_GPT5_1: {ContextWindow: 400000},
+	OPENAI_GPT5_2: {ContextWindow: 400000},
+
+	TOGETHERAI_Llama323BInstructTurbo:  {ContextWindow: 131072},
+	TOGETHERAI_OPENAIGPTOSS120B:        {ContextWindow: 131072},
+	TOGETHERAI_Qwen257BInstructTurbo:   {ContextWindow: 32768},
+	TOGETHERAI_Llama3170BInstructTurbo: {ContextWindow: 131072},
+
+	BEDROCK_CLAUDE_3_5_HAIKU:     {ContextWindow: 200000},
+	BEDROCK_LLAMA3_1_8B_INSTRUCT: {ContextWindow: 128000},
+
+	DEEPSEEK_CHAT:      {ContextWindow: 128000},
+	DEEPSEEK_REASONING: {ContextWindow: 128000},
+}
+
+// GetModelCapabilities returns the capabilities of a model listed in KnownModelCapabilities.
+//
+// Parameters:
+//   - model: The model name or ID
+//
+// Returns:
+//   - ModelCapabilities: The capabilities of the model (zero value if unknown)
+//   - bool: Whether the model is known
+func GetModelCapabilities(model string) (ModelCapabilities, bool) {
+	capabilities, ok := KnownModelCapabilities[model]
+	return capabilities, ok
+}
+
+// ModelCapabilitiesReporter is implemented by engines that report the capabilities
+// of their model (zero values for unknown models).
+type ModelCapabilitiesReporter interface {
+	Capabilities() ModelCapabilities
+}
+
+// Capabilities returns the capabilities of the engine's model (implements ModelCapabilitiesReporter).
+func (a *openAILLM) Capabilities() ModelCapabilities {
+	capabilities, _ := GetModelCapabilities(a.model)
+	return capabilities
+}
+
+// Capabilities returns the capabilities of the engine's model (implements ModelCapabilitiesReporter).
+func (b *bedrockLLM) Capabilities() ModelCapabilities {
+	capabilities, _ := GetModelCapabilities(b.modelID)
+	return capabilities
+}
@@ -0,0 +1,24 @@
+package llms
+
+import "testing"
+
+func TestModelCapabilities(t *testing.T) {
+	llm, err := NewOpenAILLMBuilder("togetherai").SetAPIKey("test-key").SetModel(TOGETHERAI_Qwen257BInstructTurbo).Build()
+	if err != nil {
+		t.Fatalf("Build() unexpected error = %v", err)
+	}
+	reporter, ok := llm.(ModelCapabilitiesReporter)
+	if !ok {
+		t.Fatalf("Expected %T to implement ModelCapabilitiesReporter", llm)
+	}
+	if got := reporter.Capabilities().ContextWindow; got != 32768 {
+		t.Errorf("ContextWindow = %d, want 32768", got)
+	}
+
+	if _, ok := GetModelCapabilities("unknown-model"); ok {
+		t.Error("Expected unknown models to be reported as unknown")
+	}
+	if got := (&openAILLM{model: "unknown-model"}).Capabilities(); got.ContextWindow != 0 {
+		t.Errorf("Expected a zero context window for unknown models, got %d", got.ContextWindow)
+	}
+}
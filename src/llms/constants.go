@@ -105,6 +105,22 @@ const (
 	//   - Result: Tool output data (if successful)
 	//   - Error: Error message (if failed)
 	StatusToolResult = "tool-result"
+
+	// StatusToolPlanned indicates that a tool would have been executed, but the
+	// agent runs in dry-run mode (AgentConfig.DryRun) and skipped it.
+	// It replaces StatusToolExecuting for that tool.
+	//
+	// When to expect:
+	//   - After receiving StatusToolCall, in dry-run mode only
+	//   - Before the StatusToolResult carrying the synthetic result
+	//
+	// Associated fields:
+	//   - ToolPlanned: Pointer to the ToolCall with the arguments it would have used
+	//   - Type: Usually "tool-planned"
+	//
+	// Use cases:
+	//   - Previewing or approving an agent's actions before running them
+	StatusToolPlanned = "tool-planned"
 )
 
 // ChunkResponse Type Constants
@@ -125,6 +141,20 @@ const (
 	//   - FullContent: All content accumulated so far
 	TypeContent = "content"
 
+	// TypeReasoning indicates a chunk containing reasoning ("thinking") tokens streamed
+	// by reasoning models (e.g., DeepSeek reasoner) separately from the answer.
+	// Reasoning is never part of the answer content or the history.
+	//
+	// When to expect:
+	//   - Before or interleaved with content chunks, for reasoning models only
+	//   - With Status: StatusStreaming and Trace: TraceThinking
+	//
+	// Associated data:
+	//   - Content: The reasoning text of this chunk
+	//   - Delta: Incremental reasoning text for this chunk
+	//   - FullContent: All reasoning accumulated so far
+	TypeReasoning = "reasoning"
+
 	// TypeToolCall indicates a chunk containing tool call requests from the LLM.
 	// The LLM has decided to use external tools and is providing the tool names
 	// and arguments needed to execute them.
@@ -162,6 +192,16 @@ const (
 	//   - Each result includes: ToolCallID, ToolName, Success, Result, Error
 	TypeToolResult = "tool-result"
 
+	// TypeToolPlanned indicates a chunk describing a tool call skipped in dry-run mode.
+	//
+	// When to expect:
+	//   - Instead of TypeToolExecuting when AgentConfig.DryRun is set
+	//   - With Status: StatusToolPlanned
+	//
+	// Associated data:
+	//   - ToolPlanned: The ToolCall (name and arguments) that was not executed
+	TypeToolPlanned = "tool-planned"
+
 	// TypeCompletion indicates the final chunk signaling that the response is complete.
 	// This type marks the end of the streaming response when no more data will be sent.
 	//
@@ -180,10 +220,12 @@ const (
 //
 // Common Combinations:
 //   - Status: StatusStreaming,  Type: TypeContent        → Regular content streaming
+//   - Status: StatusStreaming,  Type: TypeReasoning      → Reasoning tokens (Trace: TraceThinking)
 //   - Status: StatusCompleted,  Type: TypeCompletion     → Response finished
 //   - Status: StatusToolCall,   Type: TypeToolCall       → LLM requesting tools
 //   - Status: StatusToolExecuting, Type: TypeToolExecuting → Tool is running
 //   - Status: StatusToolResult, Type: TypeToolResult     → Tool results available
+//   - Status: StatusToolPlanned, Type: TypeToolPlanned   → Tool skipped in dry-run mode
 //   - Status: StatusError,      Type: (any)              → Error occurred
 //
 // Typical Flow (without tools):
@@ -197,3 +239,10 @@ const (
 //   4. Status: StatusToolResult, Type: TypeToolResult (for each tool)
 //   5. Status: StatusStreaming, Type: TypeContent (LLM continues with tool context)
 //   6. Status: StatusCompleted, Type: TypeCompletion (final chunk)
+
+// TraceThinking is the ChunkResponse.Trace of reasoning chunks (TypeReasoning).
+const TraceThinking = "thinking"
+
+// TraceToolProgress is the ChunkResponse.Trace of the TypeContent chunks a running
+// tool sends to report progress (see tools.Progress). They are not part of the answer.
+const TraceToolProgress = "tool-progress"
@@ -0,0 +1,84 @@
+package llms
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// EmbeddingEngine turns texts into embedding vectors.
+//
+// Implement it to plug in any embedding API; NewEmbeddingEngine provides an
+// OpenAI-compatible implementation.
+type EmbeddingEngine interface {
+	// Embed returns one vector per input text, in the same order as texts.
+	//
+	// Parameters:
+	//   - ctx: Context for cancellation
+	//   - texts: The texts to embed
+	//
+	// Returns:
+	//   - [][]float32: The embedding vectors
+	//   - error: If the request fails or the response does not match the input
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// openAIEmbeddingEngine implements EmbeddingEngine on top of the client of an openAILLM.
+type openAIEmbeddingEngine struct {
+	client openai.Client
+	model  string
+}
+
+// NewEmbeddingEngine creates an embedding engine sharing the client (base URL,
+// API key and request options) of an engine built by OpenAILLMBuilder or
+// GetAzureOpenAILLM.
+//
+// Parameters:
+//   - engine: An OpenAI-compatible engine
+//   - model: The embedding model (empty uses OPENAI_TEXT_EMBEDDING_3_SMALL)
+//
+// Returns:
+//   - EmbeddingEngine: The embedding engine
+//   - error: If the engine is not OpenAI-compatible
+func NewEmbeddingEngine(engine LLMEngine, model string) (EmbeddingEngine, error) {
+	llm, ok := engine.(*openAILLM)
+	if !ok {
+		return nil, fmt.Errorf("engine %T does not support embeddings", engine)
+	}
+	if model == "" {
+		model = OPENAI_TEXT_EMBEDDING_3_SMALL
+	}
+	return &openAIEmbeddingEngine{client: llm.client, model: model}, nil
+}
+
+// Embed implements EmbeddingEngine.
+func (e *openAIEmbeddingEngine) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	res, err := e.client.Embeddings.New(ctx, openai.EmbeddingNewParams{
+		Model: openai.EmbeddingModel(e.model),
+		Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	if len(res.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(res.Data))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, item := range res.Data {
+		if item.Index < 0 || int(item.Index) >= len(texts) || vectors[item.Index] != nil {
+			return nil, fmt.Errorf("unexpected embedding index %d", item.Index)
+		}
+		vector := make([]float32, len(item.Embedding))
+		for i, v := range item.Embedding {
+			vector[i] = float32(v)
+		}
+		vectors[item.Index] = vector
+	}
+	return vectors, nil
+}
@@ -0,0 +1,54 @@
+package llms
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEmbeddingEngine(t *testing.T) {
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.Header().Set("Content-Type", "application/json")
+		// Out of order on purpose: vectors are placed by index
+		_, _ = w.Write([]byte(`{"object":"list","model":"test-embed","data":[
+			{"object":"embedding","index":1,"embedding":[0,1]},
+			{"object":"embedding","index":0,"embedding":[1,0.5]}
+		],"usage":{"prompt_tokens":2,"total_tokens":2}}`))
+	}))
+	defer server.Close()
+
+	engine, err := NewEmbeddingEngine(newOpenAILLM(context.Background(), server.URL, "test-model", "test-key"), "test-embed")
+	if err != nil {
+		t.Fatalf("NewEmbeddingEngine() unexpected error = %v", err)
+	}
+
+	vectors, err := engine.Embed(context.Background(), []string{"first", "second"})
+	if err != nil {
+		t.Fatalf("Embed() unexpected error = %v", err)
+	}
+	if received["model"] != "test-embed" {
+		t.Errorf("Expected model test-embed, got %v", received["model"])
+	}
+	if input, _ := received["input"].([]any); len(input) != 2 || input[0] != "first" {
+		t.Errorf("Unexpected input: %v", received["input"])
+	}
+	if len(vectors) != 2 || vectors[0][1] != 0.5 || vectors[1][1] != 1 {
+		t.Errorf("Unexpected vectors: %v", vectors)
+	}
+
+	if _, err := engine.Embed(context.Background(), []string{"only one"}); err == nil {
+		t.Error("Expected error when the response does not match the input count")
+	}
+
+	if _, err := NewEmbeddingEngine(nil, ""); err == nil {
+		t.Error("Expected error for a non OpenAI-compatible engine")
+	}
+}
@@ -3,6 +3,9 @@ package llms
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	agentforge "github.com/thinktwice/agentForge/src"
 )
@@ -13,40 +16,89 @@ type OpenAILLMBuilder struct {
 	BaseURL  string
 	Provider string
 	Ctx      context.Context
+	// IdleTimeout aborts a stream when no chunk arrives within the duration.
+	// Zero (default) disables the timeout.
+	IdleTimeout time.Duration
+	// Options are the generation options applied to every request.
+	Options GenerationOptions
+	// PromoteSystemToDeveloper sends system messages with the developer role,
+	// which models such as gpt-5 follow more closely.
+	PromoteSystemToDeveloper bool
+	// ResponseBufferSize is the number of chunks buffered by the engine's response
+	// channels. Zero (default) uses DefaultResponseBufferSize.
+	ResponseBufferSize int
+	// RateLimiter paces the engine's requests (see NewRateLimiter).
+	// Nil (default) disables rate limiting.
+	RateLimiter RateLimiter
+	// ToolCallID generates the IDs of tool calls returned without one.
+	// Nil (default) uses DefaultToolCallID.
+	ToolCallID ToolCallIDFunc
 }
 
+// NewOpenAILLMBuilder creates a builder for an OpenAI-compatible engine.
+//
+// The provider name is case-insensitive ("openai", "deepseek" or "togetherai").
+// Unset fields are resolved at Build time from DefaultBaseURL, DefaultModel and
+// the provider's API key environment variable (see ProviderAPIKeyEnvVar).
+//
+// Parameters:
+//   - provider: The provider name
+//
+// Returns:
+//   - *OpenAILLMBuilder: A new builder
+//
+// Panics:
+//   - If the provider is not supported
 func NewOpenAILLMBuilder(provider string) *OpenAILLMBuilder {
-	if provider != "openai" && provider != "deepseek" && provider != "togetherai" {
-		panic(fmt.Sprintf("Invalid provider: %s", provider))
+	normalized, err := normalizeProvider(provider)
+	if err != nil {
+		panic(err.Error())
 	}
 	return &OpenAILLMBuilder{
-		Provider: provider,
+		Provider: normalized,
 		Ctx:      context.Background(),
 	}
 }
 
-func (b *OpenAILLMBuilder) validate() {
-	c, err := agentforge.NewConfig()
-	if err != nil {
-		agentforge.Error(fmt.Sprintf("Failed to load config: %v", err))
+// normalizeProvider lower-cases a provider name and checks that it is supported.
+func normalizeProvider(provider string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(provider))
+	if _, ok := DefaultBaseURL[normalized]; !ok {
+		supported := make([]string, 0, len(DefaultBaseURL))
+		for name := range DefaultBaseURL {
+			supported = append(supported, name)
+		}
+		sort.Strings(supported)
+		return "", fmt.Errorf("invalid provider: %s (supported: %s)", provider, strings.Join(supported, ", "))
 	}
+	return normalized, nil
+}
 
-	if b.Provider == "" {
-		agentforge.Error("Provider is required")
+// validate resolves the defaults of unset fields and checks the builder is complete.
+func (b *OpenAILLMBuilder) validate() error {
+	provider, err := normalizeProvider(b.Provider)
+	if err != nil {
+		return err
 	}
+	b.Provider = provider
 
 	if b.ApiKey == "" {
-		if b.Provider == "openai" {
+		c, err := agentforge.NewConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		switch b.Provider {
+		case "openai":
 			b.ApiKey = c.AFOpenAIAPIKey
-		} else if b.Provider == "deepseek" {
+		case "deepseek":
 			b.ApiKey = c.AFDeepSeekAPIKey
-		} else if b.Provider == "togetherai" {
+		case "togetherai":
 			b.ApiKey = c.AFTogetherAIAPIKey
 		}
 	}
 
 	if b.ApiKey == "" {
-		agentforge.Warn("No API key found for provider: %s", b.Provider)
+		return fmt.Errorf("no API key for provider %s: set %s or call SetAPIKey", b.Provider, ProviderAPIKeyEnvVar[b.Provider])
 	}
 
 	if b.Ctx == nil {
@@ -54,29 +106,19 @@ func (b *OpenAILLMBuilder) validate() {
 	}
 
 	if b.BaseURL == "" {
-		canidateBaseURL, ok := DefaultBaseURL[b.Provider]
-		if ok {
-			b.BaseURL = canidateBaseURL
-		} else {
-			panic(fmt.Sprintf("No default base URL found for provider: %s", b.Provider))
-		}
+		b.BaseURL = DefaultBaseURL[b.Provider]
 	}
 
 	if b.Model == "" {
 		canidateModel, ok := DefaultModel[b.Provider]
-		if ok {
-			b.Model = canidateModel
-		} else {
-			panic(fmt.Sprintf("No default model found for provider: %s", b.Provider))
+		if !ok {
+			return fmt.Errorf("no default model found for provider: %s", b.Provider)
 		}
+		b.Model = canidateModel
 	}
 
-	agentforge.Info("LLM builder validated: %+v", b)
-	agentforge.Info("LLM builder validated: %+v", b.Provider)
-	agentforge.Info("LLM builder validated: %+d", len(b.ApiKey))
-	agentforge.Info("LLM builder validated: %+v", b.Model)
-	agentforge.Info("LLM builder validated: %+v", b.BaseURL)
-	agentforge.Info("LLM builder validated: %+v", b.Ctx)
+	agentforge.Debug("LLM builder validated: provider=%s model=%s baseURL=%s", b.Provider, b.Model, b.BaseURL)
+	return nil
 }
 
 func (b *OpenAILLMBuilder) SetProvider(p string) *OpenAILLMBuilder {
@@ -89,6 +131,11 @@ func (b *OpenAILLMBuilder) SetApiKey(apiKey string) *OpenAILLMBuilder {
 	return b
 }
 
+// SetAPIKey sets the API key, overriding the provider's environment variable.
+func (b *OpenAILLMBuilder) SetAPIKey(apiKey string) *OpenAILLMBuilder {
+	return b.SetApiKey(apiKey)
+}
+
 func (b *OpenAILLMBuilder) SetModel(model string) *OpenAILLMBuilder {
 	b.Model = model
 	return b
@@ -104,9 +151,143 @@ func (b *OpenAILLMBuilder) SetCtx(ctx context.Context) *OpenAILLMBuilder {
 	return b
 }
 
+// SetContext sets the context used by the engine's requests.
+func (b *OpenAILLMBuilder) SetContext(ctx context.Context) *OpenAILLMBuilder {
+	return b.SetCtx(ctx)
+}
+
+// SetIdleTimeout sets the maximum time to wait between stream chunks.
+// A zero or negative duration disables the timeout.
+func (b *OpenAILLMBuilder) SetIdleTimeout(timeout time.Duration) *OpenAILLMBuilder {
+	b.IdleTimeout = timeout
+	return b
+}
+
+// SetPromoteSystemToDeveloper enables or disables sending system messages with
+// the developer role. See ModelPrefersDeveloperRole for models that expect it.
+func (b *OpenAILLMBuilder) SetPromoteSystemToDeveloper(enabled bool) *OpenAILLMBuilder {
+	b.PromoteSystemToDeveloper = enabled
+	return b
+}
+
+// SetResponseBufferSize sets the number of chunks buffered by the engine's response
+// channels. A zero or negative size uses DefaultResponseBufferSize.
+func (b *OpenAILLMBuilder) SetResponseBufferSize(size int) *OpenAILLMBuilder {
+	b.ResponseBufferSize = size
+	return b
+}
+
+// SetRateLimiter sets the limiter consulted before each request. Share one
+// limiter between engines to apply a common limit.
+func (b *OpenAILLMBuilder) SetRateLimiter(limiter RateLimiter) *OpenAILLMBuilder {
+	b.RateLimiter = limiter
+	return b
+}
+
+// SetToolCallIDFunc sets the function naming tool calls that the provider
+// returned without an ID. Nil uses DefaultToolCallID.
+func (b *OpenAILLMBuilder) SetToolCallIDFunc(fn ToolCallIDFunc) *OpenAILLMBuilder {
+	b.ToolCallID = fn
+	return b
+}
+
+// SetResponseFormat requests structured output from the model.
+//
+// Parameters:
+//   - format: ResponseFormatText, ResponseFormatJSONObject or ResponseFormatJSONSchema
+//   - schema: The JSON schema (required for ResponseFormatJSONSchema, nil otherwise)
+func (b *OpenAILLMBuilder) SetResponseFormat(format ResponseFormat, schema *JSONSchema) *OpenAILLMBuilder {
+	b.Options.ResponseFormat = format
+	b.Options.JSONSchema = schema
+	return b
+}
+
+// SetToolChoice sets the default tool choice of the engine
+// (ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired or a tool name).
+func (b *OpenAILLMBuilder) SetToolChoice(choice ToolChoice) *OpenAILLMBuilder {
+	b.Options.ToolChoice = choice
+	return b
+}
+
+// Build creates the engine, resolving defaults for unset fields.
+//
+// Returns:
+//   - LLMEngine: The configured engine
+//   - error: If the provider is invalid, no API key is available, or the options are invalid
 func (b *OpenAILLMBuilder) Build() (LLMEngine, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := b.validateOptions(); err != nil {
+		return nil, err
+	}
+
+	llm := newOpenAILLM(b.Ctx, b.BaseURL, b.Model, b.ApiKey)
+	if b.IdleTimeout > 0 {
+		llm.idleTimeout = b.IdleTimeout
+	}
+	llm.options = b.Options
+	llm.provider = b.Provider
+	llm.promoteSystemToDeveloper = b.PromoteSystemToDeveloper
+	llm.rateLimiter = b.RateLimiter
+	llm.responseBufferSize = b.ResponseBufferSize
+	llm.supportsPrefill = ProviderSupportsAssistantPrefill[b.Provider]
+	llm.toolCallID = b.ToolCallID
+	return llm, nil
+}
+
+// validateOptions checks the generation options and that the provider supports them.
+func (b *OpenAILLMBuilder) validateOptions() error {
+	if err := b.Options.validate(); err != nil {
+		return fmt.Errorf("invalid generation options: %w", err)
+	}
+
+	return checkResponseFormat(b.Provider, b.Options.ResponseFormat)
+}
 
-	b.validate()
+// checkResponseFormat checks that the provider supports the response format
+// (see ProviderResponseFormats). An empty format is always supported.
+func checkResponseFormat(provider string, responseFormat ResponseFormat) error {
+	if responseFormat == "" {
+		return nil
+	}
+	for _, format := range ProviderResponseFormats[provider] {
+		if format == responseFormat {
+			return nil
+		}
+	}
+	return fmt.Errorf("provider %s does not support response format %q", provider, responseFormat)
+}
+
+// GetDeepSeekLLM creates an engine for a DeepSeek model.
+// The API key is read from AF_DEEPSEEK_API_KEY.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - model: The model name (empty uses DEEPSEEK_CHAT)
+//
+// Returns:
+//   - LLMEngine: The configured engine
+//   - error: An error if the API key is missing
+func GetDeepSeekLLM(ctx context.Context, model string) (LLMEngine, error) {
+	return NewOpenAILLMBuilder("deepseek").SetContext(ctx).SetModel(model).Build()
+}
 
-	return newOpenAILLM(b.Ctx, b.BaseURL, b.Model, b.ApiKey), nil
+// GetDeepSeekReasoningLLM creates an engine for the DeepSeek reasoner model.
+//
+// The reasoner streams its chain of thought in the reasoning_content delta
+// field. The engine forwards it as TypeReasoning chunks (Trace TraceThinking),
+// keeps it out of the answer and never sends it back, as DeepSeek rejects
+// requests that echo reasoning content. Tool calls are handled as for the
+// chat model. The API key is read from AF_DEEPSEEK_API_KEY.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//
+// Returns:
+//   - LLMEngine: The configured engine
+//   - error: An error if the API key is missing
+func GetDeepSeekReasoningLLM(ctx context.Context) (LLMEngine, error) {
+	return GetDeepSeekLLM(ctx, DEEPSEEK_REASONING)
 }
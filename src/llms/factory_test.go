@@ -0,0 +1,157 @@
+package llms
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewOpenAILLMBuilder_Provider(t *testing.T) {
+	t.Run("case-insensitive", func(t *testing.T) {
+		b := NewOpenAILLMBuilder(" TogetherAI ")
+		if b.Provider != "togetherai" {
+			t.Errorf("Expected normalized provider 'togetherai', got %q", b.Provider)
+		}
+	})
+
+	t.Run("invalid provider panics", func(t *testing.T) {
+		defer func() {
+			r := recover()
+			if r == nil || !strings.Contains(r.(string), "invalid provider: anthropic (supported: deepseek, openai, togetherai)") {
+				t.Errorf("Expected invalid provider panic, got %v", r)
+			}
+		}()
+		NewOpenAILLMBuilder("anthropic")
+	})
+}
+
+func TestOpenAILLMBuilder_Build(t *testing.T) {
+	t.Run("resolves defaults", func(t *testing.T) {
+		llm, err := NewOpenAILLMBuilder("DeepSeek").SetAPIKey("test-key").Build()
+		if err != nil {
+			t.Fatalf("Build() unexpected error = %v", err)
+		}
+		engine := llm.(*openAILLM)
+		if engine.model != DefaultModel["deepseek"] {
+			t.Errorf("Expected default model %q, got %q", DefaultModel["deepseek"], engine.model)
+		}
+	})
+
+	t.Run("explicit settings", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), struct{}{}, "marker")
+		b := NewOpenAILLMBuilder("openai").
+			SetAPIKey("test-key").
+			SetModel("custom-model").
+			SetBaseURL("http://localhost:1234/v1").
+			SetContext(ctx)
+		if _, err := b.Build(); err != nil {
+			t.Fatalf("Build() unexpected error = %v", err)
+		}
+		if b.Model != "custom-model" || b.BaseURL != "http://localhost:1234/v1" || b.Ctx != ctx {
+			t.Errorf("Expected explicit settings to be kept, got %+v", b)
+		}
+	})
+
+	t.Run("response buffer size", func(t *testing.T) {
+		llm, err := NewOpenAILLMBuilder("openai").SetAPIKey("test-key").SetResponseBufferSize(32).Build()
+		if err != nil {
+			t.Fatalf("Build() unexpected error = %v", err)
+		}
+		if got := llm.(*openAILLM).responseBufferSize; got != 32 {
+			t.Errorf("responseBufferSize = %d, want 32", got)
+		}
+		if got := cap(newResponseChWithBuffer(32).Response); got != 32 {
+			t.Errorf("cap(Response) = %d, want 32", got)
+		}
+		if got := cap(NewResponseChWithBuffer(0).Response); got != DefaultResponseBufferSize {
+			t.Errorf("Expected a zero size to use the default, got %d", got)
+		}
+	})
+
+	t.Run("API key from environment", func(t *testing.T) {
+		t.Setenv(TogetherAIAPIKeyEnvVar, "env-key")
+		b := NewOpenAILLMBuilder("togetherai")
+		if _, err := b.Build(); err != nil {
+			t.Fatalf("Build() unexpected error = %v", err)
+		}
+		if b.ApiKey != "env-key" {
+			t.Errorf("Expected API key from %s, got %q", TogetherAIAPIKeyEnvVar, b.ApiKey)
+		}
+	})
+
+	t.Run("missing API key", func(t *testing.T) {
+		t.Setenv(DeepSeekAPIKeyEnvVar, "")
+		_, err := NewOpenAILLMBuilder("deepseek").Build()
+		if err == nil || !strings.Contains(err.Error(), "no API key for provider deepseek: set AF_DEEPSEEK_API_KEY") {
+			t.Errorf("Expected missing API key error, got %v", err)
+		}
+	})
+}
+
+func TestGetDeepSeekReasoningLLM(t *testing.T) {
+	t.Run("model and API key resolution", func(t *testing.T) {
+		t.Setenv(DeepSeekAPIKeyEnvVar, "env-key")
+		llm, err := GetDeepSeekReasoningLLM(context.Background())
+		if err != nil {
+			t.Fatalf("GetDeepSeekReasoningLLM() unexpected error = %v", err)
+		}
+		engine := llm.(*openAILLM)
+		if engine.model != "deepseek-reasoner" || engine.apiKey != "env-key" || engine.baseURL != DEEPSEEK_BASE_URL {
+			t.Errorf("Unexpected engine settings: model=%q apiKey=%q baseURL=%q", engine.model, engine.apiKey, engine.baseURL)
+		}
+
+		chat, err := GetDeepSeekLLM(context.Background(), "")
+		if err != nil {
+			t.Fatalf("GetDeepSeekLLM() unexpected error = %v", err)
+		}
+		if chat.(*openAILLM).model != DEEPSEEK_CHAT {
+			t.Errorf("Expected default model %q, got %q", DEEPSEEK_CHAT, chat.(*openAILLM).model)
+		}
+	})
+
+	t.Run("missing API key", func(t *testing.T) {
+		t.Setenv(DeepSeekAPIKeyEnvVar, "")
+		_, err := GetDeepSeekReasoningLLM(context.Background())
+		if err == nil || !strings.Contains(err.Error(), "set AF_DEEPSEEK_API_KEY") {
+			t.Errorf("Expected missing API key error naming AF_DEEPSEEK_API_KEY, got %v", err)
+		}
+	})
+
+	t.Run("reasoning content and tool calls", func(t *testing.T) {
+		server := newMockOpenAIServer(t, []string{
+			reasoningChunkJSON("reasoning_content", "I need the weather."),
+			`{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"deepseek-reasoner","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Oslo\"}"}}]}}]}`,
+		}, false)
+		llm := newOpenAILLM(context.Background(), server.URL, DEEPSEEK_REASONING, "test-key")
+
+		history := []UnifiedMessage{UserMessage("Weather in Oslo?")}
+		chunks, err := collectChunks(t, llm.ChatStream(history, []Tool{weatherTool{name: "get_weather"}}), 5*time.Second)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var reasoning string
+		var toolCalls []ToolCall
+		for _, chunk := range chunks {
+			switch chunk.Type {
+			case TypeReasoning:
+				reasoning += chunk.Content
+			case TypeToolCall:
+				toolCalls = chunk.ToolCalls
+			}
+		}
+		if reasoning != "I need the weather." {
+			t.Errorf("Expected reasoning chunk, got %q", reasoning)
+		}
+		if len(toolCalls) != 1 || toolCalls[0].Arguments["city"] != "Oslo" {
+			t.Errorf("Expected get_weather tool call, got %+v", toolCalls)
+		}
+		if last := chunks[len(chunks)-1]; last.FullContent != "" {
+			t.Errorf("Expected reasoning kept out of the answer, got %q", last.FullContent)
+		}
+		if !strings.Contains(server.LastBody(), `"tools"`) || strings.Contains(server.LastBody(), "reasoning_content") {
+			t.Errorf("Expected tools and no reasoning content in the request, got %s", server.LastBody())
+		}
+	})
+}
@@ -0,0 +1,189 @@
+package llms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/openai/openai-go/v3"
+	agentforge "github.com/thinktwice/agentForge/src"
+)
+
+// FallbackEngine serves each call with an ordered list of engines: when an engine
+// fails with a retryable error before streaming anything, the same messages, tools
+// and options are sent to the next one. Once a chunk has reached the consumer the
+// call stays with that engine and its errors are returned as is.
+type FallbackEngine struct {
+	engines []LLMEngine
+
+	// Retryable reports whether an error moves the call to the next engine.
+	// Defaults to IsRetryableError.
+	Retryable func(err error) bool
+}
+
+// NewFallbackEngine creates an engine failing over from the first engine to the next.
+//
+// Parameters:
+//   - engines: The engines in order of preference
+//
+// Returns:
+//   - *FallbackEngine: The fallback engine
+//   - error: If no engine is given or an engine is nil
+func NewFallbackEngine(engines ...LLMEngine) (*FallbackEngine, error) {
+	if len(engines) == 0 {
+		return nil, fmt.Errorf("at least one engine is required")
+	}
+	for i, engine := range engines {
+		if engine == nil {
+			return nil, fmt.Errorf("engine %d is nil", i)
+		}
+	}
+	return &FallbackEngine{engines: engines}, nil
+}
+
+// IsRetryableError reports whether another provider may succeed where err failed:
+// rate limits (429), timeouts (408), server errors (5xx) and errors that are not
+// API errors, such as network failures. Other API errors of OpenAI-compatible
+// providers and Bedrock (bad request, authentication...), malformed tool call
+// arguments (*ToolArgumentsError, bad model output the agent retries on its own)
+// and cancellations are not retryable.
+func IsRetryableError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var argErr *ToolArgumentsError
+	if errors.As(err, &argErr) {
+		return false
+	}
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		return isRetryableStatus(apiErr.StatusCode)
+	}
+	// AWS SDK (Bedrock) response errors carry the HTTP status of the response
+	var httpErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &httpErr) {
+		return isRetryableStatus(httpErr.HTTPStatusCode())
+	}
+	return true
+}
+
+// isRetryableStatus reports whether an API error with the HTTP status code is retryable.
+func isRetryableStatus(code int) bool {
+	return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// Capabilities reports the capabilities of the first engine (implements ModelCapabilitiesReporter).
+func (f *FallbackEngine) Capabilities() ModelCapabilities {
+	if reporter, ok := f.engines[0].(ModelCapabilitiesReporter); ok {
+		return reporter.Capabilities()
+	}
+	return ModelCapabilities{}
+}
+
+// ChatStream implements LLMEngine.
+func (f *FallbackEngine) ChatStream(messages []UnifiedMessage, tools []Tool) *responseCh {
+	return f.ChatStreamWithOptions(messages, tools, GenerationOptions{})
+}
+
+// ChatStreamWithOptions implements LLMEngineWithOptions. The options are passed on
+// to the engines that accept them.
+func (f *FallbackEngine) ChatStreamWithOptions(messages []UnifiedMessage, tools []Tool, opts GenerationOptions) *responseCh {
+	out := newResponseCh()
+	go f.serve(messages, tools, opts, out)
+	return out
+}
+
+// serve tries the engines in order until one streams or fails for good.
+func (f *FallbackEngine) serve(messages []UnifiedMessage, tools []Tool, opts GenerationOptions, out *responseCh) {
+	defer out.Close()
+
+	retryable := f.Retryable
+	if retryable == nil {
+		retryable = IsRetryableError
+	}
+
+	var errs []error
+	for i, engine := range f.engines {
+		var inner *responseCh
+		if withOptions, ok := engine.(LLMEngineWithOptions); ok {
+			inner = withOptions.ChatStreamWithOptions(messages, tools, opts)
+		} else {
+			inner = engine.ChatStream(messages, tools)
+		}
+
+		forwarded, err := relay(inner, out)
+		if err == nil {
+			return
+		}
+		select {
+		case <-out.Cancelled():
+			return
+		default:
+		}
+		if forwarded || !retryable(err) {
+			out.Error <- err
+			return
+		}
+		errs = append(errs, fmt.Errorf("engine %d: %w", i, err))
+		if i < len(f.engines)-1 {
+			agentforge.Warn("LLM engine %d failed (%v), falling back to engine %d", i, err, i+1)
+		}
+	}
+	out.Error <- fmt.Errorf("all %d engines failed: %w", len(f.engines), errors.Join(errs...))
+}
+
+// relay forwards the chunks of inner to out until the stream completes, fails or
+// out is cancelled (which cancels inner and is not an error).
+//
+// Returns:
+//   - bool: Whether any chunk was forwarded
+//   - error: The error that ended the stream
+func relay(inner, out *responseCh) (bool, error) {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-out.Cancelled():
+			inner.Cancel()
+		case <-done:
+		}
+	}()
+	defer inner.Cancel()
+
+	// Like Start, stop at completion or at the first error even if Response stays open
+	forwarded := false
+	errCh := inner.Error
+	for {
+		select {
+		case chunkBytes, ok := <-inner.Response:
+			if !ok {
+				if errCh != nil {
+					if err := <-errCh; err != nil {
+						return forwarded, err
+					}
+				}
+				return forwarded, nil
+			}
+			select {
+			case out.Response <- chunkBytes:
+				forwarded = true
+			case <-out.Cancelled():
+				return forwarded, nil
+			}
+			var chunk ChunkResponse
+			if err := json.Unmarshal(chunkBytes, &chunk); err == nil && chunk.Status == StatusCompleted {
+				return forwarded, nil
+			}
+		case err, ok := <-errCh:
+			if !ok {
+				errCh = nil
+				continue
+			}
+			if err != nil {
+				return forwarded, err
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package llms
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/openai/openai-go/v3"
+)
+
+// collectFallback drains a fallback stream and returns the completion content and the stream error.
+func collectFallback(t *testing.T, rc *ResponseCh) (string, error) {
+	t.Helper()
+	chunks, err := collectChunks(t, rc, time.Second)
+	if err == nil {
+		select {
+		case err = <-rc.Error:
+		default:
+		}
+	}
+	for _, chunk := range chunks {
+		if chunk.Status == StatusCompleted {
+			return chunk.FullContent, err
+		}
+	}
+	return "", err
+}
+
+// countingEngine wraps an engine and counts its calls.
+type countingEngine struct {
+	LLMEngine
+	calls    int
+	messages []UnifiedMessage
+}
+
+func (e *countingEngine) ChatStream(messages []UnifiedMessage, tools []Tool) *ResponseCh {
+	e.calls++
+	e.messages = messages
+	return e.LLMEngine.ChatStream(messages, tools)
+}
+
+func TestFallbackEngine(t *testing.T) {
+	primary := &countingEngine{LLMEngine: streamOnlyEngine{err: errors.New("connection refused")}}
+	secondary := &countingEngine{LLMEngine: streamOnlyEngine{}}
+	engine, err := NewFallbackEngine(primary, secondary)
+	if err != nil {
+		t.Fatalf("NewFallbackEngine() error = %v", err)
+	}
+
+	messages := []UnifiedMessage{UserMessage("ping")}
+	content, err := collectFallback(t, engine.ChatStream(messages, nil))
+	if err != nil {
+		t.Fatalf("Expected the secondary engine to answer, got error %v", err)
+	}
+	if content != "pong" {
+		t.Errorf("Expected the secondary engine's answer, got %q", content)
+	}
+	if primary.calls != 1 || secondary.calls != 1 {
+		t.Errorf("Expected one call per engine, got %d and %d", primary.calls, secondary.calls)
+	}
+	if len(secondary.messages) != 1 || secondary.messages[0].Content() != "ping" {
+		t.Errorf("Expected the secondary engine to get the same messages, got %+v", secondary.messages)
+	}
+}
+
+func TestFallbackEngine_AllFail(t *testing.T) {
+	first := errors.New("connection refused")
+	second := &openai.Error{StatusCode: http.StatusServiceUnavailable}
+	engine, err := NewFallbackEngine(streamOnlyEngine{err: first}, streamOnlyEngine{err: second})
+	if err != nil {
+		t.Fatalf("NewFallbackEngine() error = %v", err)
+	}
+
+	_, err = collectFallback(t, engine.ChatStream(nil, nil))
+	if err == nil || !strings.Contains(err.Error(), "all 2 engines failed") {
+		t.Fatalf("Expected an all-engines error, got %v", err)
+	}
+	if !errors.Is(err, first) {
+		t.Errorf("Expected the error to wrap each engine's error, got %v", err)
+	}
+}
+
+func TestFallbackEngine_NonRetryable(t *testing.T) {
+	badRequest := &openai.Error{StatusCode: http.StatusBadRequest}
+	secondary := &countingEngine{LLMEngine: streamOnlyEngine{}}
+	engine, _ := NewFallbackEngine(streamOnlyEngine{err: badRequest}, secondary)
+
+	if _, err := collectFallback(t, engine.ChatStream(nil, nil)); !errors.Is(err, badRequest) {
+		t.Errorf("Expected the primary engine's error, got %v", err)
+	}
+	if secondary.calls != 0 {
+		t.Errorf("Expected no fallback on a non-retryable error, got %d calls", secondary.calls)
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	// bedrockError builds an error as the AWS SDK returns it for a failed call
+	bedrockError := func(status int) error {
+		return &smithy.OperationError{
+			ServiceID:     "Bedrock Runtime",
+			OperationName: "ConverseStream",
+			Err: &smithyhttp.ResponseError{
+				Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+				Err:      errors.New("api error"),
+			},
+		}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "network failure", err: errors.New("connection reset"), want: true},
+		{name: "openai rate limit", err: &openai.Error{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "openai bad request", err: &openai.Error{StatusCode: http.StatusBadRequest}, want: false},
+		{name: "bedrock throttling", err: bedrockError(http.StatusTooManyRequests), want: true},
+		{name: "bedrock server error", err: bedrockError(http.StatusServiceUnavailable), want: true},
+		{name: "bedrock access denied", err: bedrockError(http.StatusForbidden), want: false},
+		{name: "bedrock validation", err: bedrockError(http.StatusBadRequest), want: false},
+		{name: "malformed tool arguments", err: fmt.Errorf("stream: %w", &ToolArgumentsError{ToolName: "foo", Err: errors.New("bad json")}), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryableError(tt.err); got != tt.want {
+				t.Errorf("IsRetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFallbackEngine_Invalid(t *testing.T) {
+	if _, err := NewFallbackEngine(); err == nil {
+		t.Error("Expected an error without engines")
+	}
+	if _, err := NewFallbackEngine(streamOnlyEngine{}, nil); err == nil {
+		t.Error("Expected an error for a nil engine")
+	}
+}
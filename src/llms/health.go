@@ -0,0 +1,113 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+	"github.com/openai/openai-go/v3/shared"
+)
+
+// Connectivity errors returned by CheckConnectivity. The provider's error is
+// wrapped alongside, so errors.As still reaches it.
+var (
+	// ErrAuthentication means the provider rejected the API key (HTTP 401 or 403).
+	ErrAuthentication = errors.New("authentication failed")
+	// ErrModelNotFound means the provider does not serve the configured model (HTTP 404).
+	ErrModelNotFound = errors.New("model not found")
+	// ErrUnreachable means the request did not get an answer from the provider
+	// (DNS, connection or timeout errors).
+	ErrUnreachable = errors.New("provider unreachable")
+)
+
+// Pinger is implemented by engines that can check their provider is reachable
+// and accepts their credentials and model.
+type Pinger interface {
+	// Ping issues a minimal request to the provider.
+	//
+	// Parameters:
+	//   - ctx: Context bounding the request
+	//
+	// Returns:
+	//   - error: nil if the provider answered, otherwise an error wrapping
+	//     ErrAuthentication, ErrModelNotFound or ErrUnreachable when it applies
+	Ping(ctx context.Context) error
+}
+
+// CheckConnectivity confirms an engine's provider is reachable and accepts its
+// API key and model, e.g. before starting a server.
+//
+// Engines implementing Pinger are pinged. Other engines are sent a one-message
+// chat and their first error, if any, is returned as is.
+//
+// Parameters:
+//   - ctx: Context bounding the check
+//   - engine: The engine to check
+//
+// Returns:
+//   - error: nil if the provider answered (see Pinger for the categorized errors)
+func CheckConnectivity(ctx context.Context, engine LLMEngine) error {
+	if pinger, ok := engine.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	responseCh := engine.ChatStream([]UnifiedMessage{UserMessage("ping")}, nil)
+	defer responseCh.Cancel()
+
+	errCh := responseCh.Error
+	for {
+		select {
+		case _, ok := <-responseCh.Response:
+			if !ok {
+				// An error sent before the close may still be buffered
+				select {
+				case err := <-errCh:
+					return err
+				default:
+					return nil
+				}
+			}
+			// The provider answered
+			return nil
+		case err, ok := <-errCh:
+			if !ok {
+				errCh = nil
+				continue
+			}
+			return err
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrUnreachable, ctx.Err())
+		}
+	}
+}
+
+// Ping implements Pinger with a one-token chat completion. It is not retried.
+func (a *openAILLM) Ping(ctx context.Context) error {
+	_, err := a.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+		Model:     shared.ChatModel(a.model),
+		Messages:  []openai.ChatCompletionMessageParamUnion{openai.UserMessage("ping")},
+		MaxTokens: openai.Int(1),
+	}, option.WithMaxRetries(0))
+	if err != nil {
+		return classifyPingError(err)
+	}
+	return nil
+}
+
+// classifyPingError wraps a failed ping with the connectivity error it denotes.
+func classifyPingError(err error) error {
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return fmt.Errorf("%w: %w", ErrAuthentication, err)
+		case http.StatusNotFound:
+			return fmt.Errorf("%w: %w", ErrModelNotFound, err)
+		}
+		return fmt.Errorf("provider error: %w", err)
+	}
+	return fmt.Errorf("%w: %w", ErrUnreachable, err)
+}
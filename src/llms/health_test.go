@@ -0,0 +1,97 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newStatusServer returns a server answering chat completions with the given status.
+func newStatusServer(t *testing.T, status int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			fmt.Fprintf(w, `{"error":{"message":"status %d","type":"invalid_request_error"}}`, status)
+			return
+		}
+		fmt.Fprint(w, `{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"test-model","choices":[{"index":0,"finish_reason":"length","message":{"role":"assistant","content":"p"}}]}`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCheckConnectivity(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr error
+	}{
+		{name: "ok", status: http.StatusOK},
+		{name: "invalid key", status: http.StatusUnauthorized, wantErr: ErrAuthentication},
+		{name: "unknown model", status: http.StatusNotFound, wantErr: ErrModelNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStatusServer(t, tt.status)
+			llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+
+			err := CheckConnectivity(context.Background(), llm)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("CheckConnectivity() error = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckConnectivity() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckConnectivity_Unreachable(t *testing.T) {
+	server := newStatusServer(t, http.StatusOK)
+	server.Close()
+	llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+
+	if err := CheckConnectivity(context.Background(), llm); !errors.Is(err, ErrUnreachable) {
+		t.Errorf("CheckConnectivity() error = %v, want ErrUnreachable", err)
+	}
+}
+
+// streamOnlyEngine is an engine without Ping that answers or fails every chat.
+type streamOnlyEngine struct {
+	err error
+}
+
+func (e streamOnlyEngine) ChatStream(messages []UnifiedMessage, tools []Tool) *ResponseCh {
+	responseCh := NewResponseCh()
+	go func() {
+		defer responseCh.Close()
+		if e.err != nil {
+			responseCh.Error <- e.err
+			return
+		}
+		responseCh.Response <- []byte(`{"status":"completed","type":"completion","fullContent":"pong"}`)
+	}()
+	return responseCh
+}
+
+func TestCheckConnectivity_WithoutPing(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := CheckConnectivity(ctx, streamOnlyEngine{}); err != nil {
+		t.Errorf("CheckConnectivity() error = %v, want nil", err)
+	}
+	failure := errors.New("access denied")
+	if err := CheckConnectivity(ctx, streamOnlyEngine{err: failure}); !errors.Is(err, failure) {
+		t.Errorf("CheckConnectivity() error = %v, want %v", err, failure)
+	}
+}
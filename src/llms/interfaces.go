@@ -1,6 +1,8 @@
 package llms
 
 import (
+	"encoding/json"
+
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/packages/param"
 	"github.com/openai/openai-go/v3/shared"
@@ -9,7 +11,17 @@ import (
 type FunctionObjectParameter struct {
 	Type_       string `json:"type"`
 	Description string `json:"description,omitempty"`
-	Name        string `json:"name"`
+	Name        string `json:"name,omitempty"`
+	// Properties describes the fields of an "object" parameter
+	Properties map[string]FunctionObjectParameter `json:"properties,omitempty"`
+	// Required lists the required fields of an "object" parameter
+	Required []string `json:"required,omitempty"`
+	// Items describes the element schema of an "array" parameter
+	Items *FunctionObjectParameter `json:"items,omitempty"`
+	// Enum lists the allowed values of the parameter
+	Enum []any `json:"enum,omitempty"`
+	// Default is the value used when the parameter is omitted
+	Default any `json:"default,omitempty"`
 }
 
 type FunctionParameters struct {
@@ -59,6 +71,25 @@ type LLMEngine interface {
 	ChatStream(messages []UnifiedMessage, tools []Tool) *responseCh
 }
 
+// LLMEngineWithOptions is implemented by engines that accept per-call generation options.
+//
+// Options passed to ChatStreamWithOptions override the engine defaults field by field
+// (zero-valued fields keep the defaults).
+type LLMEngineWithOptions interface {
+	LLMEngine
+
+	// ChatStreamWithOptions is like ChatStream with per-call generation options.
+	//
+	// Parameters:
+	//   - messages: The messages to send
+	//   - tools: Optional tools available for this request (can be nil or empty)
+	//   - opts: Generation options overriding the engine defaults for this call
+	//
+	// Returns:
+	//   - *responseCh: responseCh instance with channels for streaming
+	ChatStreamWithOptions(messages []UnifiedMessage, tools []Tool, opts GenerationOptions) *responseCh
+}
+
 // Tool is an interface for tools that can be used by agents.
 //
 // This interface is compatible with the agent package's AgentTool interface
@@ -82,5 +113,16 @@ type ToolReturn interface {
 	Data() string
 }
 
+// ToolReturnJSON is a ToolReturn carrying structured data.
+//
+// Data() returns the same JSON as a string, so consumers that only know
+// ToolReturn keep working. The agent copies DataJSON into ToolResult.Data.
+type ToolReturnJSON interface {
+	ToolReturn
+
+	// DataJSON returns the result data as well-formed JSON (nil if none).
+	DataJSON() json.RawMessage
+}
+
 // toolReturn is an alias for ToolReturn to maintain backward compatibility.
 type toolReturn = ToolReturn
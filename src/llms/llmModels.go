@@ -1,5 +1,7 @@
 package llms
 
+import "strings"
+
 // Base URLs for LLM providers
 const DEEPSEEK_BASE_URL = "https://api.deepseek.com/v1"
 const TOGETHERAI_BASE_URL = "https://api.together.xyz/v1"
@@ -7,11 +9,15 @@ const OPENAI_BASE_URL = "https://api.openai.com/v1"
 
 // Environment variable names for API keys
 const (
-	DeepSeekAPIKeyEnvVar   = "AF_DEEPSEEK_API_KEY"
-	TogetherAIAPIKeyEnvVar = "AF_TOGETHERAI_API_KEY"
-	OpenAIAPIKeyEnvVar     = "AF_OPENAI_API_KEY"
+	DeepSeekAPIKeyEnvVar    = "AF_DEEPSEEK_API_KEY"
+	TogetherAIAPIKeyEnvVar  = "AF_TOGETHERAI_API_KEY"
+	OpenAIAPIKeyEnvVar      = "AF_OPENAI_API_KEY"
+	AzureOpenAIAPIKeyEnvVar = "AF_AZURE_OPENAI_API_KEY"
 )
 
+// AZURE_OPENAI_API_VERSION is the default Azure OpenAI REST API version.
+const AZURE_OPENAI_API_VERSION = "2024-10-21"
+
 const TOGETHERAI_Llama323BInstructTurbo = "meta-llama/Llama-3.2-3B-Instruct-Turbo"
 const TOGETHERAI_OPENAIGPTOSS120B = "openai/gpt-oss-120b"
 const TOGETHERAI_Qwen257BInstructTurbo = "Qwen/Qwen2.5-7B-Instruct-Turbo"
@@ -21,8 +27,17 @@ const OPENAI_GPT5O = "gpt-5"
 const OPENAI_GPT5_1 = "gpt-5.1"
 const OPENAI_GPT5_2 = "gpt-5.2"
 
+// OPENAI_TEXT_EMBEDDING_3_SMALL is the default embedding model of NewEmbeddingEngine.
+const OPENAI_TEXT_EMBEDDING_3_SMALL = "text-embedding-3-small"
+
+// AWS Bedrock model IDs (see GetBedrockLLM)
+const BEDROCK_CLAUDE_3_5_HAIKU = "anthropic.claude-3-5-haiku-20241022-v1:0"
+const BEDROCK_LLAMA3_1_8B_INSTRUCT = "meta.llama3-1-8b-instruct-v1:0"
+
 const DEEPSEEK_CHAT = "deepseek-chat"
-const DEEPSEEK_REASONING = "deepseek-reasoning"
+
+// DEEPSEEK_REASONING is the DeepSeek reasoner model (see GetDeepSeekReasoningLLM).
+const DEEPSEEK_REASONING = "deepseek-reasoner"
 
 var DefaultModel = map[string]string{
 	"openai":     OPENAI_GPT5_1,
@@ -41,3 +56,38 @@ var ProviderAPIKeyEnvVar = map[string]string{
 	"deepseek":   DeepSeekAPIKeyEnvVar,
 	"togetherai": TogetherAIAPIKeyEnvVar,
 }
+
+// ProviderResponseFormats lists the response formats each provider accepts.
+// Requesting an unlisted format fails at Build time, or before the request for
+// a per-call format, instead of being ignored.
+var ProviderResponseFormats = map[string][]ResponseFormat{
+	"openai":     {ResponseFormatText, ResponseFormatJSONObject, ResponseFormatJSONSchema},
+	"deepseek":   {ResponseFormatText, ResponseFormatJSONObject},
+	"togetherai": {ResponseFormatText, ResponseFormatJSONObject, ResponseFormatJSONSchema},
+}
+
+// ProviderSupportsAssistantPrefill reports the providers that continue a trailing
+// assistant message, which GenerationOptions.AssistantPrefill relies on.
+// OpenAI treats a trailing assistant message as history and DeepSeek only supports
+// prefix completion on its beta endpoint, so for them the prefill is sent as guidance.
+var ProviderSupportsAssistantPrefill = map[string]bool{
+	"openai":     false,
+	"deepseek":   false,
+	"togetherai": true,
+}
+
+// developerRoleModelPrefixes lists the model families that expect instructions
+// in the developer role rather than the system role.
+var developerRoleModelPrefixes = []string{"gpt-5", "o1", "o3", "o4"}
+
+// ModelPrefersDeveloperRole reports whether the model expects the developer role
+// for instructions, e.g. to pass to SetPromoteSystemToDeveloper.
+func ModelPrefersDeveloperRole(model string) bool {
+	model = strings.ToLower(model)
+	for _, prefix := range developerRoleModelPrefixes {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,11 @@
 package llms
 
-import "encoding/json"
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+)
 
 /// Common message interface
 
@@ -15,16 +20,60 @@ const (
 	MessageRoleUser      MessageRole = "user"
 	MessageRoleAssistant MessageRole = "assistant"
 	MessageRoleTool      MessageRole = "tool"
+	// MessageRoleDeveloper carries instructions for models that prefer it over
+	// the system role (e.g., gpt-5-class and o-series OpenAI models).
+	MessageRoleDeveloper MessageRole = "developer"
 )
 
+// ContentPartType identifies the kind of a multi-part content element.
+type ContentPartType string
+
+const (
+	ContentPartTypeText     ContentPartType = "text"
+	ContentPartTypeImageURL ContentPartType = "image_url"
+)
+
+// ContentPart is one element of multi-part (multimodal) message content.
+type ContentPart struct {
+	Type ContentPartType `json:"type"`
+	// Text is the text of a ContentPartTypeText part
+	Text string `json:"text,omitempty"`
+	// ImageURL is an http(s) URL or a base64 data URL ("data:image/png;base64,...")
+	ImageURL string `json:"imageUrl,omitempty"`
+	// Detail is the optional image fidelity hint: "auto", "low" or "high"
+	Detail string `json:"detail,omitempty"`
+}
+
+// TextPart creates a text content part.
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: ContentPartTypeText, Text: text}
+}
+
+// ImageURLPart creates an image content part from an http(s) or data URL.
+func ImageURLPart(url string) ContentPart {
+	return ContentPart{Type: ContentPartTypeImageURL, ImageURL: url}
+}
+
+// ImageBase64Part creates an image content part from raw image bytes.
+//
+// Parameters:
+//   - mediaType: The image MIME type (e.g., "image/png", "image/jpeg")
+//   - data: The raw image bytes
+func ImageBase64Part(mediaType string, data []byte) ContentPart {
+	return ImageURLPart(fmt.Sprintf("data:%s;base64,%s", mediaType, base64.StdEncoding.EncodeToString(data)))
+}
+
 type UnifiedMessage struct {
 	role             MessageRole
 	content          string
-	toolCallID       string     // For tool messages - the ID of the tool call this responds to
-	toolCalls        []ToolCall // For assistant messages - tool calls made by the assistant
-	promptTokens     int        // Input tokens consumed
-	completionTokens int        // Output tokens generated
-	totalTokens      int        // Total tokens used
+	contentParts     []ContentPart     // Multi-part content (text + images); content holds the text
+	toolCallID       string            // For tool messages - the ID of the tool call this responds to
+	toolCalls        []ToolCall        // For assistant messages - tool calls made by the assistant
+	promptTokens     int               // Input tokens consumed
+	completionTokens int               // Output tokens generated
+	totalTokens      int               // Total tokens used
+	incomplete       bool              // For assistant messages - the stream failed before completing
+	metadata         map[string]string // Application tags (e.g. "source", "userId"); never sent to the LLM
 }
 
 func (m *UnifiedMessage) Role() MessageRole {
@@ -35,6 +84,11 @@ func (m *UnifiedMessage) Content() string {
 	return m.content
 }
 
+// ContentParts returns the multi-part content, or nil for plain text messages.
+func (m *UnifiedMessage) ContentParts() []ContentPart {
+	return m.contentParts
+}
+
 func (m *UnifiedMessage) ToolCallID() string {
 	return m.toolCallID
 }
@@ -43,6 +97,27 @@ func (m *UnifiedMessage) ToolCalls() []ToolCall {
 	return m.toolCalls
 }
 
+// Incomplete reports whether the message holds partial content from a failed stream.
+func (m *UnifiedMessage) Incomplete() bool {
+	return m.incomplete
+}
+
+// Metadata returns the application tags of the message, or nil if it has none.
+func (m *UnifiedMessage) Metadata() map[string]string {
+	return m.metadata
+}
+
+// WithMetadata returns a copy of the message tagged with metadata, replacing
+// previous tags. The map is copied; empty metadata removes the tags.
+func (m UnifiedMessage) WithMetadata(metadata map[string]string) UnifiedMessage {
+	if len(metadata) == 0 {
+		m.metadata = nil
+		return m
+	}
+	m.metadata = maps.Clone(metadata)
+	return m
+}
+
 func (m *UnifiedMessage) PromptTokens() int {
 	return m.promptTokens
 }
@@ -62,6 +137,57 @@ func UserMessage(content string) UnifiedMessage {
 	}
 }
 
+// UserMessageWithMetadata creates a user message tagged with metadata (see WithMetadata).
+func UserMessageWithMetadata(content string, metadata map[string]string) UnifiedMessage {
+	return UserMessage(content).WithMetadata(metadata)
+}
+
+// FilterMessages returns the messages for which keep returns true, in order.
+func FilterMessages(messages []UnifiedMessage, keep func(UnifiedMessage) bool) []UnifiedMessage {
+	var filtered []UnifiedMessage
+	for _, m := range messages {
+		if keep(m) {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
+
+// UserMessageWithImages creates a user message with text followed by images.
+//
+// Parameters:
+//   - text: The text of the message (may be empty)
+//   - imageURLs: http(s) or base64 data URLs of the images
+func UserMessageWithImages(text string, imageURLs []string) UnifiedMessage {
+	var parts []ContentPart
+	if text != "" {
+		parts = append(parts, TextPart(text))
+	}
+	for _, url := range imageURLs {
+		parts = append(parts, ImageURLPart(url))
+	}
+	return UserMessageWithParts(parts...)
+}
+
+// UserMessageWithParts creates a user message with multi-part content.
+// Content() returns the concatenated text parts.
+func UserMessageWithParts(parts ...ContentPart) UnifiedMessage {
+	var text string
+	for _, part := range parts {
+		if part.Type == ContentPartTypeText {
+			if text != "" {
+				text += "\n"
+			}
+			text += part.Text
+		}
+	}
+	return UnifiedMessage{
+		role:         MessageRoleUser,
+		content:      text,
+		contentParts: parts,
+	}
+}
+
 func AssistantMessage(content string, promptTokens, completionTokens, totalTokens int) UnifiedMessage {
 	return UnifiedMessage{
 		role:             MessageRoleAssistant,
@@ -79,6 +205,24 @@ func SystemMessage(content string) UnifiedMessage {
 	}
 }
 
+// IncompleteAssistantMessage creates an assistant message holding the content
+// streamed before the stream failed.
+func IncompleteAssistantMessage(content string) UnifiedMessage {
+	return UnifiedMessage{
+		role:       MessageRoleAssistant,
+		content:    content,
+		incomplete: true,
+	}
+}
+
+// DeveloperMessage creates a message with the developer role.
+func DeveloperMessage(content string) UnifiedMessage {
+	return UnifiedMessage{
+		role:    MessageRoleDeveloper,
+		content: content,
+	}
+}
+
 func ToolMessage(toolCallID, content string) UnifiedMessage {
 	return UnifiedMessage{
 		role:       MessageRoleTool,
@@ -98,38 +242,104 @@ func AssistantMessageWithToolCalls(content string, toolCalls []ToolCall, promptT
 	}
 }
 
+// MapText returns a copy of the message with fn applied to its text content,
+// including the text parts of multi-part content. Other fields are kept.
+func (m UnifiedMessage) MapText(fn func(string) string) UnifiedMessage {
+	m.content = fn(m.content)
+	if m.contentParts != nil {
+		parts := make([]ContentPart, len(m.contentParts))
+		for i, part := range m.contentParts {
+			if part.Type == ContentPartTypeText {
+				part.Text = fn(part.Text)
+			}
+			parts[i] = part
+		}
+		m.contentParts = parts
+	}
+	return m
+}
+
+// Clone returns a deep copy of the message: content parts, tool calls (with
+// their arguments) and metadata are copied, so the copy shares no memory with m.
+func (m UnifiedMessage) Clone() UnifiedMessage {
+	if m.contentParts != nil {
+		m.contentParts = append([]ContentPart(nil), m.contentParts...)
+	}
+	if m.toolCalls != nil {
+		toolCalls := make([]ToolCall, len(m.toolCalls))
+		for i, tc := range m.toolCalls {
+			if tc.Arguments != nil {
+				tc.Arguments = cloneValue(tc.Arguments).(map[string]any)
+			}
+			toolCalls[i] = tc
+		}
+		m.toolCalls = toolCalls
+	}
+	m.metadata = maps.Clone(m.metadata)
+	return m
+}
+
+// cloneValue deep-copies the maps and slices of a decoded JSON value.
+func cloneValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		clone := make(map[string]any, len(v))
+		for key, item := range v {
+			clone[key] = cloneValue(item)
+		}
+		return clone
+	case []any:
+		clone := make([]any, len(v))
+		for i, item := range v {
+			clone[i] = cloneValue(item)
+		}
+		return clone
+	default:
+		return v
+	}
+}
+
 // MarshalJSON implements custom JSON marshaling for UnifiedMessage
 func (m UnifiedMessage) MarshalJSON() ([]byte, error) {
 	type Alias struct {
-		Role             MessageRole `json:"role"`
-		Content          string      `json:"content"`
-		ToolCallID       string      `json:"toolCallId,omitempty"`
-		ToolCalls        []ToolCall  `json:"toolCalls,omitempty"`
-		PromptTokens     int         `json:"promptTokens,omitempty"`
-		CompletionTokens int         `json:"completionTokens,omitempty"`
-		TotalTokens      int         `json:"totalTokens,omitempty"`
+		Role             MessageRole       `json:"role"`
+		Content          string            `json:"content"`
+		ContentParts     []ContentPart     `json:"contentParts,omitempty"`
+		ToolCallID       string            `json:"toolCallId,omitempty"`
+		ToolCalls        []ToolCall        `json:"toolCalls,omitempty"`
+		PromptTokens     int               `json:"promptTokens,omitempty"`
+		CompletionTokens int               `json:"completionTokens,omitempty"`
+		TotalTokens      int               `json:"totalTokens,omitempty"`
+		Incomplete       bool              `json:"incomplete,omitempty"`
+		Metadata         map[string]string `json:"metadata,omitempty"`
 	}
 	return json.Marshal(Alias{
 		Role:             m.role,
 		Content:          m.content,
+		ContentParts:     m.contentParts,
 		ToolCallID:       m.toolCallID,
 		ToolCalls:        m.toolCalls,
 		PromptTokens:     m.promptTokens,
 		CompletionTokens: m.completionTokens,
 		TotalTokens:      m.totalTokens,
+		Incomplete:       m.incomplete,
+		Metadata:         m.metadata,
 	})
 }
 
 // UnmarshalJSON implements custom JSON unmarshaling for UnifiedMessage
 func (m *UnifiedMessage) UnmarshalJSON(data []byte) error {
 	type Alias struct {
-		Role             MessageRole `json:"role"`
-		Content          string      `json:"content"`
-		ToolCallID       string      `json:"toolCallId,omitempty"`
-		ToolCalls        []ToolCall  `json:"toolCalls,omitempty"`
-		PromptTokens     int         `json:"promptTokens,omitempty"`
-		CompletionTokens int         `json:"completionTokens,omitempty"`
-		TotalTokens      int         `json:"totalTokens,omitempty"`
+		Role             MessageRole       `json:"role"`
+		Content          string            `json:"content"`
+		ContentParts     []ContentPart     `json:"contentParts,omitempty"`
+		ToolCallID       string            `json:"toolCallId,omitempty"`
+		ToolCalls        []ToolCall        `json:"toolCalls,omitempty"`
+		PromptTokens     int               `json:"promptTokens,omitempty"`
+		CompletionTokens int               `json:"completionTokens,omitempty"`
+		TotalTokens      int               `json:"totalTokens,omitempty"`
+		Incomplete       bool              `json:"incomplete,omitempty"`
+		Metadata         map[string]string `json:"metadata,omitempty"`
 	}
 	var alias Alias
 	if err := json.Unmarshal(data, &alias); err != nil {
@@ -137,10 +347,13 @@ func (m *UnifiedMessage) UnmarshalJSON(data []byte) error {
 	}
 	m.role = alias.Role
 	m.content = alias.Content
+	m.contentParts = alias.ContentParts
 	m.toolCallID = alias.ToolCallID
 	m.toolCalls = alias.ToolCalls
 	m.promptTokens = alias.PromptTokens
 	m.completionTokens = alias.CompletionTokens
 	m.totalTokens = alias.TotalTokens
+	m.incomplete = alias.Incomplete
+	m.metadata = alias.Metadata
 	return nil
 }
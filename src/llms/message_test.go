@@ -0,0 +1,156 @@
+package llms
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestToOpenAIMessages_ImageParts(t *testing.T) {
+	messages := []UnifiedMessage{
+		UserMessageWithImages("What is in these images?", []string{
+			"https://example.com/cat.png",
+			"data:image/png;base64,iVBORw0KGgo=",
+		}),
+		UserMessage("plain text"),
+	}
+
+	openaiMessages, err := toOpenAIMessages(messages, false)
+	if err != nil {
+		t.Fatalf("toOpenAIMessages() unexpected error = %v", err)
+	}
+
+	raw, err := json.Marshal(openaiMessages[0])
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+	var multi struct {
+		Role    string `json:"role"`
+		Content []struct {
+			Type     string `json:"type"`
+			Text     string `json:"text"`
+			ImageURL struct {
+				URL string `json:"url"`
+			} `json:"image_url"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(raw, &multi); err != nil {
+		t.Fatalf("Expected multi-part content array, got %s (%v)", raw, err)
+	}
+	if multi.Role != "user" || len(multi.Content) != 3 {
+		t.Fatalf("Expected user message with 3 parts, got %s", raw)
+	}
+	if multi.Content[0].Type != "text" || multi.Content[0].Text != "What is in these images?" {
+		t.Errorf("Unexpected text part: %+v", multi.Content[0])
+	}
+	if multi.Content[1].Type != "image_url" || multi.Content[1].ImageURL.URL != "https://example.com/cat.png" {
+		t.Errorf("Unexpected URL image part: %+v", multi.Content[1])
+	}
+	if multi.Content[2].Type != "image_url" || !strings.HasPrefix(multi.Content[2].ImageURL.URL, "data:image/png;base64,") {
+		t.Errorf("Unexpected base64 image part: %+v", multi.Content[2])
+	}
+
+	// Messages without parts fall back to plain string content
+	raw, _ = json.Marshal(openaiMessages[1])
+	if !strings.Contains(string(raw), `"content":"plain text"`) {
+		t.Errorf("Expected plain string content, got %s", raw)
+	}
+}
+
+func TestToOpenAIMessages_InvalidPart(t *testing.T) {
+	_, err := toOpenAIMessages([]UnifiedMessage{UserMessageWithParts(ContentPart{Type: "audio"})}, false)
+	if err == nil || !strings.Contains(err.Error(), "invalid content part type") {
+		t.Errorf("Expected invalid part error, got %v", err)
+	}
+}
+
+func TestUnifiedMessage_ContentPartsJSON(t *testing.T) {
+	original := UserMessageWithParts(TextPart("look"), ImageBase64Part("image/jpeg", []byte{0xff, 0xd8}))
+	if original.Content() != "look" {
+		t.Errorf("Expected text content 'look', got %q", original.Content())
+	}
+
+	raw, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+	var decoded UnifiedMessage
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal message: %v", err)
+	}
+
+	parts := decoded.ContentParts()
+	if len(parts) != 2 || parts[1].ImageURL != "data:image/jpeg;base64,/9g=" {
+		t.Errorf("Expected content parts to round-trip, got %+v", parts)
+	}
+}
+
+func TestUnifiedMessage_MapText(t *testing.T) {
+	original := UserMessageWithParts(TextPart("hello"), ImageURLPart("https://example.com/a.png"))
+	mapped := original.MapText(strings.ToUpper)
+
+	if mapped.Content() != "HELLO" {
+		t.Errorf("Content() = %q, want %q", mapped.Content(), "HELLO")
+	}
+	parts := mapped.ContentParts()
+	if parts[0].Text != "HELLO" || parts[1].ImageURL != "https://example.com/a.png" {
+		t.Errorf("Unexpected parts: %+v", parts)
+	}
+	if original.Content() != "hello" || original.ContentParts()[0].Text != "hello" {
+		t.Error("Expected the original message to be unchanged")
+	}
+}
+
+func TestUnifiedMessage_Metadata(t *testing.T) {
+	tags := map[string]string{"source": "web", "userId": "123"}
+	original := UserMessageWithMetadata("hi", tags)
+	tags["source"] = "changed"
+	if got := original.Metadata()["source"]; got != "web" {
+		t.Errorf("Expected metadata to be copied, got source=%q", got)
+	}
+
+	raw, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+	var decoded UnifiedMessage
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal message: %v", err)
+	}
+	if decoded.Metadata()["source"] != "web" || decoded.Metadata()["userId"] != "123" {
+		t.Errorf("Expected metadata to round-trip, got %v", decoded.Metadata())
+	}
+
+	untagged, err := json.Marshal(UserMessage("hi"))
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+	if strings.Contains(string(untagged), "metadata") {
+		t.Errorf("Expected metadata to be omitted, got %s", untagged)
+	}
+	if cleared := original.WithMetadata(nil); cleared.Metadata() != nil {
+		t.Errorf("Expected WithMetadata(nil) to remove the tags, got %v", cleared.Metadata())
+	}
+}
+
+func TestUnifiedMessage_Clone(t *testing.T) {
+	original := AssistantMessageWithToolCalls("", []ToolCall{
+		{ID: "call_1", Name: "search", Arguments: map[string]any{"query": "go", "filters": map[string]any{"tags": []any{"lang"}}}},
+	}, 1, 2, 3).WithMetadata(map[string]string{"source": "web"})
+	clone := original.Clone()
+
+	clone.ToolCalls()[0].Arguments["query"] = "rust"
+	clone.ToolCalls()[0].Arguments["filters"].(map[string]any)["tags"].([]any)[0] = "changed"
+	clone.Metadata()["source"] = "cli"
+
+	args := original.ToolCalls()[0].Arguments
+	if args["query"] != "go" || args["filters"].(map[string]any)["tags"].([]any)[0] != "lang" {
+		t.Errorf("Expected the original arguments to be unchanged, got %v", args)
+	}
+	if original.Metadata()["source"] != "web" {
+		t.Errorf("Expected the original metadata to be unchanged, got %v", original.Metadata())
+	}
+	if clone.TotalTokens() != 3 || clone.ToolCalls()[0].ID != "call_1" {
+		t.Errorf("Expected the other fields to be copied, got %+v", clone)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sync"
+	"unicode/utf8"
 )
 
 // ToolCall represents a tool call request from the LLM.
@@ -20,6 +21,65 @@ type ToolResult struct {
 	Success    bool   `json:"success"`    // Whether the tool executed successfully
 	Result     string `json:"result"`     // Result data from the tool
 	Error      string `json:"error"`      // Error message if tool failed
+
+	// Err is the error behind Error when the agent itself failed the call
+	// (e.g. agents.ErrToolNotFound), for use with errors.Is. Not serialized.
+	Err error `json:"-"`
+
+	// Data is the structured result of tools returning ToolReturnJSON.
+	// Result then holds the same JSON as a string. Omitted for plain string results.
+	Data json.RawMessage `json:"data,omitempty"`
+
+	// Metadata carries structured information about the execution
+	// (see ToolMetadata* keys). Omitted when empty.
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// ToolResult Metadata Keys
+//
+// These keys are populated by the agent on every ToolResult.Metadata map.
+const (
+	// ToolMetadataDuration is the tool execution time in milliseconds (int64).
+	ToolMetadataDuration = "durationMs"
+	// ToolMetadataBytes is the size of the result data in bytes (int).
+	ToolMetadataBytes = "bytes"
+	// ToolMetadataSource identifies the agent that executed the tool (string).
+	ToolMetadataSource = "source"
+	// ToolMetadataCached is true when the result was served from the agent's tool cache (bool).
+	// Only set on cache hits.
+	ToolMetadataCached = "cached"
+	// ToolMetadataDryRun is true when the tool was not executed because the agent
+	// runs in dry-run mode (bool). Only set in dry-run mode.
+	ToolMetadataDryRun = "dryRun"
+)
+
+// ToolArgumentsError is sent on a stream's Error channel when the model emitted
+// tool call arguments that are not valid JSON. The agent reports it back to the
+// model so it can retry (see AgentConfig.MaxToolArgumentRetries).
+type ToolArgumentsError struct {
+	ToolCallID string
+	ToolName   string
+	// Arguments is the raw arguments text emitted by the model
+	Arguments string
+	// Err is the JSON parse error
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ToolArgumentsError) Error() string {
+	return fmt.Sprintf("failed to parse tool call arguments for %s: %v", e.ToolName, e.Err)
+}
+
+// Unwrap returns the JSON parse error.
+func (e *ToolArgumentsError) Unwrap() error {
+	return e.Err
+}
+
+// Usage reports the token usage of a single LLM call.
+type Usage struct {
+	PromptTokens     int `json:"promptTokens"`     // Tokens in the prompt
+	CompletionTokens int `json:"completionTokens"` // Tokens in the completion
+	TotalTokens      int `json:"totalTokens"`      // Total tokens used
 }
 
 // ChunkResponse represents a streaming response chunk.
@@ -27,17 +87,22 @@ type ToolResult struct {
 // This struct is serialized to JSON bytes and sent through channels
 // during streaming responses.
 type ChunkResponse struct {
-	Content          string       `json:"content"`                    // Current chunk content
-	Delta            string       `json:"delta"`                      // Incremental delta
-	FullContent      string       `json:"fullContent"`                // Accumulated full content
-	Status           string       `json:"status"`                     // Status: see Status* constants (StatusStreaming, StatusCompleted, etc.)
-	Type             string       `json:"type"`                       // Response type: see Type* constants (TypeContent, TypeCompletion, etc.)
-	ToolCalls        []ToolCall   `json:"toolCalls,omitempty"`        // Tool calls (when Type is "tool-call")
-	ToolExecuting    *ToolCall    `json:"toolExecuting,omitempty"`    // Tool being executed (when Status is "tool-executing")
-	ToolResults      []ToolResult `json:"toolResults,omitempty"`      // Tool execution results (when Status is "tool-result")
-	PromptTokens     int          `json:"promptTokens,omitempty"`     // Input tokens consumed
-	CompletionTokens int          `json:"completionTokens,omitempty"` // Output tokens generated
-	TotalTokens      int          `json:"totalTokens,omitempty"`      // Total tokens used
+	Content           string       `json:"content"`                     // Current chunk content
+	Delta             string       `json:"delta"`                       // Incremental delta
+	FullContent       string       `json:"fullContent"`                 // Accumulated full content
+	Status            string       `json:"status"`                      // Status: see Status* constants (StatusStreaming, StatusCompleted, etc.)
+	Type              string       `json:"type"`                        // Response type: see Type* constants (TypeContent, TypeCompletion, etc.)
+	ToolCalls         []ToolCall   `json:"toolCalls,omitempty"`         // Tool calls (when Type is "tool-call")
+	ToolExecuting     *ToolCall    `json:"toolExecuting,omitempty"`     // Tool being executed (when Status is "tool-executing")
+	ToolPlanned       *ToolCall    `json:"toolPlanned,omitempty"`       // Tool that would have been executed (when Status is "tool-planned")
+	ToolResults       []ToolResult `json:"toolResults,omitempty"`       // Tool execution results (when Status is "tool-result")
+	PromptTokens      int          `json:"promptTokens,omitempty"`      // Input tokens consumed
+	CompletionTokens  int          `json:"completionTokens,omitempty"`  // Output tokens generated
+	TotalTokens       int          `json:"totalTokens,omitempty"`       // Total tokens used
+	Trace             string       `json:"trace,omitempty"`             // Trace of the chunk (TraceThinking for reasoning chunks)
+	Speaker           string       `json:"speaker,omitempty"`           // Display name of the agent, on the first content chunk of each assistant message (see agents.AgentConfig.DisplayName)
+	Truncated         bool         `json:"truncated,omitempty"`         // The answer was cut at the agent's MaxOutputChars (completion chunks)
+	SystemFingerprint string       `json:"systemFingerprint,omitempty"` // Backend configuration that served the request, when reported by the provider (completion chunks)
 }
 
 // ResponseCh manages channels for streaming responses and errors.
@@ -51,13 +116,45 @@ type responseCh struct {
 	started bool
 	closed  bool
 	mu      sync.Mutex
+
+	// cancel is closed by Cancel to ask the engine to stop streaming
+	cancel     chan struct{}
+	cancelOnce sync.Once
+}
+
+// ResponseCh is the exported name of responseCh.
+//
+// It allows LLMEngine implementations living outside this package
+// (wrappers, test doubles) to satisfy the interface.
+type ResponseCh = responseCh
+
+// DefaultResponseBufferSize is the number of chunks a ResponseCh buffers by default.
+const DefaultResponseBufferSize = 10
+
+// NewResponseCh creates a new ResponseCh instance for external LLMEngine implementations.
+func NewResponseCh() *ResponseCh {
+	return newResponseCh()
+}
+
+// NewResponseChWithBuffer is like NewResponseCh with the number of chunks the
+// Response channel buffers. A zero or negative size uses DefaultResponseBufferSize.
+func NewResponseChWithBuffer(size int) *ResponseCh {
+	return newResponseChWithBuffer(size)
 }
 
-// NewResponseCh creates a new ResponseCh instance.
+// newResponseCh creates a new ResponseCh instance.
 func newResponseCh() *responseCh {
+	return newResponseChWithBuffer(DefaultResponseBufferSize)
+}
+
+// newResponseChWithBuffer creates a new ResponseCh instance buffering size chunks.
+func newResponseChWithBuffer(size int) *responseCh {
+	if size <= 0 {
+		size = DefaultResponseBufferSize
+	}
 	return &responseCh{
-		Response: make(chan []byte, 10), // Buffered channel
-		Error:    make(chan error, 1),   // Buffered channel for errors
+		Response: make(chan []byte, size), // Buffered channel
+		Error:    make(chan error, 1),     // Buffered channel for errors
 		started:  false,
 	}
 }
@@ -83,17 +180,37 @@ func (rc *responseCh) Start() <-chan ChunkResponse {
 	go func() {
 		defer close(chunkChan)
 
+		errCh := rc.Error
 		for {
 			select {
 			case chunkBytes, ok := <-rc.Response:
 				if !ok {
-					// Response channel closed, streaming complete
+					// Response channel closed, streaming complete. An error
+					// sent before the close may still be buffered.
+					if errCh != nil {
+						if err, ok := <-errCh; ok && err != nil {
+							chunkChan <- ChunkResponse{
+								Status:  StatusError,
+								Content: err.Error(),
+							}
+						}
+					}
 					return
 				}
 
 				// Deserialize chunk
 				var chunk ChunkResponse
 				if err := json.Unmarshal(chunkBytes, &chunk); err != nil {
+					if text, ok := PlainTextPayload(chunkBytes); ok {
+						// Not a chunk but text (e.g. sent by a tool): surface it as content
+						chunkChan <- ChunkResponse{
+							Content: text,
+							Delta:   text,
+							Status:  StatusStreaming,
+							Type:    TypeContent,
+						}
+						continue
+					}
 					// Send error as chunk
 					chunkChan <- ChunkResponse{
 						Status:  StatusError,
@@ -110,7 +227,12 @@ func (rc *responseCh) Start() <-chan ChunkResponse {
 					return
 				}
 
-			case err := <-rc.Error:
+			case err, ok := <-errCh:
+				if !ok {
+					// Error channel closed: keep draining buffered chunks
+					errCh = nil
+					continue
+				}
 				if err != nil {
 					// Send error as chunk
 					chunkChan <- ChunkResponse{
@@ -126,6 +248,27 @@ func (rc *responseCh) Start() <-chan ChunkResponse {
 	return chunkChan
 }
 
+// PlainTextPayload returns the text of a payload sent through a ResponseCh that
+// is not a JSON chunk: a JSON string is decoded, other valid UTF-8 bytes are
+// returned as they are. Start uses it to surface such payloads as content.
+//
+// Parameters:
+//   - payload: The bytes that failed to deserialize as a chunk
+//
+// Returns:
+//   - string: The text of the payload
+//   - bool: false if the payload is empty or not valid UTF-8
+func PlainTextPayload(payload []byte) (string, bool) {
+	if len(payload) == 0 || !utf8.Valid(payload) {
+		return "", false
+	}
+	var text string
+	if err := json.Unmarshal(payload, &text); err == nil {
+		return text, true
+	}
+	return string(payload), true
+}
+
 // Close closes both channels.
 //
 // This should be called when done listening to clean up resources.
@@ -143,6 +286,33 @@ func (rc *responseCh) Close() {
 	rc.closed = true
 }
 
+// Cancel asks the engine to stop streaming. The engine stops sending chunks,
+// aborts the provider request and closes the channels without an error.
+//
+// Safe to call multiple times and after the stream has completed.
+func (rc *responseCh) Cancel() {
+	cancel := rc.cancelled()
+	rc.cancelOnce.Do(func() { close(cancel) })
+}
+
+// Cancelled returns a channel that is closed when Cancel is called.
+//
+// LLMEngine implementations select on it alongside every send so a
+// cancelled stream never blocks.
+func (rc *responseCh) Cancelled() <-chan struct{} {
+	return rc.cancelled()
+}
+
+// cancelled returns the cancel channel, creating it for zero-value ResponseCh values.
+func (rc *responseCh) cancelled() chan struct{} {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.cancel == nil {
+		rc.cancel = make(chan struct{})
+	}
+	return rc.cancel
+}
+
 // serializeChunk serializes a ChunkResponse to JSON bytes.
 func serializeChunk(chunk ChunkResponse) ([]byte, error) {
 	return json.Marshal(chunk)
@@ -2,8 +2,14 @@ package llms
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/option"
@@ -20,6 +26,25 @@ type openAILLM struct {
 	model   string
 	apiKey  string
 	client  openai.Client
+	// idleTimeout aborts the stream when no chunk arrives within the duration.
+	// Zero disables the timeout.
+	idleTimeout time.Duration
+	// options are the generation options applied to every request.
+	options GenerationOptions
+	// provider checks per-call response formats against ProviderResponseFormats.
+	// Empty skips the check (engines not built by OpenAILLMBuilder).
+	provider string
+	// promoteSystemToDeveloper sends system messages with the developer role.
+	promoteSystemToDeveloper bool
+	// rateLimiter paces requests. Nil disables rate limiting.
+	rateLimiter RateLimiter
+	// responseBufferSize is the chunk buffer of the response channels (0 uses the default).
+	responseBufferSize int
+	// supportsPrefill sends GenerationOptions.AssistantPrefill as a trailing
+	// assistant message instead of as guidance.
+	supportsPrefill bool
+	// toolCallID names streamed tool calls received without an ID (nil uses DefaultToolCallID).
+	toolCallID ToolCallIDFunc
 }
 
 // newOpenAILLM creates a new openAILLM instance.
@@ -34,6 +59,19 @@ func newOpenAILLM(ctx context.Context, baseURL, model, apiKey string) *openAILLM
 	if baseURL != "" {
 		opts = append(opts, option.WithBaseURL(baseURL))
 	}
+	return newOpenAILLMWithOptions(ctx, baseURL, model, apiKey, opts)
+}
+
+// newOpenAILLMWithOptions creates a new openAILLM with explicit client options,
+// for OpenAI-compatible APIs that authenticate or route differently.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - baseURL: API base URL recorded on the engine
+//   - model: Model name sent in the request body
+//   - apiKey: API key recorded on the engine
+//   - opts: Client request options (auth, base URL, headers, query params)
+func newOpenAILLMWithOptions(ctx context.Context, baseURL, model, apiKey string, opts []option.RequestOption) *openAILLM {
 	client := openai.NewClient(opts...)
 
 	return &openAILLM{
@@ -57,21 +95,47 @@ func newOpenAILLM(ctx context.Context, baseURL, model, apiKey string) *openAILLM
 // Returns:
 //   - *responseCh: responseCh instance with channels for streaming
 func (a *openAILLM) ChatStream(messages []UnifiedMessage, tools []Tool) *responseCh {
-	responseCh := newResponseCh()
+	return a.ChatStreamWithOptions(messages, tools, GenerationOptions{})
+}
+
+// ChatStreamWithOptions is like ChatStream with per-call generation options
+// overriding the engine defaults. It implements LLMEngineWithOptions.
+//
+// Parameters:
+//   - messages: The messages to send
+//   - tools: Optional tools available for this request (can be nil or empty)
+//   - opts: Generation options for this call (zero fields keep the engine defaults)
+//
+// Returns:
+//   - *responseCh: responseCh instance with channels for streaming
+func (a *openAILLM) ChatStreamWithOptions(messages []UnifiedMessage, tools []Tool, opts GenerationOptions) *responseCh {
+	responseCh := newResponseChWithBuffer(a.responseBufferSize)
 
 	// Start streaming in a goroutine
-	go a.streamResponse(messages, tools, responseCh)
+	go a.streamResponse(messages, tools, a.options.Merge(opts), responseCh)
 
 	return responseCh
 }
 
-func toOpenAIMessages(messages []UnifiedMessage) ([]openai.ChatCompletionMessageParamUnion, error) {
+// toOpenAIMessages converts unified messages to OpenAI message params.
+// When promoteSystem is true, system messages are sent as developer messages.
+func toOpenAIMessages(messages []UnifiedMessage, promoteSystem bool) ([]openai.ChatCompletionMessageParamUnion, error) {
 	openaiMessages := make([]openai.ChatCompletionMessageParamUnion, len(messages))
 	for i, message := range messages {
-		if message.Role() == "system" {
+		if message.Role() == "system" && !promoteSystem {
 			openaiMessages[i] = openai.SystemMessage(message.Content())
+		} else if message.Role() == "system" || message.Role() == "developer" {
+			openaiMessages[i] = openai.DeveloperMessage(message.Content())
 		} else if message.Role() == "user" {
-			openaiMessages[i] = openai.UserMessage(message.Content())
+			if parts := message.ContentParts(); len(parts) > 0 {
+				openaiParts, err := toOpenAIContentParts(parts)
+				if err != nil {
+					return nil, err
+				}
+				openaiMessages[i] = openai.UserMessage(openaiParts)
+			} else {
+				openaiMessages[i] = openai.UserMessage(message.Content())
+			}
 		} else if message.Role() == "assistant" {
 			// Check if assistant message has tool calls
 			if len(message.ToolCalls()) > 0 {
@@ -93,14 +157,12 @@ func toOpenAIMessages(messages []UnifiedMessage) ([]openai.ChatCompletionMessage
 						},
 					}
 				}
-				openaiMessages[i] = openai.ChatCompletionMessageParamUnion{
-					OfAssistant: &openai.ChatCompletionAssistantMessageParam{
-						Content: openai.ChatCompletionAssistantMessageParamContentUnion{
-							OfString: openai.String(message.Content()),
-						},
-						ToolCalls: toolCalls,
-					},
+				assistant := &openai.ChatCompletionAssistantMessageParam{ToolCalls: toolCalls}
+				// Omit blank content: some providers reject "" alongside tool calls
+				if strings.TrimSpace(message.Content()) != "" {
+					assistant.Content.OfString = openai.String(message.Content())
 				}
+				openaiMessages[i] = openai.ChatCompletionMessageParamUnion{OfAssistant: assistant}
 			} else {
 				openaiMessages[i] = openai.AssistantMessage(message.Content())
 			}
@@ -121,12 +183,109 @@ func toOpenAIMessages(messages []UnifiedMessage) ([]openai.ChatCompletionMessage
 	return openaiMessages, nil
 }
 
+// toOpenAIContentParts converts multi-part content to the OpenAI content part union.
+func toOpenAIContentParts(parts []ContentPart) ([]openai.ChatCompletionContentPartUnionParam, error) {
+	openaiParts := make([]openai.ChatCompletionContentPartUnionParam, len(parts))
+	for i, part := range parts {
+		switch part.Type {
+		case ContentPartTypeText:
+			openaiParts[i] = openai.TextContentPart(part.Text)
+		case ContentPartTypeImageURL:
+			if part.ImageURL == "" {
+				return nil, fmt.Errorf("content part %d: image part has no URL", i)
+			}
+			openaiParts[i] = openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{
+				URL:    part.ImageURL,
+				Detail: part.Detail,
+			})
+		default:
+			return nil, fmt.Errorf("content part %d: invalid content part type: %s", i, part.Type)
+		}
+	}
+	return openaiParts, nil
+}
+
+// reasoningDeltaFields are the non-standard delta fields carrying reasoning tokens
+// ("reasoning_content" for DeepSeek, "reasoning" for other OpenAI-compatible APIs).
+var reasoningDeltaFields = []string{"reasoning_content", "reasoning"}
+
+// reasoningDelta returns the reasoning text of a stream delta, if any.
+func reasoningDelta(delta openai.ChatCompletionChunkChoiceDelta) string {
+	for _, name := range reasoningDeltaFields {
+		field, ok := delta.JSON.ExtraFields[name]
+		if !ok {
+			continue
+		}
+		var reasoning string
+		if err := json.Unmarshal([]byte(field.Raw()), &reasoning); err == nil && reasoning != "" {
+			return reasoning
+		}
+	}
+	return ""
+}
+
+// toolCallKey identifies a streamed tool call by the index of its choice and
+// its index within the choice.
+type toolCallKey struct {
+	choice int64
+	index  int64
+}
+
+// streamedToolCall accumulates the deltas of a streamed tool call.
+type streamedToolCall struct {
+	ID        string
+	Name      string
+	Arguments string
+}
+
+// ToolCallIDFunc generates the ID of a tool call that the provider returned without one.
+//
+// Parameters:
+//   - index: The position of the tool call in the response
+//   - name: The tool name
+//   - arguments: The raw JSON arguments
+//
+// Returns:
+//   - string: The tool call ID, used again by the tool result message
+type ToolCallIDFunc func(index int, name, arguments string) string
+
+// DefaultToolCallID generates IDs of the form "call_<index>_<hash>", where hash is
+// derived from the tool name and arguments so the same call always gets the same ID.
+func DefaultToolCallID(index int, name, arguments string) string {
+	sum := sha256.Sum256([]byte(name + "\x00" + arguments))
+	return fmt.Sprintf("call_%d_%s", index, hex.EncodeToString(sum[:6]))
+}
+
+// sortedToolCallKeys returns the keys of the streamed tool calls ordered by choice, then index.
+func sortedToolCallKeys(toolCalls map[toolCallKey]*streamedToolCall) []toolCallKey {
+	keys := make([]toolCallKey, 0, len(toolCalls))
+	for key := range toolCalls {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].choice != keys[j].choice {
+			return keys[i].choice < keys[j].choice
+		}
+		return keys[i].index < keys[j].index
+	})
+	return keys
+}
+
+// generateToolCallID names a streamed tool call received without an ID.
+func (a *openAILLM) generateToolCallID(index int, name, arguments string) string {
+	if a.toolCallID != nil {
+		return a.toolCallID(index, name, arguments)
+	}
+	return DefaultToolCallID(index, name, arguments)
+}
+
 // streamResponse handles the actual streaming from OpenAI API.
-func (a *openAILLM) streamResponse(messages []UnifiedMessage, tools []Tool, responseCh *responseCh) {
+func (a *openAILLM) streamResponse(messages []UnifiedMessage, tools []Tool, options GenerationOptions, responseCh *responseCh) {
 	defer responseCh.Close()
 
 	// Build messages
-	openaiMessages, err := toOpenAIMessages(messages)
+	messages = options.withAssistantPrefill(messages, a.supportsPrefill)
+	openaiMessages, err := toOpenAIMessages(messages, a.promoteSystemToDeveloper)
 	if err != nil {
 		responseCh.Error <- fmt.Errorf("failed to convert messages to OpenAI messages: %w", err)
 		return
@@ -141,6 +300,18 @@ func (a *openAILLM) streamResponse(messages []UnifiedMessage, tools []Tool, resp
 		},
 	}
 
+	// Apply generation options (validated before any request is made)
+	if err := options.applyToOpenAIParams(&params); err != nil {
+		responseCh.Error <- fmt.Errorf("invalid generation options: %w", err)
+		return
+	}
+	if a.provider != "" {
+		if err := checkResponseFormat(a.provider, options.ResponseFormat); err != nil {
+			responseCh.Error <- fmt.Errorf("invalid generation options: %w", err)
+			return
+		}
+	}
+
 	// Add tools if available
 	if len(tools) > 0 {
 		openaiTools := make([]openai.ChatCompletionToolUnionParam, len(tools))
@@ -152,23 +323,104 @@ func (a *openAILLM) streamResponse(messages []UnifiedMessage, tools []Tool, resp
 		params.Tools = openaiTools
 	}
 
-	// Create streaming request
-	stream := a.client.Chat.Completions.NewStreaming(a.ctx, params)
+	if err := options.applyToolChoiceToOpenAIParams(&params, tools); err != nil {
+		responseCh.Error <- fmt.Errorf("invalid generation options: %w", err)
+		return
+	}
+
+	// Create streaming request. The request context is cancelled by the idle
+	// timer when the provider stalls between chunks.
+	streamCtx, cancel := context.WithCancel(a.ctx)
+	defer cancel()
+
+	// Cancel also aborts the request
+	go func() {
+		select {
+		case <-responseCh.Cancelled():
+			cancel()
+		case <-streamCtx.Done():
+		}
+	}()
+
+	// Wait for the rate limiter, Cancel also aborts the wait
+	if a.rateLimiter != nil {
+		if err := a.rateLimiter.Wait(streamCtx); err != nil {
+			select {
+			case <-responseCh.Cancelled():
+			default:
+				responseCh.Error <- fmt.Errorf("rate limiter: %w", err)
+			}
+			return
+		}
+	}
+
+	var idleTimer *time.Timer
+	var timedOut atomic.Bool
+	if a.idleTimeout > 0 {
+		idleTimer = time.AfterFunc(a.idleTimeout, func() {
+			timedOut.Store(true)
+			cancel()
+		})
+		defer idleTimer.Stop()
+	}
+
+	// sendChunk delivers a chunk to the consumer. The idle timer is paused while
+	// a slow consumer blocks the send, only time waiting on the provider counts.
+	sendChunk := func(jsonBytes []byte) bool {
+		if idleTimer != nil {
+			idleTimer.Stop()
+			defer idleTimer.Reset(a.idleTimeout)
+		}
+		select {
+		case responseCh.Response <- jsonBytes:
+			return true
+		case <-a.ctx.Done():
+		case <-responseCh.Cancelled():
+		}
+		return false
+	}
+
+	stream := a.client.Chat.Completions.NewStreaming(streamCtx, params)
 	defer stream.Close()
 
-	var fullContent string
+	var fullContent, fullReasoning string
 	var promptTokens, completionTokens, totalTokens int
-	// Track tool calls - map of tool call index to accumulated data
-	toolCallsMap := make(map[int]*struct {
-		ID        string
-		Name      string
-		Arguments string
-	})
+	var systemFingerprint string
+
+	// The model continues the prefill, stream it first so the answer is complete
+	if a.supportsPrefill && options.AssistantPrefill != "" {
+		fullContent = options.AssistantPrefill
+		jsonBytes, err := serializeChunk(ChunkResponse{
+			Content:     fullContent,
+			Delta:       fullContent,
+			FullContent: fullContent,
+			Status:      StatusStreaming,
+			Type:        TypeContent,
+		})
+		if err != nil {
+			responseCh.Error <- fmt.Errorf("failed to serialize chunk: %w", err)
+			return
+		}
+		if !sendChunk(jsonBytes) {
+			return
+		}
+	}
+	// Track tool calls - map of choice and tool call index to accumulated data
+	toolCallsMap := make(map[toolCallKey]*streamedToolCall)
 
 	// Process stream chunks
 	for stream.Next() {
 		chunk := stream.Current()
 
+		// Reset the idle timer on every received chunk
+		if idleTimer != nil {
+			idleTimer.Reset(a.idleTimeout)
+		}
+
+		if chunk.SystemFingerprint != "" {
+			systemFingerprint = chunk.SystemFingerprint
+		}
+
 		// Capture usage information if available
 		if chunk.Usage.PromptTokens > 0 || chunk.Usage.CompletionTokens > 0 {
 			promptTokens = int(chunk.Usage.PromptTokens)
@@ -179,8 +431,34 @@ func (a *openAILLM) streamResponse(messages []UnifiedMessage, tools []Tool, resp
 		for _, choice := range chunk.Choices {
 			delta := choice.Delta
 
+			// The answer is the first choice's: content of other choices would
+			// interleave with it. Their tool calls are kept below.
+			answer := choice.Index == 0
+
+			// Handle reasoning streaming, kept apart from the answer content
+			if reasoning := reasoningDelta(delta); answer && reasoning != "" {
+				fullReasoning += reasoning
+
+				jsonBytes, err := serializeChunk(ChunkResponse{
+					Content:     reasoning,
+					Delta:       reasoning,
+					FullContent: fullReasoning,
+					Status:      StatusStreaming,
+					Type:        TypeReasoning,
+					Trace:       TraceThinking,
+				})
+				if err != nil {
+					responseCh.Error <- fmt.Errorf("failed to serialize chunk: %w", err)
+					return
+				}
+
+				if !sendChunk(jsonBytes) {
+					return
+				}
+			}
+
 			// Handle content streaming
-			if delta.Content != "" {
+			if answer && delta.Content != "" {
 				fullContent += delta.Content
 
 				// Create chunk response
@@ -199,9 +477,7 @@ func (a *openAILLM) streamResponse(messages []UnifiedMessage, tools []Tool, resp
 					return
 				}
 
-				select {
-				case responseCh.Response <- jsonBytes:
-				case <-a.ctx.Done():
+				if !sendChunk(jsonBytes) {
 					return
 				}
 			}
@@ -209,15 +485,11 @@ func (a *openAILLM) streamResponse(messages []UnifiedMessage, tools []Tool, resp
 			// Handle tool calls - accumulate deltas
 			if len(delta.ToolCalls) > 0 {
 				for _, toolCallDelta := range delta.ToolCalls {
-					idx := int(toolCallDelta.Index)
+					idx := toolCallKey{choice: choice.Index, index: toolCallDelta.Index}
 
 					// Initialize tool call entry if not exists
 					if toolCallsMap[idx] == nil {
-						toolCallsMap[idx] = &struct {
-							ID        string
-							Name      string
-							Arguments string
-						}{}
+						toolCallsMap[idx] = &streamedToolCall{}
 					}
 
 					// Accumulate tool call data
@@ -236,6 +508,15 @@ func (a *openAILLM) streamResponse(messages []UnifiedMessage, tools []Tool, resp
 	}
 
 	// Check for stream errors
+	select {
+	case <-responseCh.Cancelled():
+		return
+	default:
+	}
+	if timedOut.Load() {
+		responseCh.Error <- fmt.Errorf("openai stream idle timeout: no chunk received within %s", a.idleTimeout)
+		return
+	}
 	if err := stream.Err(); err != nil {
 		responseCh.Error <- fmt.Errorf("openai stream error: %w", err)
 		return
@@ -245,26 +526,38 @@ func (a *openAILLM) streamResponse(messages []UnifiedMessage, tools []Tool, resp
 	if len(toolCallsMap) > 0 {
 		toolCalls := make([]ToolCall, 0, len(toolCallsMap))
 
-		// Convert map to sorted slice
-		for i := 0; i < len(toolCallsMap); i++ {
-			if toolData, exists := toolCallsMap[i]; exists {
-				// Parse JSON arguments
-				var args map[string]any
-				if toolData.Arguments != "" {
-					if err := json.Unmarshal([]byte(toolData.Arguments), &args); err != nil {
-						responseCh.Error <- fmt.Errorf("failed to parse tool call arguments for %s: %w", toolData.Name, err)
-						return
+		// Convert map to a slice ordered by choice and index. Indices may be
+		// sparse, so iterate over the keys actually received.
+		for i, key := range sortedToolCallKeys(toolCallsMap) {
+			toolData := toolCallsMap[key]
+
+			// Some compatible providers omit IDs, which would leave the tool
+			// result message unpaired on the next request
+			if toolData.ID == "" {
+				toolData.ID = a.generateToolCallID(i, toolData.Name, toolData.Arguments)
+			}
+
+			// Parse JSON arguments
+			var args map[string]any
+			if toolData.Arguments != "" {
+				if err := json.Unmarshal([]byte(toolData.Arguments), &args); err != nil {
+					responseCh.Error <- &ToolArgumentsError{
+						ToolCallID: toolData.ID,
+						ToolName:   toolData.Name,
+						Arguments:  toolData.Arguments,
+						Err:        err,
 					}
-				} else {
-					args = make(map[string]any)
+					return
 				}
-
-				toolCalls = append(toolCalls, ToolCall{
-					ID:        toolData.ID,
-					Name:      toolData.Name,
-					Arguments: args,
-				})
+			} else {
+				args = make(map[string]any)
 			}
+
+			toolCalls = append(toolCalls, ToolCall{
+				ID:        toolData.ID,
+				Name:      toolData.Name,
+				Arguments: args,
+			})
 		}
 
 		// Send tool call chunk
@@ -287,19 +580,22 @@ func (a *openAILLM) streamResponse(messages []UnifiedMessage, tools []Tool, resp
 		case responseCh.Response <- jsonBytes:
 		case <-a.ctx.Done():
 			return
+		case <-responseCh.Cancelled():
+			return
 		}
 	}
 
 	// Send final completed chunk with token usage
 	finalChunk := ChunkResponse{
-		Content:          "",
-		Delta:            "",
-		FullContent:      fullContent,
-		Status:           StatusCompleted,
-		Type:             TypeCompletion,
-		PromptTokens:     promptTokens,
-		CompletionTokens: completionTokens,
-		TotalTokens:      totalTokens,
+		Content:           "",
+		Delta:             "",
+		FullContent:       fullContent,
+		Status:            StatusCompleted,
+		Type:              TypeCompletion,
+		PromptTokens:      promptTokens,
+		CompletionTokens:  completionTokens,
+		TotalTokens:       totalTokens,
+		SystemFingerprint: systemFingerprint,
 	}
 
 	jsonBytes, err := serializeChunk(finalChunk)
@@ -312,5 +608,7 @@ func (a *openAILLM) streamResponse(messages []UnifiedMessage, tools []Tool, resp
 	case responseCh.Response <- jsonBytes:
 	case <-a.ctx.Done():
 		return
+	case <-responseCh.Cancelled():
+		return
 	}
 }
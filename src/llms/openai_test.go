@@ -0,0 +1,532 @@
+package llms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockOpenAIServer is an OpenAI-compatible server streaming scripted SSE events.
+type mockOpenAIServer struct {
+	*httptest.Server
+
+	mu sync.Mutex
+	// lastBody is the JSON body of the last chat completion request
+	lastBody string
+	// lastRequest is the last received request
+	lastRequest *http.Request
+}
+
+// LastBody returns the body of the last received request.
+func (m *mockOpenAIServer) LastBody() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastBody
+}
+
+// LastRequest returns the last received request.
+func (m *mockOpenAIServer) LastRequest() *http.Request {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastRequest
+}
+
+// newMockOpenAIServer starts a server that streams the given chunk payloads as
+// SSE "data:" events. When stall is true the server stops after the events and
+// blocks until the client goes away instead of sending [DONE].
+func newMockOpenAIServer(t *testing.T, chunks []string, stall bool) *mockOpenAIServer {
+	t.Helper()
+	m := &mockOpenAIServer{}
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		m.mu.Lock()
+		m.lastBody = string(body)
+		m.lastRequest = r
+		m.mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+			flusher.Flush()
+		}
+		if stall {
+			<-r.Context().Done()
+			return
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+		flusher.Flush()
+	}))
+	t.Cleanup(m.Close)
+	return m
+}
+
+// contentChunkJSON builds a streamed chat completion chunk carrying a content delta.
+func contentChunkJSON(content string) string {
+	return fmt.Sprintf(`{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"test-model","choices":[{"index":0,"delta":{"content":%q}}]}`, content)
+}
+
+// collectChunks drains a responseCh and returns the chunks and the first error.
+func collectChunks(t *testing.T, rc *responseCh, timeout time.Duration) ([]ChunkResponse, error) {
+	t.Helper()
+	var chunks []ChunkResponse
+	errCh := rc.Error
+	deadline := time.After(timeout)
+	for {
+		select {
+		case b, ok := <-rc.Response:
+			if !ok {
+				return chunks, nil
+			}
+			var chunk ChunkResponse
+			if err := json.Unmarshal(b, &chunk); err != nil {
+				t.Fatalf("Failed to deserialize chunk: %v", err)
+			}
+			chunks = append(chunks, chunk)
+		case err, ok := <-errCh:
+			if !ok {
+				errCh = nil
+				continue
+			}
+			return chunks, err
+		case <-deadline:
+			t.Fatalf("Timed out waiting for stream after %s", timeout)
+		}
+	}
+}
+
+func TestOpenAILLM_IdleTimeout(t *testing.T) {
+	server := newMockOpenAIServer(t, []string{contentChunkJSON("Hello")}, true)
+
+	llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+	llm.idleTimeout = 100 * time.Millisecond
+
+	start := time.Now()
+	chunks, err := collectChunks(t, llm.ChatStream([]UnifiedMessage{UserMessage("hi")}, nil), 5*time.Second)
+
+	if err == nil || !strings.Contains(err.Error(), "idle timeout") {
+		t.Fatalf("Expected idle timeout error, got %v", err)
+	}
+	if len(chunks) != 1 || chunks[0].Content != "Hello" {
+		t.Errorf("Expected the chunk sent before the stall, got %+v", chunks)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Timeout took too long: %s", elapsed)
+	}
+}
+
+func TestOpenAILLM_IdleTimeoutIgnoresSlowConsumer(t *testing.T) {
+	server := newMockOpenAIServer(t, []string{contentChunkJSON("Hello"), contentChunkJSON(","), contentChunkJSON(" slow"), contentChunkJSON(" world")}, false)
+
+	llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+	llm.idleTimeout = 100 * time.Millisecond
+	llm.responseBufferSize = 1
+	rc := llm.ChatStream([]UnifiedMessage{UserMessage("hi")}, nil)
+
+	// The provider answers at once, the consumer is slower than the idle timeout
+	var chunks []ChunkResponse
+	for b := range rc.Response {
+		time.Sleep(250 * time.Millisecond)
+		var chunk ChunkResponse
+		if err := json.Unmarshal(b, &chunk); err != nil {
+			t.Fatalf("Failed to deserialize chunk: %v", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	select {
+	case err := <-rc.Error:
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	default:
+	}
+	if len(chunks) == 0 || chunks[len(chunks)-1].FullContent != "Hello, slow world" {
+		t.Errorf("Expected the complete answer, got %+v", chunks)
+	}
+}
+
+func TestOpenAILLM_NoIdleTimeoutByDefault(t *testing.T) {
+	server := newMockOpenAIServer(t, []string{contentChunkJSON("Hello"), contentChunkJSON(" world")}, false)
+
+	llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+
+	chunks, err := collectChunks(t, llm.ChatStream([]UnifiedMessage{UserMessage("hi")}, nil), 5*time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	last := chunks[len(chunks)-1]
+	if last.Status != StatusCompleted || last.FullContent != "Hello world" {
+		t.Errorf("Expected completed chunk with full content, got %+v", last)
+	}
+}
+
+func TestToOpenAIMessages_Roles(t *testing.T) {
+	messages := []UnifiedMessage{
+		SystemMessage("be brief"),
+		DeveloperMessage("use metric units"),
+		UserMessage("hi"),
+		AssistantMessage("hello", 0, 0, 0),
+		ToolMessage("call-1", "42"),
+	}
+
+	t.Run("roles map to their unions", func(t *testing.T) {
+		got, err := toOpenAIMessages(messages, false)
+		if err != nil {
+			t.Fatalf("toOpenAIMessages() unexpected error = %v", err)
+		}
+		if got[0].OfSystem == nil || got[1].OfDeveloper == nil || got[2].OfUser == nil || got[3].OfAssistant == nil || got[4].OfTool == nil {
+			t.Errorf("Unexpected message unions: %+v", got)
+		}
+	})
+
+	t.Run("system is promoted to developer", func(t *testing.T) {
+		got, err := toOpenAIMessages(messages, true)
+		if err != nil {
+			t.Fatalf("toOpenAIMessages() unexpected error = %v", err)
+		}
+		if got[0].OfSystem != nil || got[0].OfDeveloper == nil {
+			t.Errorf("Expected system message promoted to developer, got %+v", got[0])
+		}
+		if got[0].OfDeveloper.Content.OfString.Value != "be brief" {
+			t.Errorf("Expected content to be kept, got %+v", got[0].OfDeveloper.Content)
+		}
+		if got[1].OfDeveloper == nil || got[2].OfUser == nil {
+			t.Errorf("Expected other roles unchanged, got %+v", got)
+		}
+	})
+
+	t.Run("unknown role is rejected", func(t *testing.T) {
+		var bad UnifiedMessage
+		if err := json.Unmarshal([]byte(`{"role":"narrator","content":"x"}`), &bad); err != nil {
+			t.Fatalf("Failed to build message: %v", err)
+		}
+		if _, err := toOpenAIMessages([]UnifiedMessage{bad}, false); err == nil || !strings.Contains(err.Error(), "invalid message role") {
+			t.Errorf("Expected invalid role error, got %v", err)
+		}
+	})
+}
+
+func TestToOpenAIMessages_AssistantToolCalls(t *testing.T) {
+	toolCalls := []ToolCall{{ID: "call-1", Name: "search", Arguments: map[string]any{"q": "go"}}}
+
+	tests := []struct {
+		name        string
+		content     string
+		wantContent bool
+	}{
+		{name: "tool calls only", content: "", wantContent: false},
+		{name: "whitespace content", content: "\n ", wantContent: false},
+		{name: "content and tool calls", content: "Let me search.", wantContent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := toOpenAIMessages([]UnifiedMessage{AssistantMessageWithToolCalls(tt.content, toolCalls, 0, 0, 0)}, false)
+			if err != nil {
+				t.Fatalf("toOpenAIMessages() unexpected error = %v", err)
+			}
+			raw, err := json.Marshal(got[0])
+			if err != nil {
+				t.Fatalf("Failed to marshal message: %v", err)
+			}
+			var decoded map[string]any
+			if err := json.Unmarshal(raw, &decoded); err != nil {
+				t.Fatalf("Failed to decode message: %v", err)
+			}
+
+			content, hasContent := decoded["content"]
+			if hasContent != tt.wantContent {
+				t.Errorf("Expected content present=%v, got %s", tt.wantContent, raw)
+			}
+			if tt.wantContent && content != tt.content {
+				t.Errorf("content = %v, want %q", content, tt.content)
+			}
+			if calls, _ := decoded["tool_calls"].([]any); len(calls) != 1 {
+				t.Errorf("Expected one tool call, got %s", raw)
+			}
+		})
+	}
+}
+
+func TestOpenAILLMBuilder_PromoteSystemToDeveloper(t *testing.T) {
+	for _, promote := range []bool{false, true} {
+		server := newMockOpenAIServer(t, []string{contentChunkJSON("ok")}, false)
+		engine, err := NewOpenAILLMBuilder("openai").
+			SetAPIKey("test-key").
+			SetBaseURL(server.URL).
+			SetPromoteSystemToDeveloper(promote).
+			Build()
+		if err != nil {
+			t.Fatalf("Build() unexpected error = %v", err)
+		}
+		if _, err := collectChunks(t, engine.ChatStream([]UnifiedMessage{SystemMessage("be brief"), UserMessage("hi")}, nil), 5*time.Second); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var body struct {
+			Messages []struct {
+				Role string `json:"role"`
+			} `json:"messages"`
+		}
+		if err := json.Unmarshal([]byte(server.LastBody()), &body); err != nil {
+			t.Fatalf("Failed to parse request body: %v", err)
+		}
+		want := "system"
+		if promote {
+			want = "developer"
+		}
+		if len(body.Messages) != 2 || body.Messages[0].Role != want {
+			t.Errorf("promote=%v: expected first role %q, got %s", promote, want, server.LastBody())
+		}
+	}
+
+	if !ModelPrefersDeveloperRole(OPENAI_GPT5_1) || ModelPrefersDeveloperRole(DEEPSEEK_CHAT) {
+		t.Error("Unexpected ModelPrefersDeveloperRole result")
+	}
+}
+
+// reasoningChunkJSON builds a streamed chat completion chunk carrying a reasoning delta.
+func reasoningChunkJSON(field, reasoning string) string {
+	return fmt.Sprintf(`{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"test-model","choices":[{"index":0,"delta":{%q:%q}}]}`, field, reasoning)
+}
+
+func TestOpenAILLM_ReasoningChunks(t *testing.T) {
+	server := newMockOpenAIServer(t, []string{
+		reasoningChunkJSON("reasoning_content", "Let me think. "),
+		reasoningChunkJSON("reasoning", "2+2=4."),
+		`{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"test-model","choices":[{"index":0,"delta":{"content":"","reasoning_content":null}}]}`,
+		contentChunkJSON("The answer is 4"),
+	}, false)
+
+	llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+	chunks, err := collectChunks(t, llm.ChatStream([]UnifiedMessage{UserMessage("2+2?")}, nil), 5*time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var reasoning []ChunkResponse
+	var content string
+	for _, chunk := range chunks {
+		switch chunk.Type {
+		case TypeReasoning:
+			reasoning = append(reasoning, chunk)
+		case TypeContent:
+			content += chunk.Content
+		}
+	}
+
+	if len(reasoning) != 2 {
+		t.Fatalf("Expected 2 reasoning chunks, got %+v", reasoning)
+	}
+	if reasoning[1].Delta != "2+2=4." || reasoning[1].FullContent != "Let me think. 2+2=4." || reasoning[1].Trace != TraceThinking {
+		t.Errorf("Unexpected reasoning chunk: %+v", reasoning[1])
+	}
+	if content != "The answer is 4" {
+		t.Errorf("Expected content without reasoning, got %q", content)
+	}
+	last := chunks[len(chunks)-1]
+	if last.Status != StatusCompleted || last.FullContent != "The answer is 4" {
+		t.Errorf("Expected completion with the answer only, got %+v", last)
+	}
+}
+
+func TestOpenAILLM_MalformedToolArguments(t *testing.T) {
+	server := newMockOpenAIServer(t, []string{
+		`{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"test-model","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"foo","arguments":"{\"echo\": "}}]}}]}`,
+	}, false)
+
+	llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+	_, err := collectChunks(t, llm.ChatStream([]UnifiedMessage{UserMessage("hi")}, nil), 5*time.Second)
+
+	var argErr *ToolArgumentsError
+	if !errors.As(err, &argErr) {
+		t.Fatalf("Expected *ToolArgumentsError, got %v", err)
+	}
+	if argErr.ToolCallID != "call_1" || argErr.ToolName != "foo" || argErr.Arguments != `{"echo": ` {
+		t.Errorf("Unexpected error fields: %+v", argErr)
+	}
+}
+
+// toolCallChunkJSON builds a streamed chunk carrying one tool call delta.
+func toolCallChunkJSON(choice, index int, id, name, arguments string) string {
+	return fmt.Sprintf(`{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"test-model","choices":[{"index":%d,"delta":{"tool_calls":[{"index":%d,"id":%q,"type":"function","function":{"name":%q,"arguments":%q}}]}}]}`,
+		choice, index, id, name, arguments)
+}
+
+func TestOpenAILLM_SparseToolCallIndices(t *testing.T) {
+	server := newMockOpenAIServer(t, []string{
+		toolCallChunkJSON(0, 2, "call_c", "foo", `{"echo":`),
+		toolCallChunkJSON(0, 2, "", "", `"c"}`),
+		toolCallChunkJSON(0, 5, "call_f", "foo", `{"echo":"f"}`),
+		toolCallChunkJSON(1, 0, "call_x", "bar", `{}`),
+	}, false)
+
+	llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+	chunks, err := collectChunks(t, llm.ChatStream([]UnifiedMessage{UserMessage("hi")}, nil), 5*time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var toolCalls []ToolCall
+	for _, chunk := range chunks {
+		if chunk.Type == TypeToolCall {
+			toolCalls = chunk.ToolCalls
+		}
+	}
+	if len(toolCalls) != 3 {
+		t.Fatalf("Expected 3 tool calls, got %+v", toolCalls)
+	}
+	wantIDs := []string{"call_c", "call_f", "call_x"}
+	for i, id := range wantIDs {
+		if toolCalls[i].ID != id {
+			t.Errorf("toolCalls[%d].ID = %q, want %q", i, toolCalls[i].ID, id)
+		}
+	}
+	if toolCalls[0].Arguments["echo"] != "c" || toolCalls[1].Arguments["echo"] != "f" {
+		t.Errorf("Expected merged argument fragments, got %+v", toolCalls)
+	}
+}
+
+func TestOpenAILLM_SeveralChoicesContent(t *testing.T) {
+	choiceChunk := func(choice int, content string) string {
+		return fmt.Sprintf(`{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"test-model","choices":[{"index":%d,"delta":{"content":%q}}]}`, choice, content)
+	}
+	server := newMockOpenAIServer(t, []string{
+		choiceChunk(0, "Hello"),
+		choiceChunk(1, "Bonjour"),
+		choiceChunk(0, " world"),
+		choiceChunk(1, " le monde"),
+	}, false)
+
+	llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+	chunks, err := collectChunks(t, llm.ChatStream([]UnifiedMessage{UserMessage("hi")}, nil), 5*time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var deltas []string
+	for _, chunk := range chunks {
+		if chunk.Type == TypeContent {
+			deltas = append(deltas, chunk.Delta)
+		}
+	}
+	if strings.Join(deltas, "") != "Hello world" {
+		t.Errorf("Expected only the first choice's content, got %q", deltas)
+	}
+	if last := chunks[len(chunks)-1]; last.FullContent != "Hello world" {
+		t.Errorf("Expected the first choice's answer in the final chunk, got %q", last.FullContent)
+	}
+}
+
+func TestOpenAILLM_MissingToolCallIDs(t *testing.T) {
+	server := newMockOpenAIServer(t, []string{
+		toolCallChunkJSON(0, 0, "", "foo", `{"echo":"a"}`),
+		toolCallChunkJSON(0, 1, "", "foo", `{"echo":"b"}`),
+		toolCallChunkJSON(0, 2, "call_given", "bar", `{}`),
+	}, false)
+
+	streamToolCalls := func(llm *openAILLM) []ToolCall {
+		t.Helper()
+		chunks, err := collectChunks(t, llm.ChatStream([]UnifiedMessage{UserMessage("hi")}, nil), 5*time.Second)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, chunk := range chunks {
+			if chunk.Type == TypeToolCall {
+				return chunk.ToolCalls
+			}
+		}
+		t.Fatal("Expected a tool call chunk")
+		return nil
+	}
+
+	llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+	toolCalls := streamToolCalls(llm)
+	if len(toolCalls) != 3 {
+		t.Fatalf("Expected 3 tool calls, got %+v", toolCalls)
+	}
+	if toolCalls[0].ID != DefaultToolCallID(0, "foo", `{"echo":"a"}`) || !strings.HasPrefix(toolCalls[0].ID, "call_0_") {
+		t.Errorf("Unexpected synthesized ID %q", toolCalls[0].ID)
+	}
+	if toolCalls[0].ID == toolCalls[1].ID {
+		t.Errorf("Expected distinct IDs, got %q twice", toolCalls[0].ID)
+	}
+	if toolCalls[2].ID != "call_given" {
+		t.Errorf("Expected the provider ID to be kept, got %q", toolCalls[2].ID)
+	}
+	if again := streamToolCalls(llm); again[0].ID != toolCalls[0].ID {
+		t.Errorf("Expected stable IDs, got %q then %q", toolCalls[0].ID, again[0].ID)
+	}
+
+	// The next request pairs each tool result with its tool call
+	history := []UnifiedMessage{UserMessage("hi"), AssistantMessageWithToolCalls("", toolCalls, 0, 0, 0)}
+	for _, tc := range toolCalls {
+		history = append(history, ToolMessage(tc.ID, "ok"))
+	}
+	got, err := toOpenAIMessages(history, false)
+	if err != nil {
+		t.Fatalf("toOpenAIMessages() unexpected error = %v", err)
+	}
+	for i, tc := range got[1].OfAssistant.ToolCalls {
+		if id := got[2+i].OfTool.ToolCallID; tc.OfFunction.ID != id || id == "" {
+			t.Errorf("tool call %d: ID %q paired with result %q", i, tc.OfFunction.ID, id)
+		}
+	}
+
+	custom := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+	custom.toolCallID = func(index int, name, arguments string) string { return fmt.Sprintf("%s-%d", name, index) }
+	if ids := streamToolCalls(custom); ids[0].ID != "foo-0" || ids[1].ID != "foo-1" {
+		t.Errorf("Expected custom IDs, got %+v", ids)
+	}
+}
+
+func TestOpenAILLM_Cancel(t *testing.T) {
+	server := newMockOpenAIServer(t, []string{contentChunkJSON("Hello")}, true)
+
+	llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+	rc := llm.ChatStream([]UnifiedMessage{UserMessage("hi")}, nil)
+
+	// Wait for the first chunk, then cancel the stalled stream
+	<-rc.Response
+	rc.Cancel()
+	rc.Cancel()
+
+	chunks, err := collectChunks(t, rc, 5*time.Second)
+	if err != nil {
+		t.Errorf("Expected cancelled stream to close without error, got %v", err)
+	}
+	if len(chunks) != 0 {
+		t.Errorf("Expected no chunks after cancel, got %+v", chunks)
+	}
+}
+
+func TestOpenAILLM_CancelBlockedCompletion(t *testing.T) {
+	server := newMockOpenAIServer(t, []string{contentChunkJSON("Hello")}, false)
+
+	llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+	llm.responseBufferSize = 1
+	rc := llm.ChatStream([]UnifiedMessage{UserMessage("hi")}, nil)
+
+	// The content chunk fills the buffer, so the completion chunk waits for the
+	// consumer, which cancels instead of reading
+	time.Sleep(100 * time.Millisecond)
+	rc.Cancel()
+	time.Sleep(100 * time.Millisecond)
+
+	chunks, err := collectChunks(t, rc, 5*time.Second)
+	if err != nil {
+		t.Errorf("Expected cancelled stream to close without error, got %v", err)
+	}
+	for _, chunk := range chunks {
+		if chunk.Status == StatusCompleted {
+			t.Errorf("Expected no completion chunk after cancel, got %+v", chunks)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package llms
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/shared"
+)
+
+// ResponseFormat selects the output format requested from the model.
+type ResponseFormat string
+
+func (f ResponseFormat) String() string {
+	return string(f)
+}
+
+const (
+	// ResponseFormatText is plain text output (provider default).
+	ResponseFormatText ResponseFormat = "text"
+	// ResponseFormatJSONObject asks the model for any valid JSON object.
+	ResponseFormatJSONObject ResponseFormat = "json_object"
+	// ResponseFormatJSONSchema asks the model for JSON matching GenerationOptions.JSONSchema.
+	ResponseFormatJSONSchema ResponseFormat = "json_schema"
+)
+
+// ToolChoice controls whether and which tool the model must call.
+//
+// Besides the constants below, any other value is interpreted as the name of a
+// specific tool the model is forced to call.
+type ToolChoice string
+
+func (c ToolChoice) String() string {
+	return string(c)
+}
+
+const (
+	// ToolChoiceAuto lets the model decide whether to call tools (provider default).
+	ToolChoiceAuto ToolChoice = "auto"
+	// ToolChoiceNone forbids tool calls; the model answers directly.
+	ToolChoiceNone ToolChoice = "none"
+	// ToolChoiceRequired forces the model to call at least one tool.
+	ToolChoiceRequired ToolChoice = "required"
+)
+
+// IsForcing reports whether the choice forces a tool call (required or a specific tool).
+func (c ToolChoice) IsForcing() bool {
+	return c != "" && c != ToolChoiceAuto && c != ToolChoiceNone
+}
+
+// JSONSchema describes the schema used with ResponseFormatJSONSchema.
+type JSONSchema struct {
+	// Name identifies the schema (a-z, A-Z, 0-9, underscores and dashes, max 64 chars).
+	Name string
+	// Description explains what the response is for.
+	Description string
+	// Schema is the JSON Schema object the response must match.
+	Schema map[string]any
+	// Strict enables strict schema adherence where supported.
+	Strict bool
+}
+
+// GenerationOptions holds request-level generation settings applied by the engine.
+//
+// The zero value keeps the provider defaults.
+type GenerationOptions struct {
+	// ResponseFormat selects text, json_object or json_schema output.
+	// Empty means provider default (text).
+	ResponseFormat ResponseFormat
+
+	// JSONSchema is required when ResponseFormat is ResponseFormatJSONSchema.
+	JSONSchema *JSONSchema
+
+	// ToolChoice selects auto, none, required or a specific tool name.
+	// Empty means provider default (auto). Ignored when a request has no tools.
+	ToolChoice ToolChoice
+
+	// AssistantPrefill is the text the assistant's answer starts with, to steer its
+	// format (e.g. "{" for JSON). Providers that support prefill (see
+	// ProviderSupportsAssistantPrefill) continue it, and the engine streams it as the
+	// first content chunk so the answer is complete. Other providers receive it as an
+	// instruction to begin the answer with it, which the model may not follow exactly.
+	AssistantPrefill string
+
+	// Seed asks the provider for deterministic sampling, so the same request with
+	// the same seed returns the same answer where the provider supports it. Compare
+	// ChunkResponse.SystemFingerprint across runs to detect backend changes that
+	// break reproducibility. Providers that do not support it ignore it. Nil means no seed.
+	Seed *int
+}
+
+// Merge returns o with every non-zero field of override applied on top.
+//
+// Parameters:
+//   - override: Options taking precedence over o
+//
+// Returns:
+//   - GenerationOptions: The merged options
+func (o GenerationOptions) Merge(override GenerationOptions) GenerationOptions {
+	merged := o
+	if override.ResponseFormat != "" {
+		merged.ResponseFormat = override.ResponseFormat
+		merged.JSONSchema = override.JSONSchema
+	}
+	if override.ToolChoice != "" {
+		merged.ToolChoice = override.ToolChoice
+	}
+	if override.AssistantPrefill != "" {
+		merged.AssistantPrefill = override.AssistantPrefill
+	}
+	if override.Seed != nil {
+		merged.Seed = override.Seed
+	}
+	return merged
+}
+
+// prefillGuidance instructs models without prefill support to start with the prefill.
+const prefillGuidance = "Begin your response with exactly the following text and continue from it:\n%s"
+
+// withAssistantPrefill returns messages followed by the assistant prefill: a trailing
+// assistant message when the provider supports prefill, a system instruction otherwise.
+// The messages slice is not modified.
+func (o GenerationOptions) withAssistantPrefill(messages []UnifiedMessage, supported bool) []UnifiedMessage {
+	if o.AssistantPrefill == "" {
+		return messages
+	}
+	prefilled := make([]UnifiedMessage, len(messages), len(messages)+1)
+	copy(prefilled, messages)
+	if supported {
+		return append(prefilled, AssistantMessage(o.AssistantPrefill, 0, 0, 0))
+	}
+	return append(prefilled, SystemMessage(fmt.Sprintf(prefillGuidance, o.AssistantPrefill)))
+}
+
+var schemaNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// validate checks that the options are well-formed before any request is made.
+func (o GenerationOptions) validate() error {
+	switch o.ResponseFormat {
+	case "", ResponseFormatText, ResponseFormatJSONObject:
+		if o.JSONSchema != nil {
+			return fmt.Errorf("JSONSchema is only valid with response format %q", ResponseFormatJSONSchema)
+		}
+	case ResponseFormatJSONSchema:
+		if o.JSONSchema == nil {
+			return fmt.Errorf("response format %q requires a JSONSchema", ResponseFormatJSONSchema)
+		}
+		if !schemaNamePattern.MatchString(o.JSONSchema.Name) {
+			return fmt.Errorf("invalid JSON schema name %q: must match %s", o.JSONSchema.Name, schemaNamePattern)
+		}
+		if len(o.JSONSchema.Schema) == 0 {
+			return fmt.Errorf("JSON schema %q is empty", o.JSONSchema.Name)
+		}
+		if _, ok := o.JSONSchema.Schema["type"]; !ok {
+			return fmt.Errorf("JSON schema %q is missing a top-level \"type\"", o.JSONSchema.Name)
+		}
+		if _, err := json.Marshal(o.JSONSchema.Schema); err != nil {
+			return fmt.Errorf("JSON schema %q is not serializable: %w", o.JSONSchema.Name, err)
+		}
+	default:
+		return fmt.Errorf("invalid response format: %q", o.ResponseFormat)
+	}
+	return nil
+}
+
+// applyToOpenAIParams maps the options onto OpenAI chat completion parameters.
+func (o GenerationOptions) applyToOpenAIParams(params *openai.ChatCompletionNewParams) error {
+	if err := o.validate(); err != nil {
+		return err
+	}
+
+	switch o.ResponseFormat {
+	case ResponseFormatText:
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfText: &shared.ResponseFormatTextParam{},
+		}
+	case ResponseFormatJSONObject:
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
+		}
+	case ResponseFormatJSONSchema:
+		jsonSchema := shared.ResponseFormatJSONSchemaJSONSchemaParam{
+			Name:   o.JSONSchema.Name,
+			Schema: o.JSONSchema.Schema,
+		}
+		if o.JSONSchema.Description != "" {
+			jsonSchema.Description = openai.String(o.JSONSchema.Description)
+		}
+		if o.JSONSchema.Strict {
+			jsonSchema.Strict = openai.Bool(true)
+		}
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{JSONSchema: jsonSchema},
+		}
+	}
+
+	if o.Seed != nil {
+		params.Seed = openai.Int(int64(*o.Seed))
+	}
+	return nil
+}
+
+// applyToolChoiceToOpenAIParams maps the tool choice onto OpenAI parameters.
+// It must be called after the tools are set; without tools the choice is ignored.
+func (o GenerationOptions) applyToolChoiceToOpenAIParams(params *openai.ChatCompletionNewParams, tools []Tool) error {
+	if o.ToolChoice == "" || len(tools) == 0 {
+		return nil
+	}
+
+	switch o.ToolChoice {
+	case ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired:
+		params.ToolChoice = openai.ChatCompletionToolChoiceOptionUnionParam{
+			OfAuto: openai.String(string(o.ToolChoice)),
+		}
+	default:
+		found := false
+		for _, tool := range tools {
+			if tool.GetName() == string(o.ToolChoice) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("tool choice %q does not match any provided tool", o.ToolChoice)
+		}
+		params.ToolChoice = openai.ToolChoiceOptionFunctionToolChoice(openai.ChatCompletionNamedToolChoiceFunctionParam{
+			Name: string(o.ToolChoice),
+		})
+	}
+	return nil
+}
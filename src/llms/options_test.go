@@ -0,0 +1,358 @@
+package llms
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+var personSchema = &JSONSchema{
+	Name: "person",
+	Schema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string"},
+		},
+		"required": []string{"name"},
+	},
+	Strict: true,
+}
+
+func TestGenerationOptions_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options GenerationOptions
+		errMsg  string
+	}{
+		{name: "zero value", options: GenerationOptions{}},
+		{name: "json object", options: GenerationOptions{ResponseFormat: ResponseFormatJSONObject}},
+		{name: "json schema", options: GenerationOptions{ResponseFormat: ResponseFormatJSONSchema, JSONSchema: personSchema}},
+		{
+			name:    "unknown format",
+			options: GenerationOptions{ResponseFormat: "xml"},
+			errMsg:  "invalid response format",
+		},
+		{
+			name:    "schema missing",
+			options: GenerationOptions{ResponseFormat: ResponseFormatJSONSchema},
+			errMsg:  "requires a JSONSchema",
+		},
+		{
+			name:    "invalid schema name",
+			options: GenerationOptions{ResponseFormat: ResponseFormatJSONSchema, JSONSchema: &JSONSchema{Name: "my schema", Schema: map[string]any{"type": "object"}}},
+			errMsg:  "invalid JSON schema name",
+		},
+		{
+			name:    "schema without type",
+			options: GenerationOptions{ResponseFormat: ResponseFormatJSONSchema, JSONSchema: &JSONSchema{Name: "person", Schema: map[string]any{"properties": map[string]any{}}}},
+			errMsg:  "missing a top-level \"type\"",
+		},
+		{
+			name:    "unserializable schema",
+			options: GenerationOptions{ResponseFormat: ResponseFormatJSONSchema, JSONSchema: &JSONSchema{Name: "person", Schema: map[string]any{"type": "object", "bad": make(chan int)}}},
+			errMsg:  "not serializable",
+		},
+		{
+			name:    "schema with json object",
+			options: GenerationOptions{ResponseFormat: ResponseFormatJSONObject, JSONSchema: personSchema},
+			errMsg:  "only valid with response format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.validate()
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("validate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("validate() error = %v, want error containing %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestOpenAILLM_ResponseFormatParam(t *testing.T) {
+	server := newMockOpenAIServer(t, []string{contentChunkJSON(`{"name":"Ada"}`)}, false)
+
+	llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+	llm.options = GenerationOptions{ResponseFormat: ResponseFormatJSONSchema, JSONSchema: personSchema}
+
+	chunks, err := collectChunks(t, llm.ChatStream([]UnifiedMessage{UserMessage("Who wrote the first program?")}, nil), 5*time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var body struct {
+		ResponseFormat struct {
+			Type       string `json:"type"`
+			JSONSchema struct {
+				Name   string         `json:"name"`
+				Strict bool           `json:"strict"`
+				Schema map[string]any `json:"schema"`
+			} `json:"json_schema"`
+		} `json:"response_format"`
+	}
+	if err := json.Unmarshal([]byte(server.LastBody()), &body); err != nil {
+		t.Fatalf("Failed to parse request body: %v", err)
+	}
+	if body.ResponseFormat.Type != "json_schema" || body.ResponseFormat.JSONSchema.Name != "person" || !body.ResponseFormat.JSONSchema.Strict {
+		t.Errorf("Unexpected response_format in request: %s", server.LastBody())
+	}
+	if body.ResponseFormat.JSONSchema.Schema["type"] != "object" {
+		t.Errorf("Expected schema to be passed through, got %v", body.ResponseFormat.JSONSchema.Schema)
+	}
+
+	// The raw JSON is surfaced in the final chunk
+	if last := chunks[len(chunks)-1]; last.FullContent != `{"name":"Ada"}` {
+		t.Errorf("Expected raw JSON in final chunk, got %q", last.FullContent)
+	}
+}
+
+func TestOpenAILLM_MalformedSchemaRejectedBeforeCall(t *testing.T) {
+	server := newMockOpenAIServer(t, nil, false)
+
+	llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+	llm.options = GenerationOptions{ResponseFormat: ResponseFormatJSONSchema}
+
+	_, err := collectChunks(t, llm.ChatStream([]UnifiedMessage{UserMessage("hi")}, nil), 5*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "invalid generation options") {
+		t.Fatalf("Expected invalid generation options error, got %v", err)
+	}
+	if server.LastRequest() != nil {
+		t.Error("Expected no request to be sent for malformed options")
+	}
+}
+
+func TestOpenAILLM_UnsupportedResponseFormatPerCall(t *testing.T) {
+	server := newMockOpenAIServer(t, nil, false)
+
+	llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+	llm.provider = "deepseek"
+
+	opts := GenerationOptions{ResponseFormat: ResponseFormatJSONSchema, JSONSchema: personSchema}
+	_, err := collectChunks(t, llm.ChatStreamWithOptions([]UnifiedMessage{UserMessage("hi")}, nil, opts), 5*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "does not support response format") {
+		t.Fatalf("Expected unsupported format error, got %v", err)
+	}
+	if server.LastRequest() != nil {
+		t.Error("Expected no request to be sent for an unsupported format")
+	}
+}
+
+func TestOpenAILLMBuilder_UnsupportedResponseFormat(t *testing.T) {
+	_, err := NewOpenAILLMBuilder("deepseek").
+		SetApiKey("test-key").
+		SetResponseFormat(ResponseFormatJSONSchema, personSchema).
+		Build()
+	if err == nil || !strings.Contains(err.Error(), "does not support response format") {
+		t.Fatalf("Expected unsupported format error, got %v", err)
+	}
+
+	llm, err := NewOpenAILLMBuilder("openai").
+		SetApiKey("test-key").
+		SetResponseFormat(ResponseFormatJSONSchema, personSchema).
+		Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if llm.(*openAILLM).options.JSONSchema != personSchema {
+		t.Error("Expected options to be applied to the engine")
+	}
+}
+
+// stubTool is a minimal Tool used to populate requests.
+type stubTool struct{ name string }
+
+func (s stubTool) GetName() string { return s.name }
+
+func (s stubTool) Call(agentContext map[string]any, args map[string]any) ToolReturn { return nil }
+
+func (s stubTool) GetFunctionDefinition() FunctionDefinition {
+	return FunctionDefinition{
+		Name:       s.name,
+		Parameters: FunctionParameters{Type_: "object", Properties: map[string]FunctionObjectParameter{}},
+	}
+}
+
+func TestOpenAILLM_ToolChoiceParam(t *testing.T) {
+	tools := []Tool{stubTool{name: "delegate"}, stubTool{name: "foo"}}
+
+	tests := []struct {
+		name   string
+		choice ToolChoice
+		want   string
+	}{
+		{name: "auto", choice: ToolChoiceAuto, want: `"auto"`},
+		{name: "none", choice: ToolChoiceNone, want: `"none"`},
+		{name: "required", choice: ToolChoiceRequired, want: `"required"`},
+		{name: "specific tool", choice: "delegate", want: `{"function":{"name":"delegate"},"type":"function"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newMockOpenAIServer(t, []string{contentChunkJSON("ok")}, false)
+			llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+
+			rc := llm.ChatStreamWithOptions([]UnifiedMessage{UserMessage("hi")}, tools, GenerationOptions{ToolChoice: tt.choice})
+			if _, err := collectChunks(t, rc, 5*time.Second); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var body map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(server.LastBody()), &body); err != nil {
+				t.Fatalf("Failed to parse request body: %v", err)
+			}
+			if got := string(body["tool_choice"]); got != tt.want {
+				t.Errorf("tool_choice = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("per-call option overrides engine default", func(t *testing.T) {
+		server := newMockOpenAIServer(t, []string{contentChunkJSON("ok")}, false)
+		llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+		llm.options.ToolChoice = ToolChoiceNone
+
+		rc := llm.ChatStreamWithOptions([]UnifiedMessage{UserMessage("hi")}, tools, GenerationOptions{ToolChoice: ToolChoiceRequired})
+		if _, err := collectChunks(t, rc, 5*time.Second); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.Contains(server.LastBody(), `"tool_choice":"required"`) {
+			t.Errorf("Expected per-call tool_choice in body, got %s", server.LastBody())
+		}
+	})
+
+	t.Run("ignored without tools", func(t *testing.T) {
+		server := newMockOpenAIServer(t, []string{contentChunkJSON("ok")}, false)
+		llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+
+		rc := llm.ChatStreamWithOptions([]UnifiedMessage{UserMessage("hi")}, nil, GenerationOptions{ToolChoice: ToolChoiceRequired})
+		if _, err := collectChunks(t, rc, 5*time.Second); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if strings.Contains(server.LastBody(), "tool_choice") {
+			t.Errorf("Expected no tool_choice without tools, got %s", server.LastBody())
+		}
+	})
+
+	t.Run("unknown tool rejected before call", func(t *testing.T) {
+		server := newMockOpenAIServer(t, []string{contentChunkJSON("ok")}, false)
+		llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+
+		rc := llm.ChatStreamWithOptions([]UnifiedMessage{UserMessage("hi")}, tools, GenerationOptions{ToolChoice: "missing"})
+		_, err := collectChunks(t, rc, 5*time.Second)
+		if err == nil || !strings.Contains(err.Error(), `tool choice "missing" does not match any provided tool`) {
+			t.Errorf("Expected unknown tool error, got %v", err)
+		}
+		if server.LastRequest() != nil {
+			t.Error("Expected no request to be sent")
+		}
+	})
+}
+
+func TestOpenAILLM_AssistantPrefill(t *testing.T) {
+	lastMessage := func(t *testing.T, body string) map[string]any {
+		t.Helper()
+		var req struct {
+			Messages []map[string]any `json:"messages"`
+		}
+		if err := json.Unmarshal([]byte(body), &req); err != nil {
+			t.Fatalf("Failed to parse request body: %v", err)
+		}
+		return req.Messages[len(req.Messages)-1]
+	}
+
+	t.Run("supporting provider continues the prefill", func(t *testing.T) {
+		server := newMockOpenAIServer(t, []string{contentChunkJSON(`"name":"Ada"}`)}, false)
+		llm, err := (&OpenAILLMBuilder{Provider: "togetherai", ApiKey: "test-key", BaseURL: server.URL, Model: "test-model"}).Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		rc := llm.(*openAILLM).ChatStreamWithOptions([]UnifiedMessage{UserMessage("Who wrote the first program?")}, nil, GenerationOptions{AssistantPrefill: "{"})
+		chunks, err := collectChunks(t, rc, 5*time.Second)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		last := lastMessage(t, server.LastBody())
+		if last["role"] != "assistant" || last["content"] != "{" {
+			t.Errorf("Expected a trailing assistant prefill message, got %v", last)
+		}
+		if chunks[0].Type != TypeContent || chunks[0].Content != "{" {
+			t.Errorf("Expected the prefill as the first content chunk, got %+v", chunks[0])
+		}
+		if final := chunks[len(chunks)-1]; final.FullContent != `{"name":"Ada"}` {
+			t.Errorf("Expected the full answer to include the prefill, got %q", final.FullContent)
+		}
+	})
+
+	t.Run("other providers get guidance", func(t *testing.T) {
+		server := newMockOpenAIServer(t, []string{contentChunkJSON(`{"name":"Ada"}`)}, false)
+		llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+
+		rc := llm.ChatStreamWithOptions([]UnifiedMessage{UserMessage("hi")}, nil, GenerationOptions{AssistantPrefill: "{"})
+		chunks, err := collectChunks(t, rc, 5*time.Second)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		last := lastMessage(t, server.LastBody())
+		if last["role"] != "system" || !strings.Contains(last["content"].(string), "Begin your response with exactly") {
+			t.Errorf("Expected a trailing prefill instruction, got %v", last)
+		}
+		if final := chunks[len(chunks)-1]; final.FullContent != `{"name":"Ada"}` {
+			t.Errorf("Expected the model's answer only, got %q", final.FullContent)
+		}
+	})
+}
+
+func TestOpenAILLM_Seed(t *testing.T) {
+	chunk := `{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"test-model","system_fingerprint":"fp_test123","choices":[{"index":0,"delta":{"content":"4"}}]}`
+
+	t.Run("seed is sent and the fingerprint surfaced", func(t *testing.T) {
+		server := newMockOpenAIServer(t, []string{chunk}, false)
+		llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+
+		seed := 42
+		rc := llm.ChatStreamWithOptions([]UnifiedMessage{UserMessage("2+2?")}, nil, GenerationOptions{Seed: &seed})
+		chunks, err := collectChunks(t, rc, 5*time.Second)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var body struct {
+			Seed *int `json:"seed"`
+		}
+		if err := json.Unmarshal([]byte(server.LastBody()), &body); err != nil {
+			t.Fatalf("Failed to parse request body: %v", err)
+		}
+		if body.Seed == nil || *body.Seed != 42 {
+			t.Errorf("Expected seed 42 in request, got %s", server.LastBody())
+		}
+		if final := chunks[len(chunks)-1]; final.Status != StatusCompleted || final.SystemFingerprint != "fp_test123" {
+			t.Errorf("Expected the fingerprint in the completion chunk, got %+v", final)
+		}
+	})
+
+	t.Run("no seed by default", func(t *testing.T) {
+		server := newMockOpenAIServer(t, []string{contentChunkJSON("4")}, false)
+		llm := newOpenAILLM(context.Background(), server.URL, "test-model", "test-key")
+
+		chunks, err := collectChunks(t, llm.ChatStream([]UnifiedMessage{UserMessage("2+2?")}, nil), 5*time.Second)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if strings.Contains(server.LastBody(), `"seed"`) {
+			t.Errorf("Expected no seed in request, got %s", server.LastBody())
+		}
+		if final := chunks[len(chunks)-1]; final.SystemFingerprint != "" {
+			t.Errorf("Expected no fingerprint, got %q", final.SystemFingerprint)
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package llms
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimiter paces LLM requests. A single limiter can be shared by several
+// engines to keep all of them under a provider's request rate limit.
+type RateLimiter interface {
+	// Wait blocks until a request may be issued. It returns an error if ctx
+	// is done first.
+	Wait(ctx context.Context) error
+}
+
+// tokenBucketLimiter is a RateLimiter backed by a token bucket.
+type tokenBucketLimiter struct {
+	limiter *rate.Limiter
+}
+
+// NewRateLimiter creates a token-bucket RateLimiter.
+//
+// Parameters:
+//   - rps: The sustained number of requests per second
+//   - burst: The number of requests that may be issued at once (values below 1 are treated as 1)
+//
+// Returns:
+//   - RateLimiter: A limiter safe for concurrent use
+func NewRateLimiter(rps float64, burst int) RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucketLimiter{limiter: rate.NewLimiter(rate.Limit(rps), burst)}
+}
+
+// Wait implements RateLimiter.
+func (l *tokenBucketLimiter) Wait(ctx context.Context) error {
+	return l.limiter.Wait(ctx)
+}
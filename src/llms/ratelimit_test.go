@@ -0,0 +1,87 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Paces(t *testing.T) {
+	limiter := NewRateLimiter(20, 1)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() unexpected error = %v", err)
+		}
+	}
+	// The first call uses the burst, the next two wait 50ms each
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected calls to be paced, took %s", elapsed)
+	}
+}
+
+func TestRateLimiter_CancelledContext(t *testing.T) {
+	limiter := NewRateLimiter(0.01, 1)
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() unexpected error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	if err := limiter.Wait(ctx); err == nil {
+		t.Error("Expected an error for a cancelled context")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Cancelled wait took too long: %s", elapsed)
+	}
+}
+
+func TestOpenAILLM_RateLimiter(t *testing.T) {
+	server := newMockOpenAIServer(t, []string{contentChunkJSON("Hello")}, false)
+
+	llm, err := (&OpenAILLMBuilder{Provider: "openai", ApiKey: "test-key", BaseURL: server.URL, Model: "test-model"}).
+		SetRateLimiter(NewRateLimiter(10, 1)).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if _, err := collectChunks(t, llm.ChatStream([]UnifiedMessage{UserMessage("hi")}, nil), 5*time.Second); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected the second request to wait for the limiter, took %s", elapsed)
+	}
+}
+
+func TestOpenAILLM_RateLimiterCancelledContext(t *testing.T) {
+	server := newMockOpenAIServer(t, []string{contentChunkJSON("Hello")}, false)
+
+	limiter := NewRateLimiter(0.01, 1)
+	_ = limiter.Wait(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	llm := newOpenAILLM(ctx, server.URL, "test-model", "test-key")
+	llm.rateLimiter = limiter
+
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := collectChunks(t, llm.ChatStream([]UnifiedMessage{UserMessage("hi")}, nil), 5*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "rate limiter") || !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a rate limiter cancellation error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Cancelled wait took too long: %s", elapsed)
+	}
+	if server.LastRequest() != nil {
+		t.Error("Expected no request to be issued")
+	}
+}
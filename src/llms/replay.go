@@ -0,0 +1,269 @@
+package llms
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RecordedCall is one LLM call of a run recorded by RecordingEngine: the request
+// sent to the engine and every chunk streamed back. A run log is a JSON Lines file
+// with one RecordedCall per line, in call order.
+type RecordedCall struct {
+	Messages []UnifiedMessage `json:"messages"`
+	// Tools are the names of the tools offered to the model
+	Tools   []string          `json:"tools,omitempty"`
+	Options GenerationOptions `json:"options"`
+
+	Chunks []ChunkResponse `json:"chunks"`
+	// Error is the error that ended the stream (empty if it completed)
+	Error string `json:"error,omitempty"`
+}
+
+// RecordingEngine wraps an engine and appends every call to a run log that
+// NewReplayEngine can replay offline.
+type RecordingEngine struct {
+	engine LLMEngine
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewRecordingEngine creates an engine recording the calls made to engine.
+// The log file is truncated; call Close when the run is done.
+//
+// Parameters:
+//   - engine: The engine serving the calls
+//   - logPath: Path of the run log to write
+//
+// Returns:
+//   - *RecordingEngine: The recording engine
+//   - error: If the log file cannot be created
+func NewRecordingEngine(engine LLMEngine, logPath string) (*RecordingEngine, error) {
+	file, err := os.Create(logPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create run log: %w", err)
+	}
+	return &RecordingEngine{engine: engine, file: file}, nil
+}
+
+// Close closes the run log.
+func (r *RecordingEngine) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// Capabilities reports the capabilities of the wrapped engine (implements ModelCapabilitiesReporter).
+func (r *RecordingEngine) Capabilities() ModelCapabilities {
+	if reporter, ok := r.engine.(ModelCapabilitiesReporter); ok {
+		return reporter.Capabilities()
+	}
+	return ModelCapabilities{}
+}
+
+// Ping checks the wrapped engine with CheckConnectivity (implements Pinger). The
+// check is not recorded.
+func (r *RecordingEngine) Ping(ctx context.Context) error {
+	return CheckConnectivity(ctx, r.engine)
+}
+
+// ChatStream implements LLMEngine.
+func (r *RecordingEngine) ChatStream(messages []UnifiedMessage, tools []Tool) *responseCh {
+	return r.ChatStreamWithOptions(messages, tools, GenerationOptions{})
+}
+
+// ChatStreamWithOptions implements LLMEngineWithOptions. The options are passed on
+// when the wrapped engine accepts them.
+func (r *RecordingEngine) ChatStreamWithOptions(messages []UnifiedMessage, tools []Tool, opts GenerationOptions) *responseCh {
+	var inner *responseCh
+	if withOptions, ok := r.engine.(LLMEngineWithOptions); ok {
+		inner = withOptions.ChatStreamWithOptions(messages, tools, opts)
+	} else {
+		inner = r.engine.ChatStream(messages, tools)
+	}
+
+	call := RecordedCall{Messages: append([]UnifiedMessage(nil), messages...), Options: opts}
+	for _, tool := range tools {
+		call.Tools = append(call.Tools, tool.GetName())
+	}
+
+	out := newResponseCh()
+	go r.forward(inner, out, call)
+	return out
+}
+
+// forward relays the chunks of inner to out, then records the call.
+func (r *RecordingEngine) forward(inner, out *responseCh, call RecordedCall) {
+	defer out.Close()
+
+	go func() {
+		select {
+		case <-out.Cancelled():
+			inner.Cancel()
+		case <-inner.Cancelled():
+		}
+	}()
+	defer inner.Cancel()
+
+	// Like Start, stop at completion or at the first error even if Response stays open
+	var streamErr error
+	errCh := inner.Error
+	cancelled := false
+loop:
+	for {
+		select {
+		case chunkBytes, ok := <-inner.Response:
+			if !ok {
+				if errCh != nil {
+					streamErr = <-errCh
+				}
+				break loop
+			}
+			var chunk ChunkResponse
+			if err := json.Unmarshal(chunkBytes, &chunk); err == nil {
+				call.Chunks = append(call.Chunks, chunk)
+			}
+			if !cancelled {
+				select {
+				case out.Response <- chunkBytes:
+				case <-out.Cancelled():
+					cancelled = true
+				}
+			}
+			if chunk.Status == StatusCompleted {
+				break loop
+			}
+		case err, ok := <-errCh:
+			if !ok {
+				errCh = nil
+				continue
+			}
+			streamErr = err
+			break loop
+		}
+	}
+	if streamErr != nil {
+		call.Error = streamErr.Error()
+		if !cancelled {
+			out.Error <- streamErr
+		}
+	}
+
+	r.record(call)
+}
+
+// record appends a call to the run log. Recording never fails the run.
+func (r *RecordingEngine) record(call RecordedCall) {
+	line, err := json.Marshal(call)
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.file.Write(append(line, '\n'))
+}
+
+// ReplayEngine is an LLMEngine answering from a run log written by RecordingEngine.
+// Each call streams the chunks of the next recorded call, whatever its request,
+// so agent logic can be re-run offline without API keys.
+type ReplayEngine struct {
+	mu    sync.Mutex
+	calls []RecordedCall
+	next  int
+}
+
+// NewReplayEngine loads a run log for replay.
+//
+// Parameters:
+//   - logPath: Path of a run log written by RecordingEngine
+//
+// Returns:
+//   - *ReplayEngine: The replay engine
+//   - error: If the log cannot be read or parsed
+func NewReplayEngine(logPath string) (*ReplayEngine, error) {
+	file, err := os.Open(logPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open run log: %w", err)
+	}
+	defer file.Close()
+
+	engine := &ReplayEngine{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var call RecordedCall
+		if err := json.Unmarshal(scanner.Bytes(), &call); err != nil {
+			return nil, fmt.Errorf("invalid run log %s line %d: %w", logPath, line, err)
+		}
+		engine.calls = append(engine.calls, call)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read run log: %w", err)
+	}
+	return engine, nil
+}
+
+// Calls returns the recorded calls of the log.
+func (e *ReplayEngine) Calls() []RecordedCall {
+	return e.calls
+}
+
+// Remaining returns the number of recorded calls not replayed yet.
+func (e *ReplayEngine) Remaining() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.calls) - e.next
+}
+
+// ChatStream implements LLMEngine.
+func (e *ReplayEngine) ChatStream(messages []UnifiedMessage, tools []Tool) *responseCh {
+	return e.ChatStreamWithOptions(messages, tools, GenerationOptions{})
+}
+
+// ChatStreamWithOptions implements LLMEngineWithOptions. The request is ignored:
+// the next recorded call is replayed. An error is streamed once the log is exhausted.
+func (e *ReplayEngine) ChatStreamWithOptions(messages []UnifiedMessage, tools []Tool, opts GenerationOptions) *responseCh {
+	responseCh := newResponseCh()
+
+	e.mu.Lock()
+	index := e.next
+	if index < len(e.calls) {
+		e.next++
+	}
+	e.mu.Unlock()
+
+	go func() {
+		defer responseCh.Close()
+
+		if index >= len(e.calls) {
+			responseCh.Error <- fmt.Errorf("replay log exhausted after %d calls", len(e.calls))
+			return
+		}
+		call := e.calls[index]
+		for _, chunk := range call.Chunks {
+			chunkBytes, err := serializeChunk(chunk)
+			if err != nil {
+				responseCh.Error <- fmt.Errorf("failed to serialize chunk: %w", err)
+				return
+			}
+			select {
+			case responseCh.Response <- chunkBytes:
+			case <-responseCh.Cancelled():
+				return
+			}
+		}
+		if call.Error != "" {
+			responseCh.Error <- errors.New(call.Error)
+		}
+	}()
+
+	return responseCh
+}
@@ -0,0 +1,45 @@
+package llms
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReplayEngine_ErrorsAndExhaustion(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "run.jsonl")
+	server := newMockOpenAIServer(t, []string{
+		`{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"test-model","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"foo","arguments":"{\"echo\": "}}]}}]}`,
+	}, false)
+
+	recorder, err := NewRecordingEngine(newOpenAILLM(context.Background(), server.URL, "test-model", "test-key"), logPath)
+	if err != nil {
+		t.Fatalf("NewRecordingEngine() unexpected error = %v", err)
+	}
+	_, recordedErr := collectChunks(t, recorder.ChatStream([]UnifiedMessage{UserMessage("hi")}, nil), 5*time.Second)
+	if recordedErr == nil {
+		t.Fatal("Expected the malformed arguments error to be forwarded")
+	}
+	recorder.Close()
+
+	replay, err := NewReplayEngine(logPath)
+	if err != nil {
+		t.Fatalf("NewReplayEngine() unexpected error = %v", err)
+	}
+	_, replayedErr := collectChunks(t, replay.ChatStream(nil, nil), 5*time.Second)
+	if replayedErr == nil || replayedErr.Error() != recordedErr.Error() {
+		t.Errorf("Expected the recorded error %q, got %v", recordedErr, replayedErr)
+	}
+
+	_, err = collectChunks(t, replay.ChatStream(nil, nil), 5*time.Second)
+	if err == nil || err.Error() != "replay log exhausted after 1 calls" {
+		t.Errorf("Expected the exhausted log error, got %v", err)
+	}
+}
+
+func TestNewReplayEngine_InvalidLog(t *testing.T) {
+	if _, err := NewReplayEngine(filepath.Join(t.TempDir(), "missing.jsonl")); err == nil {
+		t.Error("Expected an error for a missing log")
+	}
+}
@@ -1,12 +1,15 @@
 package agentforge
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
 // LogLevel represents the severity level of a log message.
@@ -43,10 +46,26 @@ func (l LogLevel) String() string {
 //
 // The logger respects the AF_LOG_LEVEL configuration and only outputs
 // messages at or above the configured level.
+//
+// Two output formats are supported: text lines ("[LEVEL] message", the default)
+// and JSON lines (see NewJSONLogger). Structured fields can be attached with
+// With and WithFields.
 type Logger struct {
 	level  LogLevel
 	logger *log.Logger
 	mu     sync.RWMutex
+	// json enables JSON line output
+	json bool
+	// fields are attached to every entry written by this logger
+	fields map[string]any
+}
+
+// jsonLogEntry is the JSON representation of a log entry.
+type jsonLogEntry struct {
+	Timestamp string         `json:"ts"`
+	Level     string         `json:"level"`
+	Message   string         `json:"msg"`
+	Fields    map[string]any `json:"fields,omitempty"`
 }
 
 var (
@@ -70,6 +89,25 @@ func NewLogger(level LogLevel, output io.Writer) *Logger {
 	}
 }
 
+// NewJSONLogger creates a new Logger that writes one JSON object per line.
+//
+// Each entry has the shape {"ts","level","msg","fields"}, where "fields" holds
+// the structured fields attached via With or WithFields.
+//
+// Parameters:
+//   - level: The minimum log level to output (DEBUG, INFO, WARN, ERROR)
+//   - output: The output writer (e.g., os.Stdout, os.Stderr, or a file)
+//
+// Returns:
+//   - *Logger: A new JSON Logger instance
+func NewJSONLogger(level LogLevel, output io.Writer) *Logger {
+	return &Logger{
+		level:  level,
+		logger: log.New(output, "", 0),
+		json:   true,
+	}
+}
+
 // NewLoggerFromConfig creates a new Logger instance using the Config.
 //
 // Parameters:
@@ -120,6 +158,15 @@ func (l *Logger) SetLevel(level LogLevel) {
 	l.level = level
 }
 
+// SetOutput changes the writer the logger (and the children sharing its output)
+// writes to, e.g. to capture log entries in tests.
+//
+// Parameters:
+//   - output: The new output writer
+func (l *Logger) SetOutput(output io.Writer) {
+	l.logger.SetOutput(output)
+}
+
 // GetLevel returns the current log level.
 //
 // Returns:
@@ -130,6 +177,44 @@ func (l *Logger) GetLevel() LogLevel {
 	return l.level
 }
 
+// WithFields returns a child logger that attaches the given fields to every entry.
+//
+// The child inherits the parent's level, format, output and fields; new fields
+// override existing ones with the same key. The parent is not modified.
+//
+// Parameters:
+//   - fields: Structured fields to attach
+//
+// Returns:
+//   - *Logger: The child logger
+func (l *Logger) WithFields(fields map[string]any) *Logger {
+	merged := make(map[string]any, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &Logger{
+		level:  l.GetLevel(),
+		logger: l.logger,
+		json:   l.json,
+		fields: merged,
+	}
+}
+
+// With returns a child logger with a single field attached.
+//
+// Parameters:
+//   - key: The field name
+//   - value: The field value
+//
+// Returns:
+//   - *Logger: The child logger
+func (l *Logger) With(key string, value any) *Logger {
+	return l.WithFields(map[string]any{key: value})
+}
+
 // Debug logs a debug-level message.
 //
 // Parameters:
@@ -172,11 +257,58 @@ func (l *Logger) log(level LogLevel, format string, args ...interface{}) {
 	currentLevel := l.level
 	l.mu.RUnlock()
 
-	if level >= currentLevel {
-		prefix := fmt.Sprintf("[%s] ", level.String())
-		message := fmt.Sprintf(format, args...)
-		l.logger.Printf("%s%s", prefix, message)
+	if level < currentLevel {
+		return
+	}
+
+	message := fmt.Sprintf(format, args...)
+
+	if l.json {
+		entry := jsonLogEntry{
+			Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+			Level:     level.String(),
+			Message:   message,
+			Fields:    l.fields,
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			// Unserializable field values: fall back to their string form
+			entry.Fields = stringifyFields(l.fields)
+			data, _ = json.Marshal(entry)
+		}
+		l.logger.Print(string(data))
+		return
+	}
+
+	prefix := fmt.Sprintf("[%s] ", level.String())
+	l.logger.Printf("%s%s%s", prefix, message, formatFields(l.fields))
+}
+
+// formatFields renders fields as sorted " key=value" pairs for text output.
+func formatFields(fields map[string]any) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%v", k, fields[k])
+	}
+	return b.String()
+}
+
+// stringifyFields converts every field value to its string form.
+func stringifyFields(fields map[string]any) map[string]any {
+	out := make(map[string]any, len(fields))
+	for k, v := range fields {
+		out[k] = fmt.Sprintf("%v", v)
 	}
+	return out
 }
 
 // parseLogLevel converts a string log level to a LogLevel constant.
@@ -2,9 +2,11 @@ package agentforge
 
 import (
 	"bytes"
+	"encoding/json"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestLogLevel_String(t *testing.T) {
@@ -279,3 +281,57 @@ func TestPackageLevelFunctions(t *testing.T) {
 		t.Error("expected output to contain [ERROR]")
 	}
 }
+
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewJSONLogger(InfoLevel, &buf)
+
+	logger.With("agent", "main").WithFields(map[string]any{"step": 2}).Warn("tool %s failed", "fs")
+	logger.Debug("filtered out")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 JSON line, got %d: %q", len(lines), buf.String())
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("failed to parse JSON log line %q: %v", lines[0], err)
+	}
+	if entry["level"] != "WARN" {
+		t.Errorf("expected level WARN, got %v", entry["level"])
+	}
+	if entry["msg"] != "tool fs failed" {
+		t.Errorf("expected msg 'tool fs failed', got %v", entry["msg"])
+	}
+	if _, err := time.Parse(time.RFC3339Nano, entry["ts"].(string)); err != nil {
+		t.Errorf("expected RFC3339 ts, got %v", entry["ts"])
+	}
+	fields, ok := entry["fields"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected fields object, got %v", entry["fields"])
+	}
+	if fields["agent"] != "main" || fields["step"] != float64(2) {
+		t.Errorf("unexpected fields: %v", fields)
+	}
+}
+
+func TestLogger_WithFields(t *testing.T) {
+	var buf bytes.Buffer
+	parent := NewLogger(InfoLevel, &buf)
+	child := parent.WithFields(map[string]any{"agent": "main", "trace": "response"})
+
+	child.Info("hello")
+	parent.Info("plain")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+	if !strings.HasSuffix(lines[0], "[INFO] hello agent=main trace=response") {
+		t.Errorf("expected text fields on child entry, got: %s", lines[0])
+	}
+	if strings.Contains(lines[1], "agent=") {
+		t.Errorf("expected parent logger without fields, got: %s", lines[1])
+	}
+}
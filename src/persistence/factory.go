@@ -3,27 +3,86 @@ package persistence
 import (
 	"fmt"
 	"math/rand"
+	"path/filepath"
 	"time"
+
+	agentforge "github.com/thinktwice/agentForge/src"
 )
 
 // NewPersistence creates and returns a Persistence implementation based on the persistence type
 // Parameters:
 //   - agentName: The name of the agent (used for generating unique file paths)
-//   - persistenceType: The type of persistence ("json", or "" for none)
+//   - persistenceType: The type of persistence ("json", "redis", or "" for none)
+//
+// The "json" backend writes to AF_HISTORY_DIR (default: ./history).
+// The "redis" backend connects using AF_REDIS_URL (default: redis://localhost:6379/0).
 //
 // Returns:
 //   - Persistence: The appropriate persistence implementation, or nil if no persistence is configured
 func NewPersistence(agentName, persistenceType string) Persistence {
+	return NewPersistenceInDir(agentName, persistenceType, "")
+}
+
+// NewPersistenceInDir is like NewPersistence with an explicit directory for the
+// "json" backend files. The directory is created on the first save if missing.
+//
+// Parameters:
+//   - agentName: The name of the agent (used for generating unique file paths)
+//   - persistenceType: The type of persistence ("json", "redis", or "" for none)
+//   - dir: Directory of the JSON files (empty uses AF_HISTORY_DIR, default ./history)
+//
+// Returns:
+//   - Persistence: The appropriate persistence implementation, or nil if no persistence is configured
+func NewPersistenceInDir(agentName, persistenceType, dir string) Persistence {
+	return NewPersistenceInDirWithOptions(agentName, persistenceType, dir, JSONPersistenceOptions{})
+}
+
+// NewPersistenceInDirWithOptions is like NewPersistenceInDir with the format of the
+// "json" backend files. Gzipped files are named "*.json.gz".
+//
+// Parameters:
+//   - agentName: The name of the agent (used for generating unique file paths)
+//   - persistenceType: The type of persistence ("json", "redis", or "" for none)
+//   - dir: Directory of the JSON files (empty uses AF_HISTORY_DIR, default ./history)
+//   - jsonOptions: Format of the JSON files (ignored by other backends)
+//
+// Returns:
+//   - Persistence: The appropriate persistence implementation, or nil if no persistence is configured
+func NewPersistenceInDirWithOptions(agentName, persistenceType, dir string, jsonOptions JSONPersistenceOptions) Persistence {
 	if persistenceType == "" {
 		return nil
 	}
 
+	// Generate unique session ID
+	uniqueID := fmt.Sprintf("%d-%d", time.Now().UnixNano(), rand.Int63())
+
 	switch persistenceType {
 	case "json":
-		// Generate unique file path
-		uniqueID := fmt.Sprintf("%d-%d", time.Now().UnixNano(), rand.Int63())
-		filePath := fmt.Sprintf("./history/%s-%s.json", agentName, uniqueID)
-		return NewJSONPersistence(filePath)
+		if dir == "" {
+			c, err := agentforge.NewConfig()
+			if err != nil {
+				agentforge.Error("Failed to load config: %v", err)
+				return nil
+			}
+			dir = c.AFHistoryDir
+		}
+		fileName := fmt.Sprintf("%s-%s.json", agentName, uniqueID)
+		if jsonOptions.Gzip {
+			fileName += ".gz"
+		}
+		return NewJSONPersistenceWithOptions(filepath.Join(dir, fileName), jsonOptions)
+	case "redis":
+		c, err := agentforge.NewConfig()
+		if err != nil {
+			agentforge.Error("Failed to load config: %v", err)
+			return nil
+		}
+		rp, err := NewRedisPersistenceFromURL(c.AFRedisURL, RedisHistoryKey(agentName, uniqueID))
+		if err != nil {
+			agentforge.Error("Failed to create redis persistence: %v", err)
+			return nil
+		}
+		return rp
 	default:
 		// Unknown persistence type, return nil
 		return nil
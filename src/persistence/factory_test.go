@@ -0,0 +1,251 @@
+package persistence
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+func TestNewPersistence_JSONDir(t *testing.T) {
+	t.Run("explicit directory", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "nested", "history")
+		p := NewPersistenceInDir("main", "json", dir)
+		jp, ok := p.(*JSONPersistence)
+		if !ok {
+			t.Fatalf("Expected *JSONPersistence, got %T", p)
+		}
+
+		if err := jp.SaveHystory([]llms.UnifiedMessage{llms.UserMessage("hello")}); err != nil {
+			t.Fatalf("SaveHystory() unexpected error = %v", err)
+		}
+		if filepath.Dir(jp.filePath) != dir {
+			t.Errorf("Expected history file in %s, got %s", dir, jp.filePath)
+		}
+		if _, err := os.Stat(jp.filePath); err != nil {
+			t.Errorf("Expected history file to be written: %v", err)
+		}
+	})
+
+	t.Run("AF_HISTORY_DIR", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("AF_HISTORY_DIR", dir)
+
+		p := NewPersistence("main", "json")
+		p.SaveHystory([]llms.UnifiedMessage{llms.UserMessage("hello")})
+
+		files, _ := filepath.Glob(filepath.Join(dir, "main-*.json"))
+		if len(files) != 1 {
+			t.Errorf("Expected one history file in %s, got %v", dir, files)
+		}
+	})
+}
+
+func TestJSONPersistence_Metadata(t *testing.T) {
+	p := NewPersistenceInDir("main", "json", t.TempDir())
+	p.SaveHystory([]llms.UnifiedMessage{
+		llms.UserMessageWithMetadata("hello", map[string]string{"source": "web"}),
+		llms.AssistantMessage("hi", 1, 1, 2),
+	})
+
+	loaded := p.GetHystory(0, 0)
+	if len(loaded) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(loaded))
+	}
+	if got := loaded[0].Metadata()["source"]; got != "web" {
+		t.Errorf("Expected metadata to survive save/load, got source=%q", got)
+	}
+	if loaded[1].Metadata() != nil {
+		t.Errorf("Expected no metadata on the assistant message, got %v", loaded[1].Metadata())
+	}
+}
+
+func TestJSONPersistence_SaveError(t *testing.T) {
+	// The parent of the history file is a regular file, so the directory cannot be created
+	blocker := filepath.Join(t.TempDir(), "blocker")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewJSONPersistence(filepath.Join(blocker, "history.json"))
+	if err := p.SaveHystory([]llms.UnifiedMessage{llms.UserMessage("hello")}); err == nil {
+		t.Error("Expected an error when the history file cannot be written")
+	}
+}
+
+func TestJSONPersistence_Options(t *testing.T) {
+	history := []llms.UnifiedMessage{llms.UserMessage("hello"), llms.AssistantMessage("hi", 1, 1, 2)}
+
+	tests := []struct {
+		name    string
+		options JSONPersistenceOptions
+		check   func(t *testing.T, data []byte)
+	}{
+		{name: "pretty", check: func(t *testing.T, data []byte) {
+			if !strings.Contains(string(data), "\n  ") {
+				t.Errorf("Expected indented JSON, got %s", data)
+			}
+		}},
+		{name: "compact", options: JSONPersistenceOptions{Compact: true}, check: func(t *testing.T, data []byte) {
+			if strings.Contains(string(data), "\n") {
+				t.Errorf("Expected compact JSON, got %s", data)
+			}
+		}},
+		{name: "gzip", options: JSONPersistenceOptions{Compact: true, Gzip: true}, check: func(t *testing.T, data []byte) {
+			zr, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Expected a gzip file: %v", err)
+			}
+			plain, err := io.ReadAll(zr)
+			if err != nil || !json.Valid(plain) {
+				t.Errorf("Expected gzipped JSON, got %q (err=%v)", plain, err)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			p := NewPersistenceInDirWithOptions("main", "json", dir, tt.options)
+			if err := p.SaveHystory(history); err != nil {
+				t.Fatalf("SaveHystory() unexpected error = %v", err)
+			}
+
+			files, _ := filepath.Glob(filepath.Join(dir, "main-*"))
+			if len(files) != 1 {
+				t.Fatalf("Expected one history file, got %v", files)
+			}
+			if tt.options.Gzip != strings.HasSuffix(files[0], ".json.gz") {
+				t.Errorf("Unexpected file name %s", files[0])
+			}
+			data, err := os.ReadFile(files[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, data)
+
+			// Reloadable, also by a persistence with default options
+			for _, reader := range []Persistence{p, NewJSONPersistence(files[0])} {
+				loaded := reader.GetHystory(0, 0)
+				if len(loaded) != 2 || loaded[0].Content() != "hello" || loaded[1].Content() != "hi" {
+					t.Errorf("Expected the history to reload, got %+v", loaded)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONPersistence_Limits(t *testing.T) {
+	history := []llms.UnifiedMessage{
+		llms.SystemMessage("You are helpful."),
+		llms.UserMessage("first question"),
+		llms.AssistantMessageWithToolCalls("", []llms.ToolCall{{ID: "call_1", Name: "foo", Arguments: map[string]any{}}}, 1, 1, 2),
+		llms.ToolMessage("call_1", "tool output"),
+		llms.AssistantMessage("first answer", 1, 1, 2),
+		llms.UserMessage("second question"),
+		llms.AssistantMessage("second answer", 1, 1, 2),
+	}
+
+	// The system message and the last turn, in compact JSON
+	lastTurn, err := json.Marshal([]llms.UnifiedMessage{history[0], history[5], history[6]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastTurnIndented, err := json.MarshalIndent([]llms.UnifiedMessage{history[0], history[5], history[6]}, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents := func(messages []llms.UnifiedMessage) []string {
+		var out []string
+		for i := range messages {
+			out = append(out, string(messages[i].Role())+":"+messages[i].Content())
+		}
+		return out
+	}
+
+	tests := []struct {
+		name    string
+		options JSONPersistenceOptions
+		want    []string
+	}{
+		{
+			name:    "no limits",
+			options: JSONPersistenceOptions{},
+			want:    contents(history),
+		},
+		{
+			name:    "max messages",
+			options: JSONPersistenceOptions{MaxMessages: 2},
+			want:    []string{"system:You are helpful.", "user:second question", "assistant:second answer"},
+		},
+		{
+			// Cutting at the tool result would leave it without its tool call
+			name:    "max messages drops orphan tool results",
+			options: JSONPersistenceOptions{MaxMessages: 4},
+			want:    []string{"system:You are helpful.", "assistant:first answer", "user:second question", "assistant:second answer"},
+		},
+		{
+			name:    "max bytes",
+			options: JSONPersistenceOptions{Compact: true, MaxBytes: len(lastTurn)},
+			want:    []string{"system:You are helpful.", "user:second question", "assistant:second answer"},
+		},
+		{
+			name:    "max bytes indented",
+			options: JSONPersistenceOptions{MaxBytes: len(lastTurnIndented)},
+			want:    []string{"system:You are helpful.", "user:second question", "assistant:second answer"},
+		},
+		{
+			name:    "max bytes below the system message",
+			options: JSONPersistenceOptions{MaxBytes: 10},
+			want:    []string{"system:You are helpful."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "history.json")
+			p := NewJSONPersistenceWithOptions(path, tt.options)
+			if err := p.SaveHystory(history); err != nil {
+				t.Fatalf("SaveHystory() unexpected error = %v", err)
+			}
+
+			if got := contents(p.GetHystory(0, 0)); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Saved history = %v, want %v", got, tt.want)
+			}
+			if data, _ := os.ReadFile(path); tt.options.MaxBytes > 10 && len(data) > tt.options.MaxBytes {
+				t.Errorf("Expected at most %d bytes, got %d", tt.options.MaxBytes, len(data))
+			}
+		})
+	}
+}
+
+// TestJSONPersistence_Format verifies that the file is the JSON encoding of the
+// history, although messages are encoded one by one for trimming.
+func TestJSONPersistence_Format(t *testing.T) {
+	history := []llms.UnifiedMessage{
+		llms.SystemMessage("You are helpful."),
+		llms.UserMessage("<b>hi</b>"),
+		llms.AssistantMessage("hello", 1, 1, 2),
+	}
+	for _, compact := range []bool{false, true} {
+		for _, messages := range [][]llms.UnifiedMessage{history, {}} {
+			path := filepath.Join(t.TempDir(), "history.json")
+			if err := NewJSONPersistenceWithOptions(path, JSONPersistenceOptions{Compact: compact}).SaveHystory(messages); err != nil {
+				t.Fatalf("SaveHystory() unexpected error = %v", err)
+			}
+
+			want, _ := json.MarshalIndent(messages, "", "  ")
+			if compact {
+				want, _ = json.Marshal(messages)
+			}
+			if got, _ := os.ReadFile(path); string(got) != string(want) {
+				t.Errorf("Compact=%v, %d messages: got\n%s\nwant\n%s", compact, len(messages), got, want)
+			}
+		}
+	}
+}
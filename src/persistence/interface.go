@@ -4,6 +4,11 @@ import "github.com/thinktwice/agentForge/src/llms"
 
 // Persistence interface defines methods for saving and retrieving conversation history
 type Persistence interface {
-	SaveHystory(history []llms.UnifiedMessage)
+	// SaveHystory replaces the stored history. It returns an error if the history
+	// could not be stored.
+	SaveHystory(history []llms.UnifiedMessage) error
 	GetHystory(limit, offset int) []llms.UnifiedMessage
+	// Close releases the resources held by the persistence layer.
+	// It is not used again after Close.
+	Close() error
 }
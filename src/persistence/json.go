@@ -1,7 +1,11 @@
 package persistence
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
@@ -9,41 +13,172 @@ import (
 	"github.com/thinktwice/agentForge/src/llms"
 )
 
+// gzipMagic starts every gzip file.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// JSONPersistenceOptions controls the format and size of the files written by
+// JSONPersistence. The zero value writes indented, uncompressed JSON of the whole history.
+type JSONPersistenceOptions struct {
+	// Compact writes the JSON without indentation
+	Compact bool
+	// Gzip compresses the file with gzip. Compressed files are detected when
+	// reading, whatever this option, so it can be turned on for existing files.
+	Gzip bool
+
+	// MaxMessages keeps at most the last MaxMessages messages after the leading
+	// system messages, trimming the oldest on save. Zero keeps every message.
+	MaxMessages int
+	// MaxBytes trims the oldest messages after the leading system messages until
+	// the JSON (before compression) fits in MaxBytes. Zero means no limit.
+	MaxBytes int
+}
+
 // JSONPersistence implements the Persistence interface using JSON file storage
 type JSONPersistence struct {
 	filePath string
+	options  JSONPersistenceOptions
 }
 
 // NewJSONPersistence creates a new JSONPersistence instance with the specified file path
 func NewJSONPersistence(filePath string) *JSONPersistence {
+	return NewJSONPersistenceWithOptions(filePath, JSONPersistenceOptions{})
+}
+
+// NewJSONPersistenceWithOptions is like NewJSONPersistence with the file format options.
+//
+// Parameters:
+//   - filePath: The history file
+//   - options: The file format (compact and/or gzipped JSON)
+//
+// Returns:
+//   - *JSONPersistence: A new JSONPersistence instance
+func NewJSONPersistenceWithOptions(filePath string, options JSONPersistenceOptions) *JSONPersistence {
 	return &JSONPersistence{
 		filePath: filePath,
+		options:  options,
 	}
 }
 
 // SaveHystory saves the conversation history to a JSON file
-func (jp *JSONPersistence) SaveHystory(history []llms.UnifiedMessage) {
-	// Marshal to JSON with indentation for readability
-	data, err := json.MarshalIndent(history, "", "  ")
+func (jp *JSONPersistence) SaveHystory(history []llms.UnifiedMessage) error {
+	data, err := jp.marshalTrimmed(history)
 	if err != nil {
-		agentforge.Error("Failed to marshal history to JSON: %v", err)
-		return
+		return fmt.Errorf("failed to marshal history to JSON: %w", err)
+	}
+
+	if jp.options.Gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return fmt.Errorf("failed to compress history: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("failed to compress history: %w", err)
+		}
+		data = buf.Bytes()
 	}
 
 	// Ensure directory exists
 	dir := filepath.Dir(jp.filePath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
-		agentforge.Error("Failed to create directory for history file: %v", err)
-		return
+		return fmt.Errorf("failed to create directory for history file: %w", err)
 	}
 
 	// Write to file
 	if err := os.WriteFile(jp.filePath, data, 0644); err != nil {
-		agentforge.Error("Failed to write history to file: %v", err)
-		return
+		return fmt.Errorf("failed to write history to file: %w", err)
 	}
 
 	agentforge.Debug("Successfully saved history to %s", jp.filePath)
+	return nil
+}
+
+// marshalMessage encodes a message as an element of the history array, indented
+// for readability unless compact.
+func (jp *JSONPersistence) marshalMessage(message llms.UnifiedMessage) ([]byte, error) {
+	if jp.options.Compact {
+		return json.Marshal(message)
+	}
+	return json.MarshalIndent(message, "  ", "  ")
+}
+
+// joinMessages assembles encoded messages into the history array, formatted
+// like json.Marshal or json.MarshalIndent of the whole history.
+func (jp *JSONPersistence) joinMessages(encoded [][]byte) []byte {
+	if len(encoded) == 0 {
+		return []byte("[]")
+	}
+	if jp.options.Compact {
+		return append(append([]byte("["), bytes.Join(encoded, []byte(","))...), ']')
+	}
+	return append(append([]byte("[\n  "), bytes.Join(encoded, []byte(",\n  "))...), "\n]"...)
+}
+
+// messageOverhead is the number of bytes joinMessages adds around each message.
+func (jp *JSONPersistence) messageOverhead() int {
+	if jp.options.Compact {
+		return 1 // ","
+	}
+	return 4 // ",\n  "
+}
+
+// marshalTrimmed encodes the history within the MaxMessages and MaxBytes limits.
+// The leading system messages are always kept; the oldest other messages are
+// dropped first, and the kept history never starts with tool results whose tool
+// call was dropped. When the system messages alone exceed MaxBytes they are saved
+// anyway. Only the encoded copy is trimmed, history is left untouched.
+//
+// Each message is encoded once, so trimming to MaxBytes is linear in the size of
+// the history.
+func (jp *JSONPersistence) marshalTrimmed(history []llms.UnifiedMessage) ([]byte, error) {
+	encoded := make([][]byte, len(history))
+	size := 2 // "[]", or the brackets and newlines around indented messages
+	for i, message := range history {
+		data, err := jp.marshalMessage(message)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = data
+		size += len(data) + jp.messageOverhead()
+	}
+	if jp.options.Compact && len(history) > 0 {
+		size-- // no separator after the last message
+	}
+
+	system := 0
+	for system < len(history) && history[system].Role() == llms.MessageRoleSystem {
+		system++
+	}
+
+	// first is the index of the oldest message kept after the system messages
+	first := system
+	drop := func(next int) {
+		for _, data := range encoded[first:next] {
+			size -= len(data) + jp.messageOverhead()
+		}
+		first = next
+	}
+	if limit := jp.options.MaxMessages; limit > 0 && len(history)-system > limit {
+		drop(skipToolResults(history, len(history)-limit))
+	}
+	for jp.options.MaxBytes > 0 && size > jp.options.MaxBytes && first < len(history) {
+		drop(skipToolResults(history, first+1))
+	}
+
+	if first > system {
+		agentforge.Debug("Trimmed %d old messages from %s", first-system, jp.filePath)
+	}
+	return jp.joinMessages(append(encoded[:system:system], encoded[first:]...)), nil
+}
+
+// skipToolResults returns the index of the first message from i on that is not a
+// tool result, so the kept history does not start with tool results whose tool
+// call was trimmed.
+func skipToolResults(history []llms.UnifiedMessage, i int) int {
+	for i < len(history) && history[i].Role() == llms.MessageRoleTool {
+		i++
+	}
+	return i
 }
 
 // GetHystory retrieves the conversation history from the JSON file
@@ -61,6 +196,18 @@ func (jp *JSONPersistence) GetHystory(limit, offset int) []llms.UnifiedMessage {
 		return []llms.UnifiedMessage{}
 	}
 
+	// Decompress gzipped files, detected by their magic number
+	if bytes.HasPrefix(data, gzipMagic) {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err == nil {
+			data, err = io.ReadAll(zr)
+		}
+		if err != nil {
+			agentforge.Error("Failed to decompress history file: %v", err)
+			return []llms.UnifiedMessage{}
+		}
+	}
+
 	// Unmarshal from JSON
 	var messages []llms.UnifiedMessage
 	if err := json.Unmarshal(data, &messages); err != nil {
@@ -68,37 +215,10 @@ func (jp *JSONPersistence) GetHystory(limit, offset int) []llms.UnifiedMessage {
 		return []llms.UnifiedMessage{}
 	}
 
-	// Apply pagination
-	if limit == 0 && offset == 0 {
-		// Return all messages
-		return messages
-	}
-
-	// Validate pagination parameters
-	if offset < 0 {
-		agentforge.Warn("Invalid offset %d, using 0", offset)
-		offset = 0
-	}
-
-	if limit < 0 {
-		agentforge.Warn("Invalid limit %d, returning empty result", limit)
-		return []llms.UnifiedMessage{}
-	}
-
-	// Apply offset
-	if offset >= len(messages) {
-		agentforge.Debug("Offset %d is beyond message count %d, returning empty result", offset, len(messages))
-		return []llms.UnifiedMessage{}
-	}
-
-	start := offset
-	end := start + limit
-
-	// Ensure we don't go beyond array bounds
-	if end > len(messages) || limit == 0 {
-		end = len(messages)
-	}
+	return Paginate(messages, limit, offset)
+}
 
-	agentforge.Debug("Retrieved %d messages from history (offset: %d, limit: %d)", end-start, offset, limit)
-	return messages[start:end]
+// Close is a no-op: every save writes the whole file, so there is nothing to flush.
+func (jp *JSONPersistence) Close() error {
+	return nil
 }
@@ -0,0 +1,53 @@
+package persistence
+
+import (
+	agentforge "github.com/thinktwice/agentForge/src"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// Paginate returns a window of messages using the same semantics as GetHystory.
+//
+// If limit == 0 and offset == 0, all messages are returned. Otherwise offset is
+// the start index and limit the page size (0 means "until the end").
+//
+// Parameters:
+//   - messages: The full message list
+//   - limit: Maximum number of messages to return
+//   - offset: Index of the first message to return
+//
+// Returns:
+//   - []llms.UnifiedMessage: The requested window (empty if offset is out of range)
+func Paginate(messages []llms.UnifiedMessage, limit, offset int) []llms.UnifiedMessage {
+	if limit == 0 && offset == 0 {
+		// Return all messages
+		return messages
+	}
+
+	// Validate pagination parameters
+	if offset < 0 {
+		agentforge.Warn("Invalid offset %d, using 0", offset)
+		offset = 0
+	}
+
+	if limit < 0 {
+		agentforge.Warn("Invalid limit %d, returning empty result", limit)
+		return []llms.UnifiedMessage{}
+	}
+
+	// Apply offset
+	if offset >= len(messages) {
+		agentforge.Debug("Offset %d is beyond message count %d, returning empty result", offset, len(messages))
+		return []llms.UnifiedMessage{}
+	}
+
+	start := offset
+	end := start + limit
+
+	// Ensure we don't go beyond array bounds
+	if end > len(messages) || limit == 0 {
+		end = len(messages)
+	}
+
+	agentforge.Debug("Retrieved %d messages from history (offset: %d, limit: %d)", end-start, offset, limit)
+	return messages[start:end]
+}
@@ -0,0 +1,129 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+func newTestRedisPersistence(t *testing.T) (*RedisPersistence, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisPersistence(client, RedisHistoryKey("main", "session-1")), server
+}
+
+func TestRedisPersistence_SaveAndGet(t *testing.T) {
+	rp, server := newTestRedisPersistence(t)
+
+	var messages []llms.UnifiedMessage
+	for i := 0; i < 5; i++ {
+		messages = append(messages, llms.UserMessage(fmt.Sprintf("message %d", i)))
+	}
+	rp.SaveHystory(messages)
+
+	stored, err := server.List("agentforge:history:main:session-1")
+	if err != nil || len(stored) != 5 {
+		t.Fatalf("Expected 5 list elements under the session key, got %d (err: %v)", len(stored), err)
+	}
+
+	tests := []struct {
+		name   string
+		limit  int
+		offset int
+		want   []string
+	}{
+		{"all messages", 0, 0, []string{"message 0", "message 1", "message 2", "message 3", "message 4"}},
+		{"first page", 2, 0, []string{"message 0", "message 1"}},
+		{"middle window", 2, 2, []string{"message 2", "message 3"}},
+		{"window clipped at end", 3, 4, []string{"message 4"}},
+		{"offset without limit", 0, 3, []string{"message 3", "message 4"}},
+		{"out-of-range offset", 2, 5, []string{}},
+		{"negative limit", -1, 0, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rp.GetHystory(tt.limit, tt.offset)
+			if len(got) != len(tt.want) {
+				t.Fatalf("GetHystory(%d, %d) returned %d messages, want %d", tt.limit, tt.offset, len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i].Content() != tt.want[i] {
+					t.Errorf("message %d = %q, want %q", i, got[i].Content(), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRedisPersistence_SaveRewritesList(t *testing.T) {
+	rp, _ := newTestRedisPersistence(t)
+
+	rp.SaveHystory([]llms.UnifiedMessage{llms.UserMessage("a"), llms.UserMessage("b"), llms.UserMessage("c")})
+	rp.SaveHystory([]llms.UnifiedMessage{
+		llms.SystemMessage("system"),
+		llms.AssistantMessageWithToolCalls("", []llms.ToolCall{{ID: "call_1", Name: "foo", Arguments: map[string]any{"echo": "hi"}}}, 1, 2, 3),
+	})
+
+	got := rp.GetHystory(0, 0)
+	if len(got) != 2 {
+		t.Fatalf("Expected list to be replaced with 2 messages, got %d", len(got))
+	}
+	if got[0].Role() != llms.MessageRoleSystem || got[0].Content() != "system" {
+		t.Errorf("Unexpected first message: %s %q", got[0].Role(), got[0].Content())
+	}
+	if calls := got[1].ToolCalls(); len(calls) != 1 || calls[0].Name != "foo" || got[1].TotalTokens() != 3 {
+		t.Errorf("Expected tool call and usage to round-trip, got %+v", got[1])
+	}
+
+	rp.SaveHystory(nil)
+	if got := rp.GetHystory(0, 0); len(got) != 0 {
+		t.Errorf("Expected empty history after saving nil, got %d messages", len(got))
+	}
+}
+
+func TestNewPersistence_Redis(t *testing.T) {
+	server := miniredis.RunT(t)
+	t.Setenv("AF_REDIS_URL", "redis://"+server.Addr()+"/0")
+
+	p := NewPersistence("main", "redis")
+	if _, ok := p.(*RedisPersistence); !ok {
+		t.Fatalf("Expected *RedisPersistence, got %T", p)
+	}
+
+	p.SaveHystory([]llms.UnifiedMessage{llms.UserMessage("hello")})
+	if keys := server.Keys(); len(keys) != 1 || keys[0][:len("agentforge:history:main:")] != "agentforge:history:main:" {
+		t.Errorf("Expected one session key under agentforge:history:main:, got %v", keys)
+	}
+}
+
+func TestRedisPersistence_Close(t *testing.T) {
+	t.Run("caller client stays open", func(t *testing.T) {
+		rp, _ := newTestRedisPersistence(t)
+		if err := rp.Close(); err != nil {
+			t.Fatalf("Close() unexpected error = %v", err)
+		}
+		if err := rp.client.Ping(context.Background()).Err(); err != nil {
+			t.Errorf("Expected the caller's client to stay usable, got %v", err)
+		}
+	})
+
+	t.Run("owned client is closed", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		rp, err := NewRedisPersistenceFromURL("redis://"+server.Addr()+"/0", RedisHistoryKey("main", "session-1"))
+		if err != nil {
+			t.Fatalf("NewRedisPersistenceFromURL() unexpected error = %v", err)
+		}
+		if err := rp.Close(); err != nil {
+			t.Fatalf("Close() unexpected error = %v", err)
+		}
+		if err := rp.client.Ping(context.Background()).Err(); err == nil {
+			t.Error("Expected the owned client to be closed")
+		}
+	})
+}
@@ -0,0 +1,227 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// NewCalculatorTool creates a tool that evaluates arithmetic expressions deterministically.
+func NewCalculatorTool() llms.Tool {
+	return core.NewTool(
+		"calculator",
+		"Evaluate an arithmetic expression and return the exact numeric result. Use this instead of computing by hand.",
+		`Advanced Details:
+- Parameters:
+  * expression (string, required): The arithmetic expression to evaluate, e.g. "(2 + 3) * 4 ^ 2"
+- Supported syntax:
+  * Numbers: integers and decimals (e.g. 42, 3.14, .5)
+  * Operators: + - * / ^ (power, right-associative)
+  * Parentheses and unary minus/plus (e.g. -(2 + 3))
+- Precedence: parentheses, then ^, then unary minus, then * /, then + -
+- Behavior: Parses and evaluates the expression without executing any code
+- Performance: Instant response with no side effects`,
+		`Troubleshooting:
+- "division by zero": The expression divides by zero
+- "unexpected character": Only digits, '.', whitespace, operators and parentheses are allowed
+- "missing closing parenthesis" / "unexpected ')'": Check that parentheses are balanced
+- "result is not a finite number": The result overflowed or is undefined (e.g. (-8) ^ 0.5)`,
+		[]core.Parameter{
+			{Name: "expression", Type: "string", Description: "The arithmetic expression to evaluate", Required: true},
+		},
+		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+			expression := args["expression"].(string)
+
+			result, err := EvaluateExpression(expression)
+			if err != nil {
+				return core.NewErrorResponse(fmt.Sprintf("failed to evaluate %q: %v", expression, err))
+			}
+			return core.NewSuccessResponse(strconv.FormatFloat(result, 'f', -1, 64))
+		},
+	)
+}
+
+// EvaluateExpression evaluates an arithmetic expression using + - * / ^,
+// parentheses and unary signs.
+//
+// Parameters:
+//   - expression: The expression to evaluate
+//
+// Returns:
+//   - float64: The result
+//   - error: An error for malformed expressions, division by zero or non-finite results
+func EvaluateExpression(expression string) (float64, error) {
+	p := &expressionParser{input: expression}
+	p.skipSpaces()
+	if p.done() {
+		return 0, fmt.Errorf("empty expression")
+	}
+
+	result, err := p.parseSum()
+	if err != nil {
+		return 0, err
+	}
+	if !p.done() {
+		if p.peek() == ')' {
+			return 0, fmt.Errorf("unexpected ')' at position %d", p.pos+1)
+		}
+		return 0, fmt.Errorf("unexpected character %q at position %d", p.peek(), p.pos+1)
+	}
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return 0, fmt.Errorf("result is not a finite number")
+	}
+	return result, nil
+}
+
+// expressionParser is a recursive-descent parser over the grammar:
+//
+//	sum     = product { ("+" | "-") product }
+//	product = unary { ("*" | "/") unary }
+//	unary   = ("-" | "+") unary | power
+//	power   = primary [ "^" unary ]
+//	primary = number | "(" sum ")"
+type expressionParser struct {
+	input string
+	pos   int
+}
+
+func (p *expressionParser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *expressionParser) peek() byte {
+	return p.input[p.pos]
+}
+
+func (p *expressionParser) skipSpaces() {
+	for !p.done() && (p.peek() == ' ' || p.peek() == '\t' || p.peek() == '\n') {
+		p.pos++
+	}
+}
+
+// consume advances past op if it is the next non-space character.
+func (p *expressionParser) consume(op byte) bool {
+	p.skipSpaces()
+	if !p.done() && p.peek() == op {
+		p.pos++
+		p.skipSpaces()
+		return true
+	}
+	return false
+}
+
+func (p *expressionParser) parseSum() (float64, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		switch {
+		case p.consume('+'):
+			right, err := p.parseProduct()
+			if err != nil {
+				return 0, err
+			}
+			left += right
+		case p.consume('-'):
+			right, err := p.parseProduct()
+			if err != nil {
+				return 0, err
+			}
+			left -= right
+		default:
+			return left, nil
+		}
+	}
+}
+
+func (p *expressionParser) parseProduct() (float64, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		switch {
+		case p.consume('*'):
+			right, err := p.parseUnary()
+			if err != nil {
+				return 0, err
+			}
+			left *= right
+		case p.consume('/'):
+			right, err := p.parseUnary()
+			if err != nil {
+				return 0, err
+			}
+			if right == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			left /= right
+		default:
+			return left, nil
+		}
+	}
+}
+
+func (p *expressionParser) parseUnary() (float64, error) {
+	if p.consume('-') {
+		value, err := p.parseUnary()
+		return -value, err
+	}
+	if p.consume('+') {
+		return p.parseUnary()
+	}
+	return p.parsePower()
+}
+
+func (p *expressionParser) parsePower() (float64, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return 0, err
+	}
+	if p.consume('^') {
+		// Right-associative: 2^3^2 = 2^(3^2); the exponent may carry a sign
+		exponent, err := p.parseUnary()
+		if err != nil {
+			return 0, err
+		}
+		return math.Pow(base, exponent), nil
+	}
+	return base, nil
+}
+
+func (p *expressionParser) parsePrimary() (float64, error) {
+	p.skipSpaces()
+	if p.done() {
+		return 0, fmt.Errorf("unexpected end of expression")
+	}
+
+	if p.consume('(') {
+		value, err := p.parseSum()
+		if err != nil {
+			return 0, err
+		}
+		if !p.consume(')') {
+			return 0, fmt.Errorf("missing closing parenthesis")
+		}
+		return value, nil
+	}
+
+	start := p.pos
+	for !p.done() && (p.peek() >= '0' && p.peek() <= '9' || p.peek() == '.') {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, fmt.Errorf("unexpected character %q at position %d", p.peek(), p.pos+1)
+	}
+
+	value, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q at position %d", p.input[start:p.pos], start+1)
+	}
+	p.skipSpaces()
+	return value, nil
+}
@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvaluateExpression(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"8 / 4 / 2", 1},
+		{"2 ^ 3 ^ 2", 512},
+		{"-2 ^ 2", -4},
+		{"(-2) ^ 2", 4},
+		{"2 ^ -1", 0.5},
+		{"-(3 + 4) * 2", -14},
+		{"--5", 5},
+		{"3.5 * 2", 7},
+		{".5 + .25", 0.75},
+		{"((2))", 2},
+		{"2 * (3 + (4 - 1)) / 3", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := EvaluateExpression(tt.expression)
+			if err != nil {
+				t.Fatalf("EvaluateExpression(%q) unexpected error = %v", tt.expression, err)
+			}
+			if got != tt.want {
+				t.Errorf("EvaluateExpression(%q) = %v, want %v", tt.expression, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateExpression_Errors(t *testing.T) {
+	tests := []struct {
+		expression string
+		wantErr    string
+	}{
+		{"", "empty expression"},
+		{"1 / 0", "division by zero"},
+		{"1 / (2 - 2)", "division by zero"},
+		{"1 +", "unexpected end of expression"},
+		{"(1 + 2", "missing closing parenthesis"},
+		{"1 + 2)", "unexpected ')'"},
+		{"2 * x", "unexpected character 'x'"},
+		{"1.2.3", "invalid number"},
+		{"os.Exit(1)", "unexpected character 'o'"},
+		{"(-8) ^ 0.5", "not a finite number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := EvaluateExpression(tt.expression)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("EvaluateExpression(%q) error = %v, want containing %q", tt.expression, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCalculatorTool(t *testing.T) {
+	tool := NewCalculatorTool()
+
+	result := tool.Call(nil, map[string]any{"expression": "(2 + 3) * 4"})
+	if !result.Success() || result.Data() != "20" {
+		t.Errorf("Expected success with '20', got success=%v data=%q error=%q", result.Success(), result.Data(), result.Error())
+	}
+
+	result = tool.Call(nil, map[string]any{"expression": "1 / 0"})
+	if result.Success() || !strings.Contains(result.Error(), "division by zero") {
+		t.Errorf("Expected division by zero error, got success=%v error=%q", result.Success(), result.Error())
+	}
+}
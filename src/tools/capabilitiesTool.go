@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	agentforge "github.com/thinktwice/agentForge/src"
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// NewListCapabilitiesTool creates a tool that lists every tool and sub-agent
+// available to the agent with its basic description.
+//
+// It gives the model a cheap catalog to orient itself before using the expand
+// tool for details. Tools that do not implement Discoverable are listed with
+// their function description, sub-agents that do not implement it by name only.
+//
+// The tool expects the following items in agentContext:
+//   - "tools": []llms.Tool - list of available tools
+//   - "subAgents": []*core.SubAgent or []core.SubAgent - list of available sub-agents
+func NewListCapabilitiesTool() llms.Tool {
+	return core.NewTool(
+		"list_capabilities",
+		"List all available tools and sub-agents with a short description of each. Use it to find out what you can do before expanding a tool or agent.",
+		`Advanced Details:
+- Parameters: none
+- Behavior:
+  * Lists every tool in the agent context with its basic description
+  * Lists every sub-agent in the agent context with its basic description
+  * Names without a description are listed on their own
+- Usage:
+  * Call once to get an overview of your capabilities
+  * Follow up with expand(subject_type, subject_name) for details on a single entry`,
+		`Troubleshooting:
+- Empty catalog: The agent has no tools or sub-agents configured
+- Missing sub-agent: Ensure the sub-agent is added to the agent's SubAgents`,
+		[]core.Parameter{},
+		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+			return core.NewSuccessResponse(FormatCapabilities(agentContext))
+		},
+	)
+}
+
+// FormatCapabilities builds the catalog returned by the list_capabilities tool.
+//
+// Parameters:
+//   - agentContext: The agent context holding "tools" and "subAgents"
+//
+// Returns:
+//   - string: One line per tool and sub-agent, grouped by kind
+func FormatCapabilities(agentContext map[string]any) string {
+	tools, _ := agentContext["tools"].([]llms.Tool)
+	subAgents := contextSubAgents(agentContext)
+
+	var response strings.Builder
+	response.WriteString("=== CAPABILITIES ===\n")
+
+	response.WriteString(fmt.Sprintf("\nTools (%d):\n", len(tools)))
+	if len(tools) == 0 {
+		response.WriteString("(none)\n")
+	}
+	for _, tool := range tools {
+		description := tool.GetFunctionDefinition().Description
+		if discoverable, ok := tool.(agentforge.Discoverable); ok {
+			description = discoverable.BasicDescription()
+		}
+		writeCapability(&response, tool.GetName(), description)
+	}
+
+	response.WriteString(fmt.Sprintf("\nAgents (%d):\n", len(subAgents)))
+	if len(subAgents) == 0 {
+		response.WriteString("(none)\n")
+	}
+	for _, agent := range subAgents {
+		description := ""
+		if discoverable, ok := agent.(agentforge.Discoverable); ok {
+			description = discoverable.BasicDescription()
+		}
+		writeCapability(&response, agent.Name(), description)
+	}
+
+	response.WriteString("\nUse expand(subject_type, subject_name) for details on any entry.")
+	return response.String()
+}
+
+// writeCapability writes one catalog line, keeping only the first line of the description.
+func writeCapability(response *strings.Builder, name, description string) {
+	description, _, _ = strings.Cut(strings.TrimSpace(description), "\n")
+	if description == "" {
+		response.WriteString(fmt.Sprintf("- %s\n", name))
+		return
+	}
+	response.WriteString(fmt.Sprintf("- %s: %s\n", name, description))
+}
+
+// contextSubAgents returns the sub-agents of the agent context, in any of the
+// slice types used to store them. Nil entries are skipped.
+func contextSubAgents(agentContext map[string]any) []core.SubAgent {
+	var subAgents []core.SubAgent
+	switch list := agentContext["subAgents"].(type) {
+	case []*core.SubAgent:
+		for _, agent := range list {
+			if agent != nil && *agent != nil {
+				subAgents = append(subAgents, *agent)
+			}
+		}
+	case []core.SubAgent:
+		for _, agent := range list {
+			if agent != nil {
+				subAgents = append(subAgents, agent)
+			}
+		}
+	case []SubAgentDiscoverable:
+		for _, agent := range list {
+			if agent != nil {
+				subAgents = append(subAgents, agent)
+			}
+		}
+	}
+	return subAgents
+}
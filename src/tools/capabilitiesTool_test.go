@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// plainTool is an llms.Tool that does not implement Discoverable
+type plainTool struct{}
+
+func (plainTool) GetName() string { return "plain-tool" }
+
+func (plainTool) Call(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+	return core.NewSuccessResponse("ok")
+}
+
+func (plainTool) GetFunctionDefinition() llms.FunctionDefinition {
+	return llms.FunctionDefinition{Name: "plain-tool"}
+}
+
+func TestListCapabilitiesTool(t *testing.T) {
+	listTool := NewListCapabilitiesTool()
+	if listTool.GetName() != "list_capabilities" {
+		t.Errorf("Expected tool name 'list_capabilities', got '%s'", listTool.GetName())
+	}
+
+	var agent core.SubAgent = &mockDiscoverableAgent{
+		name:      "test-agent",
+		basicDesc: "A test agent",
+	}
+	agentContext := map[string]any{
+		"tools":     []llms.Tool{newMockDiscoverableTool(), NewCalculatorTool(), plainTool{}},
+		"subAgents": []*core.SubAgent{&agent},
+	}
+
+	result := listTool.Call(agentContext, map[string]any{})
+	if !result.Success() {
+		t.Fatalf("Expected success, got error: %s", result.Error())
+	}
+	data := result.Data()
+	t.Logf("Capabilities:\n%s", data)
+
+	for _, want := range []string{
+		"Tools (3):",
+		"- mock-tool: A mock tool for testing\n",
+		"- calculator: ",
+		"- plain-tool\n",
+		"Agents (1):",
+		"- test-agent: A test agent\n",
+	} {
+		if !strings.Contains(data, want) {
+			t.Errorf("Expected catalog to contain %q", want)
+		}
+	}
+	if strings.Contains(data, "Advanced mock tool") {
+		t.Error("Catalog should only contain basic descriptions")
+	}
+}
+
+func TestListCapabilitiesTool_EmptyContext(t *testing.T) {
+	result := NewListCapabilitiesTool().Call(map[string]any{}, map[string]any{})
+	if !result.Success() {
+		t.Fatalf("Expected success, got error: %s", result.Error())
+	}
+	if !strings.Contains(result.Data(), "Tools (0):\n(none)") || !strings.Contains(result.Data(), "Agents (0):\n(none)") {
+		t.Errorf("Expected empty sections, got:\n%s", result.Data())
+	}
+}
@@ -1,17 +1,44 @@
 package tools
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	agentforge "github.com/thinktwice/agentForge/src"
 	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/internal/delegation"
 	"github.com/thinktwice/agentForge/src/llms"
 )
 
 // NewDelegateTool creates a new DelegateTool with the given sub agents.
+//
+// The tool result is everything the sub-agent streamed, including intermediate
+// content such as reasoning notes. Use NewQuietDelegateTool to keep only its answer.
 func NewDelegateTool(subAgents []*core.SubAgent) llms.Tool {
-	return core.NewTool(
+	return newDelegateTool(subAgents, false)
+}
+
+// NewQuietDelegateTool creates a DelegateTool whose result is only the final answer
+// of the sub-agent (the FullContent of its last completion chunk), keeping the
+// parent's history free of the sub-agent's intermediate output. All chunks are
+// still forwarded to the parent's response channel for display.
+func NewQuietDelegateTool(subAgents []*core.SubAgent) llms.Tool {
+	return newDelegateTool(subAgents, true)
+}
+
+// newDelegateTool creates the delegate tool. When quiet is set the result is the
+// sub-agent's final answer instead of its full streamed content.
+func newDelegateTool(subAgents []*core.SubAgent, quiet bool) llms.Tool {
+	resultBehavior := "  * Accumulates and returns the full response when delegation completes"
+	if quiet {
+		resultBehavior = "  * Returns only the sub-agent's final answer when delegation completes"
+	}
+
+	tool := core.NewTool(
 		"delegate",
 		"Delegate a task to a sub agent",
 		`Advanced Details:
@@ -21,7 +48,7 @@ func NewDelegateTool(subAgents []*core.SubAgent) llms.Tool {
 - Behavior: 
   * Streams responses from the sub-agent back to the parent agent
   * Forwards all chunks including content, tool calls, and status updates
-  * Accumulates and returns the full response when delegation completes
+`+resultBehavior+`
 - Usage: 
   * Only delegate complex tasks that benefit from specialized analysis
   * Provide comprehensive context in the message - sub-agents don't inherit parent context
@@ -31,6 +58,7 @@ func NewDelegateTool(subAgents []*core.SubAgent) llms.Tool {
 - "sub agent not found" error: Verify the subAgent name matches exactly (check spelling and case)
 - Empty responses: Ensure the message parameter contains sufficient context for the sub-agent
 - Delegation loops: Avoid having sub-agents delegate back to parent agents
+- "max delegation depth exceeded": The delegation chain is deeper than MaxDelegationDepth - answer directly instead of delegating further
 - Performance: Long-running delegations are normal for complex tasks
 - Context isolation: Sub-agents don't see parent agent's history - include all relevant info in message`,
 		[]core.Parameter{
@@ -71,15 +99,36 @@ func NewDelegateTool(subAgents []*core.SubAgent) llms.Tool {
 				return core.NewErrorResponse(fmt.Sprintf("sub agent '%s' not found", subAgentName))
 			}
 
+			// Enforce the maximum delegation depth
+			depth, _ := agentContext[core.ContextDelegationDepth].(int)
+			maxDepth, _ := agentContext[core.ContextMaxDelegationDepth].(int)
+			nextDepth := depth + 1
+			if maxDepth > 0 && nextDepth > maxDepth {
+				return core.NewErrorResponse(fmt.Sprintf(
+					"max delegation depth exceeded: delegating to '%s' would reach depth %d (limit %d)",
+					subAgentName, nextDepth, maxDepth,
+				))
+			}
+
+			ctx, _ := agentContext[core.ContextTraceContext].(context.Context)
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			// Each delegation gets its own ID so a UI can tell the output of
+			// repeated delegations to the same sub-agent apart
+			delegationID := newDelegationID()
+
 			// Send delegation start notification if parent response channel is available
 			if parentResponseCh != nil {
-				startChunk := llms.ChunkResponse{
-					Status:  llms.StatusStreaming,
-					Type:    llms.TypeContent,
-					Content: fmt.Sprintf("\n [🛠️ Delegating to %s...]\nQuestion: %s\n", subAgentName, message),
+				startChunk := core.ExtendedChunkResponse{
+					Status:       llms.StatusStreaming,
+					Type:         llms.TypeContent,
+					Content:      fmt.Sprintf("\n [🛠️ Delegating to %s...]\nQuestion: %s\n", subAgentName, message),
+					DelegationID: delegationID,
 				}
 				if startBytes, err := json.Marshal(startChunk); err == nil {
-					parentResponseCh.GetResponseChan() <- startBytes
+					forwardToParent(ctx, parentResponseCh, startBytes)
 				}
 			}
 
@@ -91,45 +140,68 @@ func NewDelegateTool(subAgents []*core.SubAgent) llms.Tool {
 
 			agentforge.Info("%s ➡️ %s ➡️ %s", parentAgentName, subAgentName, message)
 
-			// Execute delegation by calling sub agent's ChatStream
-			delegateResponseCh := assignedSubAgent.ChatStream(message)
+			// Execute delegation by calling sub agent's ChatStream, propagating
+			// the delegation depth when the sub agent supports it
+			var delegateResponseCh *core.ResponseCh
+			if depthAware, ok := assignedSubAgent.(core.DelegationAwareSubAgent); ok {
+				delegateResponseCh = depthAware.ChatStreamContext(delegation.WithDepth(ctx, nextDepth, maxDepth), message)
+			} else {
+				delegateResponseCh = assignedSubAgent.ChatStream(message)
+			}
 
-			// Accumulate the full response
+			// Accumulate the full response, and the sub-agent's own final answer
 			var fullResponse string
+			var finalAnswer string
+			var hasFinalAnswer bool
 			var delegationError error
 
 			// Process chunks from the sub-agent - no reflection needed!
 			for chunk := range delegateResponseCh.Start() {
-				// Accumulate content
-				if chunk.Content != "" {
+				// Accumulate content (tool progress reports are not part of it)
+				if chunk.Content != "" && chunk.Trace != llms.TraceToolProgress {
 					fullResponse += chunk.Content
 				}
 
+				// Completions of agents further down the chain are not the answer
+				if chunk.Type == llms.TypeCompletion && chunk.AgentName == subAgentName {
+					finalAnswer = chunk.FullContent
+					hasFinalAnswer = true
+				}
+
 				// Check for errors
 				if chunk.Status == llms.StatusError {
 					delegationError = fmt.Errorf("delegation error: %s", chunk.Content)
 				}
 
-				// Forward chunk to parent if available
+				// Forward chunk to parent if available. Chunks of nested
+				// delegations keep the ID of the delegation that produced them.
 				if parentResponseCh != nil {
+					if chunk.DelegationID == "" {
+						chunk.DelegationID = delegationID
+					}
 					if chunkBytes, err := json.Marshal(chunk); err == nil {
-						parentResponseCh.GetResponseChan() <- chunkBytes
+						forwardToParent(ctx, parentResponseCh, chunkBytes)
 					}
 				}
 			}
 
 			// Send delegation completion notification
 			if parentResponseCh != nil {
-				endChunk := llms.ChunkResponse{
-					Status:  llms.StatusStreaming,
-					Type:    llms.TypeContent,
-					Content: fmt.Sprintf("\n[✅ Delegation to %s complete]\n", subAgentName),
+				endChunk := core.ExtendedChunkResponse{
+					Status:       llms.StatusStreaming,
+					Type:         llms.TypeContent,
+					Content:      fmt.Sprintf("\n[✅ Delegation to %s complete]\n", subAgentName),
+					DelegationID: delegationID,
 				}
 				if endBytes, err := json.Marshal(endChunk); err == nil {
-					parentResponseCh.GetResponseChan() <- endBytes
+					forwardToParent(ctx, parentResponseCh, endBytes)
 				}
 			}
 
+			if quiet && hasFinalAnswer {
+				fullResponse = finalAnswer
+			}
+
 			// Return the accumulated result
 			if delegationError != nil {
 				return core.NewFailureResponse(delegationError.Error(), fullResponse)
@@ -138,4 +210,27 @@ func NewDelegateTool(subAgents []*core.SubAgent) llms.Tool {
 			return core.NewSuccessResponse(fullResponse)
 		},
 	)
+	// Delegation results depend on the sub-agent, never cache them
+	tool.(*core.Tool).SetCacheable(false)
+	return tool
+}
+
+// forwardToParent sends a chunk to the parent agent's consumer. The chunk is
+// dropped once ctx (the delegating run's) is done, so a consumer that stopped
+// reading cannot block the delegation forever.
+func forwardToParent(ctx context.Context, parentResponseCh *core.ResponseCh, chunkBytes []byte) {
+	select {
+	case parentResponseCh.GetResponseChan() <- chunkBytes:
+	case <-ctx.Done():
+	case <-parentResponseCh.Stopped():
+	}
+}
+
+// newDelegationID returns a random ID identifying one delegation.
+func newDelegationID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("dlg_%d", time.Now().UnixNano())
+	}
+	return "dlg_" + hex.EncodeToString(b[:])
 }
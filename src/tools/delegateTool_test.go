@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// scriptedSubAgent is a sub-agent streaming a fixed list of chunks.
+type scriptedSubAgent struct {
+	name   string
+	chunks []llms.ChunkResponse
+}
+
+func (s *scriptedSubAgent) ChatStream(message string) *core.ResponseCh {
+	rc := core.NewResponseCh(s.name, "")
+	go func() {
+		defer rc.Close()
+		for _, chunk := range s.chunks {
+			chunkBytes, _ := json.Marshal(chunk)
+			rc.Response <- chunkBytes
+		}
+	}()
+	return rc
+}
+
+func (s *scriptedSubAgent) Name() string               { return s.name }
+func (s *scriptedSubAgent) BasicDescription() string   { return "scripted" }
+func (s *scriptedSubAgent) AdvanceDescription() string { return "" }
+func (s *scriptedSubAgent) Troubleshooting() string    { return "" }
+
+func TestDelegateTool_Result(t *testing.T) {
+	var sa core.SubAgent = &scriptedSubAgent{
+		name: "researcher",
+		chunks: []llms.ChunkResponse{
+			{Content: "🔎 Looking up sources\n", Status: llms.StatusStreaming, Type: llms.TypeContent},
+			{Content: "The answer is 42", Status: llms.StatusStreaming, Type: llms.TypeContent},
+			{FullContent: "The answer is 42", Status: llms.StatusCompleted, Type: llms.TypeCompletion},
+		},
+	}
+	subAgents := []*core.SubAgent{&sa}
+	args := map[string]any{"subAgent": "researcher", "message": "what is the answer?"}
+
+	t.Run("full response by default", func(t *testing.T) {
+		result := NewDelegateTool(subAgents).Call(map[string]any{"agentName": "main"}, args)
+		if !result.Success() || !strings.Contains(result.Data(), "🔎 Looking up sources") {
+			t.Errorf("Expected the full streamed response, got %q", result.Data())
+		}
+	})
+
+	t.Run("quiet returns the final answer", func(t *testing.T) {
+		parent := core.NewResponseChWithBuffer("main", "", 32)
+		result := NewQuietDelegateTool(subAgents).Call(map[string]any{"agentName": "main", "responseCh": parent}, args)
+		if !result.Success() || result.Data() != "The answer is 42" {
+			t.Errorf("Expected only the final answer, got %q", result.Data())
+		}
+
+		// Intermediate chunks are still forwarded for display
+		parent.Close()
+		var forwarded string
+		for chunkBytes := range parent.Response {
+			var chunk llms.ChunkResponse
+			if err := json.Unmarshal(chunkBytes, &chunk); err == nil {
+				forwarded += chunk.Content
+			}
+		}
+		if !strings.Contains(forwarded, "🔎 Looking up sources") {
+			t.Errorf("Expected intermediate chunks to be forwarded, got %q", forwarded)
+		}
+	})
+}
+
+func TestDelegateTool_DelegationIDs(t *testing.T) {
+	var sa core.SubAgent = &scriptedSubAgent{
+		name: "researcher",
+		chunks: []llms.ChunkResponse{
+			{Content: "thinking", Status: llms.StatusStreaming, Type: llms.TypeContent},
+			{FullContent: "done", Status: llms.StatusCompleted, Type: llms.TypeCompletion},
+		},
+	}
+	tool := NewDelegateTool([]*core.SubAgent{&sa})
+	args := map[string]any{"subAgent": "researcher", "message": "look it up"}
+
+	// delegate runs one delegation and returns the IDs of the chunks it forwarded
+	delegate := func() map[string]bool {
+		parent := core.NewResponseChWithBuffer("main", "", 32)
+		if result := tool.Call(map[string]any{"agentName": "main", "responseCh": parent}, args); !result.Success() {
+			t.Fatalf("Delegation failed: %s", result.Error())
+		}
+		parent.Close()
+
+		ids := map[string]bool{}
+		count := 0
+		for chunk := range parent.Start() {
+			ids[chunk.DelegationID] = true
+			count++
+		}
+		// Start and end notifications plus the sub-agent's chunks
+		if count != 4 {
+			t.Errorf("Expected 4 forwarded chunks, got %d", count)
+		}
+		return ids
+	}
+
+	first, second := delegate(), delegate()
+	if len(first) != 1 || len(second) != 1 || first[""] || second[""] {
+		t.Fatalf("Expected every chunk of a delegation to carry its ID, got %v and %v", first, second)
+	}
+	for id := range first {
+		if second[id] {
+			t.Errorf("Expected distinct delegation IDs, got %q twice", id)
+		}
+	}
+}
@@ -1,9 +1,13 @@
 package tools
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/thinktwice/agentForge/src/core"
@@ -83,6 +87,87 @@ Content:
 	return info, nil
 }
 
+// ReadFileLines reads the lines startLine to endLine (1-based, inclusive) of a file,
+// streaming it so only the requested range is kept in memory. The response also
+// reports the total line count so the caller can page through the file.
+// The path is validated to ensure it stays within the root directory.
+//
+// Parameters:
+//   - path: File path relative to the root directory
+//   - startLine: First line to return (1 for the start of the file)
+//   - endLine: Last line to return; 0 or a line past the end reads to the end of the file
+//
+// Returns:
+//   - string: The file information and the requested lines
+//   - error: If the path is invalid, the file cannot be read, or the range is invalid
+//     (startLine below 1 or past the last line, endLine before startLine)
+func (fs *Fs) ReadFileLines(path string, startLine, endLine int) (string, error) {
+	if startLine < 1 {
+		return "", fmt.Errorf("invalid start_line %d: lines are numbered from 1", startLine)
+	}
+	if endLine != 0 && endLine < startLine {
+		return "", fmt.Errorf("invalid range: end_line %d is before start_line %d", endLine, startLine)
+	}
+
+	validatedPath, err := fs.validatePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	fileInfo, err := os.Stat(validatedPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("file not found: %s", path)
+		}
+		return "", fmt.Errorf("failed to get file info for '%s': %w", path, err)
+	}
+
+	file, err := os.Open(validatedPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	var lines []string
+	totalLines := 0
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			totalLines++
+			if totalLines >= startLine && (endLine == 0 || totalLines <= endLine) {
+				lines = append(lines, strings.TrimRight(line, "\r\n"))
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read file '%s': %w", path, err)
+		}
+	}
+
+	if startLine > totalLines {
+		return "", fmt.Errorf("start_line %d is past the end of '%s' (%d lines)", startLine, path, totalLines)
+	}
+	lastLine := startLine + len(lines) - 1
+
+	// Build detailed response
+	modTime := fileInfo.ModTime().Format(time.RFC3339)
+	info := fmt.Sprintf(`File Operation: Read
+Path (relative): %s
+Path (absolute): %s
+Size: %d bytes
+Modified: %s
+Lines: %d-%d of %d
+Content:
+---
+%s
+---`, path, validatedPath, fileInfo.Size(), modTime, startLine, lastLine, totalLines, strings.Join(lines, "\n"))
+
+	return info, nil
+}
+
 // WriteFile writes content to a file, creating it if it doesn't exist.
 // The path is validated to ensure it stays within the root directory.
 // Returns detailed information about the file operation.
@@ -137,6 +222,51 @@ Directory: %s`, operation, path, validatedPath, fileInfo.Size(), modTime, dir)
 	return info, nil
 }
 
+// AppendFile appends content to the end of a file, creating it if it doesn't exist.
+// The path is validated to ensure it stays within the root directory.
+// Returns detailed information about the file operation, including the new total size.
+func (fs *Fs) AppendFile(path string, content string) (string, error) {
+	validatedPath, err := fs.validatePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	// Create directory if it doesn't exist
+	dir := filepath.Dir(validatedPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory for '%s': %w", path, err)
+	}
+
+	file, err := os.OpenFile(validatedPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file '%s' for append: %w", path, err)
+	}
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return "", fmt.Errorf("failed to append to file '%s': %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to append to file '%s': %w", path, err)
+	}
+
+	// Get file info after appending
+	fileInfo, err := os.Stat(validatedPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get file info after append: %w", err)
+	}
+
+	// Build detailed response
+	modTime := fileInfo.ModTime().Format(time.RFC3339)
+	info := fmt.Sprintf(`File Operation: Append
+Path (relative): %s
+Path (absolute): %s
+Appended: %d bytes
+Size: %d bytes
+Modified: %s`, path, validatedPath, len(content), fileInfo.Size(), modTime)
+
+	return info, nil
+}
+
 // DeleteFile deletes a file.
 // The path is validated to ensure it stays within the root directory.
 // Returns detailed information about the deletion operation.
@@ -172,7 +302,91 @@ Status: Successfully deleted`, path, validatedPath, fileInfo.Size(), modTime)
 	return info, nil
 }
 
-// NewFsTool creates a file system tool that provides read, write, and delete operations.
+// ListDir lists the entries of a directory with their name, size, type and modification time.
+// The path is validated to ensure it stays within the root directory.
+func (fs *Fs) ListDir(path string) (string, error) {
+	validatedPath, err := fs.validatePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := os.ReadDir(validatedPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("directory not found: %s", path)
+		}
+		return "", fmt.Errorf("failed to list directory '%s': %w", path, err)
+	}
+
+	// Build detailed response
+	var lines []string
+	for _, entry := range entries {
+		entryInfo, err := entry.Info()
+		if err != nil {
+			return "", fmt.Errorf("failed to get file info for '%s': %w", entry.Name(), err)
+		}
+		modTime := entryInfo.ModTime().Format(time.RFC3339)
+		if entry.IsDir() {
+			lines = append(lines, fmt.Sprintf("%s/ (dir, modified %s)", entry.Name(), modTime))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s (file, %d bytes, modified %s)", entry.Name(), entryInfo.Size(), modTime))
+		}
+	}
+
+	info := fmt.Sprintf(`File Operation: List
+Path (relative): %s
+Path (absolute): %s
+Entries: %d
+---
+%s
+---`, path, validatedPath, len(entries), strings.Join(lines, "\n"))
+
+	return info, nil
+}
+
+// Glob returns the paths matching a pattern, relative to the root directory.
+// The pattern uses filepath.Match syntax (e.g., "*.go", "docs/*/*.md") and is
+// validated like a path, so it cannot reach outside the root directory.
+func (fs *Fs) Glob(pattern string) (string, error) {
+	validatedPattern, err := fs.validatePath(pattern)
+	if err != nil {
+		return "", err
+	}
+
+	matches, err := filepath.Glob(validatedPattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid glob pattern '%s': %w", pattern, err)
+	}
+
+	absRoot, err := filepath.Abs(fs.root)
+	if err != nil {
+		return "", fmt.Errorf("invalid root directory: %w", err)
+	}
+
+	// Build detailed response with paths relative to root
+	var lines []string
+	for _, match := range matches {
+		relPath, err := filepath.Rel(absRoot, match)
+		if err != nil || strings.HasPrefix(relPath, "..") {
+			continue
+		}
+		if info, err := os.Stat(match); err == nil && info.IsDir() {
+			relPath += "/"
+		}
+		lines = append(lines, relPath)
+	}
+
+	info := fmt.Sprintf(`File Operation: Glob
+Pattern: %s
+Matches: %d
+---
+%s
+---`, pattern, len(lines), strings.Join(lines, "\n"))
+
+	return info, nil
+}
+
+// NewFsTool creates a file system tool that provides read, write, append, delete, list, and glob operations.
 // All file operations are restricted to the specified root directory for security.
 //
 // Parameters:
@@ -180,50 +394,79 @@ Status: Successfully deleted`, path, validatedPath, fileInfo.Size(), modTime)
 func NewFsTool(root string) llms.Tool {
 	fs := &Fs{root: root}
 
-	return core.NewTool(
+	tool := core.NewTool(
 		"fs",
-		"Perform file system operations (read, write, delete) on files within a restricted directory.",
+		"Perform file system operations (read, write, append, delete, list, glob) on files within a restricted directory.",
 		`Advanced Details:
 - Parameters:
-  * operation (string, required): The operation to perform - "read", "write", or "delete"
-  * path (string, required): File path relative to the root directory
-  * content (string, optional): File content - required for "write" operation
+  * operation (string, required): The operation to perform - "read", "write", "append", "delete", "list", or "glob"
+  * path (string, required): File path relative to the root directory (directory for "list", pattern for "glob")
+  * content (string, optional): File content - required for "write" and "append" operations
+  * start_line (number, optional): First line to read (1-based) - "read" only
+  * end_line (number, optional): Last line to read (inclusive) - "read" only, defaults to the end of the file
 - Behavior:
   * All file paths are validated to ensure they stay within the root directory
   * Path traversal attempts (e.g., "../") are blocked for security
-  * Read operation returns file content as a string
+  * Read operation returns file content as a string; with start_line/end_line it returns
+    only that range of lines together with the total line count ("Lines: 10-20 of 500")
   * Write operation creates the file if it doesn't exist, and creates parent directories if needed
+  * Append operation adds content to the end of the file, creating it and parent directories if needed
   * Delete operation removes the specified file
+  * List operation returns the entries of a directory with size, type and modification time
+  * Glob operation returns the paths matching a pattern (e.g., "*.go", "docs/*/*.md"), relative to the root
 - Usage:
-  * Use "read" to read file contents
+  * Use "read" to read file contents; for large files read a range of lines first
+    (e.g. start_line 1, end_line 100) and continue from the reported total
   * Use "write" to create or update files (provide content parameter)
+  * Use "append" for log-style or incremental writes (provide content parameter)
   * Use "delete" to remove files
+  * Use "list" with path "." to discover what exists in the root directory
+  * Use "glob" to find files by name pattern across directories
 - Security: All operations are sandboxed to the root directory to prevent unauthorized access`,
 		`Troubleshooting:
 - "path traversal detected": The provided path attempts to escape the root directory - use relative paths only
 - "file not found": The file doesn't exist (for read/delete operations) - verify the path is correct
-- "missing required parameter: content": Content parameter is required for write operations
-- "invalid operation": Operation must be exactly "read", "write", or "delete"
+- "directory not found": The directory doesn't exist (for list operations) - list the parent directory first
+- "invalid glob pattern": The pattern is malformed - check brackets and escapes
+- "start_line ... is past the end": The file has fewer lines - use the reported line count
+- "invalid range": end_line must not be before start_line
+- "missing required parameter: content": Content parameter is required for write and append operations
+- "invalid value for operation": Operation must be exactly "read", "write", "append", "delete", "list", or "glob"
 - Permission errors: Ensure the process has read/write/delete permissions for the root directory
 - "failed to create directory": Parent directory creation failed - check permissions`,
 		[]core.Parameter{
 			{
 				Name:        "operation",
 				Type:        "string",
-				Description: "The operation to perform: 'read', 'write', or 'delete'",
+				Description: "The operation to perform: 'read', 'write', 'append', 'delete', 'list', or 'glob'",
 				Required:    true,
+				Enum:        []any{"read", "write", "append", "delete", "list", "glob"},
 			},
 			{
 				Name:        "path",
 				Type:        "string",
-				Description: "File path relative to the root directory",
+				Description: "File path relative to the root directory (directory for 'list', pattern for 'glob')",
 				Required:    true,
 			},
 			{
 				Name:        "content",
 				Type:        "string",
-				Description: "File content - required for 'write' operation",
+				Description: "File content - required for 'write' and 'append' operations",
+				Required:    false,
+			},
+			{
+				Name:        "start_line",
+				Type:        "number",
+				Description: "First line to read, starting at 1 - 'read' only",
+				Required:    false,
+				Validator:   validateLineNumber,
+			},
+			{
+				Name:        "end_line",
+				Type:        "number",
+				Description: "Last line to read (inclusive), defaults to the end of the file - 'read' only",
 				Required:    false,
+				Validator:   validateLineNumber,
 			},
 		},
 		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
@@ -231,15 +474,28 @@ func NewFsTool(root string) llms.Tool {
 			path := args["path"].(string)
 
 			// Validate operation
-			if operation != "read" && operation != "write" && operation != "delete" {
+			if operation != "read" && operation != "write" && operation != "append" && operation != "delete" && operation != "list" && operation != "glob" {
 				return core.NewErrorResponse(fmt.Sprintf(
-					"invalid operation '%s'. Must be 'read', 'write', or 'delete'",
+					"invalid operation '%s'. Must be 'read', 'write', 'append', 'delete', 'list', or 'glob'",
 					operation,
 				))
 			}
 
 			// Handle read operation
 			if operation == "read" {
+				startLine, hasStart := toNumber(args["start_line"])
+				endLine, hasEnd := toNumber(args["end_line"])
+				if hasStart || hasEnd {
+					if !hasStart {
+						startLine = 1
+					}
+					info, err := fs.ReadFileLines(path, int(startLine), int(endLine))
+					if err != nil {
+						return core.NewErrorResponse(err.Error())
+					}
+					return core.NewSuccessResponse(info)
+				}
+
 				info, err := fs.ReadFile(path)
 				if err != nil {
 					return core.NewErrorResponse(err.Error())
@@ -247,17 +503,21 @@ func NewFsTool(root string) llms.Tool {
 				return core.NewSuccessResponse(info)
 			}
 
-			// Handle write operation
-			if operation == "write" {
+			// Handle write and append operations
+			if operation == "write" || operation == "append" {
 				content, ok := args["content"]
 				if !ok {
-					return core.NewErrorResponse("missing required parameter: content (required for write operation)")
+					return core.NewErrorResponse(fmt.Sprintf("missing required parameter: content (required for %s operation)", operation))
 				}
 				contentStr, ok := content.(string)
 				if !ok {
 					return core.NewErrorResponse("content parameter must be a string")
 				}
-				info, err := fs.WriteFile(path, contentStr)
+				write := fs.WriteFile
+				if operation == "append" {
+					write = fs.AppendFile
+				}
+				info, err := write(path, contentStr)
 				if err != nil {
 					return core.NewErrorResponse(err.Error())
 				}
@@ -273,8 +533,38 @@ func NewFsTool(root string) llms.Tool {
 				return core.NewSuccessResponse(info)
 			}
 
+			// Handle list operation
+			if operation == "list" {
+				info, err := fs.ListDir(path)
+				if err != nil {
+					return core.NewErrorResponse(err.Error())
+				}
+				return core.NewSuccessResponse(info)
+			}
+
+			// Handle glob operation
+			if operation == "glob" {
+				info, err := fs.Glob(path)
+				if err != nil {
+					return core.NewErrorResponse(err.Error())
+				}
+				return core.NewSuccessResponse(info)
+			}
+
 			// This should never be reached, but included for completeness
 			return core.NewErrorResponse(fmt.Sprintf("unhandled operation: %s", operation))
 		},
 	)
+	// File contents change between calls, never cache them
+	tool.(*core.Tool).SetCacheable(false)
+	return tool
+}
+
+// validateLineNumber checks a line number is a whole number of at least 1.
+func validateLineNumber(value any) error {
+	n, ok := toNumber(value)
+	if !ok || n != math.Trunc(n) || n < 1 {
+		return fmt.Errorf("line numbers must be integers starting at 1")
+	}
+	return nil
 }
@@ -0,0 +1,214 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newFsFixture creates a root directory with nested files for fs tests.
+func newFsFixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"readme.md":         "# readme",
+		"src/main.go":       "package main",
+		"src/util/util.go":  "package util",
+		"src/util/notes.md": "notes",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	return root
+}
+
+func TestFs_ListDir(t *testing.T) {
+	fs := &Fs{root: newFsFixture(t)}
+
+	info, err := fs.ListDir("src")
+	if err != nil {
+		t.Fatalf("ListDir() unexpected error = %v", err)
+	}
+	if !strings.Contains(info, "Entries: 2") {
+		t.Errorf("Expected 2 entries, got:\n%s", info)
+	}
+	if !strings.Contains(info, "main.go (file, 12 bytes, modified ") {
+		t.Errorf("Expected file entry with size, got:\n%s", info)
+	}
+	if !strings.Contains(info, "util/ (dir, modified ") {
+		t.Errorf("Expected directory entry, got:\n%s", info)
+	}
+
+	if _, err := fs.ListDir("missing"); err == nil || !strings.Contains(err.Error(), "directory not found") {
+		t.Errorf("Expected directory not found error, got %v", err)
+	}
+	if _, err := fs.ListDir("../"); err == nil || !strings.Contains(err.Error(), "path traversal detected") {
+		t.Errorf("Expected path traversal error, got %v", err)
+	}
+}
+
+func TestFs_Glob(t *testing.T) {
+	fs := &Fs{root: newFsFixture(t)}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"*.md", []string{"readme.md"}},
+		{"src/*/*.go", []string{"src/util/util.go"}},
+		{"src/*", []string{"src/main.go", "src/util/"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			info, err := fs.Glob(tt.pattern)
+			if err != nil {
+				t.Fatalf("Glob() unexpected error = %v", err)
+			}
+			body := strings.Split(info, "---\n")[1]
+			got := strings.Fields(body)
+			got = got[:len(got)-1] // drop the closing ---
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Glob(%q) = %v, want %v", tt.pattern, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("traversal via pattern is blocked", func(t *testing.T) {
+		for _, pattern := range []string{"../*", "src/../../*"} {
+			if _, err := fs.Glob(pattern); err == nil || !strings.Contains(err.Error(), "path traversal detected") {
+				t.Errorf("Glob(%q) expected path traversal error, got %v", pattern, err)
+			}
+		}
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		if _, err := fs.Glob("src/[a"); err == nil || !strings.Contains(err.Error(), "invalid glob pattern") {
+			t.Errorf("Expected invalid pattern error, got %v", err)
+		}
+	})
+}
+
+func TestFsTool_ListOperation(t *testing.T) {
+	tool := NewFsTool(newFsFixture(t))
+
+	result := tool.Call(nil, map[string]any{"operation": "list", "path": "."})
+	if !result.Success() {
+		t.Fatalf("Expected success, got error: %s", result.Error())
+	}
+	if !strings.Contains(result.Data(), "readme.md (file") || !strings.Contains(result.Data(), "src/ (dir") {
+		t.Errorf("Unexpected list output:\n%s", result.Data())
+	}
+}
+
+func TestFsTool_AppendOperation(t *testing.T) {
+	root := t.TempDir()
+	tool := NewFsTool(root)
+
+	for _, line := range []string{"first\n", "second\n"} {
+		result := tool.Call(nil, map[string]any{"operation": "append", "path": "logs/app.log", "content": line})
+		if !result.Success() {
+			t.Fatalf("Expected success, got error: %s", result.Error())
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, "logs", "app.log"))
+	if err != nil {
+		t.Fatalf("Failed to read appended file: %v", err)
+	}
+	if string(content) != "first\nsecond\n" {
+		t.Errorf("Expected concatenated content, got %q", content)
+	}
+
+	result := tool.Call(nil, map[string]any{"operation": "append", "path": "logs/app.log", "content": "x"})
+	if !strings.Contains(result.Data(), "Appended: 1 bytes") || !strings.Contains(result.Data(), "Size: 14 bytes") {
+		t.Errorf("Expected appended and total size in summary, got:\n%s", result.Data())
+	}
+
+	result = tool.Call(nil, map[string]any{"operation": "append", "path": "../escape.log", "content": "x"})
+	if result.Success() || !strings.Contains(result.Error(), "path traversal detected") {
+		t.Errorf("Expected path traversal error, got %+v", result)
+	}
+
+	result = tool.Call(nil, map[string]any{"operation": "append", "path": "logs/app.log"})
+	if result.Success() || !strings.Contains(result.Error(), "required for append operation") {
+		t.Errorf("Expected missing content error, got %q", result.Error())
+	}
+}
+
+func TestFs_ReadFileLines(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "lines.txt"), []byte("one\ntwo\r\nthree\nfour\nfive"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	fs := &Fs{root: root}
+
+	tests := []struct {
+		name       string
+		start, end int
+		wantLines  string
+		wantRange  string
+	}{
+		{name: "middle", start: 2, end: 4, wantLines: "two\nthree\nfour", wantRange: "Lines: 2-4 of 5"},
+		{name: "single line", start: 1, end: 1, wantLines: "one", wantRange: "Lines: 1-1 of 5"},
+		{name: "last line without newline", start: 5, end: 5, wantLines: "five", wantRange: "Lines: 5-5 of 5"},
+		{name: "to the end", start: 4, end: 0, wantLines: "four\nfive", wantRange: "Lines: 4-5 of 5"},
+		{name: "end past the file is clamped", start: 3, end: 100, wantLines: "three\nfour\nfive", wantRange: "Lines: 3-5 of 5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := fs.ReadFileLines("lines.txt", tt.start, tt.end)
+			if err != nil {
+				t.Fatalf("ReadFileLines() unexpected error = %v", err)
+			}
+			if !strings.Contains(info, tt.wantRange) || !strings.Contains(info, "---\n"+tt.wantLines+"\n---") {
+				t.Errorf("Unexpected output:\n%s", info)
+			}
+		})
+	}
+
+	for _, tt := range []struct {
+		name       string
+		start, end int
+		wantErr    string
+	}{
+		{name: "start past the end", start: 6, end: 0, wantErr: "start_line 6 is past the end of 'lines.txt' (5 lines)"},
+		{name: "start below 1", start: 0, end: 2, wantErr: "invalid start_line 0"},
+		{name: "end before start", start: 3, end: 2, wantErr: "invalid range"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := fs.ReadFileLines("lines.txt", tt.start, tt.end); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestFsTool_ReadRange(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "big.txt"), []byte("a\nb\nc\nd\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	tool := NewFsTool(root)
+
+	result := tool.Call(nil, map[string]any{"operation": "read", "path": "big.txt", "end_line": float64(2)})
+	if !result.Success() || !strings.Contains(result.Data(), "Lines: 1-2 of 4") || !strings.Contains(result.Data(), "---\na\nb\n---") {
+		t.Errorf("Expected the first two lines, got %+v", result)
+	}
+
+	result = tool.Call(nil, map[string]any{"operation": "read", "path": "big.txt", "start_line": float64(1.5)})
+	if result.Success() {
+		t.Errorf("Expected a fractional line number to be rejected, got %s", result.Data())
+	}
+
+	result = tool.Call(nil, map[string]any{"operation": "read", "path": "big.txt"})
+	if !result.Success() || strings.Contains(result.Data(), "Lines:") || !strings.Contains(result.Data(), "a\nb\nc\nd\n") {
+		t.Errorf("Expected the whole file without a range, got %+v", result)
+	}
+}
@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// NewLLMTool creates a tool that sends its input to another LLM engine in a
+// one-shot completion and returns the answer. It routes sub-tasks such as
+// classification or extraction to a cheaper (or more capable) model without
+// creating a sub-agent: the engine gets no tools and no history.
+//
+// Describe what the model is for with WithLLMDescription so the agent knows
+// when to call it:
+//
+//	tools.NewLLMTool("classify", cheapLLM, "Answer with one word: bug, feature or question.").(*core.Tool).
+//	    WithLLMDescription("Classify a support ticket as bug, feature or question.")
+//
+// Parameters:
+//   - name: The tool name
+//   - engine: The engine answering the input
+//   - systemPrompt: The system prompt of the completion (empty for none)
+//
+// Returns:
+//   - llms.Tool: The tool
+func NewLLMTool(name string, engine llms.LLMEngine, systemPrompt string) llms.Tool {
+	if name == "" {
+		panic("NewLLMTool: name is required")
+	}
+	if engine == nil {
+		panic("NewLLMTool: engine is required")
+	}
+
+	return core.NewTool(
+		name,
+		"Send an input to a separate language model and return its answer.",
+		`Advanced Details:
+- Parameters:
+  * input (string, required): The complete prompt for the model
+- Behavior:
+  * Runs a single completion and returns the model's text answer
+  * The model does not see this conversation: include all the context it needs in input`,
+		`Troubleshooting:
+- "input must not be empty": Provide the prompt in input
+- "completion failed": The model could not be reached - retry later or answer without it
+- "empty answer": The model returned no text - rephrase the input`,
+		[]core.Parameter{
+			{Name: "input", Type: "string", Description: "The complete prompt for the model", Required: true},
+		},
+		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+			input := strings.TrimSpace(args["input"].(string))
+			if input == "" {
+				return core.NewErrorResponse("input must not be empty")
+			}
+
+			ctx, _ := agentContext[core.ContextTraceContext].(context.Context)
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			var messages []llms.UnifiedMessage
+			if systemPrompt != "" {
+				messages = append(messages, llms.SystemMessage(systemPrompt))
+			}
+			messages = append(messages, llms.UserMessage(input))
+
+			answer, err := completeOnce(ctx, engine, messages)
+			if err != nil {
+				return core.NewErrorResponse(fmt.Sprintf("completion failed: %v", err))
+			}
+			if answer == "" {
+				return core.NewErrorResponse("empty answer")
+			}
+			return core.NewSuccessResponse(answer)
+		},
+	)
+}
+
+// completeOnce runs a completion without tools and returns its trimmed text.
+// The stream is cancelled when ctx is done.
+func completeOnce(ctx context.Context, engine llms.LLMEngine, messages []llms.UnifiedMessage) (string, error) {
+	responseCh := engine.ChatStream(messages, nil)
+
+	var content, finalContent string
+	errCh := responseCh.Error
+	for {
+		select {
+		case chunkBytes, ok := <-responseCh.Response:
+			if !ok {
+				// An error sent before the close may still be buffered
+				select {
+				case err, ok := <-errCh:
+					if ok && err != nil {
+						return "", err
+					}
+				default:
+				}
+				if finalContent == "" {
+					finalContent = content
+				}
+				return strings.TrimSpace(finalContent), nil
+			}
+			var chunk llms.ChunkResponse
+			if err := json.Unmarshal(chunkBytes, &chunk); err != nil {
+				return "", fmt.Errorf("failed to deserialize chunk: %w", err)
+			}
+			switch chunk.Type {
+			case llms.TypeContent:
+				content += chunk.Content
+			case llms.TypeCompletion:
+				finalContent = chunk.FullContent
+			}
+		case err, ok := <-errCh:
+			if !ok {
+				errCh = nil
+				continue
+			}
+			return "", err
+		case <-ctx.Done():
+			responseCh.Cancel()
+			return "", ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// answeringEngine is an llms.LLMEngine answering every request with a fixed
+// text (or error), recording the messages it received.
+type answeringEngine struct {
+	answer string
+	err    error
+
+	mu       sync.Mutex
+	messages []llms.UnifiedMessage
+}
+
+func (e *answeringEngine) ChatStream(messages []llms.UnifiedMessage, tools []llms.Tool) *llms.ResponseCh {
+	e.mu.Lock()
+	e.messages = messages
+	e.mu.Unlock()
+
+	rc := llms.NewResponseCh()
+	go func() {
+		defer close(rc.Response)
+		if e.err != nil {
+			rc.Error <- e.err
+			return
+		}
+		for _, chunk := range []llms.ChunkResponse{
+			{Content: e.answer, Delta: e.answer, FullContent: e.answer, Status: llms.StatusStreaming, Type: llms.TypeContent},
+			{FullContent: e.answer, Status: llms.StatusCompleted, Type: llms.TypeCompletion},
+		} {
+			chunkBytes, _ := json.Marshal(chunk)
+			rc.Response <- chunkBytes
+		}
+	}()
+	return rc
+}
+
+func TestLLMTool(t *testing.T) {
+	engine := &answeringEngine{answer: " bug\n"}
+	tool := NewLLMTool("classify", engine, "Answer with one word: bug, feature or question.")
+	if tool.GetName() != "classify" {
+		t.Errorf("Expected the tool to be named classify, got %q", tool.GetName())
+	}
+
+	result := tool.Call(nil, map[string]any{"input": "The app crashes on start"})
+	if !result.Success() || result.Data() != "bug" {
+		t.Fatalf("Expected the trimmed answer, got success=%v data=%q error=%q", result.Success(), result.Data(), result.Error())
+	}
+
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+	if len(engine.messages) != 2 {
+		t.Fatalf("Expected a system and a user message, got %d messages", len(engine.messages))
+	}
+	if engine.messages[0].Role() != llms.MessageRoleSystem || !strings.HasPrefix(engine.messages[0].Content(), "Answer with one word") {
+		t.Errorf("Expected the system prompt first, got %s %q", engine.messages[0].Role(), engine.messages[0].Content())
+	}
+	if engine.messages[1].Role() != llms.MessageRoleUser || engine.messages[1].Content() != "The app crashes on start" {
+		t.Errorf("Expected the input as the user message, got %s %q", engine.messages[1].Role(), engine.messages[1].Content())
+	}
+}
+
+func TestLLMTool_Errors(t *testing.T) {
+	tool := NewLLMTool("router", &answeringEngine{err: errors.New("rate limited")}, "")
+	result := tool.Call(nil, map[string]any{"input": "hello"})
+	if result.Success() || !strings.Contains(result.Error(), "completion failed: rate limited") {
+		t.Errorf("Expected the engine error, got success=%v error=%q", result.Success(), result.Error())
+	}
+
+	tool = NewLLMTool("router", &answeringEngine{answer: "  "}, "")
+	if result := tool.Call(nil, map[string]any{"input": "hello"}); result.Success() || result.Error() != "empty answer" {
+		t.Errorf("Expected an empty answer error, got success=%v error=%q", result.Success(), result.Error())
+	}
+	if result := tool.Call(nil, map[string]any{"input": " "}); result.Success() || result.Error() != "input must not be empty" {
+		t.Errorf("Expected an empty input error, got success=%v error=%q", result.Success(), result.Error())
+	}
+}
@@ -0,0 +1,242 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// VectorRecord is a text stored with its embedding.
+type VectorRecord struct {
+	ID     string
+	Text   string
+	Vector []float32
+}
+
+// VectorMatch is a record returned by a similarity query.
+type VectorMatch struct {
+	VectorRecord
+	// Score is the similarity to the query vector (higher is closer)
+	Score float64
+}
+
+// VectorStore persists embedded texts and finds the ones closest to a query vector.
+//
+// Implement it to back the memory tool with a vector database.
+type VectorStore interface {
+	// Add stores the text with its vector and returns the record ID.
+	Add(ctx context.Context, text string, vector []float32) (string, error)
+
+	// Query returns at most k records ordered by decreasing similarity.
+	Query(ctx context.Context, vector []float32, k int) ([]VectorMatch, error)
+}
+
+// MemoryVectorStore is an in-memory VectorStore ranking records by cosine similarity.
+//
+// It is safe for concurrent use. Records are lost when the process exits.
+type MemoryVectorStore struct {
+	mu      sync.RWMutex
+	records []VectorRecord
+}
+
+// NewMemoryVectorStore creates an empty in-memory vector store.
+func NewMemoryVectorStore() *MemoryVectorStore {
+	return &MemoryVectorStore{}
+}
+
+// Add implements VectorStore. All vectors must have the same dimension.
+func (s *MemoryVectorStore) Add(ctx context.Context, text string, vector []float32) (string, error) {
+	if len(vector) == 0 {
+		return "", fmt.Errorf("vector must not be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.records) > 0 && len(s.records[0].Vector) != len(vector) {
+		return "", fmt.Errorf("vector dimension %d does not match store dimension %d", len(vector), len(s.records[0].Vector))
+	}
+	id := fmt.Sprintf("mem-%d", len(s.records)+1)
+	s.records = append(s.records, VectorRecord{
+		ID:     id,
+		Text:   text,
+		Vector: append([]float32(nil), vector...),
+	})
+	return id, nil
+}
+
+// Query implements VectorStore.
+func (s *MemoryVectorStore) Query(ctx context.Context, vector []float32, k int) ([]VectorMatch, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.records) > 0 && len(s.records[0].Vector) != len(vector) {
+		return nil, fmt.Errorf("vector dimension %d does not match store dimension %d", len(vector), len(s.records[0].Vector))
+	}
+
+	matches := make([]VectorMatch, 0, len(s.records))
+	for _, record := range s.records {
+		matches = append(matches, VectorMatch{
+			VectorRecord: record,
+			Score:        cosineSimilarity(vector, record.Vector),
+		})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if k >= 0 && len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+// Len returns the number of stored records.
+func (s *MemoryVectorStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.records)
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b (0 if either is a zero vector).
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// Recall limits of the memory tool.
+const (
+	DefaultRecallResults = 3
+	MaxRecallResults     = 20
+)
+
+// NewMemoryTool creates a tool that lets an agent store notes and recall the
+// ones most similar to a query.
+//
+// Parameters:
+//   - store: Where notes are kept (e.g., NewMemoryVectorStore())
+//   - embedder: Embeds notes and queries (e.g., llms.NewEmbeddingEngine(llm, ""))
+func NewMemoryTool(store VectorStore, embedder llms.EmbeddingEngine) llms.Tool {
+	if store == nil {
+		panic("NewMemoryTool: store is required")
+	}
+	if embedder == nil {
+		panic("NewMemoryTool: embedder is required")
+	}
+
+	tool := core.NewTool(
+		"memory",
+		"Store notes in long-term memory and recall the notes most relevant to a query.",
+		fmt.Sprintf(`Advanced Details:
+- Parameters:
+  * operation (string, required): "store" to save a note, "recall" to search notes
+  * text (string, required): The note to store, or the query to recall notes for
+  * k (number, optional): Number of notes to recall, 1 to %d (default %d)
+- Behavior:
+  * store saves the note and returns its ID
+  * recall returns the closest notes by meaning, most similar first, with a similarity score
+- Usage:
+  * Store self-contained facts ("The user prefers metric units") rather than fragments
+  * Recall before answering questions that may depend on earlier conversations`, MaxRecallResults, DefaultRecallResults),
+		`Troubleshooting:
+- "text must not be empty": Provide the note or query text
+- "k must be an integer between 1 and N": Use a whole number within the limit
+- "embedding failed": The embedding provider returned an error - retry later
+- No memories: Nothing similar has been stored yet`,
+		[]core.Parameter{
+			{Name: "operation", Type: "string", Description: "The operation to perform", Required: true, Enum: []any{"store", "recall"}},
+			{Name: "text", Type: "string", Description: "The note to store or the query to recall notes for", Required: true},
+			{
+				Name:        "k",
+				Type:        "number",
+				Description: fmt.Sprintf("Number of notes to recall (1-%d)", MaxRecallResults),
+				Default:     DefaultRecallResults,
+				Validator:   validateRecallK,
+			},
+		},
+		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+			text := strings.TrimSpace(args["text"].(string))
+			if text == "" {
+				return core.NewErrorResponse("text must not be empty")
+			}
+
+			ctx, _ := agentContext[core.ContextTraceContext].(context.Context)
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			vectors, err := embedder.Embed(ctx, []string{text})
+			if err != nil {
+				return core.NewErrorResponse(fmt.Sprintf("embedding failed: %v", err))
+			}
+			if len(vectors) != 1 {
+				return core.NewErrorResponse(fmt.Sprintf("embedding failed: expected 1 vector, got %d", len(vectors)))
+			}
+
+			switch args["operation"].(string) {
+			case "store":
+				id, err := store.Add(ctx, text, vectors[0])
+				if err != nil {
+					return core.NewErrorResponse(fmt.Sprintf("failed to store memory: %v", err))
+				}
+				return core.NewSuccessResponse(fmt.Sprintf("Stored memory %s", id))
+			default:
+				k := DefaultRecallResults
+				if n, ok := toNumber(args["k"]); ok {
+					k = int(n)
+				}
+				matches, err := store.Query(ctx, vectors[0], k)
+				if err != nil {
+					return core.NewErrorResponse(fmt.Sprintf("failed to recall memories: %v", err))
+				}
+				return core.NewSuccessResponse(FormatMemories(text, matches))
+			}
+		},
+	)
+	// Recalls depend on what was stored since, and stores must not be skipped
+	tool.(*core.Tool).SetCacheable(false)
+	return tool
+}
+
+// validateRecallK checks k is a whole number within the allowed range.
+func validateRecallK(value any) error {
+	n, ok := toNumber(value)
+	if !ok || n != math.Trunc(n) || n < 1 || n > MaxRecallResults {
+		return fmt.Errorf("k must be an integer between 1 and %d", MaxRecallResults)
+	}
+	return nil
+}
+
+// FormatMemories renders recalled memories as a numbered list, most similar first.
+//
+// Parameters:
+//   - query: The query the memories were recalled for
+//   - matches: The recalled memories
+//
+// Returns:
+//   - string: The formatted memories
+func FormatMemories(query string, matches []VectorMatch) string {
+	if len(matches) == 0 {
+		return fmt.Sprintf("No memories found for %q.", query)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Memories for %q (%d):\n", query, len(matches))
+	for i, m := range matches {
+		fmt.Fprintf(&b, "%d. [%s, score %.3f] %s\n", i+1, m.ID, m.Score, m.Text)
+	}
+	return b.String()
+}
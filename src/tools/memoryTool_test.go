@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// keywordEmbedder is a deterministic embedder counting topic keywords,
+// so texts about the same topic get similar vectors.
+type keywordEmbedder struct {
+	calls int
+}
+
+var embedderTopics = [][]string{
+	{"cat", "cats", "kitten", "pet"},
+	{"go", "golang", "goroutine", "compiler"},
+	{"pizza", "pasta", "recipe", "cook"},
+}
+
+func (e *keywordEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.calls++
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector := make([]float32, len(embedderTopics))
+		for _, word := range strings.Fields(strings.ToLower(text)) {
+			for topic, keywords := range embedderTopics {
+				for _, keyword := range keywords {
+					if strings.Trim(word, ".,?!") == keyword {
+						vector[topic]++
+					}
+				}
+			}
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+func TestMemoryTool(t *testing.T) {
+	store := NewMemoryVectorStore()
+	tool := NewMemoryTool(store, &keywordEmbedder{})
+
+	notes := []string{
+		"My pet cat is called Miso",
+		"The project is written in Go and uses a goroutine per request",
+		"Grandma's pasta recipe needs fresh basil",
+	}
+	for _, note := range notes {
+		result := tool.Call(nil, map[string]any{"operation": "store", "text": note})
+		if !result.Success() || !strings.HasPrefix(result.Data(), "Stored memory mem-") {
+			t.Fatalf("store %q = %+v", note, result)
+		}
+	}
+	if store.Len() != 3 {
+		t.Fatalf("Expected 3 stored memories, got %d", store.Len())
+	}
+
+	t.Run("recall ranks the closest note first", func(t *testing.T) {
+		result := tool.Call(nil, map[string]any{"operation": "recall", "text": "what does the golang compiler do?", "k": float64(2)})
+		if !result.Success() {
+			t.Fatalf("Expected success, got error: %s", result.Error())
+		}
+		lines := strings.Split(strings.TrimSpace(result.Data()), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("Expected header and 2 memories, got:\n%s", result.Data())
+		}
+		if !strings.Contains(lines[1], "written in Go") {
+			t.Errorf("Expected the Go note first, got:\n%s", result.Data())
+		}
+		if !strings.Contains(lines[1], "score 1.000") {
+			t.Errorf("Expected a perfect score for the Go note, got %q", lines[1])
+		}
+	})
+
+	t.Run("store ranks directly", func(t *testing.T) {
+		vectors, _ := (&keywordEmbedder{}).Embed(context.Background(), []string{"how do I cook pizza"})
+		matches, err := store.Query(context.Background(), vectors[0], 10)
+		if err != nil {
+			t.Fatalf("Query() unexpected error = %v", err)
+		}
+		if len(matches) != 3 || matches[0].Text != notes[2] {
+			t.Errorf("Expected the recipe note first, got %+v", matches)
+		}
+		for i := 1; i < len(matches); i++ {
+			if matches[i].Score > matches[i-1].Score {
+				t.Errorf("Matches not sorted by score: %+v", matches)
+			}
+		}
+	})
+
+	t.Run("argument validation", func(t *testing.T) {
+		cases := []struct {
+			args map[string]any
+			want string
+		}{
+			{map[string]any{"operation": "forget", "text": "x"}, "invalid value for operation"},
+			{map[string]any{"operation": "store", "text": "  "}, "text must not be empty"},
+			{map[string]any{"operation": "recall", "text": "cat", "k": float64(0)}, "k must be an integer between 1 and 20"},
+		}
+		for _, c := range cases {
+			result := tool.Call(nil, c.args)
+			if result.Success() || !strings.Contains(result.Error(), c.want) {
+				t.Errorf("Call(%v) = %+v, want error containing %q", c.args, result, c.want)
+			}
+		}
+	})
+
+	t.Run("empty store and dimension mismatch", func(t *testing.T) {
+		empty := NewMemoryTool(NewMemoryVectorStore(), &keywordEmbedder{})
+		if result := empty.Call(nil, map[string]any{"operation": "recall", "text": "cat"}); result.Data() != `No memories found for "cat".` {
+			t.Errorf("Unexpected empty output: %q", result.Data())
+		}
+		if _, err := store.Add(context.Background(), "bad", []float32{1}); err == nil {
+			t.Error("Expected dimension mismatch error")
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// Progress reports the progress of a long-running tool to the consumer of the
+// agent's stream, as a TypeContent chunk with Trace llms.TraceToolProgress.
+// Progress chunks are not part of the agent's answer.
+//
+// It does nothing when agentContext has no response channel (e.g. a tool called
+// directly), when the consumer stopped the stream or when the run's context is done.
+//
+// Example:
+//
+//	func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+//	    tools.Progress(agentContext, "Downloading page 1/3...")
+//	    ...
+//	}
+//
+// Parameters:
+//   - agentContext: The agent context passed to the tool handler
+//   - message: The progress message
+func Progress(agentContext map[string]any, message string) {
+	responseCh, ok := agentContext["responseCh"].(*core.ResponseCh)
+	if !ok || responseCh == nil {
+		return
+	}
+
+	chunk := llms.ChunkResponse{
+		Status:  llms.StatusStreaming,
+		Type:    llms.TypeContent,
+		Content: message,
+		Delta:   message,
+		Trace:   llms.TraceToolProgress,
+	}
+	chunkBytes, err := json.Marshal(chunk)
+	if err != nil {
+		return
+	}
+
+	ctx, _ := agentContext[core.ContextTraceContext].(context.Context)
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	forwardToParent(ctx, responseCh, chunkBytes)
+}
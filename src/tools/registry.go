@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// Registry holds tools by name. Names are unique within a registry.
+//
+// A Registry is safe for concurrent use and can be shared by several agents
+// through AgentConfig.ToolRegistry.
+type Registry struct {
+	mu    sync.RWMutex
+	tools map[string]llms.Tool
+	// order keeps the registration order for All
+	order []string
+}
+
+// NewRegistry creates a registry holding the given tools.
+//
+// Parameters:
+//   - tools: The tools to register, in order
+//
+// Returns:
+//   - *Registry: A new registry
+//   - error: An error if a tool is nil or two tools share a name
+func NewRegistry(tools ...llms.Tool) (*Registry, error) {
+	r := &Registry{tools: make(map[string]llms.Tool)}
+	for _, tool := range tools {
+		if err := r.Register(tool); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Register adds a tool to the registry.
+//
+// Parameters:
+//   - tool: The tool to add
+//
+// Returns:
+//   - error: An error if tool is nil or a tool with the same name is already registered
+func (r *Registry) Register(tool llms.Tool) error {
+	if tool == nil {
+		return fmt.Errorf("cannot register a nil tool")
+	}
+	name := tool.GetName()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.tools == nil {
+		r.tools = make(map[string]llms.Tool)
+	}
+	if _, ok := r.tools[name]; ok {
+		return fmt.Errorf("tool %q is already registered", name)
+	}
+	r.tools[name] = tool
+	r.order = append(r.order, name)
+	return nil
+}
+
+// Get returns the tool registered under name and whether it was found.
+func (r *Registry) Get(name string) (llms.Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tool, ok := r.tools[name]
+	return tool, ok
+}
+
+// All returns the registered tools in registration order.
+func (r *Registry) All() []llms.Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tools := make([]llms.Tool, 0, len(r.order))
+	for _, name := range r.order {
+		tools = append(tools, r.tools[name])
+	}
+	return tools
+}
+
+// NewBuiltinRegistry creates a registry holding the built-in tools that need no
+// configuration: foo, calculator, reverse, uppercase and fail. Register more
+// tools (e.g. NewFsTool or NewSearchTool) on it as needed.
+//
+// Returns:
+//   - *Registry: A new registry with the built-in tools
+func NewBuiltinRegistry() *Registry {
+	r, err := NewRegistry(NewFooTool(), NewCalculatorTool(), NewReverseTool(), NewUppercaseTool(), NewFailTool())
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
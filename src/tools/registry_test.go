@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	calculator := NewCalculatorTool()
+	registry, err := NewRegistry(calculator, newMockDiscoverableTool())
+	if err != nil {
+		t.Fatalf("NewRegistry() unexpected error = %v", err)
+	}
+
+	if tool, ok := registry.Get("calculator"); !ok || tool != calculator {
+		t.Errorf("Expected to find the calculator tool, got %v", tool)
+	}
+	if _, ok := registry.Get("unknown"); ok {
+		t.Error("Expected unknown tool to be missing")
+	}
+
+	err = registry.Register(NewCalculatorTool())
+	if err == nil || !strings.Contains(err.Error(), `"calculator" is already registered`) {
+		t.Errorf("Expected duplicate name to be rejected, got %v", err)
+	}
+	if err := registry.Register(nil); err == nil {
+		t.Error("Expected nil tool to be rejected")
+	}
+	if err := registry.Register(plainTool{}); err != nil {
+		t.Fatalf("Register() unexpected error = %v", err)
+	}
+
+	var names []string
+	for _, tool := range registry.All() {
+		names = append(names, tool.GetName())
+	}
+	if strings.Join(names, ",") != "calculator,mock-tool,plain-tool" {
+		t.Errorf("Expected tools in registration order, got %v", names)
+	}
+}
+
+func TestNewRegistry_Duplicate(t *testing.T) {
+	if _, err := NewRegistry(plainTool{}, plainTool{}); err == nil {
+		t.Error("Expected NewRegistry to reject duplicate names")
+	}
+}
+
+func TestNewBuiltinRegistry(t *testing.T) {
+	registry := NewBuiltinRegistry()
+	for _, name := range []string{"foo", "calculator", "reverse", "uppercase", "fail"} {
+		if _, ok := registry.Get(name); !ok {
+			t.Errorf("Expected built-in tool %q", name)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// renamedTool exposes a tool under another name.
+type renamedTool struct {
+	wrappedTool
+	name string
+}
+
+// WithName wraps a tool so it is exposed under another name, e.g. to give one
+// agent two instances of the same tool ("fs_docs" and "fs_src"). It works for
+// any llms.Tool, including wrapped ones such as WithRetry, and leaves the
+// original tool untouched, so a tool shared through a Registry can be renamed
+// for one agent only. The wrapped tool keeps its definition, approval and
+// caching settings.
+//
+// Example:
+//
+//	docs := tools.WithName(tools.NewFsTool("./docs"), "fs_docs")
+//
+// Parameters:
+//   - tool: The tool to rename
+//   - name: The new name
+//
+// Returns:
+//   - llms.Tool: The renamed tool
+func WithName(tool llms.Tool, name string) llms.Tool {
+	return withDiscovery(&renamedTool{wrappedTool: wrappedTool{tool}, name: name}, tool)
+}
+
+// GetName implements llms.Tool.
+func (t *renamedTool) GetName() string {
+	return t.name
+}
+
+// GetFunctionDefinition implements llms.Tool with the new name.
+func (t *renamedTool) GetFunctionDefinition() llms.FunctionDefinition {
+	def := t.Tool.GetFunctionDefinition()
+	def.Name = t.name
+	return def
+}
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"testing"
+
+	agentforge "github.com/thinktwice/agentForge/src"
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+func TestWithName(t *testing.T) {
+	tool, calls := newFlakyTool(0)
+	tool.(*core.Tool).SetRequiresApproval(true)
+
+	renamed := WithName(WithRetry(tool, 2, nil), "flaky_docs")
+	if renamed.GetName() != "flaky_docs" || renamed.GetFunctionDefinition().Name != "flaky_docs" {
+		t.Errorf("Expected the new name in the tool and its definition, got %q and %q",
+			renamed.GetName(), renamed.GetFunctionDefinition().Name)
+	}
+	if tool.GetName() != "flaky" {
+		t.Errorf("Expected the original tool to keep its name, got %q", tool.GetName())
+	}
+	if at, ok := renamed.(interface{ RequiresApproval() bool }); !ok || !at.RequiresApproval() {
+		t.Error("Expected the renamed tool to still require approval")
+	}
+
+	if result := renamed.Call(map[string]any{}, map[string]any{}); !result.Success() || *calls != 1 {
+		t.Errorf("Expected the call to reach the original tool, got %+v after %d calls", result, *calls)
+	}
+}
+
+func TestWithName_Discoverable(t *testing.T) {
+	renamed := WithName(NewFooTool(), "echo")
+	if d, ok := renamed.(interface{ BasicDescription() string }); !ok || d.BasicDescription() == "" {
+		t.Error("Expected the renamed tool to keep its description")
+	}
+
+	for name, wrapped := range map[string]llms.Tool{
+		"WithName":  WithName(plainTool{}, "plain"),
+		"WithRetry": WithRetry(plainTool{}, 2, nil),
+	} {
+		if _, ok := wrapped.(agentforge.Discoverable); ok {
+			t.Errorf("%s: expected a wrapped non-discoverable tool not to be Discoverable", name)
+		}
+	}
+}
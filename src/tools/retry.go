@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	agentforge "github.com/thinktwice/agentForge/src"
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// retryTool re-invokes a tool whose call returned a failure response.
+type retryTool struct {
+	wrappedTool
+	attempts int
+	backoff  func(attempt int) time.Duration
+}
+
+// WithRetry wraps a tool so a call returning a failure response is retried,
+// e.g. for network-backed tools. Panics are not retried.
+//
+// Before retry n (1 for the first retry) the wrapper waits backoff(n). It stops
+// early when the tool call's context (core.ContextTraceContext) is done or its
+// deadline would pass during the wait, and returns the last failure response.
+// The wrapped tool keeps its name, definition, approval and caching settings.
+//
+// Example:
+//
+//	search := tools.WithRetry(tools.NewSearchTool(provider), 3, tools.ExponentialBackoff(500*time.Millisecond))
+//
+// Parameters:
+//   - tool: The tool to wrap
+//   - attempts: The maximum number of calls (values below 1 mean 1)
+//   - backoff: The wait before each retry (nil retries immediately)
+//
+// Returns:
+//   - llms.Tool: The wrapped tool
+func WithRetry(tool llms.Tool, attempts int, backoff func(attempt int) time.Duration) llms.Tool {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return withDiscovery(&retryTool{wrappedTool: wrappedTool{tool}, attempts: attempts, backoff: backoff}, tool)
+}
+
+// ExponentialBackoff returns a backoff doubling from base: base, 2*base, 4*base...
+func ExponentialBackoff(base time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		return base << (attempt - 1)
+	}
+}
+
+// Call implements llms.Tool.
+func (t *retryTool) Call(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+	ctx, _ := agentContext[core.ContextTraceContext].(context.Context)
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	result := t.Tool.Call(agentContext, args)
+	for retry := 1; retry < t.attempts && result != nil && !result.Success(); retry++ {
+		var wait time.Duration
+		if t.backoff != nil {
+			wait = t.backoff(retry)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			break
+		}
+
+		agentforge.Debug("Tool '%s' failed (%s), retry %d/%d in %s", t.GetName(), result.Error(), retry, t.attempts-1, wait)
+		Progress(agentContext, fmt.Sprintf("Retrying %s (%d/%d)...", t.GetName(), retry, t.attempts-1))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result
+		case <-timer.C:
+		}
+
+		result = t.Tool.Call(agentContext, args)
+	}
+	return result
+}
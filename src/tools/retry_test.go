@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// newFlakyTool returns a tool failing the first failures calls, and its call counter.
+func newFlakyTool(failures int) (llms.Tool, *int) {
+	calls := 0
+	tool := core.NewTool("flaky", "Fails a few times", "", "", nil,
+		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+			calls++
+			if calls <= failures {
+				return core.NewErrorResponse("temporarily unavailable")
+			}
+			return core.NewSuccessResponse("ok")
+		},
+	)
+	return tool, &calls
+}
+
+func TestWithRetry(t *testing.T) {
+	var waits []time.Duration
+	backoff := func(attempt int) time.Duration {
+		wait := ExponentialBackoff(time.Millisecond)(attempt)
+		waits = append(waits, wait)
+		return wait
+	}
+
+	tool, calls := newFlakyTool(2)
+	result := WithRetry(tool, 3, backoff).Call(map[string]any{}, map[string]any{})
+	if !result.Success() || result.Data() != "ok" {
+		t.Errorf("Expected success after retries, got success=%v error=%q", result.Success(), result.Error())
+	}
+	if *calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", *calls)
+	}
+	if len(waits) != 2 || waits[0] != time.Millisecond || waits[1] != 2*time.Millisecond {
+		t.Errorf("Unexpected backoff waits %v", waits)
+	}
+}
+
+func TestWithRetry_Exhausted(t *testing.T) {
+	tool, calls := newFlakyTool(5)
+	wrapped := WithRetry(tool, 3, nil)
+
+	result := wrapped.Call(map[string]any{}, map[string]any{})
+	if result.Success() || result.Error() != "temporarily unavailable" {
+		t.Errorf("Expected the last failure, got success=%v error=%q", result.Success(), result.Error())
+	}
+	if *calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", *calls)
+	}
+	if wrapped.GetName() != "flaky" {
+		t.Errorf("Expected the wrapped tool's name, got %q", wrapped.GetName())
+	}
+}
+
+func TestWithRetry_ContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	tool, calls := newFlakyTool(1)
+	start := time.Now()
+	result := WithRetry(tool, 3, func(int) time.Duration { return time.Hour }).
+		Call(map[string]any{core.ContextTraceContext: ctx}, map[string]any{})
+	if result.Success() {
+		t.Error("Expected the failure when the backoff would pass the deadline")
+	}
+	if *calls != 1 || time.Since(start) > time.Second {
+		t.Errorf("Expected no retry and no wait, got %d calls in %s", *calls, time.Since(start))
+	}
+}
+
+func TestWithRetry_KeepsToolSettings(t *testing.T) {
+	tool, _ := newFlakyTool(0)
+	tool.(*core.Tool).SetRequiresApproval(true)
+	tool.(*core.Tool).SetCacheable(false)
+
+	wrapped := WithRetry(tool, 2, nil)
+	if at, ok := wrapped.(interface{ RequiresApproval() bool }); !ok || !at.RequiresApproval() {
+		t.Error("Expected the wrapped tool to still require approval")
+	}
+	if ct, ok := wrapped.(interface{ Cacheable() bool }); !ok || ct.Cacheable() {
+		t.Error("Expected the wrapped tool to still be non-cacheable")
+	}
+}
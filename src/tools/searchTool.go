@@ -0,0 +1,241 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	agentforge "github.com/thinktwice/agentForge/src"
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// SearchResult is a single web search hit.
+type SearchResult struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet"`
+}
+
+// SearchProvider runs web searches for the search tool.
+//
+// Implement it to plug in any search API.
+type SearchProvider interface {
+	// Search returns at most limit results for the query.
+	Search(ctx context.Context, query string, limit int) ([]SearchResult, error)
+}
+
+// Search result limits of the search tool.
+const (
+	DefaultSearchResults = 5
+	MaxSearchResults     = 20
+)
+
+// NewSearchTool creates a tool that searches the web through the given provider.
+//
+// Parameters:
+//   - provider: The search backend (e.g., NewTavilySearchProvider(""))
+func NewSearchTool(provider SearchProvider) llms.Tool {
+	if provider == nil {
+		panic("NewSearchTool: provider is required")
+	}
+
+	return core.NewTool(
+		"web_search",
+		"Search the web and return matching pages with their titles, URLs and snippets.",
+		fmt.Sprintf(`Advanced Details:
+- Parameters:
+  * query (string, required): The search query, e.g. "go 1.22 release notes"
+  * num_results (number, optional): Number of results to return, 1 to %d (default %d)
+- Behavior:
+  * Returns a numbered list of results, each with its title, URL and a snippet
+  * Snippets are short excerpts; fetch or read the page for full details
+- Usage:
+  * Use specific queries with key terms rather than full sentences
+  * Search again with a refined query if the results are not relevant`, MaxSearchResults, DefaultSearchResults),
+		`Troubleshooting:
+- "query must not be empty": Provide a non-empty search query
+- "num_results must be an integer between 1 and N": Use a whole number within the limit
+- "search failed": The search provider returned an error - retry later or rephrase the query
+- No results: Broaden the query or use different keywords`,
+		[]core.Parameter{
+			{Name: "query", Type: "string", Description: "The search query", Required: true},
+			{
+				Name:        "num_results",
+				Type:        "number",
+				Description: fmt.Sprintf("Number of results to return (1-%d)", MaxSearchResults),
+				Default:     DefaultSearchResults,
+				Validator:   validateNumResults,
+			},
+		},
+		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+			query := strings.TrimSpace(args["query"].(string))
+			if query == "" {
+				return core.NewErrorResponse("query must not be empty")
+			}
+			limit := DefaultSearchResults
+			if n, ok := toNumber(args["num_results"]); ok {
+				limit = int(n)
+			}
+
+			ctx, _ := agentContext[core.ContextTraceContext].(context.Context)
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			results, err := provider.Search(ctx, query, limit)
+			if err != nil {
+				return core.NewErrorResponse(fmt.Sprintf("search failed: %v", err))
+			}
+			if len(results) > limit {
+				results = results[:limit]
+			}
+			return core.NewSuccessResponse(FormatSearchResults(query, results))
+		},
+	)
+}
+
+// validateNumResults checks num_results is a whole number within the allowed range.
+func validateNumResults(value any) error {
+	n, ok := toNumber(value)
+	if !ok || n != math.Trunc(n) || n < 1 || n > MaxSearchResults {
+		return fmt.Errorf("num_results must be an integer between 1 and %d", MaxSearchResults)
+	}
+	return nil
+}
+
+// toNumber converts the numeric types produced by JSON decoding or Go callers to float64.
+func toNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// FormatSearchResults renders search results as a numbered, readable list.
+//
+// Parameters:
+//   - query: The query the results are for
+//   - results: The search results
+//
+// Returns:
+//   - string: The formatted results
+func FormatSearchResults(query string, results []SearchResult) string {
+	if len(results) == 0 {
+		return fmt.Sprintf("No results found for %q.", query)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Search results for %q (%d):\n", query, len(results))
+	for i, r := range results {
+		fmt.Fprintf(&b, "\n%d. %s\n   URL: %s\n", i+1, r.Title, r.URL)
+		if r.Snippet != "" {
+			fmt.Fprintf(&b, "   %s\n", strings.TrimSpace(r.Snippet))
+		}
+	}
+	return b.String()
+}
+
+// TAVILY_BASE_URL is the endpoint of the Tavily search API.
+const TAVILY_BASE_URL = "https://api.tavily.com"
+
+// TavilySearchProvider is a SearchProvider backed by the Tavily search API.
+type TavilySearchProvider struct {
+	// APIKey authenticates requests
+	APIKey string
+	// BaseURL is the API endpoint (default TAVILY_BASE_URL)
+	BaseURL string
+	// Client sends the requests (default: a client with a 30s timeout)
+	Client *http.Client
+}
+
+// NewTavilySearchProvider creates a Tavily search provider.
+//
+// Parameters:
+//   - apiKey: The Tavily API key. If empty, AF_TAVILY_API_KEY is used.
+//
+// Returns:
+//   - *TavilySearchProvider: A provider ready to pass to NewSearchTool
+func NewTavilySearchProvider(apiKey string) *TavilySearchProvider {
+	if apiKey == "" {
+		c, err := agentforge.NewConfig()
+		if err != nil {
+			agentforge.Error("Failed to load config: %v", err)
+		} else {
+			apiKey = c.AFTavilyAPIKey
+		}
+	}
+	if apiKey == "" {
+		agentforge.Warn("No API key found for Tavily search: set AF_TAVILY_API_KEY")
+	}
+	return &TavilySearchProvider{
+		APIKey:  apiKey,
+		BaseURL: TAVILY_BASE_URL,
+		Client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// tavilyResponse is the subset of the Tavily search response used by the provider.
+type tavilyResponse struct {
+	Results []struct {
+		Title   string `json:"title"`
+		URL     string `json:"url"`
+		Content string `json:"content"`
+	} `json:"results"`
+}
+
+// Search implements SearchProvider.
+func (p *TavilySearchProvider) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	if p.APIKey == "" {
+		return nil, fmt.Errorf("no Tavily API key: set AF_TAVILY_API_KEY")
+	}
+
+	body, err := json.Marshal(map[string]any{"query": query, "max_results": limit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(p.BaseURL, "/")+"/search", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.APIKey)
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("tavily returned %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+
+	var decoded tavilyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	results := make([]SearchResult, 0, len(decoded.Results))
+	for _, r := range decoded.Results {
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Content})
+	}
+	return results, nil
+}
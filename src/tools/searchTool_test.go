@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// mockSearchProvider records the last search and returns scripted results.
+type mockSearchProvider struct {
+	results   []SearchResult
+	err       error
+	lastQuery string
+	lastLimit int
+}
+
+func (m *mockSearchProvider) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	m.lastQuery = query
+	m.lastLimit = limit
+	return m.results, m.err
+}
+
+func TestSearchTool(t *testing.T) {
+	provider := &mockSearchProvider{results: []SearchResult{
+		{Title: "Go 1.22 Release Notes", URL: "https://go.dev/doc/go1.22", Snippet: "Go 1.22 changes loop variables."},
+		{Title: "Range over int", URL: "https://go.dev/blog/range", Snippet: ""},
+	}}
+	tool := NewSearchTool(provider)
+
+	t.Run("formats results", func(t *testing.T) {
+		result := tool.Call(nil, map[string]any{"query": " go 1.22 "})
+		if !result.Success() {
+			t.Fatalf("Expected success, got error: %s", result.Error())
+		}
+		if provider.lastQuery != "go 1.22" || provider.lastLimit != DefaultSearchResults {
+			t.Errorf("Expected trimmed query with default limit, got %q / %d", provider.lastQuery, provider.lastLimit)
+		}
+		expected := `Search results for "go 1.22" (2):
+
+1. Go 1.22 Release Notes
+   URL: https://go.dev/doc/go1.22
+   Go 1.22 changes loop variables.
+
+2. Range over int
+   URL: https://go.dev/blog/range
+`
+		if result.Data() != expected {
+			t.Errorf("Unexpected formatting:\n%s", result.Data())
+		}
+	})
+
+	t.Run("num_results is passed and enforced", func(t *testing.T) {
+		result := tool.Call(nil, map[string]any{"query": "go", "num_results": float64(1)})
+		if !result.Success() {
+			t.Fatalf("Expected success, got error: %s", result.Error())
+		}
+		if provider.lastLimit != 1 {
+			t.Errorf("Expected limit 1, got %d", provider.lastLimit)
+		}
+		if strings.Contains(result.Data(), "Range over int") {
+			t.Errorf("Expected results capped at num_results, got:\n%s", result.Data())
+		}
+	})
+
+	t.Run("argument validation", func(t *testing.T) {
+		cases := []struct {
+			args map[string]any
+			want string
+		}{
+			{map[string]any{}, "missing required parameter: query"},
+			{map[string]any{"query": "   "}, "query must not be empty"},
+			{map[string]any{"query": "go", "num_results": float64(0)}, "num_results must be an integer between 1 and 20"},
+			{map[string]any{"query": "go", "num_results": float64(2.5)}, "num_results must be an integer between 1 and 20"},
+			{map[string]any{"query": "go", "num_results": float64(21)}, "num_results must be an integer between 1 and 20"},
+		}
+		for _, c := range cases {
+			result := tool.Call(nil, c.args)
+			if result.Success() || !strings.Contains(result.Error(), c.want) {
+				t.Errorf("Call(%v) = %+v, want error containing %q", c.args, result, c.want)
+			}
+		}
+	})
+
+	t.Run("provider errors and empty results", func(t *testing.T) {
+		failing := NewSearchTool(&mockSearchProvider{err: errors.New("quota exceeded")})
+		if result := failing.Call(nil, map[string]any{"query": "go"}); result.Success() || result.Error() != "search failed: quota exceeded" {
+			t.Errorf("Expected provider error, got %+v", result)
+		}
+
+		empty := NewSearchTool(&mockSearchProvider{})
+		if result := empty.Call(nil, map[string]any{"query": "zzz"}); result.Data() != `No results found for "zzz".` {
+			t.Errorf("Unexpected empty output: %q", result.Data())
+		}
+	})
+}
+
+func TestTavilySearchProvider(t *testing.T) {
+	var received map[string]any
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" {
+			http.NotFound(w, r)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"results":[{"title":"Go","url":"https://go.dev","content":"The Go language"}]}`))
+	}))
+	defer server.Close()
+
+	provider := NewTavilySearchProvider("test-key")
+	provider.BaseURL = server.URL
+
+	results, err := provider.Search(context.Background(), "golang", 3)
+	if err != nil {
+		t.Fatalf("Search() unexpected error = %v", err)
+	}
+	if auth != "Bearer test-key" {
+		t.Errorf("Expected bearer auth, got %q", auth)
+	}
+	if received["query"] != "golang" || received["max_results"] != float64(3) {
+		t.Errorf("Unexpected request body: %v", received)
+	}
+	if len(results) != 1 || results[0].URL != "https://go.dev" || results[0].Snippet != "The Go language" {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+
+	provider.BaseURL = server.URL + "/missing"
+	if _, err := provider.Search(context.Background(), "golang", 3); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected HTTP status error, got %v", err)
+	}
+}
@@ -0,0 +1,367 @@
+package tools
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// Result limits of the SQL tool.
+const (
+	DefaultSQLMaxRows    = 100
+	DefaultSQLMaxColumns = 50
+)
+
+// Result formats of the SQL tool.
+const (
+	SQLFormatTable = "table" // Markdown-style table (default)
+	SQLFormatJSON  = "json"  // {"columns": [...], "rows": [[...]], "truncated": bool}
+)
+
+// SQLToolOptions configures NewSQLTool.
+type SQLToolOptions struct {
+	// MaxRows is the maximum number of rows returned by a query. Default: DefaultSQLMaxRows
+	MaxRows int
+	// MaxColumns is the maximum number of columns returned by a query. Default: DefaultSQLMaxColumns
+	MaxColumns int
+	// Format is the result format, SQLFormatTable or SQLFormatJSON. Default: SQLFormatTable
+	Format string
+	// AllowWrites adds the "execute" operation for statements that modify the
+	// database. The tool then requires approval (see agents.AgentConfig.Approver);
+	// the Approver can let "query" calls through by inspecting the operation argument.
+	AllowWrites bool
+}
+
+// readOnlySQLKeywords are the statements the query operation accepts.
+var readOnlySQLKeywords = map[string]bool{
+	"select": true, "with": true, "values": true, "explain": true, "show": true, "describe": true,
+}
+
+// writeSQLKeywords are rejected anywhere in a query (e.g. "WITH ... DELETE" or "SELECT ... INTO").
+var writeSQLKeywords = map[string]bool{
+	"insert": true, "update": true, "delete": true, "merge": true, "upsert": true,
+	"create": true, "drop": true, "alter": true, "truncate": true, "into": true,
+	"attach": true, "detach": true, "grant": true, "revoke": true, "vacuum": true,
+}
+
+// NewSQLTool creates a tool that runs SQL statements against a database. Values
+// are always bound through the params argument, never interpolated, and only a
+// single statement is accepted per call.
+//
+// Queries run in a transaction that is rolled back, so a read-only query cannot
+// change the database even if the driver accepts a write.
+//
+// Parameters:
+//   - db: The database (the caller keeps ownership and closes it)
+//   - opts: Row/column limits, result format and whether writes are allowed
+//
+// Returns:
+//   - llms.Tool: The "sql" tool
+func NewSQLTool(db *sql.DB, opts SQLToolOptions) llms.Tool {
+	if db == nil {
+		panic("NewSQLTool: db is required")
+	}
+	if opts.MaxRows <= 0 {
+		opts.MaxRows = DefaultSQLMaxRows
+	}
+	if opts.MaxColumns <= 0 {
+		opts.MaxColumns = DefaultSQLMaxColumns
+	}
+	switch opts.Format {
+	case "":
+		opts.Format = SQLFormatTable
+	case SQLFormatTable, SQLFormatJSON:
+	default:
+		panic(fmt.Sprintf("NewSQLTool: unknown format %q", opts.Format))
+	}
+
+	operations := []any{"query"}
+	operationHelp := `"query" runs a read-only statement (SELECT, WITH, VALUES, EXPLAIN...)`
+	if opts.AllowWrites {
+		operations = append(operations, "execute")
+		operationHelp += `, "execute" runs a statement that modifies the database (INSERT, UPDATE, DELETE, CREATE...)`
+	}
+
+	tool := core.NewTool(
+		"sql",
+		"Run a SQL statement against the database, with values bound as parameters.",
+		fmt.Sprintf(`Advanced Details:
+- Parameters:
+  * operation (string, required): %s
+  * statement (string, required): A single SQL statement, using placeholders for values (e.g. "SELECT * FROM users WHERE id = ?")
+  * params (array, optional): The values bound to the placeholders, in order
+- Behavior:
+  * Queries return at most %d rows and %d columns, as %s
+  * Only one statement is accepted per call
+- Usage:
+  * Never put values in the statement text; pass them in params
+  * Inspect the schema first when table or column names are unknown
+  * Select only the needed columns and filter or aggregate in SQL to stay under the row limit`,
+			operationHelp, opts.MaxRows, opts.MaxColumns, opts.Format),
+		`Troubleshooting:
+- "statement must not be empty": Provide a SQL statement
+- "only a single statement is allowed": Split the statements into separate calls
+- "query only accepts read-only statements": Use a SELECT, or the execute operation if it is available
+- "query failed" / "execute failed": The database rejected the statement - check table and column names and the number of params
+- Truncated results: Add a WHERE clause, an aggregate or a LIMIT`,
+		[]core.Parameter{
+			{Name: "operation", Type: "string", Description: "The operation to perform", Required: true, Enum: operations},
+			{Name: "statement", Type: "string", Description: "A single SQL statement with placeholders for values", Required: true},
+			{Name: "params", Type: "array", Description: "The values bound to the statement's placeholders, in order"},
+		},
+		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+			statement := strings.TrimSpace(args["statement"].(string))
+			if statement == "" {
+				return core.NewErrorResponse("statement must not be empty")
+			}
+			keyword, words, err := inspectSQL(statement)
+			if err != nil {
+				return core.NewErrorResponse(err.Error())
+			}
+			params, _ := args["params"].([]any)
+			params = sqlParams(params)
+
+			ctx, _ := agentContext[core.ContextTraceContext].(context.Context)
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			if args["operation"].(string) == "execute" {
+				if !opts.AllowWrites {
+					return core.NewErrorResponse("writes are not allowed")
+				}
+				result, err := db.ExecContext(ctx, statement, params...)
+				if err != nil {
+					return core.NewErrorResponse(fmt.Sprintf("execute failed: %v", err))
+				}
+				if affected, err := result.RowsAffected(); err == nil {
+					return core.NewSuccessResponse(fmt.Sprintf("Statement executed: %d rows affected", affected))
+				}
+				return core.NewSuccessResponse("Statement executed")
+			}
+
+			if !readOnlySQLKeywords[keyword] {
+				return core.NewErrorResponse("query only accepts read-only statements")
+			}
+			for _, word := range words {
+				if writeSQLKeywords[word] {
+					return core.NewErrorResponse("query only accepts read-only statements")
+				}
+			}
+			result, err := runSQLQuery(ctx, db, statement, params, opts)
+			if err != nil {
+				return core.NewErrorResponse(fmt.Sprintf("query failed: %v", err))
+			}
+			if opts.Format == SQLFormatJSON {
+				return core.NewSuccessResponse(result.JSON())
+			}
+			return core.NewSuccessResponse(result.Table())
+		},
+	)
+	t := tool.(*core.Tool)
+	t.SetCacheable(false)
+	t.SetRequiresApproval(opts.AllowWrites)
+	return t
+}
+
+// inspectSQL returns the first keyword and the lowercased words of a statement,
+// ignoring string literals, quoted identifiers and comments. It fails when the
+// statement contains more than one statement or an unterminated quote or comment.
+func inspectSQL(statement string) (string, []string, error) {
+	var words []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, strings.ToLower(word.String()))
+			word.Reset()
+		}
+	}
+
+	runes := []rune(statement)
+	ended := false // a ';' was seen: only whitespace and comments may follow
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			flush()
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			flush()
+			closed := false
+			for i += 2; i+1 < len(runes); i++ {
+				if runes[i] == '*' && runes[i+1] == '/' {
+					i++
+					closed = true
+					break
+				}
+			}
+			if !closed {
+				return "", nil, fmt.Errorf("unterminated comment")
+			}
+		case unicode.IsSpace(r):
+			flush()
+		case ended:
+			return "", nil, fmt.Errorf("only a single statement is allowed")
+		case r == ';':
+			flush()
+			ended = true
+		case r == '\'' || r == '"' || r == '`':
+			flush()
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == r {
+					// A doubled quote is an escaped quote
+					if i+1 < len(runes) && runes[i+1] == r {
+						i++
+						continue
+					}
+					closed = true
+					break
+				}
+			}
+			if !closed {
+				return "", nil, fmt.Errorf("unterminated quote")
+			}
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			word.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	if len(words) == 0 {
+		return "", nil, fmt.Errorf("statement must not be empty")
+	}
+	return words[0], words, nil
+}
+
+// sqlParams converts decoded JSON values to driver values: whole numbers are
+// bound as integers so they compare equal to integer columns.
+func sqlParams(params []any) []any {
+	converted := make([]any, len(params))
+	for i, p := range params {
+		if f, ok := p.(float64); ok && f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+			converted[i] = int64(f)
+			continue
+		}
+		converted[i] = p
+	}
+	return converted
+}
+
+// SQLResult is the result of a query run by the SQL tool.
+type SQLResult struct {
+	Columns []string `json:"columns"`
+	Rows    [][]any  `json:"rows"`
+	// Truncated is true when rows or columns were cut at the tool's limits
+	Truncated bool `json:"truncated"`
+}
+
+// runSQLQuery runs a query in a rolled back transaction and reads at most
+// opts.MaxRows rows and opts.MaxColumns columns.
+func runSQLQuery(ctx context.Context, db *sql.DB, statement string, params []any, opts SQLToolOptions) (*SQLResult, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, statement, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := &SQLResult{Columns: columns, Rows: [][]any{}}
+	if len(columns) > opts.MaxColumns {
+		result.Columns = columns[:opts.MaxColumns]
+		result.Truncated = true
+	}
+
+	for rows.Next() {
+		if len(result.Rows) == opts.MaxRows {
+			result.Truncated = true
+			break
+		}
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		row := make([]any, len(result.Columns))
+		for i := range row {
+			row[i] = sqlValue(values[i])
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result, rows.Err()
+}
+
+// sqlValue converts a scanned value to a printable, JSON-friendly value.
+func sqlValue(value any) any {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return v
+	}
+}
+
+// Table renders the result as a Markdown-style table, NULL values as NULL.
+func (r *SQLResult) Table() string {
+	if len(r.Rows) == 0 {
+		return fmt.Sprintf("No rows (columns: %s).", strings.Join(r.Columns, ", "))
+	}
+
+	var b strings.Builder
+	b.WriteString("| " + strings.Join(r.Columns, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(r.Columns)) + "\n")
+	for _, row := range r.Rows {
+		cells := make([]string, len(row))
+		for i, value := range row {
+			if value == nil {
+				cells[i] = "NULL"
+				continue
+			}
+			cell := fmt.Sprint(value)
+			cell = strings.ReplaceAll(cell, "|", `\|`)
+			cells[i] = strings.ReplaceAll(cell, "\n", " ")
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	fmt.Fprintf(&b, "(%d rows", len(r.Rows))
+	if r.Truncated {
+		b.WriteString(", truncated at the tool's row/column limits")
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+// JSON renders the result as a JSON object with columns, rows and truncated fields.
+func (r *SQLResult) JSON() string {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Sprintf(`{"error": %q}`, err.Error())
+	}
+	return string(data)
+}
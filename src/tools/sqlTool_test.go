@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// newTestDB opens an in-memory SQLite database with a small users table.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	// Each connection of an in-memory database is a separate database
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, email TEXT);
+		INSERT INTO users (name, email) VALUES ('Ada', 'ada@example.com'), ('Linus', NULL), ('Grace', 'grace@example.com')`)
+	if err != nil {
+		t.Fatalf("Failed to create test data: %v", err)
+	}
+	return db
+}
+
+func TestSQLTool_Select(t *testing.T) {
+	tool := NewSQLTool(newTestDB(t), SQLToolOptions{})
+
+	result := tool.Call(nil, map[string]any{"operation": "query", "statement": "SELECT id, name, email FROM users ORDER BY id"})
+	if !result.Success() {
+		t.Fatalf("Expected success, got error: %s", result.Error())
+	}
+	expected := `| id | name | email |
+| --- | --- | --- |
+| 1 | Ada | ada@example.com |
+| 2 | Linus | NULL |
+| 3 | Grace | grace@example.com |
+(3 rows)`
+	if result.Data() != expected {
+		t.Errorf("Unexpected table:\n%s", result.Data())
+	}
+}
+
+func TestSQLTool_Parameterized(t *testing.T) {
+	tool := NewSQLTool(newTestDB(t), SQLToolOptions{Format: SQLFormatJSON})
+
+	result := tool.Call(nil, map[string]any{
+		"operation": "query",
+		"statement": "SELECT name FROM users WHERE id = ? OR name = ?",
+		"params":    []any{float64(1), "Grace' OR '1'='1"},
+	})
+	if !result.Success() {
+		t.Fatalf("Expected success, got error: %s", result.Error())
+	}
+	if result.Data() != `{"columns":["name"],"rows":[["Ada"]],"truncated":false}` {
+		t.Errorf("Expected only the bound id to match, got %s", result.Data())
+	}
+}
+
+func TestSQLTool_Limits(t *testing.T) {
+	tool := NewSQLTool(newTestDB(t), SQLToolOptions{MaxRows: 2, MaxColumns: 1, Format: SQLFormatJSON})
+
+	result := tool.Call(nil, map[string]any{"operation": "query", "statement": "SELECT id, name FROM users ORDER BY id"})
+	if result.Data() != `{"columns":["id"],"rows":[[1],[2]],"truncated":true}` {
+		t.Errorf("Expected 2 rows of 1 column, got %s", result.Data())
+	}
+}
+
+func TestSQLTool_Rejected(t *testing.T) {
+	db := newTestDB(t)
+	tool := NewSQLTool(db, SQLToolOptions{})
+
+	tests := []struct {
+		name      string
+		operation string
+		statement string
+		wantErr   string
+	}{
+		{name: "multiple statements", operation: "query", statement: "SELECT 1; DROP TABLE users", wantErr: "only a single statement is allowed"},
+		{name: "write in query", operation: "query", statement: "DELETE FROM users", wantErr: "query only accepts read-only statements"},
+		{name: "write in CTE", operation: "query", statement: "WITH x AS (SELECT 1) DELETE FROM users", wantErr: "query only accepts read-only statements"},
+		{name: "execute without AllowWrites", operation: "execute", statement: "DELETE FROM users", wantErr: "invalid value for operation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tool.Call(nil, map[string]any{"operation": tt.operation, "statement": tt.statement})
+			if result.Success() || !strings.Contains(result.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got success=%v error=%q", tt.wantErr, result.Success(), result.Error())
+			}
+		})
+	}
+
+	// Semicolons and keywords inside literals and comments are not statements
+	result := tool.Call(nil, map[string]any{"operation": "query", "statement": "SELECT 'a;b', 'delete' -- drop;\n;"})
+	if !result.Success() {
+		t.Errorf("Expected success, got error: %s", result.Error())
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil || count != 3 {
+		t.Errorf("Expected the table to be untouched, got %d rows (%v)", count, err)
+	}
+}
+
+func TestSQLTool_AllowWrites(t *testing.T) {
+	db := newTestDB(t)
+	tool := NewSQLTool(db, SQLToolOptions{AllowWrites: true})
+	if at, ok := tool.(interface{ RequiresApproval() bool }); !ok || !at.RequiresApproval() {
+		t.Error("Expected a tool allowing writes to require approval")
+	}
+
+	result := tool.Call(nil, map[string]any{
+		"operation": "execute",
+		"statement": "UPDATE users SET email = ? WHERE name = ?",
+		"params":    []any{"linus@example.com", "Linus"},
+	})
+	if !result.Success() || result.Data() != "Statement executed: 1 rows affected" {
+		t.Fatalf("Unexpected result: success=%v data=%v error=%q", result.Success(), result.Data(), result.Error())
+	}
+
+	var email string
+	if err := db.QueryRow("SELECT email FROM users WHERE name = 'Linus'").Scan(&email); err != nil || email != "linus@example.com" {
+		t.Errorf("Expected the update to be applied, got %q (%v)", email, err)
+	}
+}
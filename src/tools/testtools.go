@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"strings"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// Deterministic tools for tests. Together with the foo tool they let tests
+// check routing among several tools and failure handling without a real LLM.
+
+// NewReverseTool creates a test tool that returns its text argument reversed.
+func NewReverseTool() llms.Tool {
+	return core.NewTool(
+		"reverse",
+		"A test tool that returns the text argument reversed. Use this to test only.",
+		`Advanced Details:
+- Parameters:
+  * text (string, required): The text to reverse
+- Behavior: Returns the characters of the text in reverse order
+- Usage: Testing tool routing and argument handling
+- Performance: Instant response with no side effects`,
+		`Troubleshooting:
+- If the tool fails, ensure the 'text' parameter is provided as a string`,
+		[]core.Parameter{
+			{Name: "text", Type: "string", Description: "The text to reverse", Required: true},
+		},
+		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+			return core.NewSuccessResponse(ReverseString(args["text"].(string)))
+		},
+	)
+}
+
+// NewUppercaseTool creates a test tool that returns its text argument in upper case.
+func NewUppercaseTool() llms.Tool {
+	return core.NewTool(
+		"uppercase",
+		"A test tool that returns the text argument in upper case. Use this to test only.",
+		`Advanced Details:
+- Parameters:
+  * text (string, required): The text to convert
+- Behavior: Returns the text with every letter in upper case
+- Usage: Testing tool routing and argument handling
+- Performance: Instant response with no side effects`,
+		`Troubleshooting:
+- If the tool fails, ensure the 'text' parameter is provided as a string`,
+		[]core.Parameter{
+			{Name: "text", Type: "string", Description: "The text to convert", Required: true},
+		},
+		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+			return core.NewSuccessResponse(strings.ToUpper(args["text"].(string)))
+		},
+	)
+}
+
+// NewFailTool creates a test tool that always returns an error response.
+func NewFailTool() llms.Tool {
+	return core.NewTool(
+		"fail",
+		"A test tool that always fails. Use this to test only.",
+		`Advanced Details:
+- Parameters:
+  * reason (string, optional): The error message to return (default: "tool failed on purpose")
+- Behavior: Always returns an error response
+- Usage: Testing how failed tool calls are reported to the model`,
+		`Troubleshooting:
+- This tool is expected to fail on every call`,
+		[]core.Parameter{
+			{Name: "reason", Type: "string", Description: "The error message to return"},
+		},
+		func(agentContext map[string]any, args map[string]any) llms.ToolReturn {
+			reason, _ := args["reason"].(string)
+			if reason == "" {
+				reason = "tool failed on purpose"
+			}
+			return core.NewErrorResponse(reason)
+		},
+	)
+}
+
+// ReverseString returns s with its characters (runes) in reverse order.
+func ReverseString(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
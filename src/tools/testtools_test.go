@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+func TestReverseTool(t *testing.T) {
+	tests := map[string]string{
+		"":      "",
+		"a":     "a",
+		"hello": "olleh",
+		"héllo": "olléh",
+		"日本語":   "語本日",
+	}
+	tool := NewReverseTool()
+	for input, want := range tests {
+		result := tool.Call(map[string]any{}, map[string]any{"text": input})
+		if !result.Success() {
+			t.Fatalf("reverse(%q) unexpected error: %s", input, result.Error())
+		}
+		if result.Data() != want {
+			t.Errorf("reverse(%q) = %q, want %q", input, result.Data(), want)
+		}
+	}
+}
+
+func TestUppercaseTool(t *testing.T) {
+	result := NewUppercaseTool().Call(map[string]any{}, map[string]any{"text": "Hello, wörld 42"})
+	if !result.Success() {
+		t.Fatalf("uppercase unexpected error: %s", result.Error())
+	}
+	if result.Data() != "HELLO, WÖRLD 42" {
+		t.Errorf("uppercase = %q, want %q", result.Data(), "HELLO, WÖRLD 42")
+	}
+}
+
+func TestTransformTools_MissingText(t *testing.T) {
+	for _, tool := range []llms.Tool{NewReverseTool(), NewUppercaseTool()} {
+		result := tool.Call(map[string]any{}, map[string]any{})
+		if result.Success() {
+			t.Errorf("%s: expected a missing text argument to fail", tool.GetName())
+		}
+	}
+}
+
+func TestFailTool(t *testing.T) {
+	tool := NewFailTool()
+
+	result := tool.Call(map[string]any{}, map[string]any{})
+	if result.Success() {
+		t.Fatal("Expected fail tool to fail")
+	}
+	if result.Error() != "tool failed on purpose" {
+		t.Errorf("Expected default reason, got %q", result.Error())
+	}
+
+	result = tool.Call(map[string]any{}, map[string]any{"reason": "disk full"})
+	if result.Success() || result.Error() != "disk full" {
+		t.Errorf("Expected custom reason, got success=%t error=%q", result.Success(), result.Error())
+	}
+}
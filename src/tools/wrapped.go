@@ -0,0 +1,52 @@
+package tools
+
+import (
+	agentforge "github.com/thinktwice/agentForge/src"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// wrappedTool is embedded by tool wrappers (WithRetry, WithName) to forward the
+// optional interfaces of the tool they wrap.
+type wrappedTool struct {
+	llms.Tool
+}
+
+// Cacheable reports whether the wrapped tool's results may be cached.
+func (t wrappedTool) Cacheable() bool {
+	if ct, ok := t.Tool.(interface{ Cacheable() bool }); ok {
+		return ct.Cacheable()
+	}
+	return true
+}
+
+// RequiresApproval reports whether the wrapped tool requires approval.
+func (t wrappedTool) RequiresApproval() bool {
+	if at, ok := t.Tool.(interface{ RequiresApproval() bool }); ok {
+		return at.RequiresApproval()
+	}
+	return false
+}
+
+// LLMDescription returns the wrapped tool's LLM description, if any.
+func (t wrappedTool) LLMDescription() string {
+	if d, ok := t.Tool.(interface{ LLMDescription() string }); ok {
+		return d.LLMDescription()
+	}
+	return ""
+}
+
+// discoverableTool is a wrapper of a tool implementing agentforge.Discoverable,
+// exposing the descriptions of that tool.
+type discoverableTool struct {
+	wrappedTool
+	agentforge.Discoverable
+}
+
+// withDiscovery returns wrapper, made discoverable when the tool it wraps is, so
+// wrappers of other tools do not look discoverable with empty descriptions.
+func withDiscovery(wrapper llms.Tool, tool llms.Tool) llms.Tool {
+	if d, ok := tool.(agentforge.Discoverable); ok {
+		return discoverableTool{wrappedTool: wrappedTool{wrapper}, Discoverable: d}
+	}
+	return wrapper
+}
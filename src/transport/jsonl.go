@@ -0,0 +1,41 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/thinktwice/agentForge/src/core"
+)
+
+// WriteJSONLines writes agent chunks as JSON Lines: one core.ExtendedChunkResponse
+// JSON object per line, in the same encoding as the SSE data.
+//
+// It is meant for piping agent output into other programs. An error chunk of the
+// run (see isRunError) is written like any other chunk and ends the stream.
+//
+// If a write fails (e.g. a broken pipe), the stream is stopped (see
+// core.ResponseCh.Stop) so the producing agent ends its run instead of calling
+// the LLM and running tools for nobody.
+//
+// Parameters:
+//   - w: The destination, e.g. os.Stdout
+//   - rc: The response channel returned by the agent
+//
+// Returns:
+//   - error: The error chunk's error, a write error, or nil when the stream completed
+func WriteJSONLines(w io.Writer, rc *core.ResponseCh) error {
+	encoder := json.NewEncoder(w)
+	for chunk := range rc.Start() {
+		if err := encoder.Encode(chunk); err != nil {
+			rc.Stop()
+			return fmt.Errorf("failed to write chunk: %w", err)
+		}
+
+		if isRunError(chunk) {
+			rc.Stop()
+			return chunkError(chunk)
+		}
+	}
+	return nil
+}
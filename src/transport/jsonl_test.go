@@ -0,0 +1,112 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+func TestWriteJSONLines(t *testing.T) {
+	var out bytes.Buffer
+	rc := completedResponse(t,
+		core.ExtendedChunkResponse{Content: "Hel", Delta: "Hel", Status: llms.StatusStreaming, Type: llms.TypeContent, AgentName: "main"},
+		core.ExtendedChunkResponse{Content: "lo\nworld", Delta: "lo\nworld", Status: llms.StatusStreaming, Type: llms.TypeContent, AgentName: "main"},
+		core.ExtendedChunkResponse{FullContent: "Hello\nworld", Status: llms.StatusCompleted, Type: llms.TypeCompletion, TotalTokens: 7, AgentName: "main"},
+	)
+
+	if err := WriteJSONLines(&out, rc); err != nil {
+		t.Fatalf("WriteJSONLines() unexpected error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d:\n%s", len(lines), out.String())
+	}
+	var chunks []core.ExtendedChunkResponse
+	for _, line := range lines {
+		var chunk core.ExtendedChunkResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			t.Fatalf("Line is not a JSON object: %q: %v", line, err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	if chunks[1].Delta != "lo\nworld" || chunks[1].AgentName != "main" {
+		t.Errorf("Unexpected second chunk: %+v", chunks[1])
+	}
+	if chunks[2].Type != llms.TypeCompletion || chunks[2].FullContent != "Hello\nworld" || chunks[2].TotalTokens != 7 {
+		t.Errorf("Unexpected completion chunk: %+v", chunks[2])
+	}
+}
+
+func TestWriteJSONLines_Error(t *testing.T) {
+	var out bytes.Buffer
+	cause := errors.New("llm stream error: boom")
+	rc := core.NewResponseCh("main", "")
+	rc.Response <- []byte(`{"delta":"Hi","status":"streaming","type":"content"}`)
+	rc.Error <- cause
+	rc.Close()
+
+	err := WriteJSONLines(&out, rc)
+	if !errors.Is(err, cause) {
+		t.Errorf("Expected the chunk error, got %v", err)
+	}
+	if strings.Count(out.String(), "\n") != 2 || !strings.Contains(out.String(), `"status":"error"`) {
+		t.Errorf("Expected the error chunk to be the last line, got:\n%s", out.String())
+	}
+}
+
+// failingWriter fails every write, like a closed pipe.
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestWriteJSONLines_WriteFailure(t *testing.T) {
+	rc := core.NewResponseChWithBuffer("main", "", 1)
+	produced := make(chan struct{})
+	go func() {
+		defer close(produced)
+		defer rc.Close()
+		for i := 0; i < 3; i++ {
+			rc.Response <- []byte(`{"delta":"x"}`)
+		}
+	}()
+
+	if err := WriteJSONLines(failingWriter{}, rc); err == nil {
+		t.Fatal("WriteJSONLines() expected error but got nil")
+	}
+
+	// The stream is stopped so the run ends instead of going on for nobody
+	select {
+	case <-rc.Stopped():
+	default:
+		t.Error("Expected the stream to be stopped after the write failure")
+	}
+	select {
+	case <-produced:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Producer blocked after the write failure")
+	}
+}
+
+func TestWriteJSONLines_SubAgentErrorChunk(t *testing.T) {
+	var out bytes.Buffer
+	rc := completedResponse(t,
+		core.ExtendedChunkResponse{Content: "helper failed", Status: llms.StatusError, AgentName: "helper", DelegationID: "d1"},
+		core.ExtendedChunkResponse{FullContent: "The helper failed, sorry", Status: llms.StatusCompleted, Type: llms.TypeCompletion, AgentName: "main"},
+	)
+
+	if err := WriteJSONLines(&out, rc); err != nil {
+		t.Fatalf("WriteJSONLines() unexpected error = %v", err)
+	}
+	if strings.Count(out.String(), "\n") != 2 || !strings.Contains(out.String(), "The helper failed, sorry") {
+		t.Errorf("Expected both chunks, got:\n%s", out.String())
+	}
+}
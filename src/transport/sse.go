@@ -0,0 +1,115 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+const (
+	// EventDone is the event sent after the last chunk when the stream completed.
+	EventDone = "done"
+	// EventError is the event sent when the stream ended with an error chunk.
+	EventError = "error"
+)
+
+// SSEDoneData is the data of the terminating "done" Server-Sent Event.
+const SSEDoneData = "[DONE]"
+
+// WriteSSE streams agent chunks to an HTTP client as Server-Sent Events.
+//
+// Every chunk is written as a "data:" event carrying its JSON encoding and flushed
+// immediately. When the stream ends, a final "done" event (data "[DONE]") is sent. An
+// error chunk of the run (see isRunError) is written as an "error" event and ends
+// the stream; error chunks forwarded from a failed sub-agent are ordinary events.
+//
+// If the client goes away, the stream is stopped (see core.ResponseCh.Stop) so
+// the producing agent ends its run instead of blocking.
+//
+// Usage:
+//
+//	http.HandleFunc("/chat", func(w http.ResponseWriter, r *http.Request) {
+//	    transport.WriteSSE(w, agent.ChatStreamContext(r.Context(), r.URL.Query().Get("q")))
+//	})
+//
+// Parameters:
+//   - w: The response writer; it must implement http.Flusher
+//   - rc: The response channel returned by the agent
+//
+// Returns:
+//   - error: The error chunk's error, a write error, or nil when the stream completed
+func WriteSSE(w http.ResponseWriter, rc *core.ResponseCh) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		rc.Stop()
+		return fmt.Errorf("response writer does not support flushing")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for chunk := range rc.Start() {
+		data, err := json.Marshal(chunk)
+		if err != nil {
+			rc.Stop()
+			return fmt.Errorf("failed to serialize chunk: %w", err)
+		}
+
+		if isRunError(chunk) {
+			rc.Stop()
+			if err := writeSSEEvent(w, EventError, string(data)); err != nil {
+				return err
+			}
+			flusher.Flush()
+			return chunkError(chunk)
+		}
+
+		if err := writeSSEEvent(w, "", string(data)); err != nil {
+			rc.Stop()
+			return err
+		}
+		flusher.Flush()
+	}
+
+	if err := writeSSEEvent(w, EventDone, SSEDoneData); err != nil {
+		return err
+	}
+	flusher.Flush()
+	return nil
+}
+
+// writeSSEEvent writes a single event. An empty event name writes a default "message" event.
+func writeSSEEvent(w http.ResponseWriter, event string, data string) error {
+	if event != "" {
+		if _, err := fmt.Fprintf(w, "event: %s\n", event); err != nil {
+			return fmt.Errorf("failed to write event: %w", err)
+		}
+	}
+	if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	return nil
+}
+
+// isRunError reports whether chunk is an error chunk of the run itself. Error
+// chunks the delegate tool forwards from a failed sub-agent carry a DelegationID:
+// the run goes on with the failed tool result, so they do not end the stream.
+func isRunError(chunk core.ExtendedChunkResponse) bool {
+	return chunk.Status == llms.StatusError && chunk.DelegationID == ""
+}
+
+// chunkError returns the error carried by an error chunk, or its content as an
+// error when it has none.
+func chunkError(chunk core.ExtendedChunkResponse) error {
+	if chunk.Err != nil {
+		return chunk.Err
+	}
+	return fmt.Errorf("%s", chunk.Content)
+}
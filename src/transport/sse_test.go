@@ -0,0 +1,117 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// completedResponse returns a closed response channel holding the given chunks.
+func completedResponse(t *testing.T, chunks ...core.ExtendedChunkResponse) *core.ResponseCh {
+	t.Helper()
+	rc := core.NewResponseChWithBuffer("main", "", len(chunks)+1)
+	for _, chunk := range chunks {
+		data, err := json.Marshal(chunk)
+		if err != nil {
+			t.Fatalf("Failed to serialize chunk: %v", err)
+		}
+		rc.Response <- data
+	}
+	rc.Close()
+	return rc
+}
+
+func TestWriteSSE(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rc := completedResponse(t,
+		core.ExtendedChunkResponse{Delta: "Hel", Status: llms.StatusStreaming, Type: llms.TypeContent, AgentName: "main"},
+		core.ExtendedChunkResponse{Delta: "lo", Status: llms.StatusStreaming, Type: llms.TypeContent, AgentName: "main"},
+	)
+
+	if err := WriteSSE(rec, rc); err != nil {
+		t.Fatalf("WriteSSE() unexpected error = %v", err)
+	}
+
+	if got := rec.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Expected Content-Type text/event-stream, got %q", got)
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("Expected Cache-Control no-cache, got %q", got)
+	}
+	if !rec.Flushed {
+		t.Error("Expected the response to be flushed")
+	}
+
+	events := strings.Split(strings.TrimSuffix(rec.Body.String(), "\n\n"), "\n\n")
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d:\n%s", len(events), rec.Body.String())
+	}
+	if !strings.HasPrefix(events[0], "data: {") || !strings.Contains(events[0], `"delta":"Hel"`) {
+		t.Errorf("Unexpected first event: %q", events[0])
+	}
+	if !strings.Contains(events[1], `"delta":"lo"`) {
+		t.Errorf("Unexpected second event: %q", events[1])
+	}
+	if events[2] != "event: done\ndata: [DONE]" {
+		t.Errorf("Expected terminating done event, got %q", events[2])
+	}
+}
+
+func TestWriteSSE_ErrorChunk(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rc := completedResponse(t,
+		core.ExtendedChunkResponse{Delta: "partial", Status: llms.StatusStreaming, Type: llms.TypeContent},
+		core.ExtendedChunkResponse{Content: "rate limited", Status: llms.StatusError},
+		core.ExtendedChunkResponse{Delta: "ignored", Status: llms.StatusStreaming, Type: llms.TypeContent},
+	)
+
+	err := WriteSSE(rec, rc)
+	if err == nil || err.Error() != "rate limited" {
+		t.Fatalf("WriteSSE() error = %v, want 'rate limited'", err)
+	}
+
+	body := rec.Body.String()
+	if !strings.HasSuffix(body, "\n\n") || !strings.Contains(body, "event: error\ndata: {") {
+		t.Errorf("Expected terminating error event, got:\n%s", body)
+	}
+	if strings.Contains(body, "ignored") || strings.Contains(body, "event: done") {
+		t.Errorf("Expected the stream to end at the error, got:\n%s", body)
+	}
+}
+
+func TestWriteSSE_SubAgentErrorChunk(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rc := completedResponse(t,
+		core.ExtendedChunkResponse{Content: "helper failed", Status: llms.StatusError, AgentName: "helper", DelegationID: "d1"},
+		core.ExtendedChunkResponse{FullContent: "The helper failed, sorry", Status: llms.StatusCompleted, Type: llms.TypeCompletion, AgentName: "main"},
+	)
+
+	if err := WriteSSE(rec, rc); err != nil {
+		t.Fatalf("WriteSSE() unexpected error = %v", err)
+	}
+
+	body := rec.Body.String()
+	if strings.Contains(body, "event: error") {
+		t.Errorf("Expected the sub-agent error as an ordinary event, got:\n%s", body)
+	}
+	if !strings.Contains(body, "helper failed") || !strings.Contains(body, "The helper failed, sorry") || !strings.HasSuffix(body, "event: done\ndata: [DONE]\n\n") {
+		t.Errorf("Expected the root answer and the done event, got:\n%s", body)
+	}
+}
+
+func TestWriteSSE_WrapsRunError(t *testing.T) {
+	errBudget := errors.New("budget exceeded")
+	rc := core.NewResponseCh("main", "")
+	rc.Error <- fmt.Errorf("run failed: %w", errBudget)
+	rc.Close()
+
+	if err := WriteSSE(httptest.NewRecorder(), rc); !errors.Is(err, errBudget) {
+		t.Fatalf("WriteSSE() error = %v, want one wrapping %v", err, errBudget)
+	}
+}
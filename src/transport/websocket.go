@@ -0,0 +1,66 @@
+package transport
+
+import (
+	"fmt"
+
+	"github.com/thinktwice/agentForge/src/core"
+)
+
+// JSONWriter is a WebSocket connection that can send a value as a JSON text message.
+//
+// *websocket.Conn from github.com/gorilla/websocket satisfies it directly. For
+// github.com/coder/websocket (formerly nhooyr.io/websocket), wrap the connection:
+//
+//	type wsConn struct{ conn *websocket.Conn; ctx context.Context }
+//
+//	func (c wsConn) WriteJSON(v any) error { return wsjson.Write(c.ctx, c.conn, v) }
+type JSONWriter interface {
+	WriteJSON(v any) error
+}
+
+// WebSocketEvent is the terminating message sent by WriteWebSocket.
+type WebSocketEvent struct {
+	// Event is EventDone or EventError.
+	Event string `json:"event"`
+	// Error is the error message when Event is EventError.
+	Error string `json:"error,omitempty"`
+}
+
+// WriteWebSocket streams agent chunks over a WebSocket connection.
+//
+// Every chunk is sent as a JSON message. When the stream ends, a final
+// WebSocketEvent{Event: "done"} is sent. An error chunk of the run (see isRunError)
+// is sent followed by WebSocketEvent{Event: "error"} and ends the stream; error
+// chunks forwarded from a failed sub-agent are sent like any other chunk.
+// Closing the connection is left to the caller.
+//
+// If a write fails, the stream is stopped (see core.ResponseCh.Stop) so the
+// producing agent ends its run instead of blocking.
+//
+// Parameters:
+//   - conn: The WebSocket connection
+//   - rc: The response channel returned by the agent
+//
+// Returns:
+//   - error: The error chunk's error, a write error, or nil when the stream completed
+func WriteWebSocket(conn JSONWriter, rc *core.ResponseCh) error {
+	for chunk := range rc.Start() {
+		if err := conn.WriteJSON(chunk); err != nil {
+			rc.Stop()
+			return fmt.Errorf("failed to write chunk: %w", err)
+		}
+
+		if isRunError(chunk) {
+			rc.Stop()
+			if err := conn.WriteJSON(WebSocketEvent{Event: EventError, Error: chunk.Content}); err != nil {
+				return fmt.Errorf("failed to write error event: %w", err)
+			}
+			return chunkError(chunk)
+		}
+	}
+
+	if err := conn.WriteJSON(WebSocketEvent{Event: EventDone}); err != nil {
+		return fmt.Errorf("failed to write done event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/thinktwice/agentForge/src/core"
+	"github.com/thinktwice/agentForge/src/llms"
+)
+
+// recordingConn records the JSON messages written to it.
+type recordingConn struct {
+	messages []string
+	failAt   int // index of the write that fails, -1 to never fail
+}
+
+func (c *recordingConn) WriteJSON(v any) error {
+	if len(c.messages) == c.failAt {
+		return errors.New("connection closed")
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	c.messages = append(c.messages, string(data))
+	return nil
+}
+
+func TestWriteWebSocket(t *testing.T) {
+	conn := &recordingConn{failAt: -1}
+	rc := completedResponse(t,
+		core.ExtendedChunkResponse{Delta: "Hi", Status: llms.StatusStreaming, Type: llms.TypeContent},
+	)
+
+	if err := WriteWebSocket(conn, rc); err != nil {
+		t.Fatalf("WriteWebSocket() unexpected error = %v", err)
+	}
+	if len(conn.messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %v", conn.messages)
+	}
+	if conn.messages[1] != `{"event":"done"}` {
+		t.Errorf("Expected terminating done event, got %s", conn.messages[1])
+	}
+}
+
+func TestWriteWebSocket_ErrorChunk(t *testing.T) {
+	conn := &recordingConn{failAt: -1}
+	rc := completedResponse(t,
+		core.ExtendedChunkResponse{Content: "boom", Status: llms.StatusError},
+		core.ExtendedChunkResponse{Delta: "ignored", Status: llms.StatusStreaming},
+	)
+
+	if err := WriteWebSocket(conn, rc); err == nil || err.Error() != "boom" {
+		t.Fatalf("WriteWebSocket() error = %v, want 'boom'", err)
+	}
+	if len(conn.messages) != 2 || conn.messages[1] != `{"event":"error","error":"boom"}` {
+		t.Errorf("Expected error chunk then error event, got %v", conn.messages)
+	}
+}
+
+func TestWriteWebSocket_SubAgentErrorChunk(t *testing.T) {
+	conn := &recordingConn{failAt: -1}
+	rc := completedResponse(t,
+		core.ExtendedChunkResponse{Content: "helper failed", Status: llms.StatusError, AgentName: "helper", DelegationID: "d1"},
+		core.ExtendedChunkResponse{FullContent: "The helper failed, sorry", Status: llms.StatusCompleted, Type: llms.TypeCompletion, AgentName: "main"},
+	)
+
+	if err := WriteWebSocket(conn, rc); err != nil {
+		t.Fatalf("WriteWebSocket() unexpected error = %v", err)
+	}
+	if len(conn.messages) != 3 || conn.messages[2] != `{"event":"done"}` {
+		t.Errorf("Expected both chunks then the done event, got %v", conn.messages)
+	}
+}
+
+func TestWriteWebSocket_WriteFailure(t *testing.T) {
+	conn := &recordingConn{failAt: 0}
+	rc := core.NewResponseChWithBuffer("main", "", 1)
+	produced := make(chan struct{})
+	go func() {
+		defer close(produced)
+		defer rc.Close()
+		for i := 0; i < 3; i++ {
+			rc.Response <- []byte(`{"delta":"x"}`)
+		}
+	}()
+
+	if err := WriteWebSocket(conn, rc); err == nil {
+		t.Fatal("WriteWebSocket() expected error but got nil")
+	}
+
+	// The stream is stopped and the rest discarded so the producer is not blocked
+	select {
+	case <-rc.Stopped():
+	default:
+		t.Error("Expected the stream to be stopped after the write failure")
+	}
+	select {
+	case <-produced:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Producer blocked after the write failure")
+	}
+}
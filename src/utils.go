@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -51,8 +52,25 @@ func loadEnvFile(filePath string) (map[string]string, error) {
 	return env, nil
 }
 
+// Environment variables controlling where GetEnvVar reads .env files from. They are
+// always read from the process environment.
+const (
+	// EnvFileVar names a specific .env file to read instead of searching for one.
+	EnvFileVar = "AF_ENV_FILE"
+	// DisableDotenvVar, when true (1, t, true, ...), disables .env files: only
+	// os.Getenv is used.
+	DisableDotenvVar = "AF_DISABLE_DOTENV"
+)
+
+// dotenvDisabled reports whether AF_DISABLE_DOTENV is set to a true value.
+func dotenvDisabled() bool {
+	disabled, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(DisableDotenvVar)))
+	return err == nil && disabled
+}
+
 // GetEnvVar retrieves an environment variable with fallback priority:
-// 1. .env file (in current directory or parent directories up to project root)
+// 1. .env file (the file named by AF_ENV_FILE, or the first one found in the current
+// directory or parent directories up to project root); skipped if AF_DISABLE_DOTENV is true
 // 2. os.Getenv()
 // 3. Returns error if not found
 //
@@ -63,6 +81,33 @@ func loadEnvFile(filePath string) (map[string]string, error) {
 //   - string: The environment variable value
 //   - error: Error if the variable is not found in .env file or os environment
 func GetEnvVar(key string) (string, error) {
+	if !dotenvDisabled() {
+		if value, ok := lookupDotenv(key); ok {
+			return value, nil
+		}
+	}
+
+	// Fallback to os.Getenv()
+	if value := os.Getenv(key); value != "" {
+		return value, nil
+	}
+
+	// Not found anywhere
+	return "", fmt.Errorf("environment variable %s not found in .env file or os environment", key)
+}
+
+// lookupDotenv looks key up in the .env file named by AF_ENV_FILE or, if it is not
+// set, in the .env files of the current directory and its parents.
+func lookupDotenv(key string) (string, bool) {
+	if envPath := os.Getenv(EnvFileVar); envPath != "" {
+		if env, err := loadEnvFile(envPath); err == nil {
+			if value, ok := env[key]; ok && value != "" {
+				return value, true
+			}
+		}
+		return "", false
+	}
+
 	// Try to find .env file starting from current directory
 	// Search up directories until we find .env file or reach filesystem root
 	dir, err := os.Getwd()
@@ -75,7 +120,7 @@ func GetEnvVar(key string) (string, error) {
 		envPath := filepath.Join(dir, ".env")
 		if env, err := loadEnvFile(envPath); err == nil {
 			if value, ok := env[key]; ok && value != "" {
-				return value, nil
+				return value, true
 			}
 		}
 		// Move up one directory
@@ -85,12 +130,5 @@ func GetEnvVar(key string) (string, error) {
 		}
 		dir = parent
 	}
-
-	// Fallback to os.Getenv()
-	if value := os.Getenv(key); value != "" {
-		return value, nil
-	}
-
-	// Not found anywhere
-	return "", fmt.Errorf("environment variable %s not found in .env file or os environment", key)
+	return "", false
 }
@@ -61,3 +61,80 @@ func TestGetEnvVarSearchPath(t *testing.T) {
 		t.Logf("No .env file found at: %s", parentEnvPath)
 	}
 }
+
+// chdir changes the working directory for the duration of the test.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+}
+
+// writeEnvFile writes a .env style file and returns its path.
+func writeEnvFile(t *testing.T, path, content string) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+func TestGetEnvVar_Sources(t *testing.T) {
+	const key = "AF_TEST_ENV_SOURCE"
+
+	root := t.TempDir()
+	writeEnvFile(t, filepath.Join(root, ".env"), key+"=searched\n")
+	explicit := writeEnvFile(t, filepath.Join(root, "config", "app.env"), key+"=explicit\n")
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	chdir(t, nested)
+
+	tests := []struct {
+		name     string
+		envFile  string
+		disable  string
+		osValue  string
+		expected string
+		wantErr  bool
+	}{
+		{name: "upward search finds parent .env", expected: "searched"},
+		{name: "upward search wins over os env", osValue: "os", expected: "searched"},
+		{name: "explicit file", envFile: explicit, expected: "explicit"},
+		{name: "explicit file is not searched past", envFile: filepath.Join(root, "missing.env"), wantErr: true},
+		{name: "explicit file falls back to os env", envFile: filepath.Join(root, "missing.env"), osValue: "os", expected: "os"},
+		{name: "disabled uses os env only", disable: "true", osValue: "os", expected: "os"},
+		{name: "disabled ignores explicit file", envFile: explicit, disable: "1", wantErr: true},
+		{name: "false does not disable", disable: "false", expected: "searched"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvFileVar, tt.envFile)
+			t.Setenv(DisableDotenvVar, tt.disable)
+			t.Setenv(key, tt.osValue)
+
+			value, err := GetEnvVar(key)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("GetEnvVar() = %q, want error", value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetEnvVar() unexpected error = %v", err)
+			}
+			if value != tt.expected {
+				t.Errorf("GetEnvVar() = %q, want %q", value, tt.expected)
+			}
+		})
+	}
+}